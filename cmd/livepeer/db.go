@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/livepeer/go-livepeer/common"
+)
+
+const dbUsage = `Usage: livepeer db <command> [-network name] [-datadir dir] [args]
+
+Commands:
+  migrate          Apply pending schema migrations
+  backup <file>    Write a copy of the database to file without migrating it
+  export [file]    Write the contents of the database as JSON to file or stdout
+`
+
+// dbFile returns the path of the node database in datadir
+func dbFile(datadir string) string {
+	return filepath.Join(datadir, "lpdb.sqlite3")
+}
+
+// defaultDatadir returns the data directory used when -datadir is not set
+func defaultDatadir(network string) (string, error) {
+	homedir := os.Getenv("HOME")
+	if homedir == "" {
+		usr, err := user.Current()
+		if err != nil {
+			return "", fmt.Errorf("cannot find current user: %v", err)
+		}
+		homedir = usr.HomeDir
+	}
+	return filepath.Join(homedir, ".lpData", network), nil
+}
+
+// runDB runs a `livepeer db` command
+func runDB(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(dbUsage)
+	}
+	cmd := args[0]
+
+	fs := flag.NewFlagSet("db "+cmd, flag.ContinueOnError)
+	network := fs.String("network", "offchain", "Network the node connects to")
+	datadir := fs.String("datadir", "", "data directory")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *datadir == "" {
+		dir, err := defaultDatadir(*network)
+		if err != nil {
+			return err
+		}
+		*datadir = dir
+	}
+	path := dbFile(*datadir)
+
+	switch cmd {
+	case "migrate":
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+		dbh, err := common.InitDB(path)
+		if err != nil {
+			return err
+		}
+		defer dbh.Close()
+		version, err := dbh.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Database %v is at version %v\n", path, version)
+		return nil
+	case "backup":
+		if fs.NArg() != 1 {
+			return errors.New(dbUsage)
+		}
+		if err := common.BackupDB(path, fs.Arg(0)); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Database %v backed up to %v\n", path, fs.Arg(0))
+		return nil
+	case "export":
+		if fs.NArg() > 1 {
+			return errors.New(dbUsage)
+		}
+		if fs.NArg() == 0 {
+			return common.ExportDB(path, stdout)
+		}
+		f, err := os.OpenFile(fs.Arg(0), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return err
+		}
+		if err := common.ExportDB(path, f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return errors.New(dbUsage)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDB(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "TestRunDB")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	run := func(args ...string) error {
+		out.Reset()
+		return runDB(args, &out)
+	}
+
+	// Usage errors
+	assert.EqualError(run(), dbUsage)
+	assert.EqualError(run("foo", "-datadir", dir), dbUsage)
+	assert.EqualError(run("backup", "-datadir", dir), dbUsage)
+
+	// Commands require an existing database
+	assert.True(os.IsNotExist(run("migrate", "-datadir", dir)))
+	_, err = os.Stat(dbFile(dir))
+	assert.True(os.IsNotExist(err))
+
+	dbh, err := common.InitDB(dbFile(dir))
+	require.Nil(err)
+	dbh.Close()
+
+	require.Nil(run("migrate", "-datadir", dir))
+	assert.Equal(fmt.Sprintf("Database %v is at version %v\n", dbFile(dir), common.LivepeerDBVersion), out.String())
+
+	backupFile := filepath.Join(dir, "backup.sqlite3")
+	require.Nil(run("backup", "-datadir", dir, backupFile))
+	_, err = os.Stat(backupFile)
+	assert.Nil(err)
+
+	// Export to stdout
+	require.Nil(run("export", "-datadir", dir))
+	var export map[string][]map[string]interface{}
+	require.Nil(json.Unmarshal(out.Bytes(), &export))
+	assert.Contains(export, "kv")
+
+	// Export to a file, which must not exist yet
+	exportFile := filepath.Join(dir, "export.json")
+	require.Nil(run("export", "-datadir", dir, exportFile))
+	data, err := ioutil.ReadFile(exportFile)
+	require.Nil(err)
+	require.Nil(json.Unmarshal(data, &export))
+	assert.True(os.IsExist(run("export", "-datadir", dir, exportFile)))
+}
+
+func TestDefaultDatadir(t *testing.T) {
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+
+	os.Setenv("HOME", "/foo")
+	dir, err := defaultDatadir("rinkeby")
+	assert.Nil(t, err)
+	assert.Equal(t, "/foo/.lpData/rinkeby", dir)
+}
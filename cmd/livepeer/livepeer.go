@@ -14,11 +14,11 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
-	"os/user"
 
 	"path/filepath"
 	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
@@ -27,6 +27,7 @@ import (
 	"github.com/livepeer/go-livepeer/server"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/golang/glog"
 	"github.com/livepeer/go-livepeer/common"
@@ -53,6 +54,8 @@ var (
 
 	// The gas required to redeem a PM ticket
 	redeemGas = 100000
+	// The estimated gas required to withdraw fees
+	withdrawFeesGas = 200000
 	// The multiplier on the transaction cost to use for PM ticket faceValue
 	txCostMultiplier = 100
 
@@ -60,6 +63,8 @@ var (
 	cleanupInterval = 1 * time.Minute
 	// The time to live for cached max float values for PM senders (else they will be cleaned up) in seconds
 	smTTL = 60 // 1 minute
+	// The interval at which orchestrators save credit balances to the DB
+	balanceSnapshotInterval = 10 * time.Second
 )
 
 const RtmpPort = "1935"
@@ -71,10 +76,16 @@ func main() {
 	// incorrectly add their own flags (specifically, due to the 'testing'
 	// package being linked)
 	flag.Set("logtostderr", "true")
-	usr, err := user.Current()
-	if err != nil {
-		glog.Fatalf("Cannot find current user: %v", err)
+
+	// Database maintenance commands run without starting the node
+	if len(os.Args) > 1 && os.Args[1] == "db" {
+		if err := runDB(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
+
 	vFlag := flag.Lookup("v")
 	//We preserve this flag before resetting all the flags.  Not a scalable approach, but it'll do for now.  More discussions here - https://github.com/livepeer/go-livepeer/pull/617
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
@@ -95,6 +106,10 @@ func main() {
 	transcoder := flag.Bool("transcoder", false, "Set to true to be a transcoder")
 	broadcaster := flag.Bool("broadcaster", false, "Set to true to be a broadcaster")
 	orchSecret := flag.String("orchSecret", "", "Shared secret with the orchestrator as a standalone transcoder")
+	orchReqMaxAge := flag.Duration("orchReqMaxAge", server.OrchestratorReqMaxAge, "Orchestrator only. Maximum difference between the time of a broadcaster request and the time of the orchestrator")
+	rejectLegacyOrchReqs := flag.Bool("rejectLegacyOrchReqs", false, "Orchestrator only. Set to true to reject requests from older broadcasters that do not sign a timestamp and can be replayed")
+	sendLegacyOrchReqs := flag.Bool("sendLegacyOrchReqs", true, "Broadcaster only. Set to false to never retry requests without a timestamp for older orchestrators that reject timestamped requests")
+	requireSignedOrchInfo := flag.Bool("requireSignedOrchInfo", false, "Broadcaster only. Set to true to reject on-chain orchestrators that do not sign their OrchestratorInfo and pin their TLS certificate. If false, signatures are only required from orchestrators that signed before")
 	transcodingOptions := flag.String("transcodingOptions", "P240p30fps16x9,P360p30fps16x9", "Transcoding options for broadcast job")
 	maxAttempts := flag.Int("maxAttempts", 3, "Maximum transcode attempts")
 	maxSessions := flag.Int("maxSessions", 10, "Maximum number of concurrent transcoding sessions for Orchestrator, maximum number or RTMP streams for Broadcaster, or maximum capacity for transcoder")
@@ -105,20 +120,46 @@ func main() {
 	ethAcctAddr := flag.String("ethAcctAddr", "", "Existing Eth account address")
 	ethPassword := flag.String("ethPassword", "", "Password for existing Eth account address")
 	ethKeystorePath := flag.String("ethKeystorePath", "", "Path for the Eth Key")
-	ethUrl := flag.String("ethUrl", "", "geth/parity rpc or websocket url")
+	ethUrl := flag.String("ethUrl", "", "geth/parity rpc or websocket url. Multiple comma-separated urls fail over to each other")
+	ethHealthCheckInterval := flag.Duration("ethHealthCheckInterval", 15*time.Second, "Interval at which multiple ethUrl endpoints are health checked")
+	ethMaxBlockLag := flag.Uint64("ethMaxBlockLag", 5, "Number of blocks that an ethUrl endpoint can fall behind the others before it is considered unhealthy")
+	ethSigner := flag.String("ethSigner", "", "URL or IPC path of a remote signer (e.g. clef) that holds the Eth account key instead of the keystore")
+	segmentSignerAddr := flag.String("segmentSignerAddr", "", "Address of an Eth account in the keystore that signs transcoded segments instead of the orchestrator account")
+	segmentSignerPassword := flag.String("segmentSignerPassword", "", "Password for the segment signer account")
+	senderAddrs := flag.String("senderAddrs", "", "Comma-separated addresses of additional Eth accounts in the keystore that a broadcaster pays tickets from. The accounts are unlocked with ethPassword")
 	ethController := flag.String("ethController", "", "Protocol smart contract address")
 	gasLimit := flag.Int("gasLimit", 0, "Gas limit for ETH transactions")
 	gasPrice := flag.Int("gasPrice", 0, "Gas price for ETH transactions")
+	txReplaceInterval := flag.Duration("txReplaceInterval", 5*time.Minute, "The time a transaction can be pending before it is replaced with a higher gas price")
+	maxTxGasPrice := flag.String("maxTxGasPrice", "", "The gas price (in wei) that replacement transactions do not exceed. If not set, there is no ceiling")
 	initializeRound := flag.Bool("initializeRound", false, "Set to true if running as a transcoder and the node should automatically initialize new rounds")
 	ticketEV := flag.String("ticketEV", "1000000000000", "The expected value for PM tickets")
 	// Broadcaster max acceptable ticket EV
 	maxTicketEV := flag.String("maxTicketEV", "100000000000000", "The maximum acceptable expected value for PM tickets")
 	// Broadcaster deposit multiplier to determine max acceptable ticket faceValue
 	depositMultiplier := flag.Int("depositMultiplier", 1, "The deposit multiplier used to determine max acceptable faceValue for PM tickets")
+	// Broadcaster automatic funding of the deposit and reserve
+	depositTarget := flag.String("depositTarget", "", "The amount (in wei) the broadcaster tops up its deposit to. If not set, the deposit is not topped up automatically")
+	depositThreshold := flag.String("depositThreshold", "", "The deposit (in wei) below which the broadcaster tops up its deposit. Defaults to -depositTarget")
+	reserveTarget := flag.String("reserveTarget", "", "The amount (in wei) the broadcaster tops up its reserve to. If not set, the reserve is not topped up automatically")
+	reserveThreshold := flag.String("reserveThreshold", "", "The reserve (in wei) below which the broadcaster tops up its reserve. Defaults to -reserveTarget")
+	maxFunding := flag.String("maxFunding", "", "The maximum total amount (in wei) the broadcaster adds to its deposit and reserve automatically. If not set, it is unlimited")
+	minETHBalance := flag.String("minEthBalance", "", "The ETH balance (in wei) the broadcaster keeps in its account for gas when topping up its deposit and reserve")
+	// Orchestrator gas-aware ticket redemption
+	redeemMaxGasPrice := flag.String("redeemMaxGasPrice", "", "The gas price (in wei) above which an orchestrator defers ticket redemptions. If not set, there is no ceiling")
+	redeemMaxCostRatio := flag.String("redeemMaxCostRatio", "", "The ratio of the redemption transaction cost to the ticket face value above which an orchestrator defers ticket redemptions, e.g. 0.1. If not set, there is no limit")
+	// Orchestrator automatic earnings claiming and fee withdrawal
+	earningsInterval := flag.Duration("earningsInterval", 0, "The interval at which an orchestrator claims earnings and withdraws fees automatically, e.g. 1h. If not set, earnings and fees are not claimed automatically")
+	minClaimRounds := flag.Int64("minClaimRounds", 10, "The number of unclaimed rounds at which an orchestrator claims earnings automatically")
+	maxClaimRounds := flag.Int64("maxClaimRounds", 20, "The maximum number of rounds an orchestrator claims earnings for in a single transaction")
+	feeGasMultiplier := flag.Int64("feeGasMultiplier", 10, "The multiplier on the gas cost of withdrawing fees that the fees must reach to be withdrawn automatically")
 	// Orchestrator base pricing info
 	pricePerUnit := flag.Int("pricePerUnit", 0, "The price per 'pixelsPerUnit' amount pixels")
 	// Broadcaster max acceptable price
 	maxPricePerUnit := flag.Int("maxPricePerUnit", 0, "The maximum transcoding price (in wei) per 'pixelsPerUnit' a broadcaster is willing to accept. If not set explicitly, broadcaster is willing to accept ANY price")
+	// Broadcaster spending budgets
+	streamBudget := flag.String("streamBudget", "", "The maximum amount (in wei) a broadcaster spends on a stream before it stops transcoding it. If not set, spending is unlimited")
+	dailyBudget := flag.String("dailyBudget", "", "The maximum amount (in wei) a broadcaster spends per day (UTC) before it stops transcoding. If not set, spending is unlimited")
 	// Unit of pixels for both O's basePriceInfo and B's MaxBroadcastPrice
 	pixelsPerUnit := flag.Int("pixelsPerUnit", 1, "Amount of pixels per unit. Set to '> 1' to have smaller price granularity than 1 wei / pixel")
 	// Interval to poll for blocks
@@ -138,11 +179,28 @@ func main() {
 	// API
 	authWebhookURL := flag.String("authWebhookUrl", "", "RTMP authentication webhook URL")
 	orchWebhookURL := flag.String("orchWebhookUrl", "", "Orchestrator discovery callback URL")
+	orchWebhookRefreshInterval := flag.Duration("orchWebhookRefreshInterval", time.Minute, "Minimum time between orchestrator discovery callback requests")
+	orchWebhookSecret := flag.String("orchWebhookSecret", "", "Secret to verify the HMAC-SHA256 signature of orchestrator discovery callback responses")
+	orchWebhookTags := flag.String("orchWebhookTags", "", "Comma-separated regions of the broadcaster. Orchestrators from the discovery callback with tags are only used if they have one of these tags")
+	orchFile := flag.String("orchFile", "", "Path to a JSON or YAML file of orchestrators to use for discovery. The file is reloaded when it changes")
+	orchDNS := flag.String("orchDNS", "", "DNS name to resolve orchestrators from. SRV records are used if the name has any, otherwise A and AAAA records with an optional name:port")
+	orchLatencyProbeInterval := flag.Duration("orchLatencyProbeInterval", 0, "Interval to measure the latency to orchestrators from -orchAddr or the on-chain orchestrator list to prefer nearby orchestrators. 0 disables latency measurement")
+	orchInfoCacheTTL := flag.Duration("orchInfoCacheTTL", 0, "Time to share the info of an orchestrator from -orchAddr or the on-chain orchestrator list between streams. 0 disables the info cache")
+	orchTiers := flag.String("orchTiers", "", "Comma separated orchestrator pools to use in order of priority with their payment mode, e.g. orchAddr:none,onchain:tickets. Pools are orchAddr, orchWebhookUrl, orchFile, orchDNS and onchain. Payment modes are tickets and none")
+	sessionPoolSize := flag.Int("sessionPoolSize", 0, "Number of orchestrators to keep ready for new streams. 0 disables the session pool")
+	sessionPoolTTL := flag.Duration("sessionPoolTTL", 1*time.Minute, "Time to keep an orchestrator in the session pool since its info was last fetched")
+	orchDNSServer := flag.String("orchDNSServer", "", "DNS server to use with -orchDNS. Defaults to the first nameserver in /etc/resolv.conf")
+	budgetWebhookURL := flag.String("budgetWebhookUrl", "", "URL notified when a stream reaches a spending budget")
+	fundingWebhookURL := flag.String("fundingWebhookUrl", "", "URL notified when the deposit and reserve cannot be topped up")
+
+	// Shutdown
+	drainTimeout := flag.Int("drainTimeout", 30, "Maximum time in seconds to wait for in-flight work to finish when shutting down")
 
 	flag.Parse()
 	vFlag.Value.Set(*verbosity)
 
 	blockPollingTime := time.Duration(*blockPollingInterval) * time.Second
+	drainDeadline := time.Duration(*drainTimeout) * time.Second
 
 	if *version {
 		fmt.Println("Livepeer Node Version: " + core.LivepeerVersion)
@@ -207,11 +265,11 @@ func main() {
 	}
 
 	if *datadir == "" {
-		homedir := os.Getenv("HOME")
-		if homedir == "" {
-			homedir = usr.HomeDir
+		dir, err := defaultDatadir(*network)
+		if err != nil {
+			glog.Fatal(err)
 		}
-		*datadir = filepath.Join(homedir, ".lpData", *network)
+		*datadir = dir
 	}
 
 	//Make sure datadir is present
@@ -223,7 +281,7 @@ func main() {
 	}
 
 	//Set up DB
-	dbh, err := common.InitDB(*datadir + "/lpdb.sqlite3")
+	dbh, err := common.InitDB(dbFile(*datadir))
 	if err != nil {
 		glog.Errorf("Error opening DB: %v", err)
 		return
@@ -284,11 +342,31 @@ func main() {
 		if n.OrchSecret == "" {
 			glog.Fatal("Missing -orchSecret")
 		}
-		if len(orchURLs) > 0 {
-			server.RunTranscoder(n, orchURLs[0].Host, *maxSessions)
-		} else {
+		if len(orchURLs) == 0 {
 			glog.Fatal("Missing -orchAddr")
 		}
+
+		// The CLI server allows draining the transcoder through the API
+		s := server.NewLivepeerServer(*rtmpAddr, n)
+		go s.StartCliWebserver(*cliAddr)
+
+		tc := make(chan struct{})
+		go func() {
+			server.RunTranscoder(n, orchURLs[0].Host, *maxSessions)
+			close(tc)
+		}()
+
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-tc:
+		case sig := <-c:
+			glog.Infof("Exiting Livepeer Transcoder: %v", sig)
+			drainNode(n, drainDeadline)
+		case <-n.Draining():
+			drainNode(n, drainDeadline)
+		}
+		// Exiting closes the connection to the orchestrator, unregistering the transcoder
 		return
 	}
 
@@ -322,10 +400,34 @@ func main() {
 		}
 
 		//Set up eth client
-		backend, err := ethclient.Dial(*ethUrl)
-		if err != nil {
-			glog.Errorf("Failed to connect to Ethereum client: %v", err)
-			return
+		var backend eth.Backend
+		var multiClient *eth.MultiClient
+		ethUrls := strings.Split(*ethUrl, ",")
+		if len(ethUrls) > 1 {
+			for i := range ethUrls {
+				ethUrls[i] = strings.TrimSpace(ethUrls[i])
+			}
+			multiClient, err = eth.NewMultiClient(ethUrls, eth.MultiClientConfig{
+				HealthCheckInterval: *ethHealthCheckInterval,
+				HealthCheckTimeout:  ethRPCTimeout,
+				MaxBlockLag:         *ethMaxBlockLag,
+			})
+			if err != nil {
+				glog.Errorf("Failed to connect to Ethereum client: %v", err)
+				return
+			}
+			if err := multiClient.Start(ctx); err != nil {
+				glog.Errorf("Failed to start Ethereum client: %v", err)
+				return
+			}
+			defer multiClient.Stop()
+			backend = multiClient
+		} else {
+			backend, err = ethclient.Dial(*ethUrl)
+			if err != nil {
+				glog.Errorf("Failed to connect to Ethereum client: %v", err)
+				return
+			}
 		}
 
 		chainID, err := backend.ChainID(ctx)
@@ -344,7 +446,17 @@ func main() {
 			return
 		}
 
-		client, err := eth.NewClient(ethcommon.HexToAddress(*ethAcctAddr), keystoreDir, backend, ethcommon.HexToAddress(*ethController), EthTxTimeout)
+		var client eth.LivepeerEthClient
+		if *ethSigner != "" {
+			am, err := eth.NewRemoteAccountManager(*ethSigner, ethcommon.HexToAddress(*ethAcctAddr), types.NewEIP155Signer(chainID))
+			if err != nil {
+				glog.Errorf("Failed to set up remote signer: %v", err)
+				return
+			}
+			client, err = eth.NewClientWithAccountManager(am, backend, ethcommon.HexToAddress(*ethController), EthTxTimeout)
+		} else {
+			client, err = eth.NewClient(ethcommon.HexToAddress(*ethAcctAddr), keystoreDir, backend, ethcommon.HexToAddress(*ethController), EthTxTimeout)
+		}
 		if err != nil {
 			glog.Errorf("Failed to create client: %v", err)
 			return
@@ -363,13 +475,36 @@ func main() {
 
 		n.Eth = client
 
+		// Track all transactions and replace transactions that are not mined in time
+		txBackend, err := client.Backend()
+		if err != nil {
+			glog.Errorf("Failed to get Ethereum client backend: %v", err)
+			return
+		}
+		tm := eth.NewTransactionManager(txBackend, client, dbh, eth.TxManagerConfig{
+			PollingInterval: blockPollingTime,
+			ReplaceInterval: *txReplaceInterval,
+			MaxGasPrice:     parseWei("maxTxGasPrice", *maxTxGasPrice),
+		})
+		if err := tm.Start(); err != nil {
+			glog.Errorf("Failed to start transaction manager: %v", err)
+			return
+		}
+		defer tm.Stop()
+		client.SetTransactionManager(tm)
+
 		addrMap := n.Eth.ContractAddresses()
 
 		// Initialize block watcher that will emit logs used by event watchers
-		blockWatcherClient, err := blockwatch.NewRPCClient(*ethUrl, ethRPCTimeout)
-		if err != nil {
-			glog.Errorf("Failed to setup blockwatch client: %v", err)
-			return
+		var blockWatcherClient *blockwatch.RPCClient
+		if multiClient != nil {
+			blockWatcherClient = blockwatch.NewRPCClientWithCaller(multiClient, multiClient, ethRPCTimeout)
+		} else {
+			blockWatcherClient, err = blockwatch.NewRPCClient(*ethUrl, ethRPCTimeout)
+			if err != nil {
+				glog.Errorf("Failed to setup blockwatch client: %v", err)
+				return
+			}
 		}
 		topics := watchers.FilterTopics()
 
@@ -454,6 +589,16 @@ func main() {
 		n.Balances = core.NewAddressBalances(cleanupInterval)
 		defer n.Balances.StopCleanup()
 
+		if *orchestrator {
+			// Restore the credit broadcasters have already paid for before a restart
+			if err := n.Balances.LoadBalances(dbh); err != nil {
+				glog.Errorf("Unable to load balances err=%v", err)
+				return
+			}
+			n.Balances.StartSnapshots(dbh, balanceSnapshotInterval)
+			defer n.Balances.StopSnapshots()
+		}
+
 		if *orchestrator {
 
 			// Set price per pixel base info
@@ -487,6 +632,28 @@ func main() {
 				return
 			}
 
+			if *segmentSignerAddr != "" {
+				if !ethcommon.IsHexAddress(*segmentSignerAddr) {
+					glog.Errorf("-segmentSignerAddr must be a valid address, but %v provided", *segmentSignerAddr)
+					return
+				}
+				am, err := eth.NewAccountManager(ethcommon.HexToAddress(*segmentSignerAddr), keystoreDir, types.NewEIP155Signer(chainID))
+				if err != nil {
+					glog.Errorf("Failed to create segment signer account manager: %v", err)
+					return
+				}
+				if err := am.Unlock(*segmentSignerPassword); err != nil {
+					glog.Errorf("Failed to unlock segment signer account: %v", err)
+					return
+				}
+				n.SegmentSigner, err = core.NewSegmentSigner(am, n.Eth)
+				if err != nil {
+					glog.Errorf("Failed to set up segment signer: %v", err)
+					return
+				}
+				glog.Infof("Signing transcoded segments with segment signer %v", n.SegmentSigner.Address().Hex())
+			}
+
 			sigVerifier := &pm.DefaultSigVerifier{}
 			validator := pm.NewValidator(sigVerifier, timeWatcher)
 			gpm := eth.NewGasPriceMonitor(backend, blockPollingTime)
@@ -497,6 +664,7 @@ func main() {
 				return
 			}
 			defer gpm.Stop()
+			n.GasPriceMonitor = gpm
 
 			sm := pm.NewSenderMonitor(n.Eth.Account().Address, n.Eth, senderWatcher, timeWatcher, cleanupInterval, smTTL)
 			// Start sender monitor
@@ -504,9 +672,11 @@ func main() {
 			defer sm.Stop()
 
 			cfg := pm.TicketParamsConfig{
-				EV:               ev,
-				RedeemGas:        redeemGas,
-				TxCostMultiplier: txCostMultiplier,
+				EV:                 ev,
+				RedeemGas:          redeemGas,
+				TxCostMultiplier:   txCostMultiplier,
+				RedeemMaxGasPrice:  parseWei("redeemMaxGasPrice", *redeemMaxGasPrice),
+				RedeemMaxCostRatio: parseRatio("redeemMaxCostRatio", *redeemMaxCostRatio),
 			}
 			n.Recipient, err = pm.NewRecipient(
 				n.Eth.Account().Address,
@@ -535,8 +705,26 @@ func main() {
 
 			// Create reward service to claim/distribute inflationary rewards every round
 			rs := eventservices.NewRewardService(n.Eth, blockPollingTime)
+			rs.SetTransactionManager(tm)
 			rs.Start(ctx)
 			defer rs.Stop()
+
+			// Create earnings service to claim earnings and withdraw fees periodically
+			if *earningsInterval > 0 {
+				if *minClaimRounds <= 0 || *maxClaimRounds <= 0 || *feeGasMultiplier <= 0 {
+					glog.Errorf("-minClaimRounds, -maxClaimRounds and -feeGasMultiplier must be greater than 0")
+					return
+				}
+				es := eventservices.NewEarningsService(n.Eth, gpm, eventservices.EarningsServiceConfig{
+					PollingInterval:  *earningsInterval,
+					MinClaimRounds:   *minClaimRounds,
+					MaxClaimRounds:   *maxClaimRounds,
+					WithdrawGas:      uint64(withdrawFeesGas),
+					FeeGasMultiplier: *feeGasMultiplier,
+				})
+				es.Start(ctx)
+				defer es.Stop()
+			}
 		}
 
 		if n.NodeType == core.BroadcasterNode {
@@ -553,7 +741,62 @@ func main() {
 				panic(fmt.Errorf("-depositMultiplier must be greater than 0, but %v provided. Restart the node with a valid value for -depositMultiplier", *depositMultiplier))
 			}
 
-			n.Sender = pm.NewSender(n.Eth, timeWatcher, senderWatcher, ev, *depositMultiplier)
+			if *senderAddrs != "" {
+				signers := []pm.Signer{n.Eth}
+				for _, addr := range strings.Split(*senderAddrs, ",") {
+					addr = strings.TrimSpace(addr)
+					if !ethcommon.IsHexAddress(addr) {
+						glog.Errorf("-senderAddrs must be valid addresses, but %v provided", addr)
+						return
+					}
+					am, err := eth.NewAccountManager(ethcommon.HexToAddress(addr), keystoreDir, types.NewEIP155Signer(chainID))
+					if err != nil {
+						glog.Errorf("Failed to create sender account manager: %v", err)
+						return
+					}
+					if err := am.Unlock(*ethPassword); err != nil {
+						glog.Errorf("Failed to unlock sender account %v: %v", addr, err)
+						return
+					}
+					signers = append(signers, am)
+				}
+				n.Sender = pm.NewSenderPool(signers, timeWatcher, senderWatcher, ev, *depositMultiplier)
+				glog.Infof("Paying tickets from %v sender accounts", len(signers))
+			} else {
+				n.Sender = pm.NewSender(n.Eth, timeWatcher, senderWatcher, ev, *depositMultiplier)
+			}
+
+			if *depositTarget != "" || *reserveTarget != "" {
+				if *fundingWebhookURL != "" {
+					if _, err := validateURL(*fundingWebhookURL); err != nil {
+						glog.Fatal("Error setting funding webhook URL ", err)
+					}
+					glog.Info("Using funding webhook URL ", *fundingWebhookURL)
+					server.FundingWebhookURL = *fundingWebhookURL
+				}
+
+				backend, err := n.Eth.Backend()
+				if err != nil {
+					glog.Errorf("Failed to get Ethereum backend: %v", err)
+					return
+				}
+				cfg := pm.AutoFundConfig{
+					DepositTarget:    parseWei("depositTarget", *depositTarget),
+					DepositThreshold: parseWei("depositThreshold", *depositThreshold),
+					ReserveTarget:    parseWei("reserveTarget", *reserveTarget),
+					ReserveThreshold: parseWei("reserveThreshold", *reserveThreshold),
+					MaxSpend:         parseWei("maxFunding", *maxFunding),
+					MinBalance:       parseWei("minEthBalance", *minETHBalance),
+				}
+				funder, err := pm.NewAutoFunder(n.Eth.Account().Address, cfg, n.Eth, senderWatcher, timeWatcher, backend, dbh, server.NotifyFundingWarning)
+				if err != nil {
+					glog.Errorf("Error setting up automatic funding: %v", err)
+					return
+				}
+				glog.Infof("Automatically funding depositTarget=%v depositThreshold=%v reserveTarget=%v reserveThreshold=%v", cfg.DepositTarget, cfg.DepositThreshold, cfg.ReserveTarget, cfg.ReserveThreshold)
+				funder.Start()
+				defer funder.Stop()
+			}
 
 			if *pixelsPerUnit <= 0 {
 				// Can't divide by 0
@@ -636,6 +879,26 @@ func main() {
 		*httpAddr = defaultAddr(*httpAddr, "127.0.0.1", RpcPort)
 
 		bcast := core.NewBroadcaster(n)
+		server.RequireSignedOrchestratorInfo = *requireSignedOrchInfo
+		server.SendLegacyOrchestratorReqs = *sendLegacyOrchReqs
+
+		// latencyPool is the orchestrator pool that prefers nearby orchestrators, if any
+		var latency *discovery.LatencyTracker
+		var latencyPool common.OrchestratorPool
+		var usesLatencyPool bool
+		// pools are the configured orchestrator pools by the name of their flag
+		pools := make(map[string]common.OrchestratorPool)
+		if *orchLatencyProbeInterval > 0 {
+			latency = discovery.NewLatencyTracker()
+		}
+		var infoCache *discovery.OrchestratorInfoCache
+		if *orchInfoCacheTTL > 0 {
+			if timeWatcher != nil {
+				infoCache = discovery.NewOrchestratorInfoCache(*orchInfoCacheTTL, timeWatcher)
+			} else {
+				infoCache = discovery.NewOrchestratorInfoCache(*orchInfoCacheTTL, nil)
+			}
+		}
 
 		// When the node is on-chain mode always cache the on-chain orchestrators and poll for updates
 		// Right now we rely on the DBOrchestratorPoolCache constructor to do this. Consider separating the logic
@@ -647,8 +910,17 @@ func main() {
 			if err != nil {
 				glog.Errorf("Could not create orchestrator pool with DB cache: %v", err)
 			}
+			if dbOrchPoolCache != nil && latency != nil {
+				dbOrchPoolCache.SetLatencyTracker(latency)
+				latencyPool = dbOrchPoolCache
+			}
+			if dbOrchPoolCache != nil && infoCache != nil {
+				dbOrchPoolCache.SetInfoCache(infoCache)
+			}
 
-			n.OrchestratorPool = dbOrchPoolCache
+			if dbOrchPoolCache != nil {
+				pools["onchain"] = dbOrchPoolCache
+			}
 		}
 
 		// Set up orchestrator discovery
@@ -658,14 +930,97 @@ func main() {
 				glog.Fatal("Error setting orch webhook URL ", err)
 			}
 			glog.Info("Using orchestrator webhook URL ", whurl)
-			n.OrchestratorPool = discovery.NewWebhookPool(bcast, whurl)
-		} else if len(orchURLs) > 0 {
-			n.OrchestratorPool = discovery.NewOrchestratorPool(bcast, orchURLs)
+			whCfg := discovery.WebhookPoolConfig{
+				RefreshInterval: *orchWebhookRefreshInterval,
+				Secret:          []byte(*orchWebhookSecret),
+			}
+			if *orchWebhookTags != "" {
+				for _, tag := range strings.Split(*orchWebhookTags, ",") {
+					whCfg.Tags = append(whCfg.Tags, strings.TrimSpace(tag))
+				}
+			}
+			pools["orchWebhookUrl"] = discovery.NewWebhookPoolWithConfig(bcast, whurl, whCfg)
+		}
+		if *orchFile != "" {
+			filePool, err := discovery.NewFileOrchestratorPool(ctx, bcast, *orchFile)
+			if err != nil {
+				glog.Fatalf("Error loading orchestrator file path=%v err=%v", *orchFile, err)
+			}
+			glog.Info("Using orchestrator file ", *orchFile)
+			pools["orchFile"] = filePool
+		}
+		if *orchDNS != "" {
+			glog.Info("Using orchestrator DNS name ", *orchDNS)
+			pools["orchDNS"] = discovery.NewDNSPool(bcast, *orchDNS, *orchDNSServer)
+		}
+		if len(orchURLs) > 0 {
+			pool := discovery.NewOrchestratorPoolWithLatency(bcast, orchURLs, latency)
+			if latency != nil {
+				latencyPool = pool
+			}
+			if infoCache != nil {
+				pool.SetInfoCache(infoCache)
+			}
+			pools["orchAddr"] = pool
+		}
+
+		if *orchTiers != "" {
+			var tiers []discovery.PoolTier
+			for i, t := range strings.Split(*orchTiers, ",") {
+				parts := strings.SplitN(strings.TrimSpace(t), ":", 2)
+				payment := discovery.PaymentTickets
+				if len(parts) == 2 {
+					payment, err = discovery.ParsePaymentMode(parts[1])
+					if err != nil {
+						glog.Fatalf("Error setting orchestrator tier %v err=%v", t, err)
+					}
+				}
+				pool, ok := pools[parts[0]]
+				if !ok {
+					glog.Fatalf("Orchestrator tier %v is not configured", parts[0])
+				}
+				if latencyPool != nil && latencyPool == pool {
+					usesLatencyPool = true
+				}
+				glog.Infof("Using orchestrator tier priority=%v pool=%v payment=%v", i, parts[0], payment)
+				tiers = append(tiers, discovery.PoolTier{Pool: pool, Priority: i, Payment: payment})
+			}
+			n.OrchestratorPool = discovery.NewTieredPool(tiers...)
+		} else {
+			// Without tiers the first configured pool is used
+			for _, name := range []string{"orchWebhookUrl", "orchFile", "orchDNS", "orchAddr", "onchain"} {
+				if pool, ok := pools[name]; ok {
+					n.OrchestratorPool = pool
+					usesLatencyPool = latencyPool != nil && latencyPool == pool
+					break
+				}
+			}
+		}
+
+		if usesLatencyPool {
+			glog.Info("Measuring orchestrator latency every ", *orchLatencyProbeInterval)
+			go latency.Probe(ctx, latencyPool.GetURLs, *orchLatencyProbeInterval)
+		} else if latency != nil {
+			glog.Error("Orchestrator latency is only measured for -orchAddr and on-chain orchestrators")
+		}
+
+		n.OrchFilter, err = common.NewOrchestratorFilter(n.Database)
+		if err != nil {
+			glog.Fatalf("Error loading orchestrator lists err=%v", err)
+		}
+		if p, ok := n.OrchestratorPool.(interface {
+			SetFilter(*common.OrchestratorFilter)
+		}); ok {
+			p.SetFilter(n.OrchFilter)
 		}
 
 		if n.OrchestratorPool == nil {
 			// Not a fatal error; may continue operating in segment-only mode
 			glog.Error("No orchestrator specified; transcoding will not happen")
+		} else if *sessionPoolSize > 0 {
+			n.SessionPool = core.NewSessionPool(n.OrchestratorPool, n.Sender, *sessionPoolSize, *sessionPoolTTL)
+			glog.Infof("Keeping %d orchestrators ready for new streams", *sessionPoolSize)
+			go n.SessionPool.Fill()
 		}
 		if *authWebhookURL != "" {
 			_, err := validateURL(*authWebhookURL)
@@ -676,6 +1031,22 @@ func main() {
 			server.AuthWebhookURL = *authWebhookURL
 		}
 
+		// Set up spending budgets
+		if *budgetWebhookURL != "" {
+			_, err := validateURL(*budgetWebhookURL)
+			if err != nil {
+				glog.Fatal("Error setting budget webhook URL ", err)
+			}
+			glog.Info("Using budget webhook URL ", *budgetWebhookURL)
+			server.BudgetWebhookURL = *budgetWebhookURL
+		}
+		n.Spending, err = core.NewSpendTracker(dbh, server.NotifyBudgetExceeded)
+		if err != nil {
+			glog.Errorf("Error loading spending err=%v", err)
+			return
+		}
+		n.Spending.SetBudgets(parseBudget("streamBudget", *streamBudget), parseBudget("dailyBudget", *dailyBudget))
+
 		// Set up verifier
 		if *verifierURL != "" {
 			_, err := validateURL(*verifierURL)
@@ -713,6 +1084,12 @@ func main() {
 		if !*transcoder && n.OrchSecret == "" {
 			glog.Fatal("Running an orchestrator requires an -orchSecret for standalone mode or -transcoder for orchestrator+transcoder mode")
 		}
+
+		if *orchReqMaxAge <= 0 {
+			glog.Fatal("-orchReqMaxAge must be greater than 0")
+		}
+		server.OrchestratorReqMaxAge = *orchReqMaxAge
+		server.AcceptLegacyOrchestratorReqs = !*rejectLegacyOrchReqs
 	}
 	*cliAddr = defaultAddr(*cliAddr, "127.0.0.1", CliPort)
 
@@ -782,8 +1159,8 @@ func main() {
 		glog.Infof("**Liveepeer Running in Transcoder Mode***")
 	}
 
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt)
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	select {
 	case err := <-watcherErr:
 		glog.Error(err)
@@ -801,9 +1178,61 @@ func main() {
 		return
 	case sig := <-c:
 		glog.Infof("Exiting Livepeer: %v", sig)
+		drainNode(n, drainDeadline)
 		time.Sleep(time.Millisecond * 500) //Give time for other processes to shut down completely
 		return
+	case <-n.Draining():
+		glog.Infof("Exiting Livepeer: drain requested")
+		drainNode(n, drainDeadline)
+		time.Sleep(time.Millisecond * 500) //Give time for other processes to shut down completely
+		return
+	}
+}
+
+// drainNode stops the node from accepting new work and waits up to the
+// deadline for its in-flight work to finish
+func drainNode(n *core.LivepeerNode, deadline time.Duration) {
+	glog.Infof("Draining node; waiting up to %v for %v units of in-flight work", deadline, n.ActiveWork())
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+	if err := n.Drain(ctx); err != nil {
+		glog.Errorf("Node did not finish draining: %v", err)
+		return
+	}
+	glog.Info("Node drained")
+}
+
+// parseBudget parses a budget flag in wei. An empty budget is unlimited
+func parseBudget(name, val string) *big.Rat {
+	b := parseWei(name, val)
+	if b == nil {
+		return nil
+	}
+	return new(big.Rat).SetInt(b)
+}
+
+// parseWei parses a flag in wei, returning nil if the flag is not set
+func parseWei(name, val string) *big.Int {
+	if val == "" {
+		return nil
+	}
+	b, err := common.ParseBigInt(val)
+	if err != nil || b.Sign() < 0 {
+		glog.Fatalf("Invalid %v: %v", name, val)
+	}
+	return b
+}
+
+// parseRatio parses a flag as a non-negative rational number, returning nil if the flag is not set
+func parseRatio(name, val string) *big.Rat {
+	if val == "" {
+		return nil
+	}
+	r, ok := new(big.Rat).SetString(val)
+	if !ok || r.Sign() < 0 {
+		glog.Fatalf("Invalid %v: %v", name, val)
 	}
+	return r
 }
 
 func validateURL(u string) (*url.URL, error) {
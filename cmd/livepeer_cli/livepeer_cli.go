@@ -101,6 +101,9 @@ func (w *wizard) initializeOptions() []wizardOpt {
 		{desc: "Invoke \"cancel unlock of broadcasting funds\"", invoke: w.cancelUnlock, notOrchestrator: true},
 		{desc: "Invoke \"withdraw broadcasting funds\"", invoke: w.withdraw, notOrchestrator: true},
 		{desc: "Set broadcast config", invoke: w.setBroadcastConfig, notOrchestrator: true},
+		{desc: "View orchestrator allowlist and denylist", invoke: w.orchestratorLists, notOrchestrator: true},
+		{desc: "Add orchestrator to allowlist or denylist", invoke: func() { w.updateOrchestratorList("add") }, notOrchestrator: true},
+		{desc: "Remove orchestrator from allowlist or denylist", invoke: func() { w.updateOrchestratorList("remove") }, notOrchestrator: true},
 		{desc: "Set Eth gas price", invoke: w.setGasPrice},
 		{desc: "Get test LPT", invoke: w.requestTokens, testnet: true},
 		{desc: "Get test ETH", invoke: func() {
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/golang/glog"
+)
+
+type orchLists struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+func (w *wizard) orchestratorLists() {
+	result := httpGet(fmt.Sprintf("http://%v:%v/orchestratorLists", w.host, w.httpPort))
+	if result == "" {
+		glog.Errorf("Error getting orchestrator lists")
+		return
+	}
+
+	var lists orchLists
+	if err := json.Unmarshal([]byte(result), &lists); err != nil {
+		glog.Errorf("Error unmarshalling orchestrator lists: %v", err)
+		return
+	}
+
+	wtr := tabwriter.NewWriter(os.Stdout, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(wtr, "List\tOrchestrator")
+	for _, entry := range lists.Allow {
+		fmt.Fprintf(wtr, "allow\t%v\n", entry)
+	}
+	for _, entry := range lists.Deny {
+		fmt.Fprintf(wtr, "deny\t%v\n", entry)
+	}
+	wtr.Flush()
+
+	if len(lists.Allow) > 0 {
+		fmt.Println("Only orchestrators on the allowlist are used")
+	}
+}
+
+func (w *wizard) updateOrchestratorList(action string) {
+	fmt.Printf("Enter the list (allow or deny) - ")
+	list := w.readStringAndValidate(func(in string) (string, error) {
+		if in != "allow" && in != "deny" {
+			return "", errors.New("Enter allow or deny")
+		}
+		return in, nil
+	})
+
+	fmt.Printf("Enter the orchestrator ethereum address or service URI (e.g. https://127.0.0.1:8935) - ")
+	addr := w.readString()
+
+	val := url.Values{
+		"list":    {list},
+		"address": {addr},
+		"action":  {action},
+	}
+	httpPostWithParams(fmt.Sprintf("http://%v:%v/orchestratorLists", w.host, w.httpPort), val)
+
+	w.orchestratorLists()
+}
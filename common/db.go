@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -37,6 +38,7 @@ type DB struct {
 	findLatestMiniHeader             *sql.Stmt
 	findAllMiniHeadersSortedByNumber *sql.Stmt
 	deleteMiniHeader                 *sql.Stmt
+	insertLedgerEntry                *sql.Stmt
 }
 
 // DBOrch is the type binding for a row result from the orchestrators table
@@ -57,6 +59,129 @@ type DBUnbondingLock struct {
 	WithdrawRound int64
 }
 
+// DBBalance is the type binding for a row result from the balances table
+type DBBalance struct {
+	Sender     ethcommon.Address
+	ManifestID string
+	Amount     *big.Rat
+	UpdatedAt  time.Time
+}
+
+// DBBalanceFilter is an object used to attach a filter to a Balances query
+type DBBalanceFilter struct {
+	Sender     *ethcommon.Address
+	ManifestID string
+}
+
+// Types of entries in the ledger table
+const (
+	LedgerTicket     = "ticket"
+	LedgerDebit      = "debit"
+	LedgerRedemption = "redemption"
+)
+
+// DBLedgerEntry is the type binding for a row result from the ledger table
+type DBLedgerEntry struct {
+	ID         int64
+	CreatedAt  time.Time
+	Type       string
+	Sender     ethcommon.Address
+	ManifestID string
+	// Amount is the EV of a received ticket, the fees of a debit or the
+	// face value of a redeemed ticket in wei
+	Amount     *big.Rat
+	FaceValue  *big.Int
+	Won        bool
+	Pixels     int64
+	TicketHash ethcommon.Hash
+	TxHash     ethcommon.Hash
+	Error      string
+}
+
+// DBLedgerFilter is an object used to attach a filter to a LedgerEntries query.
+// From is inclusive and To is exclusive
+type DBLedgerFilter struct {
+	Sender     *ethcommon.Address
+	ManifestID string
+	Type       string
+	From       time.Time
+	To         time.Time
+}
+
+// DBSpending is the type binding for a row result from the spending table
+type DBSpending struct {
+	CreatedAt  time.Time
+	ManifestID string
+	// Orchestrator is the service URI of the orchestrator that was paid
+	Orchestrator string
+	// Recipient is the address that tickets were sent to
+	Recipient ethcommon.Address
+	Tickets   int
+	// EV is the total expected value of the tickets sent in wei
+	EV     *big.Rat
+	Pixels int64
+	// Fees are the transcoding fees for Pixels at the orchestrator's price in wei
+	Fees *big.Rat
+}
+
+// DBSpendingFilter is an object used to attach a filter to a SpendingEntries query.
+// From is inclusive and To is exclusive
+type DBSpendingFilter struct {
+	ManifestID   string
+	Orchestrator string
+	From         time.Time
+	To           time.Time
+}
+
+// Statuses of transactions in the txs table
+const (
+	TxPending = "pending"
+	TxMined   = "mined"
+	// TxFailed is a transaction that was mined but reverted
+	TxFailed = "failed"
+	// TxDropped is a transaction whose nonce was used by another transaction
+	TxDropped = "dropped"
+)
+
+// DBTx is the type binding for a row result from the txs table. A transaction
+// is identified by the hash of its first submission and keeps the same nonce
+// when it is replaced with a higher gas price
+type DBTx struct {
+	Hash        ethcommon.Hash
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Sender      ethcommon.Address
+	Nonce       uint64
+	Method      string
+	CurrentHash ethcommon.Hash
+	GasPrice    *big.Int
+	Attempts    int
+	Status      string
+	// RawTx is the RLP encoding of the latest signed transaction
+	RawTx []byte
+	Error string
+}
+
+// DBTxFilter is an object used to attach a filter to a Txs query
+type DBTxFilter struct {
+	Sender *ethcommon.Address
+	Status string
+}
+
+// Lists of orchestrators in the orchLists table
+const (
+	OrchAllowList = "allow"
+	OrchDenyList  = "deny"
+)
+
+// DBOrchListEntry is the type binding for a row result from the orchLists table.
+// Value is an ethereum address or a service URI
+type DBOrchListEntry struct {
+	List      string
+	Value     string
+	CreatedAt time.Time
+}
+
 // DBOrchFilter is an object used to attach a filter to a selectOrch query
 type DBOrchFilter struct {
 	MaxPrice     *big.Rat
@@ -64,7 +189,9 @@ type DBOrchFilter struct {
 	Addresses    []ethcommon.Address
 }
 
-var LivepeerDBVersion = 1
+// LivepeerDBVersion is the schema version expected by this node, i.e. the
+// version of the last migration in dbMigrations
+var LivepeerDBVersion = 7
 
 var ErrDBTooNew = errors.New("DB Too New")
 
@@ -76,6 +203,12 @@ var schema = `
 	);
 	INSERT OR IGNORE INTO kv(key, value) VALUES('dbVersion', '{{ . }}');
 
+	CREATE TABLE IF NOT EXISTS schemaMigrations (
+		version INTEGER PRIMARY KEY,
+		description STRING,
+		appliedAt STRING DEFAULT CURRENT_TIMESTAMP
+	);
+
 	CREATE TABLE IF NOT EXISTS orchestrators (
 		ethereumAddr STRING PRIMARY KEY,
 		createdAt STRING DEFAULT CURRENT_TIMESTAMP NOT NULL,
@@ -151,7 +284,7 @@ func InitDB(dbPath string) (*DB, error) {
 	d.dbh = db
 	schemaBuf := new(bytes.Buffer)
 	tmpl := template.Must(template.New("schema").Parse(schema))
-	tmpl.Execute(schemaBuf, LivepeerDBVersion)
+	tmpl.Execute(schemaBuf, baseDBVersion)
 	_, err = db.Exec(schemaBuf.String())
 	if err != nil {
 		glog.Error("Error initializing schema ", err)
@@ -169,14 +302,18 @@ func InitDB(dbPath string) (*DB, error) {
 		return nil, err
 	}
 	if dbVersion > LivepeerDBVersion {
-		glog.Error("Database too new")
+		glog.Errorf("Database too new dbVersion=%v supportedVersion=%v", dbVersion, LivepeerDBVersion)
 		d.Close()
 		return nil, ErrDBTooNew
 	} else if dbVersion < LivepeerDBVersion {
 		// Upgrade stepwise up to the correct version using the migration
 		// procedure for each version
-	} else if dbVersion == LivepeerDBVersion {
-		// all good; nothing to do
+		glog.Infof("Migrating database from version %v to %v", dbVersion, LivepeerDBVersion)
+		if _, err := migrateDB(db, dbVersion); err != nil {
+			glog.Error("Unable to migrate DB ", err)
+			d.Close()
+			return nil, err
+		}
 	}
 
 	// selectKV prepared statement
@@ -313,6 +450,23 @@ func InitDB(dbPath string) (*DB, error) {
 	}
 	d.deleteMiniHeader = stmt
 
+	// Insert ledger entry. Redemptions don't know their manifestID so it is
+	// looked up from the entry of the redeemed ticket
+	stmt, err = db.Prepare(`
+	INSERT INTO ledger(createdAt, type, sender, manifestID, amount, faceValue, won, pixels, ticketHash, txHash, error)
+	VALUES(:createdAt, :type, :sender,
+		CASE WHEN :manifestID = '' AND :ticketHash != ''
+		THEN (SELECT manifestID FROM ledger WHERE ticketHash = :ticketHash AND type = 'ticket' LIMIT 1)
+		ELSE :manifestID END,
+		:amount, :faceValue, :won, :pixels, :ticketHash, :txHash, :error)
+	`)
+	if err != nil {
+		glog.Error("Unable to prepare insertLedgerEntry ", err)
+		d.Close()
+		return nil, err
+	}
+	d.insertLedgerEntry = stmt
+
 	glog.V(DEBUG).Info("Initialized DB node")
 	return &d, nil
 }
@@ -358,6 +512,9 @@ func (db *DB) Close() {
 	if db.deleteMiniHeader != nil {
 		db.deleteMiniHeader.Close()
 	}
+	if db.insertLedgerEntry != nil {
+		db.insertLedgerEntry.Close()
+	}
 	if db.dbh != nil {
 		db.dbh.Close()
 	}
@@ -401,6 +558,51 @@ func (db *DB) SetChainID(id *big.Int) error {
 	return nil
 }
 
+// CheckWritable verifies that the DB accepts writes by updating
+// the time of the last health check stored in the kv table
+func (db *DB) CheckWritable() error {
+	if db == nil || db.dbh == nil {
+		return fmt.Errorf("database is not initialized")
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := db.updateKVStore("lastHealthCheck", now); err != nil {
+		return err
+	}
+	val, err := db.selectKVStore("lastHealthCheck")
+	if err != nil {
+		return err
+	}
+	if val != now {
+		return fmt.Errorf("read back unexpected value %v", val)
+	}
+	return nil
+}
+
+// FundedAmount returns the total amount funded automatically for addr
+func (db *DB) FundedAmount(addr ethcommon.Address) (*big.Int, error) {
+	val, err := db.selectKVStore(fundedAmountKey(addr))
+	if err != nil {
+		return nil, err
+	}
+	if val == "" {
+		return big.NewInt(0), nil
+	}
+	amount, ok := new(big.Int).SetString(val, 10)
+	if !ok {
+		return nil, fmt.Errorf("unable to convert funded amount %v to big.Int", val)
+	}
+	return amount, nil
+}
+
+// SetFundedAmount stores the total amount funded automatically for addr
+func (db *DB) SetFundedAmount(addr ethcommon.Address, amount *big.Int) error {
+	return db.updateKVStore(fundedAmountKey(addr), amount.String())
+}
+
+func fundedAmountKey(addr ethcommon.Address) string {
+	return "fundedAmount-" + addr.Hex()
+}
+
 func (db *DB) selectKVStore(key string) (string, error) {
 	row := db.selectKV.QueryRow(key)
 	var valueString string
@@ -804,6 +1006,622 @@ func (db *DB) DeleteMiniHeader(hash ethcommon.Hash) error {
 	return nil
 }
 
+// InsertLedgerEntry adds an entry to the ledger. If CreatedAt is not set the
+// current time is used
+func (db *DB) InsertLedgerEntry(e *DBLedgerEntry) error {
+	if db == nil || e == nil {
+		return nil
+	}
+
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	var amount, faceValue, ticketHash, txHash string
+	if e.Amount != nil {
+		amount = e.Amount.String()
+	}
+	if e.FaceValue != nil {
+		faceValue = e.FaceValue.String()
+	}
+	if (e.TicketHash != ethcommon.Hash{}) {
+		ticketHash = e.TicketHash.Hex()
+	}
+	if (e.TxHash != ethcommon.Hash{}) {
+		txHash = e.TxHash.Hex()
+	}
+
+	_, err := db.insertLedgerEntry.Exec(
+		sql.Named("createdAt", createdAt.UTC().Format(dbTimeLayout)),
+		sql.Named("type", e.Type),
+		sql.Named("sender", e.Sender.Hex()),
+		sql.Named("manifestID", e.ManifestID),
+		sql.Named("amount", amount),
+		sql.Named("faceValue", faceValue),
+		sql.Named("won", e.Won),
+		sql.Named("pixels", e.Pixels),
+		sql.Named("ticketHash", ticketHash),
+		sql.Named("txHash", txHash),
+		sql.Named("error", e.Error),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed inserting %v ledger entry for sender: %v", e.Type, e.Sender.Hex())
+	}
+	return nil
+}
+
+// StoreRedemption records the outcome of redeeming a winning ticket in the ledger
+func (db *DB) StoreRedemption(ticket *pm.Ticket, txHash ethcommon.Hash, redeemErr error) error {
+	if ticket == nil {
+		return errors.New("cannot store nil ticket")
+	}
+
+	e := &DBLedgerEntry{
+		Type:       LedgerRedemption,
+		Sender:     ticket.Sender,
+		Amount:     new(big.Rat).SetInt(ticket.FaceValue),
+		FaceValue:  ticket.FaceValue,
+		Won:        true,
+		TicketHash: ticket.Hash(),
+		TxHash:     txHash,
+	}
+	if redeemErr != nil {
+		e.Error = redeemErr.Error()
+	}
+	return db.InsertLedgerEntry(e)
+}
+
+// StoreDeferredTicket persists a winning ticket whose redemption is deferred
+// so that it is queued again after a restart
+func (db *DB) StoreDeferredTicket(ticket *pm.SignedTicket) error {
+	if ticket == nil || ticket.Ticket == nil {
+		return errors.New("cannot store nil ticket")
+	}
+	if ticket.Sig == nil {
+		return errors.New("cannot store nil sig")
+	}
+	if ticket.RecipientRand == nil {
+		return errors.New("cannot store nil recipientRand")
+	}
+
+	var paramsExpirationBlock, pricePerPixel sql.NullString
+	if ticket.ParamsExpirationBlock != nil {
+		paramsExpirationBlock = sql.NullString{String: ticket.ParamsExpirationBlock.String(), Valid: true}
+	}
+	if ticket.PricePerPixel != nil {
+		pricePerPixel = sql.NullString{String: ticket.PricePerPixel.RatString(), Valid: true}
+	}
+
+	_, err := db.dbh.Exec(`
+	INSERT OR IGNORE INTO deferredTickets(ticketHash, createdAt, sender, recipient, faceValue, winProb, senderNonce, recipientRandHash,
+		creationRound, creationRoundBlockHash, paramsExpirationBlock, pricePerPixel, sig, recipientRand)
+	VALUES(?, datetime(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ticket.Hash().Hex(), ticket.Sender.Hex(), ticket.Recipient.Hex(), ticket.FaceValue.String(), ticket.WinProb.String(),
+		ticket.SenderNonce, ticket.RecipientRandHash.Hex(), ticket.CreationRound, ticket.CreationRoundBlockHash.Hex(),
+		paramsExpirationBlock, pricePerPixel, ticket.Sig, ticket.RecipientRand.Bytes(),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed inserting deferred ticket for sender: %v", ticket.Sender.Hex())
+	}
+	return nil
+}
+
+// RemoveDeferredTicket removes a ticket whose redemption is no longer deferred
+func (db *DB) RemoveDeferredTicket(ticket *pm.Ticket) error {
+	if ticket == nil {
+		return errors.New("cannot remove nil ticket")
+	}
+
+	_, err := db.dbh.Exec("DELETE FROM deferredTickets WHERE ticketHash = ?", ticket.Hash().Hex())
+	if err != nil {
+		return errors.Wrapf(err, "failed removing deferred ticket for sender: %v", ticket.Sender.Hex())
+	}
+	return nil
+}
+
+// LoadDeferredTickets returns the deferred tickets in the order they were deferred
+func (db *DB) LoadDeferredTickets() ([]*pm.SignedTicket, error) {
+	rows, err := db.dbh.Query(`
+	SELECT sender, recipient, faceValue, winProb, senderNonce, recipientRandHash, creationRound, creationRoundBlockHash,
+		paramsExpirationBlock, pricePerPixel, sig, recipientRand
+	FROM deferredTickets ORDER BY createdAt, rowid`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed loading deferred tickets")
+	}
+	defer rows.Close()
+
+	tickets := []*pm.SignedTicket{}
+	for rows.Next() {
+		var (
+			sender, recipient, faceValue, winProb, recipientRandHash, creationRoundBlockHash string
+			paramsExpirationBlock, pricePerPixel                                             sql.NullString
+			senderNonce                                                                      uint32
+			creationRound                                                                    int64
+			sig, recipientRand                                                               []byte
+		)
+		if err := rows.Scan(&sender, &recipient, &faceValue, &winProb, &senderNonce, &recipientRandHash, &creationRound, &creationRoundBlockHash,
+			&paramsExpirationBlock, &pricePerPixel, &sig, &recipientRand); err != nil {
+			return nil, errors.Wrap(err, "failed scanning a deferred ticket row")
+		}
+
+		ticket := &pm.Ticket{
+			Sender:                 ethcommon.HexToAddress(sender),
+			Recipient:              ethcommon.HexToAddress(recipient),
+			SenderNonce:            senderNonce,
+			RecipientRandHash:      ethcommon.HexToHash(recipientRandHash),
+			CreationRound:          creationRound,
+			CreationRoundBlockHash: ethcommon.HexToHash(creationRoundBlockHash),
+		}
+		var ok bool
+		if ticket.FaceValue, ok = new(big.Int).SetString(faceValue, 10); !ok {
+			return nil, fmt.Errorf("invalid deferred ticket faceValue %v", faceValue)
+		}
+		if ticket.WinProb, ok = new(big.Int).SetString(winProb, 10); !ok {
+			return nil, fmt.Errorf("invalid deferred ticket winProb %v", winProb)
+		}
+		if paramsExpirationBlock.Valid {
+			if ticket.ParamsExpirationBlock, ok = new(big.Int).SetString(paramsExpirationBlock.String, 10); !ok {
+				return nil, fmt.Errorf("invalid deferred ticket paramsExpirationBlock %v", paramsExpirationBlock.String)
+			}
+		}
+		if pricePerPixel.Valid {
+			if ticket.PricePerPixel, ok = new(big.Rat).SetString(pricePerPixel.String); !ok {
+				return nil, fmt.Errorf("invalid deferred ticket pricePerPixel %v", pricePerPixel.String)
+			}
+		}
+
+		tickets = append(tickets, &pm.SignedTicket{
+			Ticket:        ticket,
+			Sig:           sig,
+			RecipientRand: new(big.Int).SetBytes(recipientRand),
+		})
+	}
+	return tickets, rows.Err()
+}
+
+// LedgerEntries returns the ledger entries that match the filter in the order
+// they were added
+func (db *DB) LedgerEntries(filter *DBLedgerFilter) ([]*DBLedgerEntry, error) {
+	if db == nil {
+		return []*DBLedgerEntry{}, nil
+	}
+
+	qry := "SELECT id, createdAt, type, sender, manifestID, amount, faceValue, won, pixels, ticketHash, txHash, error FROM ledger"
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter != nil {
+		if filter.Sender != nil {
+			conds = append(conds, "sender = ?")
+			args = append(args, filter.Sender.Hex())
+		}
+		if filter.ManifestID != "" {
+			conds = append(conds, "manifestID = ?")
+			args = append(args, filter.ManifestID)
+		}
+		if filter.Type != "" {
+			conds = append(conds, "type = ?")
+			args = append(args, filter.Type)
+		}
+		if !filter.From.IsZero() {
+			conds = append(conds, "createdAt >= ?")
+			args = append(args, filter.From.UTC().Format(dbTimeLayout))
+		}
+		if !filter.To.IsZero() {
+			conds = append(conds, "createdAt < ?")
+			args = append(args, filter.To.UTC().Format(dbTimeLayout))
+		}
+	}
+	if len(conds) > 0 {
+		qry += " WHERE " + strings.Join(conds, " AND ")
+	}
+	qry += " ORDER BY id"
+
+	rows, err := db.dbh.Query(qry, args...)
+	if err != nil {
+		glog.Error("db: Unable to select ledger entries ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*DBLedgerEntry{}
+	for rows.Next() {
+		var (
+			e                             DBLedgerEntry
+			createdAt, sender             string
+			manifestID, amount, faceValue sql.NullString
+			ticketHash, txHash, errStr    sql.NullString
+		)
+		if err := rows.Scan(&e.ID, &createdAt, &e.Type, &sender, &manifestID, &amount, &faceValue, &e.Won, &e.Pixels, &ticketHash, &txHash, &errStr); err != nil {
+			glog.Error("db: Unable to fetch ledger entry ", err)
+			continue
+		}
+		e.CreatedAt, err = time.Parse(dbTimeLayout, createdAt)
+		if err != nil {
+			glog.Errorf("db: Unable to parse ledger entry createdAt %v: %v", createdAt, err)
+		}
+		e.Sender = ethcommon.HexToAddress(sender)
+		e.ManifestID = manifestID.String
+		if amount.String != "" {
+			e.Amount, _ = new(big.Rat).SetString(amount.String)
+		}
+		if faceValue.String != "" {
+			e.FaceValue, _ = new(big.Int).SetString(faceValue.String, 10)
+		}
+		if ticketHash.String != "" {
+			e.TicketHash = ethcommon.HexToHash(ticketHash.String)
+		}
+		if txHash.String != "" {
+			e.TxHash = ethcommon.HexToHash(txHash.String)
+		}
+		e.Error = errStr.String
+
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// InsertSpending adds an entry to the broadcaster's spending ledger. If
+// CreatedAt is not set the current time is used
+func (db *DB) InsertSpending(s *DBSpending) error {
+	if db == nil || s == nil {
+		return nil
+	}
+
+	createdAt := s.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	var recipient, ev, fees string
+	if (s.Recipient != ethcommon.Address{}) {
+		recipient = s.Recipient.Hex()
+	}
+	if s.EV != nil {
+		ev = s.EV.String()
+	}
+	if s.Fees != nil {
+		fees = s.Fees.String()
+	}
+
+	_, err := db.dbh.Exec(
+		"INSERT INTO spending(createdAt, manifestID, orchestrator, recipient, tickets, ev, pixels, fees) VALUES(?, ?, ?, ?, ?, ?, ?, ?)",
+		createdAt.UTC().Format(dbTimeLayout), s.ManifestID, s.Orchestrator, recipient, s.Tickets, ev, s.Pixels, fees,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed inserting spending for manifestID: %v", s.ManifestID)
+	}
+	return nil
+}
+
+// SpendingEntries returns the entries of the spending ledger that match the
+// filter in the order they were added
+func (db *DB) SpendingEntries(filter *DBSpendingFilter) ([]*DBSpending, error) {
+	if db == nil {
+		return []*DBSpending{}, nil
+	}
+
+	qry := "SELECT createdAt, manifestID, orchestrator, recipient, tickets, ev, pixels, fees FROM spending"
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter != nil {
+		if filter.ManifestID != "" {
+			conds = append(conds, "manifestID = ?")
+			args = append(args, filter.ManifestID)
+		}
+		if filter.Orchestrator != "" {
+			conds = append(conds, "orchestrator = ?")
+			args = append(args, filter.Orchestrator)
+		}
+		if !filter.From.IsZero() {
+			conds = append(conds, "createdAt >= ?")
+			args = append(args, filter.From.UTC().Format(dbTimeLayout))
+		}
+		if !filter.To.IsZero() {
+			conds = append(conds, "createdAt < ?")
+			args = append(args, filter.To.UTC().Format(dbTimeLayout))
+		}
+	}
+	if len(conds) > 0 {
+		qry += " WHERE " + strings.Join(conds, " AND ")
+	}
+	qry += " ORDER BY id"
+
+	rows, err := db.dbh.Query(qry, args...)
+	if err != nil {
+		glog.Error("db: Unable to select spending ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*DBSpending{}
+	for rows.Next() {
+		var (
+			s                  DBSpending
+			createdAt          string
+			recipient, ev, fee sql.NullString
+		)
+		if err := rows.Scan(&createdAt, &s.ManifestID, &s.Orchestrator, &recipient, &s.Tickets, &ev, &s.Pixels, &fee); err != nil {
+			glog.Error("db: Unable to fetch spending ", err)
+			continue
+		}
+		s.CreatedAt, err = time.Parse(dbTimeLayout, createdAt)
+		if err != nil {
+			glog.Errorf("db: Unable to parse spending createdAt %v: %v", createdAt, err)
+		}
+		if recipient.String != "" {
+			s.Recipient = ethcommon.HexToAddress(recipient.String)
+		}
+		s.EV = big.NewRat(0, 1)
+		if ev.String != "" {
+			s.EV.SetString(ev.String)
+		}
+		s.Fees = big.NewRat(0, 1)
+		if fee.String != "" {
+			s.Fees.SetString(fee.String)
+		}
+
+		entries = append(entries, &s)
+	}
+	return entries, rows.Err()
+}
+
+// StoreTx inserts or updates a transaction. If CreatedAt is not set the current time is used
+func (db *DB) StoreTx(t *DBTx) error {
+	if db == nil || t == nil {
+		return nil
+	}
+
+	now := time.Now()
+	createdAt := t.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	var gasPrice string
+	if t.GasPrice != nil {
+		gasPrice = t.GasPrice.String()
+	}
+
+	_, err := db.dbh.Exec(
+		`INSERT OR REPLACE INTO txs(hash, createdAt, updatedAt, sender, nonce, method, currentHash, gasPrice, attempts, status, rawTx, error)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.Hash.Hex(), createdAt.UTC().Format(dbTimeLayout), now.UTC().Format(dbTimeLayout), t.Sender.Hex(), int64(t.Nonce),
+		t.Method, t.CurrentHash.Hex(), gasPrice, t.Attempts, t.Status, t.RawTx, t.Error,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed storing tx: %v", t.Hash.Hex())
+	}
+	return nil
+}
+
+// Txs returns the transactions that match the filter in the order they were submitted
+func (db *DB) Txs(filter *DBTxFilter) ([]*DBTx, error) {
+	if db == nil {
+		return []*DBTx{}, nil
+	}
+
+	qry := "SELECT hash, createdAt, updatedAt, sender, nonce, method, currentHash, gasPrice, attempts, status, rawTx, error FROM txs"
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter != nil {
+		if filter.Sender != nil {
+			conds = append(conds, "sender = ?")
+			args = append(args, filter.Sender.Hex())
+		}
+		if filter.Status != "" {
+			conds = append(conds, "status = ?")
+			args = append(args, filter.Status)
+		}
+	}
+	if len(conds) > 0 {
+		qry += " WHERE " + strings.Join(conds, " AND ")
+	}
+	qry += " ORDER BY createdAt, nonce"
+
+	rows, err := db.dbh.Query(qry, args...)
+	if err != nil {
+		glog.Error("db: Unable to select txs ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	txs := []*DBTx{}
+	for rows.Next() {
+		var (
+			t                                  DBTx
+			hash, createdAt, updatedAt, sender string
+			currentHash                        string
+			nonce                              int64
+			method, gasPrice, errStr           sql.NullString
+			rawTx                              []byte
+		)
+		if err := rows.Scan(&hash, &createdAt, &updatedAt, &sender, &nonce, &method, &currentHash, &gasPrice, &t.Attempts, &t.Status, &rawTx, &errStr); err != nil {
+			glog.Error("db: Unable to fetch tx ", err)
+			continue
+		}
+		t.Hash = ethcommon.HexToHash(hash)
+		t.CreatedAt, err = time.Parse(dbTimeLayout, createdAt)
+		if err != nil {
+			glog.Errorf("db: Unable to parse tx createdAt %v: %v", createdAt, err)
+		}
+		t.UpdatedAt, err = time.Parse(dbTimeLayout, updatedAt)
+		if err != nil {
+			glog.Errorf("db: Unable to parse tx updatedAt %v: %v", updatedAt, err)
+		}
+		t.Sender = ethcommon.HexToAddress(sender)
+		t.Nonce = uint64(nonce)
+		t.Method = method.String
+		t.CurrentHash = ethcommon.HexToHash(currentHash)
+		if gasPrice.String != "" {
+			t.GasPrice, _ = new(big.Int).SetString(gasPrice.String, 10)
+		}
+		t.RawTx = rawTx
+		t.Error = errStr.String
+
+		txs = append(txs, &t)
+	}
+	return txs, rows.Err()
+}
+
+// InsertOrchListEntry adds an entry to an orchestrator list. Adding an existing entry has no effect
+func (db *DB) InsertOrchListEntry(list, value string) error {
+	if db == nil {
+		return nil
+	}
+
+	_, err := db.dbh.Exec("INSERT OR IGNORE INTO orchLists(list, value, createdAt) VALUES(?, ?, datetime())", list, value)
+	if err != nil {
+		return errors.Wrapf(err, "failed inserting %v list entry: %v", list, value)
+	}
+	return nil
+}
+
+// DeleteOrchListEntry removes an entry from an orchestrator list
+func (db *DB) DeleteOrchListEntry(list, value string) error {
+	if db == nil {
+		return nil
+	}
+
+	_, err := db.dbh.Exec("DELETE FROM orchLists WHERE list = ? AND value = ?", list, value)
+	if err != nil {
+		return errors.Wrapf(err, "failed deleting %v list entry: %v", list, value)
+	}
+	return nil
+}
+
+// OrchListEntries returns the entries of all orchestrator lists in the order they were added
+func (db *DB) OrchListEntries() ([]*DBOrchListEntry, error) {
+	if db == nil {
+		return []*DBOrchListEntry{}, nil
+	}
+
+	rows, err := db.dbh.Query("SELECT list, value, createdAt FROM orchLists ORDER BY createdAt, rowid")
+	if err != nil {
+		glog.Error("db: Unable to select orchestrator list entries ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*DBOrchListEntry{}
+	for rows.Next() {
+		var (
+			e         DBOrchListEntry
+			createdAt string
+		)
+		if err := rows.Scan(&e.List, &e.Value, &createdAt); err != nil {
+			glog.Error("db: Unable to fetch orchestrator list entry ", err)
+			continue
+		}
+		e.CreatedAt, err = time.Parse(dbTimeLayout, createdAt)
+		if err != nil {
+			glog.Errorf("db: Unable to parse orchestrator list entry createdAt %v: %v", createdAt, err)
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// dbTimeLayout is the layout of timestamps written by SQLite's datetime()
+const dbTimeLayout = "2006-01-02 15:04:05"
+
+// StoreBalances replaces the balances stored in the DB with the given snapshot
+func (db *DB) StoreBalances(balances []*DBBalance) error {
+	if db == nil {
+		return nil
+	}
+	glog.V(DEBUG).Infof("db: Storing %v balances", len(balances))
+
+	tx, err := db.dbh.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM balances"); err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed deleting balances")
+	}
+	for _, b := range balances {
+		if b == nil || b.Amount == nil {
+			continue
+		}
+		_, err := tx.Exec(
+			"INSERT INTO balances(sender, manifestID, amount, updatedAt) VALUES(?, ?, ?, ?)",
+			b.Sender.Hex(), b.ManifestID, b.Amount.String(), b.UpdatedAt.UTC().Format(dbTimeLayout),
+		)
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "failed inserting balance for sender: %v, manifestID: %v", b.Sender.Hex(), b.ManifestID)
+		}
+	}
+	return tx.Commit()
+}
+
+// Balances returns the balances stored in the DB that match the filter
+func (db *DB) Balances(filter *DBBalanceFilter) ([]*DBBalance, error) {
+	if db == nil {
+		return []*DBBalance{}, nil
+	}
+
+	qry := "SELECT sender, manifestID, amount, updatedAt FROM balances"
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter != nil {
+		if filter.Sender != nil {
+			conds = append(conds, "sender = ?")
+			args = append(args, filter.Sender.Hex())
+		}
+		if filter.ManifestID != "" {
+			conds = append(conds, "manifestID = ?")
+			args = append(args, filter.ManifestID)
+		}
+	}
+	if len(conds) > 0 {
+		qry += " WHERE " + strings.Join(conds, " AND ")
+	}
+	qry += " ORDER BY sender, manifestID"
+
+	rows, err := db.dbh.Query(qry, args...)
+	if err != nil {
+		glog.Error("db: Unable to select balances ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []*DBBalance{}
+	for rows.Next() {
+		var (
+			sender    string
+			b         DBBalance
+			amount    string
+			updatedAt string
+		)
+		if err := rows.Scan(&sender, &b.ManifestID, &amount, &updatedAt); err != nil {
+			glog.Error("db: Unable to fetch balance ", err)
+			continue
+		}
+		b.Sender = ethcommon.HexToAddress(sender)
+
+		var ok bool
+		b.Amount, ok = new(big.Rat).SetString(amount)
+		if !ok {
+			glog.Errorf("db: Unable to convert amount string %v to big rat", amount)
+			continue
+		}
+		b.UpdatedAt, err = time.Parse(dbTimeLayout, updatedAt)
+		if err != nil {
+			glog.Errorf("db: Unable to parse balance updatedAt %v: %v", updatedAt, err)
+		}
+
+		balances = append(balances, &b)
+	}
+	return balances, rows.Err()
+}
+
 func encodeLogsJSON(logs []types.Log) ([]byte, error) {
 	logsEnc, err := json.Marshal(logs)
 	if err != nil {
@@ -0,0 +1,292 @@
+package common
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang/glog"
+)
+
+// baseDBVersion is the version of the schema created by InitDB before any
+// migrations are applied
+const baseDBVersion = 1
+
+// dbMigration upgrades the database schema by a single version
+type dbMigration struct {
+	version     int
+	description string
+	up          string
+}
+
+// dbMigrations are applied in order to bring a database from baseDBVersion up to
+// LivepeerDBVersion. To change the schema, append a migration with the next version
+// and bump LivepeerDBVersion. Released migrations must never be modified
+var dbMigrations = []dbMigration{
+	{
+		version:     2,
+		description: "add balances",
+		up: `
+	CREATE TABLE IF NOT EXISTS balances (
+		sender STRING NOT NULL,
+		manifestID STRING NOT NULL,
+		amount STRING NOT NULL,
+		updatedAt STRING DEFAULT CURRENT_TIMESTAMP NOT NULL,
+		PRIMARY KEY(sender, manifestID)
+	);
+	`,
+	},
+	{
+		version:     3,
+		description: "add ledger",
+		up: `
+	CREATE TABLE IF NOT EXISTS ledger (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		createdAt STRING NOT NULL,
+		type STRING NOT NULL,
+		sender STRING NOT NULL,
+		manifestID STRING,
+		amount STRING,
+		faceValue STRING,
+		won INTEGER DEFAULT 0 NOT NULL,
+		pixels INTEGER DEFAULT 0 NOT NULL,
+		ticketHash STRING,
+		txHash STRING,
+		error STRING
+	);
+	CREATE INDEX IF NOT EXISTS idx_ledger_createdat ON ledger(createdAt);
+	CREATE INDEX IF NOT EXISTS idx_ledger_sender_manifestid ON ledger(sender, manifestID);
+	CREATE INDEX IF NOT EXISTS idx_ledger_tickethash ON ledger(ticketHash);
+	`,
+	},
+	{
+		version:     4,
+		description: "add spending",
+		up: `
+	CREATE TABLE IF NOT EXISTS spending (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		createdAt STRING NOT NULL,
+		manifestID STRING NOT NULL,
+		orchestrator STRING NOT NULL,
+		recipient STRING,
+		tickets INTEGER DEFAULT 0 NOT NULL,
+		ev STRING,
+		pixels INTEGER DEFAULT 0 NOT NULL,
+		fees STRING
+	);
+	CREATE INDEX IF NOT EXISTS idx_spending_createdat ON spending(createdAt);
+	CREATE INDEX IF NOT EXISTS idx_spending_manifestid ON spending(manifestID);
+	`,
+	},
+	{
+		version:     5,
+		description: "add txs",
+		up: `
+	CREATE TABLE IF NOT EXISTS txs (
+		hash STRING PRIMARY KEY,
+		createdAt STRING NOT NULL,
+		updatedAt STRING NOT NULL,
+		sender STRING NOT NULL,
+		nonce INTEGER NOT NULL,
+		method STRING,
+		currentHash STRING NOT NULL,
+		gasPrice STRING,
+		attempts INTEGER DEFAULT 1 NOT NULL,
+		status STRING NOT NULL,
+		rawTx BLOB,
+		error STRING
+	);
+	CREATE INDEX IF NOT EXISTS idx_txs_status ON txs(status);
+	CREATE INDEX IF NOT EXISTS idx_txs_sender_nonce ON txs(sender, nonce);
+	`,
+	},
+	{
+		version:     6,
+		description: "add orchestrator lists",
+		up: `
+	CREATE TABLE IF NOT EXISTS orchLists (
+		list STRING NOT NULL,
+		value STRING NOT NULL,
+		createdAt STRING DEFAULT CURRENT_TIMESTAMP NOT NULL,
+		PRIMARY KEY(list, value)
+	);
+	`,
+	},
+	{
+		version:     7,
+		description: "add deferred tickets",
+		up: `
+	CREATE TABLE IF NOT EXISTS deferredTickets (
+		ticketHash STRING PRIMARY KEY,
+		createdAt STRING DEFAULT CURRENT_TIMESTAMP NOT NULL,
+		sender STRING NOT NULL,
+		recipient STRING NOT NULL,
+		faceValue STRING NOT NULL,
+		winProb STRING NOT NULL,
+		senderNonce INTEGER NOT NULL,
+		recipientRandHash STRING NOT NULL,
+		creationRound INTEGER NOT NULL,
+		creationRoundBlockHash STRING NOT NULL,
+		paramsExpirationBlock STRING,
+		pricePerPixel STRING,
+		sig BLOB NOT NULL,
+		recipientRand BLOB NOT NULL
+	);
+	`,
+	},
+}
+
+// migrateDB applies all migrations newer than the current version of the
+// database. Each migration runs in its own transaction together with the
+// update of the schema version so that a failed migration leaves the database
+// at the last successfully applied version
+func migrateDB(db *sql.DB, current int) (int, error) {
+	for _, m := range dbMigrations {
+		if m.version <= current {
+			continue
+		}
+		if m.version != current+1 {
+			return current, fmt.Errorf("missing DB migration from version %v to %v", current, m.version)
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return current, err
+		}
+		if _, err := tx.Exec(m.up); err != nil {
+			tx.Rollback()
+			return current, fmt.Errorf("DB migration to version %v failed: %v", m.version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schemaMigrations(version, description) VALUES(?, ?)", m.version, m.description); err != nil {
+			tx.Rollback()
+			return current, err
+		}
+		if _, err := tx.Exec("UPDATE kv SET value = ?, updatedAt = datetime() WHERE key = 'dbVersion'", m.version); err != nil {
+			tx.Rollback()
+			return current, err
+		}
+		if err := tx.Commit(); err != nil {
+			return current, err
+		}
+
+		glog.Infof("Applied DB migration version=%v description=%q", m.version, m.description)
+		current = m.version
+	}
+
+	if current != LivepeerDBVersion {
+		return current, fmt.Errorf("DB migrations ended at version %v, expected version %v", current, LivepeerDBVersion)
+	}
+	return current, nil
+}
+
+// Version returns the schema version of the DB
+func (db *DB) Version() (int, error) {
+	var version int
+	row := db.dbh.QueryRow("SELECT value FROM kv WHERE key = 'dbVersion'")
+	if err := row.Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// openDBReadOnly opens an existing database without initializing or migrating it
+func openDBReadOnly(dbPath string) (*sql.DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+	return sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+}
+
+// BackupDB writes a consistent copy of the database at dbPath to backupPath.
+// The database is not migrated, so a backup taken before upgrading the node
+// can be used to restore the previous version
+func BackupDB(dbPath, backupPath string) error {
+	if _, err := os.Stat(backupPath); err == nil {
+		return fmt.Errorf("backup file %v already exists", backupPath)
+	}
+
+	db, err := openDBReadOnly(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("VACUUM INTO ?", backupPath)
+	return err
+}
+
+// ExportDB writes the rows of every table of the database at dbPath to w as a
+// JSON object keyed by table name. BLOB values are base64 encoded. The database
+// is not migrated
+func ExportDB(dbPath string, w io.Writer) error {
+	db, err := openDBReadOnly(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	export := make(map[string][]map[string]interface{})
+	for _, table := range tables {
+		tableRows, err := exportTable(db, table)
+		if err != nil {
+			return fmt.Errorf("could not export table %v: %v", table, err)
+		}
+		export[table] = tableRows
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+func exportTable(db *sql.DB, table string) ([]map[string]interface{}, error) {
+	// Table names come from sqlite_master so quoting is sufficient here
+	rows, err := db.Query(fmt.Sprintf("SELECT * FROM %q", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []map[string]interface{}{}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			row[col] = vals[i]
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,176 @@
+package common
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBMigrations_InOrder(t *testing.T) {
+	// The migrations shipped with the node must lead up to LivepeerDBVersion
+	version := baseDBVersion
+	for _, m := range dbMigrations {
+		assert.Equal(t, version+1, m.version)
+		assert.NotEmpty(t, m.description)
+		version = m.version
+	}
+	assert.Equal(t, LivepeerDBVersion, version)
+}
+
+// withTestMigrations appends migrations to the ones shipped with the node
+func withTestMigrations(t *testing.T, migrations []dbMigration) func() {
+	oldMigrations, oldVersion := dbMigrations, LivepeerDBVersion
+	dbMigrations = append(append([]dbMigration{}, oldMigrations...), migrations...)
+	LivepeerDBVersion = migrations[len(migrations)-1].version
+	return func() {
+		dbMigrations, LivepeerDBVersion = oldMigrations, oldVersion
+	}
+}
+
+func TestDBMigrations_Apply(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Create a database at the current version
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbraw.Close()
+	// Keep the in-memory database alive while dbh is closed
+	require.Nil(dbraw.Ping())
+	dbh.Close()
+
+	v := LivepeerDBVersion
+	defer withTestMigrations(t, []dbMigration{
+		{v + 1, "add foo", "CREATE TABLE foo (id INTEGER PRIMARY KEY, bar STRING);"},
+		{v + 2, "add baz to foo", "ALTER TABLE foo ADD COLUMN baz INTEGER;"},
+	})()
+
+	// Opening the database applies the pending migrations
+	dbh, err = InitDB(dbPath(t))
+	require.Nil(err)
+	defer dbh.Close()
+
+	version, err := dbh.Version()
+	assert.Nil(err)
+	assert.Equal(v+2, version)
+
+	_, err = dbraw.Exec("INSERT INTO foo(bar, baz) VALUES('a', 1)")
+	assert.Nil(err)
+
+	rows, err := dbraw.Query("SELECT version, description FROM schemaMigrations WHERE version > ? ORDER BY version", v)
+	require.Nil(err)
+	var applied []string
+	for rows.Next() {
+		var v int
+		var desc string
+		require.Nil(rows.Scan(&v, &desc))
+		applied = append(applied, desc)
+	}
+	rows.Close()
+	assert.Equal([]string{"add foo", "add baz to foo"}, applied)
+
+	// Reopening the database does not apply migrations again
+	dbh2, err := InitDB(dbPath(t))
+	require.Nil(err)
+	dbh2.Close()
+}
+
+func TestDBMigrations_Failure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbraw.Close()
+	// Keep the in-memory database alive while dbh is closed
+	require.Nil(dbraw.Ping())
+	dbh.Close()
+
+	v := LivepeerDBVersion
+	defer withTestMigrations(t, []dbMigration{
+		{v + 1, "add foo", "CREATE TABLE foo (id INTEGER PRIMARY KEY);"},
+		{v + 2, "broken", "CREATE TABLE bar (id INTEGER PRIMARY KEY); ALTER TABLE missing ADD COLUMN baz INTEGER;"},
+	})()
+
+	_, err = InitDB(dbPath(t))
+	assert.EqualError(err, fmt.Sprintf("DB migration to version %v failed: no such table: missing", v+2))
+
+	// The database stays at the last successful migration
+	var version int
+	require.Nil(dbraw.QueryRow("SELECT value FROM kv WHERE key = 'dbVersion'").Scan(&version))
+	assert.Equal(v+1, version)
+
+	// The failed migration was rolled back
+	_, err = dbraw.Exec("SELECT * FROM bar")
+	assert.EqualError(err, "no such table: bar")
+}
+
+func TestDBMigrations_Missing(t *testing.T) {
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(t, err)
+	defer dbraw.Close()
+	dbh.Close()
+
+	v := LivepeerDBVersion
+	defer withTestMigrations(t, []dbMigration{
+		{v + 2, "skips a version", "CREATE TABLE foo (id INTEGER PRIMARY KEY);"},
+	})()
+
+	_, err = InitDB(dbPath(t))
+	assert.EqualError(t, err, fmt.Sprintf("missing DB migration from version %v to %v", v, v+2))
+}
+
+func TestDBBackupAndExport(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "TestDBBackupAndExport")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	dbFile := filepath.Join(dir, "lpdb.sqlite3")
+	dbh, err := InitDB(dbFile)
+	require.Nil(err)
+	require.Nil(dbh.SetChainID(big.NewInt(1)))
+	dbh.Close()
+
+	// Missing database
+	assert.NotNil(BackupDB(filepath.Join(dir, "missing.sqlite3"), filepath.Join(dir, "out.sqlite3")))
+	assert.NotNil(ExportDB(filepath.Join(dir, "missing.sqlite3"), &bytes.Buffer{}))
+
+	// Backup
+	backupFile := filepath.Join(dir, "backup.sqlite3")
+	require.Nil(BackupDB(dbFile, backupFile))
+	assert.EqualError(BackupDB(dbFile, backupFile), "backup file "+backupFile+" already exists")
+
+	backup, err := sql.Open("sqlite3", backupFile)
+	require.Nil(err)
+	defer backup.Close()
+	var chainID string
+	require.Nil(backup.QueryRow("SELECT value FROM kv WHERE key = 'chainID'").Scan(&chainID))
+	assert.Equal("1", chainID)
+
+	// Export
+	var buf bytes.Buffer
+	require.Nil(ExportDB(dbFile, &buf))
+	var export map[string][]map[string]interface{}
+	require.Nil(json.Unmarshal(buf.Bytes(), &export))
+	assert.Contains(export, "orchestrators")
+	assert.Contains(export, "schemaMigrations")
+	assert.Empty(export["orchestrators"])
+	kv := make(map[string]interface{})
+	for _, row := range export["kv"] {
+		kv[row["key"].(string)] = row["value"]
+	}
+	assert.EqualValues(1, kv["chainID"])
+	assert.EqualValues(LivepeerDBVersion, kv["dbVersion"])
+}
@@ -2,11 +2,13 @@ package common
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	ethcommon "github.com/ethereum/go-ethereum/common"
@@ -102,6 +104,36 @@ func TestSetChainID(t *testing.T) {
 	assert.Equal(chainID, expectedChainIDInt)
 }
 
+func TestDBFundedAmount(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	addr := pm.RandAddress()
+	amount, err := dbh.FundedAmount(addr)
+	require.Nil(err)
+	assert.Equal(big.NewInt(0), amount)
+
+	require.Nil(dbh.SetFundedAmount(addr, big.NewInt(100)))
+	require.Nil(dbh.SetFundedAmount(addr, big.NewInt(250)))
+	amount, err = dbh.FundedAmount(addr)
+	require.Nil(err)
+	assert.Equal(big.NewInt(250), amount)
+
+	// Amounts are stored per account
+	amount, err = dbh.FundedAmount(pm.RandAddress())
+	require.Nil(err)
+	assert.Equal(big.NewInt(0), amount)
+
+	require.Nil(dbh.updateKVStore(fundedAmountKey(addr), "foo"))
+	_, err = dbh.FundedAmount(addr)
+	assert.EqualError(err, "unable to convert funded amount foo to big.Int")
+}
+
 func TestDBLastSeenBlock(t *testing.T) {
 	dbh, dbraw, err := TempDB(t)
 	if err != nil {
@@ -983,6 +1015,54 @@ func TestLoadWinningTickets_GivenTwoSessionsWithTickets_ReturnsAllTickets(t *tes
 	assert.Equal(recipientRand1, recipientRands[1])
 }
 
+func TestDBDeferredTickets(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	tickets, err := dbh.LoadDeferredTickets()
+	require.Nil(err)
+	assert.Empty(tickets)
+
+	_, ticket, sig, recipientRand := defaultWinningTicket(t)
+	ticket.CreationRound = 10
+	ticket.CreationRoundBlockHash = pm.RandHash()
+	ticket.ParamsExpirationBlock = big.NewInt(100)
+	ticket.PricePerPixel = big.NewRat(1, 3)
+	first := &pm.SignedTicket{Ticket: ticket, Sig: sig, RecipientRand: recipientRand}
+
+	_, ticket, sig, recipientRand = defaultWinningTicket(t)
+	ticket.SenderNonce++
+	second := &pm.SignedTicket{Ticket: ticket, Sig: sig, RecipientRand: recipientRand}
+
+	require.Nil(dbh.StoreDeferredTicket(first))
+	require.Nil(dbh.StoreDeferredTicket(second))
+	// Storing a ticket again is a no-op
+	require.Nil(dbh.StoreDeferredTicket(first))
+
+	tickets, err = dbh.LoadDeferredTickets()
+	require.Nil(err)
+	require.Len(tickets, 2)
+	assert.Equal(first, tickets[0])
+	assert.Equal(first.Hash(), tickets[0].Hash())
+	assert.Equal(second, tickets[1])
+
+	require.Nil(dbh.RemoveDeferredTicket(first.Ticket))
+	tickets, err = dbh.LoadDeferredTickets()
+	require.Nil(err)
+	require.Len(tickets, 1)
+	assert.Equal(second, tickets[0])
+
+	assert.EqualError(dbh.StoreDeferredTicket(nil), "cannot store nil ticket")
+	assert.EqualError(dbh.StoreDeferredTicket(&pm.SignedTicket{Ticket: ticket, RecipientRand: recipientRand}), "cannot store nil sig")
+	assert.EqualError(dbh.StoreDeferredTicket(&pm.SignedTicket{Ticket: ticket, Sig: sig}), "cannot store nil recipientRand")
+	assert.EqualError(dbh.RemoveDeferredTicket(nil), "cannot remove nil ticket")
+}
+
 func TestInsertMiniHeader_ReturnsFindLatestMiniHeader(t *testing.T) {
 	dbh, dbraw, err := TempDB(t)
 	defer dbh.Close()
@@ -1145,3 +1225,359 @@ func defaultMiniHeader() *blockwatch.MiniHeader {
 	block.Logs = []types.Log{log}
 	return block
 }
+
+func TestDBCheckWritable(t *testing.T) {
+	assert := assert.New(t)
+
+	var nilDB *DB
+	assert.EqualError(nilDB.CheckWritable(), "database is not initialized")
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(t, err)
+	defer dbraw.Close()
+
+	assert.Nil(dbh.CheckWritable())
+	val, err := dbh.selectKVStore("lastHealthCheck")
+	assert.Nil(err)
+	assert.NotEmpty(val)
+
+	// Writes fail after the DB is closed
+	dbh.Close()
+	assert.NotNil(dbh.CheckWritable())
+}
+
+func TestDBBalances(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var nilDB *DB
+	assert.Nil(nilDB.StoreBalances([]*DBBalance{{}}))
+	balances, err := nilDB.Balances(nil)
+	assert.Nil(err)
+	assert.Empty(balances)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	balances, err = dbh.Balances(nil)
+	assert.Nil(err)
+	assert.Empty(balances)
+
+	sender1 := pm.RandAddress()
+	sender2 := pm.RandAddress()
+	now := time.Now().UTC().Truncate(time.Second)
+	require.Nil(dbh.StoreBalances([]*DBBalance{
+		{Sender: sender1, ManifestID: "foo", Amount: big.NewRat(1, 3), UpdatedAt: now},
+		{Sender: sender1, ManifestID: "bar", Amount: big.NewRat(-5, 1), UpdatedAt: now},
+		{Sender: sender2, ManifestID: "foo", Amount: big.NewRat(7, 2), UpdatedAt: now},
+		nil,
+	}))
+
+	balances, err = dbh.Balances(nil)
+	require.Nil(err)
+	require.Len(balances, 3)
+	for _, b := range balances {
+		assert.Equal(now, b.UpdatedAt)
+	}
+
+	balances, err = dbh.Balances(&DBBalanceFilter{Sender: &sender1})
+	require.Nil(err)
+	require.Len(balances, 2)
+	assert.Equal("bar", balances[0].ManifestID)
+	assert.Zero(balances[0].Amount.Cmp(big.NewRat(-5, 1)))
+	assert.Equal("foo", balances[1].ManifestID)
+	assert.Zero(balances[1].Amount.Cmp(big.NewRat(1, 3)))
+
+	balances, err = dbh.Balances(&DBBalanceFilter{ManifestID: "foo"})
+	require.Nil(err)
+	assert.Len(balances, 2)
+
+	balances, err = dbh.Balances(&DBBalanceFilter{Sender: &sender2, ManifestID: "foo"})
+	require.Nil(err)
+	require.Len(balances, 1)
+	assert.Equal(sender2, balances[0].Sender)
+	assert.Zero(balances[0].Amount.Cmp(big.NewRat(7, 2)))
+
+	// Storing a new snapshot replaces the previous one
+	require.Nil(dbh.StoreBalances([]*DBBalance{
+		{Sender: sender2, ManifestID: "baz", Amount: big.NewRat(1, 1), UpdatedAt: now},
+	}))
+	balances, err = dbh.Balances(nil)
+	require.Nil(err)
+	require.Len(balances, 1)
+	assert.Equal("baz", balances[0].ManifestID)
+}
+
+func TestDBLedger(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var nilDB *DB
+	assert.Nil(nilDB.InsertLedgerEntry(&DBLedgerEntry{}))
+	entries, err := nilDB.LedgerEntries(nil)
+	assert.Nil(err)
+	assert.Empty(entries)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	sender := pm.RandAddress()
+	ticket := &pm.Ticket{
+		Sender:            sender,
+		Recipient:         pm.RandAddress(),
+		FaceValue:         big.NewInt(1000),
+		WinProb:           big.NewInt(500),
+		RecipientRandHash: pm.RandHash(),
+	}
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	require.Nil(dbh.InsertLedgerEntry(&DBLedgerEntry{
+		CreatedAt:  start,
+		Type:       LedgerTicket,
+		Sender:     sender,
+		ManifestID: "foo",
+		Amount:     big.NewRat(100, 3),
+		FaceValue:  ticket.FaceValue,
+		Won:        true,
+		TicketHash: ticket.Hash(),
+	}))
+	require.Nil(dbh.InsertLedgerEntry(&DBLedgerEntry{
+		CreatedAt:  start.Add(time.Minute),
+		Type:       LedgerDebit,
+		Sender:     sender,
+		ManifestID: "foo",
+		Amount:     big.NewRat(20, 1),
+		Pixels:     10,
+	}))
+	txHash := pm.RandHash()
+	require.Nil(dbh.StoreRedemption(ticket, txHash, nil))
+	require.Nil(dbh.StoreRedemption(ticket, ethcommon.Hash{}, errors.New("redeem error")))
+	assert.EqualError(dbh.StoreRedemption(nil, txHash, nil), "cannot store nil ticket")
+
+	entries, err = dbh.LedgerEntries(nil)
+	require.Nil(err)
+	require.Len(entries, 4)
+
+	e := entries[0]
+	assert.Equal(start, e.CreatedAt)
+	assert.Equal(LedgerTicket, e.Type)
+	assert.Equal(sender, e.Sender)
+	assert.Equal("foo", e.ManifestID)
+	assert.Zero(e.Amount.Cmp(big.NewRat(100, 3)))
+	assert.Equal(big.NewInt(1000), e.FaceValue)
+	assert.True(e.Won)
+	assert.Equal(ticket.Hash(), e.TicketHash)
+	assert.Equal(ethcommon.Hash{}, e.TxHash)
+	assert.Empty(e.Error)
+
+	assert.Equal(LedgerDebit, entries[1].Type)
+	assert.Equal(int64(10), entries[1].Pixels)
+	assert.Nil(entries[1].FaceValue)
+
+	// Redemptions are attributed to the manifestID of the redeemed ticket
+	e = entries[2]
+	assert.Equal(LedgerRedemption, e.Type)
+	assert.Equal("foo", e.ManifestID)
+	assert.Zero(e.Amount.Cmp(big.NewRat(1000, 1)))
+	assert.Equal(txHash, e.TxHash)
+	assert.Empty(e.Error)
+	assert.Equal("redeem error", entries[3].Error)
+	assert.Equal(ethcommon.Hash{}, entries[3].TxHash)
+
+	// Filters
+	entries, err = dbh.LedgerEntries(&DBLedgerFilter{Type: LedgerRedemption})
+	require.Nil(err)
+	assert.Len(entries, 2)
+
+	entries, err = dbh.LedgerEntries(&DBLedgerFilter{From: start, To: start.Add(time.Minute)})
+	require.Nil(err)
+	require.Len(entries, 1)
+	assert.Equal(LedgerTicket, entries[0].Type)
+
+	entries, err = dbh.LedgerEntries(&DBLedgerFilter{From: start.Add(time.Minute), To: start.Add(2 * time.Minute)})
+	require.Nil(err)
+	require.Len(entries, 1)
+	assert.Equal(LedgerDebit, entries[0].Type)
+
+	other := pm.RandAddress()
+	entries, err = dbh.LedgerEntries(&DBLedgerFilter{Sender: &other})
+	require.Nil(err)
+	assert.Empty(entries)
+
+	entries, err = dbh.LedgerEntries(&DBLedgerFilter{Sender: &sender, ManifestID: "foo"})
+	require.Nil(err)
+	assert.Len(entries, 4)
+}
+
+func TestDBSpending(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var nilDB *DB
+	assert.Nil(nilDB.InsertSpending(&DBSpending{}))
+	entries, err := nilDB.SpendingEntries(nil)
+	assert.Nil(err)
+	assert.Empty(entries)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	assert.Nil(dbh.InsertSpending(nil))
+
+	recipient := pm.RandAddress()
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	require.Nil(dbh.InsertSpending(&DBSpending{
+		CreatedAt:    start,
+		ManifestID:   "foo",
+		Orchestrator: "https://127.0.0.1:8935",
+		Recipient:    recipient,
+		Tickets:      2,
+		EV:           big.NewRat(100, 3),
+		Pixels:       1000,
+		Fees:         big.NewRat(30, 1),
+	}))
+	require.Nil(dbh.InsertSpending(&DBSpending{
+		CreatedAt:    start.Add(time.Minute),
+		ManifestID:   "bar",
+		Orchestrator: "https://127.0.0.1:8936",
+		Pixels:       500,
+	}))
+	// CreatedAt defaults to now
+	require.Nil(dbh.InsertSpending(&DBSpending{
+		ManifestID:   "foo",
+		Orchestrator: "https://127.0.0.1:8936",
+		Tickets:      1,
+		EV:           big.NewRat(5, 1),
+	}))
+
+	entries, err = dbh.SpendingEntries(nil)
+	require.Nil(err)
+	require.Len(entries, 3)
+
+	e := entries[0]
+	assert.Equal(start, e.CreatedAt)
+	assert.Equal("foo", e.ManifestID)
+	assert.Equal("https://127.0.0.1:8935", e.Orchestrator)
+	assert.Equal(recipient, e.Recipient)
+	assert.Equal(2, e.Tickets)
+	assert.Zero(e.EV.Cmp(big.NewRat(100, 3)))
+	assert.Equal(int64(1000), e.Pixels)
+	assert.Zero(e.Fees.Cmp(big.NewRat(30, 1)))
+
+	e = entries[1]
+	assert.Equal(ethcommon.Address{}, e.Recipient)
+	assert.Zero(e.EV.Sign())
+	assert.Zero(e.Fees.Sign())
+	assert.WithinDuration(time.Now(), entries[2].CreatedAt, 5*time.Second)
+
+	// Filters
+	entries, err = dbh.SpendingEntries(&DBSpendingFilter{ManifestID: "foo"})
+	require.Nil(err)
+	assert.Len(entries, 2)
+
+	entries, err = dbh.SpendingEntries(&DBSpendingFilter{Orchestrator: "https://127.0.0.1:8936"})
+	require.Nil(err)
+	assert.Len(entries, 2)
+
+	entries, err = dbh.SpendingEntries(&DBSpendingFilter{ManifestID: "foo", Orchestrator: "https://127.0.0.1:8936"})
+	require.Nil(err)
+	require.Len(entries, 1)
+	assert.Equal(1, entries[0].Tickets)
+
+	entries, err = dbh.SpendingEntries(&DBSpendingFilter{From: start, To: start.Add(time.Minute)})
+	require.Nil(err)
+	require.Len(entries, 1)
+	assert.Equal("foo", entries[0].ManifestID)
+
+	entries, err = dbh.SpendingEntries(&DBSpendingFilter{From: start.Add(time.Minute)})
+	require.Nil(err)
+	assert.Len(entries, 2)
+}
+
+func TestDBTxs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var nilDB *DB
+	assert.Nil(nilDB.StoreTx(&DBTx{}))
+	txs, err := nilDB.Txs(nil)
+	assert.Nil(err)
+	assert.Empty(txs)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	assert.Nil(dbh.StoreTx(nil))
+
+	sender := pm.RandAddress()
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	tx := &DBTx{
+		Hash:      pm.RandHash(),
+		CreatedAt: start,
+		Sender:    sender,
+		Nonce:     7,
+		Method:    "reward",
+		GasPrice:  big.NewInt(100),
+		Attempts:  1,
+		Status:    TxPending,
+		RawTx:     []byte("foo"),
+	}
+	tx.CurrentHash = tx.Hash
+	require.Nil(dbh.StoreTx(tx))
+	require.Nil(dbh.StoreTx(&DBTx{
+		Hash:   pm.RandHash(),
+		Sender: pm.RandAddress(),
+		Nonce:  1,
+		Status: TxMined,
+	}))
+
+	txs, err = dbh.Txs(&DBTxFilter{Status: TxPending})
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal(tx.Hash, txs[0].Hash)
+	assert.Equal(tx.CurrentHash, txs[0].CurrentHash)
+	assert.Equal(start, txs[0].CreatedAt)
+	assert.False(txs[0].UpdatedAt.IsZero())
+	assert.Equal(sender, txs[0].Sender)
+	assert.Equal(uint64(7), txs[0].Nonce)
+	assert.Equal("reward", txs[0].Method)
+	assert.Equal(big.NewInt(100), txs[0].GasPrice)
+	assert.Equal(1, txs[0].Attempts)
+	assert.Equal([]byte("foo"), txs[0].RawTx)
+	assert.Empty(txs[0].Error)
+
+	// Updating a transaction replaces the row
+	tx.CurrentHash = pm.RandHash()
+	tx.GasPrice = big.NewInt(120)
+	tx.Attempts = 2
+	tx.Status = TxFailed
+	tx.Error = "reverted"
+	require.Nil(dbh.StoreTx(tx))
+
+	txs, err = dbh.Txs(&DBTxFilter{Sender: &sender})
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal(tx.Hash, txs[0].Hash)
+	assert.Equal(tx.CurrentHash, txs[0].CurrentHash)
+	assert.Equal(start, txs[0].CreatedAt)
+	assert.Equal(big.NewInt(120), txs[0].GasPrice)
+	assert.Equal(2, txs[0].Attempts)
+	assert.Equal(TxFailed, txs[0].Status)
+	assert.Equal("reverted", txs[0].Error)
+
+	txs, err = dbh.Txs(nil)
+	require.Nil(err)
+	assert.Len(txs, 2)
+	txs, err = dbh.Txs(&DBTxFilter{Status: TxPending})
+	require.Nil(err)
+	assert.Empty(txs)
+}
@@ -0,0 +1,206 @@
+package common
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// OrchestratorFilter is an allowlist and a denylist of orchestrators by ethereum address or
+// service URI that are stored in the DB and can be changed at runtime. Orchestrators on the
+// denylist are never used. If the allowlist is not empty only orchestrators on it are used.
+// A nil OrchestratorFilter allows all orchestrators
+type OrchestratorFilter struct {
+	db *DB
+
+	mu    sync.RWMutex
+	lists map[string]map[string]bool
+	// denied contains the service URIs that other sources, e.g. discovery webhooks, deny by source
+	denied map[string]map[string]bool
+}
+
+// NewOrchestratorFilter creates an OrchestratorFilter with the lists stored in db
+func NewOrchestratorFilter(db *DB) (*OrchestratorFilter, error) {
+	f := &OrchestratorFilter{
+		db: db,
+		lists: map[string]map[string]bool{
+			OrchAllowList: make(map[string]bool),
+			OrchDenyList:  make(map[string]bool),
+		},
+		denied: make(map[string]map[string]bool),
+	}
+
+	entries, err := db.OrchListEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if _, ok := f.lists[e.List]; ok {
+			f.lists[e.List][e.Value] = true
+		}
+	}
+	return f, nil
+}
+
+// Add adds an ethereum address or a service URI to a list
+func (f *OrchestratorFilter) Add(list, value string) error {
+	value, err := f.entry(list, value)
+	if err != nil {
+		return err
+	}
+	if err := f.db.InsertOrchListEntry(list, value); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lists[list][value] = true
+	return nil
+}
+
+// Remove removes an ethereum address or a service URI from a list
+func (f *OrchestratorFilter) Remove(list, value string) error {
+	value, err := f.entry(list, value)
+	if err != nil {
+		return err
+	}
+	if err := f.db.DeleteOrchListEntry(list, value); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.lists[list], value)
+	return nil
+}
+
+// List returns the entries of a list
+func (f *OrchestratorFilter) List(list string) []string {
+	if f == nil {
+		return []string{}
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	entries := []string{}
+	for value := range f.lists[list] {
+		entries = append(entries, value)
+	}
+	return entries
+}
+
+// SetDenied replaces the service URIs that source denies. Unlike the denylist these URIs are not
+// stored in the DB and are not returned by List
+func (f *OrchestratorFilter) SetDenied(source string, uris []*url.URL) {
+	if f == nil {
+		return
+	}
+
+	denied := make(map[string]bool)
+	for _, uri := range uris {
+		denied[orchListURI(uri)] = true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.denied[source] = denied
+}
+
+// isDenied returns whether key is on the denylist or denied by any source. The caller must hold f.mu
+func (f *OrchestratorFilter) isDenied(key string) bool {
+	if f.lists[OrchDenyList][key] {
+		return true
+	}
+	for _, denied := range f.denied {
+		if denied[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed returns whether the orchestrator at uri with the ticket recipient recipient can be used
+func (f *OrchestratorFilter) Allowed(uri *url.URL, recipient ethcommon.Address) bool {
+	if f == nil {
+		return true
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	keys := []string{orchListURI(uri), orchListAddress(recipient)}
+	for _, key := range keys {
+		if f.isDenied(key) {
+			return false
+		}
+	}
+	if len(f.lists[OrchAllowList]) == 0 {
+		return true
+	}
+	for _, key := range keys {
+		if f.lists[OrchAllowList][key] {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedURI returns whether the orchestrator at uri can be used before its ticket recipient is known
+func (f *OrchestratorFilter) AllowedURI(uri *url.URL) bool {
+	if f == nil {
+		return true
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key := orchListURI(uri)
+	if f.isDenied(key) {
+		return false
+	}
+	if len(f.lists[OrchAllowList]) == 0 || f.lists[OrchAllowList][key] {
+		return true
+	}
+	// The orchestrator may be allowed by its address
+	for value := range f.lists[OrchAllowList] {
+		if ethcommon.IsHexAddress(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// entry validates a list and returns the normalized form of an ethereum address or a service URI
+func (f *OrchestratorFilter) entry(list, value string) (string, error) {
+	if f == nil {
+		return "", fmt.Errorf("orchestrator lists are not enabled")
+	}
+	if list != OrchAllowList && list != OrchDenyList {
+		return "", fmt.Errorf("invalid orchestrator list %q", list)
+	}
+
+	value = strings.TrimSpace(value)
+	if ethcommon.IsHexAddress(value) {
+		return orchListAddress(ethcommon.HexToAddress(value)), nil
+	}
+	uri, err := url.ParseRequestURI(value)
+	if err != nil || uri.Scheme == "" || uri.Host == "" {
+		return "", fmt.Errorf("invalid orchestrator address or URI %q", value)
+	}
+	return orchListURI(uri), nil
+}
+
+func orchListAddress(addr ethcommon.Address) string {
+	return strings.ToLower(addr.Hex())
+}
+
+func orchListURI(uri *url.URL) string {
+	if uri == nil {
+		return ""
+	}
+	return strings.ToLower(uri.Scheme + "://" + uri.Host)
+}
@@ -0,0 +1,119 @@
+package common
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrchestratorFilter_Lists(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	f, err := NewOrchestratorFilter(dbh)
+	require.Nil(err)
+	assert.Empty(f.List(OrchAllowList))
+	assert.Empty(f.List(OrchDenyList))
+
+	addr := ethcommon.HexToAddress("0x000000000000000000000000000000000000000A")
+
+	// Entries are normalized
+	require.Nil(f.Add(OrchDenyList, addr.Hex()))
+	require.Nil(f.Add(OrchDenyList, " HTTPS://Orch.Example.com:8935/ "))
+	require.Nil(f.Add(OrchDenyList, "https://orch.example.com:8935"))
+	require.Nil(f.Add(OrchAllowList, "https://127.0.0.1:8935"))
+	assert.ElementsMatch([]string{strings.ToLower(addr.Hex()), "https://orch.example.com:8935"}, f.List(OrchDenyList))
+	assert.Equal([]string{"https://127.0.0.1:8935"}, f.List(OrchAllowList))
+
+	// Lists are loaded from the DB
+	f, err = NewOrchestratorFilter(dbh)
+	require.Nil(err)
+	assert.ElementsMatch([]string{strings.ToLower(addr.Hex()), "https://orch.example.com:8935"}, f.List(OrchDenyList))
+	assert.Equal([]string{"https://127.0.0.1:8935"}, f.List(OrchAllowList))
+
+	require.Nil(f.Remove(OrchDenyList, strings.ToLower(addr.Hex())))
+	require.Nil(f.Remove(OrchAllowList, "https://127.0.0.1:8935"))
+	f, err = NewOrchestratorFilter(dbh)
+	require.Nil(err)
+	assert.Equal([]string{"https://orch.example.com:8935"}, f.List(OrchDenyList))
+	assert.Empty(f.List(OrchAllowList))
+
+	// Invalid lists and entries are rejected
+	assert.EqualError(f.Add("foo", addr.Hex()), `invalid orchestrator list "foo"`)
+	assert.EqualError(f.Add(OrchDenyList, "127.0.0.1:8935"), `invalid orchestrator address or URI "127.0.0.1:8935"`)
+	assert.EqualError(f.Remove(OrchDenyList, "0x123"), `invalid orchestrator address or URI "0x123"`)
+
+	// A nil filter has no lists
+	var nilFilter *OrchestratorFilter
+	assert.Empty(nilFilter.List(OrchDenyList))
+	assert.EqualError(nilFilter.Add(OrchDenyList, addr.Hex()), "orchestrator lists are not enabled")
+}
+
+func TestOrchestratorFilter_Allowed(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	parse := func(s string) *url.URL {
+		uri, err := url.ParseRequestURI(s)
+		require.Nil(err)
+		return uri
+	}
+	uri1, uri2, uri3 := parse("https://127.0.0.1:1"), parse("https://127.0.0.1:2"), parse("https://127.0.0.1:3")
+	addr1 := ethcommon.HexToAddress("0x1")
+	addr2 := ethcommon.HexToAddress("0x2")
+
+	// A nil filter allows all orchestrators
+	var f *OrchestratorFilter
+	assert.True(f.Allowed(uri1, addr1))
+	assert.True(f.AllowedURI(uri1))
+
+	// Without lists all orchestrators are allowed
+	f, err := NewOrchestratorFilter(nil)
+	require.Nil(err)
+	assert.True(f.Allowed(uri1, addr1))
+	assert.True(f.AllowedURI(uri1))
+
+	// Orchestrators on the denylist are not allowed by URI or address
+	require.Nil(f.Add(OrchDenyList, uri1.String()))
+	require.Nil(f.Add(OrchDenyList, addr2.Hex()))
+	assert.False(f.AllowedURI(uri1))
+	assert.False(f.Allowed(uri1, addr1))
+	assert.True(f.AllowedURI(uri2))
+	assert.False(f.Allowed(uri2, addr2))
+	assert.True(f.Allowed(uri2, addr1))
+
+	// Only orchestrators on the allowlist are allowed
+	require.Nil(f.Add(OrchAllowList, uri2.String()))
+	assert.True(f.AllowedURI(uri2))
+	assert.False(f.AllowedURI(uri3))
+	assert.True(f.Allowed(uri2, addr1))
+	assert.False(f.Allowed(uri3, addr1))
+
+	// Orchestrators can be allowed by address once their address is known
+	require.Nil(f.Add(OrchAllowList, addr1.Hex()))
+	assert.True(f.AllowedURI(uri3))
+	assert.True(f.Allowed(uri3, addr1))
+	assert.False(f.Allowed(uri3, ethcommon.HexToAddress("0x3")))
+
+	// The denylist takes precedence over the allowlist
+	require.Nil(f.Add(OrchAllowList, uri1.String()))
+	assert.False(f.Allowed(uri1, addr1))
+
+	// URIs denied by other sources are not allowed until the source stops denying them
+	f.SetDenied("webhook", []*url.URL{uri2})
+	assert.False(f.AllowedURI(uri2))
+	assert.False(f.Allowed(uri2, addr1))
+	assert.NotContains(f.List(OrchDenyList), "https://127.0.0.1:2")
+	f.SetDenied("webhook", nil)
+	assert.True(f.AllowedURI(uri2))
+	assert.True(f.Allowed(uri2, addr1))
+}
@@ -2,10 +2,13 @@ package core
 
 import (
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/golang/glog"
+	"github.com/livepeer/go-livepeer/common"
 )
 
 // Balance holds the credit balance for a broadcast session
@@ -59,6 +62,12 @@ type AddressBalances struct {
 	balances map[ethcommon.Address]*Balances
 	mtx      sync.Mutex
 	ttl      time.Duration
+
+	// saveMtx serializes writing snapshots to the DB so that a snapshot
+	// is never overwritten by an older one
+	saveMtx      sync.Mutex
+	quit         chan struct{}
+	snapshotDone chan struct{}
 }
 
 // NewAddressBalances creates a new AddressBalances instance
@@ -66,6 +75,7 @@ func NewAddressBalances(ttl time.Duration) *AddressBalances {
 	return &AddressBalances{
 		balances: make(map[ethcommon.Address]*Balances),
 		ttl:      ttl,
+		quit:     make(chan struct{}),
 	}
 }
 
@@ -99,6 +109,91 @@ func (a *AddressBalances) StopCleanup() {
 	}
 }
 
+// Snapshot returns a copy of all balances sorted by address and ManifestID
+func (a *AddressBalances) Snapshot() []*common.DBBalance {
+	a.mtx.Lock()
+	addrs := make([]ethcommon.Address, 0, len(a.balances))
+	for addr := range a.balances {
+		addrs = append(addrs, addr)
+	}
+	a.mtx.Unlock()
+
+	var snapshot []*common.DBBalance
+	for _, addr := range addrs {
+		snapshot = append(snapshot, a.balancesForAddr(addr).snapshot(addr)...)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Sender != snapshot[j].Sender {
+			return snapshot[i].Sender.Hex() < snapshot[j].Sender.Hex()
+		}
+		return snapshot[i].ManifestID < snapshot[j].ManifestID
+	})
+	return snapshot
+}
+
+// LoadBalances restores the balances stored in the DB. Restored balances are
+// considered updated at load time so they are not immediately cleaned up
+func (a *AddressBalances) LoadBalances(db *common.DB) error {
+	balances, err := db.Balances(nil)
+	if err != nil {
+		return err
+	}
+	for _, b := range balances {
+		a.balancesForAddr(b.Sender).restore(ManifestID(b.ManifestID), b.Amount)
+	}
+	glog.Infof("Loaded %v balances from the DB", len(balances))
+	return nil
+}
+
+// SaveBalances replaces the balances stored in the DB with a snapshot of the
+// current balances
+func (a *AddressBalances) SaveBalances(db *common.DB) error {
+	a.saveMtx.Lock()
+	defer a.saveMtx.Unlock()
+	return db.StoreBalances(a.Snapshot())
+}
+
+// StartSnapshots saves the balances to the DB every interval until
+// StopSnapshots is called
+func (a *AddressBalances) StartSnapshots(db *common.DB, interval time.Duration) {
+	a.mtx.Lock()
+	a.snapshotDone = make(chan struct{})
+	done := a.snapshotDone
+	a.mtx.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.SaveBalances(db); err != nil {
+					glog.Errorf("Error saving balances err=%v", err)
+				}
+			case <-a.quit:
+				if err := a.SaveBalances(db); err != nil {
+					glog.Errorf("Error saving balances err=%v", err)
+				}
+				return
+			}
+		}
+	}()
+}
+
+// StopSnapshots stops the snapshot loop started by StartSnapshots and waits
+// for the final snapshot to be saved
+func (a *AddressBalances) StopSnapshots() {
+	a.mtx.Lock()
+	done := a.snapshotDone
+	a.mtx.Unlock()
+
+	close(a.quit)
+	if done != nil {
+		<-done
+	}
+}
+
 func (a *AddressBalances) balancesForAddr(addr ethcommon.Address) *Balances {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
@@ -181,13 +276,35 @@ func (b *Balances) Balance(id ManifestID) *big.Rat {
 	return b.balances[id].amount
 }
 
+func (b *Balances) snapshot(addr ethcommon.Address) []*common.DBBalance {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	snapshot := make([]*common.DBBalance, 0, len(b.balances))
+	for id, balance := range b.balances {
+		snapshot = append(snapshot, &common.DBBalance{
+			Sender:     addr,
+			ManifestID: string(id),
+			Amount:     new(big.Rat).Set(balance.amount),
+			UpdatedAt:  balance.lastUpdate,
+		})
+	}
+	return snapshot
+}
+
+func (b *Balances) restore(id ManifestID, amount *big.Rat) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.balances[id] = &balance{amount: new(big.Rat).Set(amount), lastUpdate: time.Now()}
+}
+
 func (b *Balances) cleanup() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
 	for id, balance := range b.balances {
-		b.mtx.Lock()
 		if int64(time.Since(balance.lastUpdate)) > int64(b.ttl) {
 			delete(b.balances, id)
 		}
-		b.mtx.Unlock()
 	}
 }
 
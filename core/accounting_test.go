@@ -6,7 +6,9 @@ import (
 	"time"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBalance_Credit(t *testing.T) {
@@ -248,3 +250,70 @@ func TestBalancesCleanup(t *testing.T) {
 	// Now balance for mid1 should be cleaned as well
 	assert.Nil(b.Balance(mid1))
 }
+
+func TestAddressBalances_Snapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	balances := NewAddressBalances(1 * time.Minute)
+	defer balances.StopCleanup()
+	assert.Empty(balances.Snapshot())
+
+	addr1 := ethcommon.Address{1}
+	addr2 := ethcommon.Address{2}
+	balances.Credit(addr2, ManifestID("foo"), big.NewRat(5, 1))
+	balances.Credit(addr1, ManifestID("foo"), big.NewRat(1, 3))
+	balances.Debit(addr1, ManifestID("bar"), big.NewRat(2, 1))
+
+	snapshot := balances.Snapshot()
+	assert.Len(snapshot, 3)
+	assert.Equal(addr1, snapshot[0].Sender)
+	assert.Equal("bar", snapshot[0].ManifestID)
+	assert.Zero(snapshot[0].Amount.Cmp(big.NewRat(-2, 1)))
+	assert.Equal(addr1, snapshot[1].Sender)
+	assert.Equal("foo", snapshot[1].ManifestID)
+	assert.Equal(addr2, snapshot[2].Sender)
+	assert.False(snapshot[2].UpdatedAt.IsZero())
+
+	// The snapshot is a copy
+	snapshot[2].Amount.SetInt64(100)
+	assert.Zero(balances.Balance(addr2, ManifestID("foo")).Cmp(big.NewRat(5, 1)))
+}
+
+func TestAddressBalances_Persistence(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	addr := ethcommon.Address{1}
+	balances := NewAddressBalances(1 * time.Minute)
+	defer balances.StopCleanup()
+	balances.Credit(addr, ManifestID("foo"), big.NewRat(7, 2))
+
+	require.Nil(balances.SaveBalances(dbh))
+
+	// Balances are restored by a new instance, e.g. after a restart
+	restored := NewAddressBalances(1 * time.Minute)
+	defer restored.StopCleanup()
+	require.Nil(restored.LoadBalances(dbh))
+	assert.Zero(restored.Balance(addr, ManifestID("foo")).Cmp(big.NewRat(7, 2)))
+
+	// Snapshots are saved periodically and when stopped
+	restored.StartSnapshots(dbh, 10*time.Millisecond)
+	restored.Credit(addr, ManifestID("foo"), big.NewRat(1, 2))
+	time.Sleep(50 * time.Millisecond)
+	stored, err := dbh.Balances(nil)
+	require.Nil(err)
+	require.Len(stored, 1)
+	assert.Zero(stored[0].Amount.Cmp(big.NewRat(4, 1)))
+
+	restored.Credit(addr, ManifestID("bar"), big.NewRat(1, 1))
+	restored.StopSnapshots()
+	stored, err = dbh.Balances(&common.DBBalanceFilter{ManifestID: "bar"})
+	require.Nil(err)
+	require.Len(stored, 1)
+	assert.Equal(addr, stored[0].Sender)
+}
@@ -3,6 +3,7 @@ package core
 import (
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/livepeer/go-livepeer/pm"
 )
 
 // Broadcaster RPC interface implementation
@@ -23,8 +24,34 @@ func (bcast *broadcaster) Address() ethcommon.Address {
 	}
 	return bcast.node.Eth.Account().Address
 }
+
+// SenderPool returns the sender pool of the node if it pays from multiple accounts
+func (bcast *broadcaster) SenderPool() *pm.SenderPool {
+	if bcast.node == nil {
+		return nil
+	}
+	pool, _ := bcast.node.Sender.(*pm.SenderPool)
+	return pool
+}
 func NewBroadcaster(node *LivepeerNode) *broadcaster {
 	return &broadcaster{
 		node: node,
 	}
 }
+
+// signerBroadcaster is a broadcaster that signs with one of the accounts of a sender pool
+type signerBroadcaster struct {
+	signer pm.Signer
+}
+
+func (bcast *signerBroadcaster) Sign(msg []byte) ([]byte, error) {
+	return bcast.signer.Sign(crypto.Keccak256(msg))
+}
+func (bcast *signerBroadcaster) Address() ethcommon.Address {
+	return bcast.signer.Account().Address
+}
+
+// NewSignerBroadcaster creates a broadcaster that identifies as the account of signer
+func NewSignerBroadcaster(signer pm.Signer) *signerBroadcaster {
+	return &signerBroadcaster{signer: signer}
+}
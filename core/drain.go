@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// drainRedemptionsInterval is how often Drain checks for pending ticket redemptions
+var drainRedemptionsInterval = 100 * time.Millisecond
+
+// ErrNodeDraining is returned when new work is refused because the node is draining
+var ErrNodeDraining = errors.New("ErrNodeDraining")
+
+// workTracker keeps count of in-flight work so that a node can stop accepting
+// new work and wait for the work it already accepted to finish before exiting.
+// The zero value is ready to use
+type workTracker struct {
+	mu       sync.Mutex
+	draining bool
+	active   int
+	// drainc is closed when draining starts
+	drainc chan struct{}
+	// idlec is closed when draining and there is no active work left
+	idlec chan struct{}
+	idle  bool
+}
+
+func (w *workTracker) init() {
+	if w.drainc == nil {
+		w.drainc = make(chan struct{})
+		w.idlec = make(chan struct{})
+	}
+}
+
+// setIdle closes idlec once. Work tracked while draining can make the node idle more than once.
+// The caller must hold w.mu
+func (w *workTracker) setIdle() {
+	if !w.idle {
+		w.idle = true
+		close(w.idlec)
+	}
+}
+
+// BeginWork registers a new unit of work with the node. ErrNodeDraining is
+// returned if the node is draining, in which case the work should be refused.
+// Every successful call must be followed by a call to EndWork
+func (n *LivepeerNode) BeginWork() error {
+	w := &n.work
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.draining {
+		return ErrNodeDraining
+	}
+	w.active++
+	return nil
+}
+
+// TrackWork registers a unit of work that must complete before the node
+// finishes draining even if it is started while draining, e.g. the redemption
+// of a ticket received for an in-flight segment. Every call must be followed
+// by a call to EndWork
+func (n *LivepeerNode) TrackWork() {
+	w := &n.work
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.active++
+}
+
+// EndWork marks a unit of work registered with BeginWork or TrackWork as done
+func (n *LivepeerNode) EndWork() {
+	w := &n.work
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.active <= 0 {
+		return
+	}
+	w.active--
+	if w.draining && w.active == 0 {
+		w.setIdle()
+	}
+}
+
+// ActiveWork returns the number of in-flight units of work
+func (n *LivepeerNode) ActiveWork() int {
+	w := &n.work
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.active
+}
+
+// IsDraining returns whether the node is draining
+func (n *LivepeerNode) IsDraining() bool {
+	w := &n.work
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.draining
+}
+
+// Draining returns a channel that is closed once the node starts draining
+func (n *LivepeerNode) Draining() <-chan struct{} {
+	w := &n.work
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.init()
+	return w.drainc
+}
+
+// StartDrain makes the node refuse new work. It does not wait for in-flight
+// work to finish; use Drain for that. Returns false if already draining
+func (n *LivepeerNode) StartDrain() bool {
+	w := &n.work
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.draining {
+		return false
+	}
+	w.init()
+	w.draining = true
+	close(w.drainc)
+	if w.active == 0 {
+		w.setIdle()
+	}
+	return true
+}
+
+// Drain makes the node refuse new work and blocks until all in-flight work
+// and pending ticket redemptions have finished or the context is done
+func (n *LivepeerNode) Drain(ctx context.Context) error {
+	n.StartDrain()
+
+	w := &n.work
+	w.mu.Lock()
+	idlec := w.idlec
+	w.mu.Unlock()
+
+	select {
+	case <-idlec:
+	case <-ctx.Done():
+		return fmt.Errorf("%v with %v units of work in flight", ctx.Err(), n.ActiveWork())
+	}
+
+	// Winning tickets received for the last segments are redeemed asynchronously
+	ticker := time.NewTicker(drainRedemptionsInterval)
+	defer ticker.Stop()
+	for {
+		pending := n.pendingRedemptions()
+		if pending == 0 {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("%v with %v ticket redemptions pending", ctx.Err(), pending)
+		}
+	}
+}
+
+// pendingRedemptions returns the number of winning tickets that the node's recipient has yet to redeem
+func (n *LivepeerNode) pendingRedemptions() int {
+	if r, ok := n.Recipient.(interface{ PendingRedemptions() int }); ok {
+		return r.PendingRedemptions()
+	}
+	return 0
+}
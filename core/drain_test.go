@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livepeer/go-livepeer/pm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain_NoActiveWork(t *testing.T) {
+	assert := assert.New(t)
+	n := &LivepeerNode{}
+
+	assert.False(n.IsDraining())
+	select {
+	case <-n.Draining():
+		t.Fatal("draining channel closed before draining")
+	default:
+	}
+
+	assert.Nil(n.Drain(context.Background()))
+	assert.True(n.IsDraining())
+	<-n.Draining()
+
+	// New work is refused
+	assert.Equal(ErrNodeDraining, n.BeginWork())
+	assert.Zero(n.ActiveWork())
+
+	// Draining again is a no-op
+	assert.False(n.StartDrain())
+	assert.Nil(n.Drain(context.Background()))
+}
+
+func TestDrain_WaitsForActiveWork(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	n := &LivepeerNode{}
+
+	require.Nil(n.BeginWork())
+	require.Nil(n.BeginWork())
+	assert.Equal(2, n.ActiveWork())
+
+	errc := make(chan error)
+	go func() { errc <- n.Drain(context.Background()) }()
+
+	<-n.Draining()
+	assert.Equal(ErrNodeDraining, n.BeginWork())
+
+	// Tracked work is accepted while draining
+	n.TrackWork()
+	assert.Equal(3, n.ActiveWork())
+
+	n.EndWork()
+	n.EndWork()
+	select {
+	case <-errc:
+		t.Fatal("drain finished with active work")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	n.EndWork()
+	assert.Nil(<-errc)
+	assert.Zero(n.ActiveWork())
+
+	// Extra calls to EndWork are ignored
+	n.EndWork()
+	assert.Zero(n.ActiveWork())
+}
+
+func TestDrain_Deadline(t *testing.T) {
+	n := &LivepeerNode{}
+	require.Nil(t, n.BeginWork())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := n.Drain(ctx)
+	assert.EqualError(t, err, "context deadline exceeded with 1 units of work in flight")
+	assert.True(t, n.IsDraining())
+}
+
+func TestDrain_TrackWorkAfterIdle(t *testing.T) {
+	n := &LivepeerNode{}
+	assert.True(t, n.StartDrain())
+
+	// Work tracked after the node became idle does not close the idle channel again
+	n.TrackWork()
+	assert.NotPanics(t, n.EndWork)
+	n.TrackWork()
+	assert.NotPanics(t, n.EndWork)
+	assert.Nil(t, n.Drain(context.Background()))
+}
+
+type stubPendingRecipient struct {
+	pm.MockRecipient
+	mu      sync.Mutex
+	pending int
+}
+
+func (r *stubPendingRecipient) PendingRedemptions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pending
+}
+
+func (r *stubPendingRecipient) setPending(pending int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = pending
+}
+
+func TestDrain_WaitsForPendingRedemptions(t *testing.T) {
+	oldInterval := drainRedemptionsInterval
+	drainRedemptionsInterval = time.Millisecond
+	defer func() { drainRedemptionsInterval = oldInterval }()
+
+	recipient := &stubPendingRecipient{pending: 2}
+	n := &LivepeerNode{Recipient: recipient}
+
+	// Pending redemptions are reported when the deadline is reached
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.EqualError(t, n.Drain(ctx), "context deadline exceeded with 2 ticket redemptions pending")
+
+	errc := make(chan error)
+	go func() { errc <- n.Drain(context.Background()) }()
+	select {
+	case <-errc:
+		t.Fatal("drain finished with pending redemptions")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	recipient.setPending(0)
+	assert.Nil(t, <-errc)
+}
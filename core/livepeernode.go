@@ -60,9 +60,15 @@ type LivepeerNode struct {
 	Transcoder        Transcoder
 	TranscoderManager *RemoteTranscoderManager
 	Balances          *AddressBalances
+	GasPriceMonitor   *eth.GasPriceMonitor
+	SegmentSigner     *SegmentSigner
 
 	// Broadcaster public fields
-	Sender pm.Sender
+	Sender      pm.Sender
+	Spending    *SpendTracker
+	SessionPool *SessionPool
+	// OrchFilter is the allowlist and denylist of orchestrators, if any
+	OrchFilter *common.OrchestratorFilter
 
 	// Thread safety for config fields
 	mu sync.RWMutex
@@ -70,6 +76,9 @@ type LivepeerNode struct {
 	priceInfo    *big.Rat
 	serviceURI   url.URL
 	segmentMutex *sync.RWMutex
+
+	// in-flight work tracking for graceful shutdown
+	work workTracker
 }
 
 //NewLivepeerNode creates a new Livepeer Node. Eth can be nil.
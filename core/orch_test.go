@@ -537,6 +537,16 @@ func TestOrchCheckCapacity(t *testing.T) {
 	assert.Nil(err)
 	MaxSessions = 0
 	assert.Nil(o.CheckCapacity(md.ManifestID))
+	MaxSessions = cap
+
+	// draining orchestrators refuse new sessions but not active ones
+	n.StartDrain()
+	assert.Nil(o.CheckCapacity(md.ManifestID))
+	newMd := StubSegTranscodingMetadata()
+	newMd.ManifestID = ManifestID("new")
+	assert.Equal(ErrNodeDraining, o.CheckCapacity(newMd.ManifestID))
+	_, err = o.TranscodeSeg(newMd, nil)
+	assert.Equal(ErrNodeDraining, err)
 }
 
 func TestProcessPayment_GivenRecipientError_ReturnsNil(t *testing.T) {
@@ -801,6 +811,19 @@ func TestProcessPayment_GivenWinningTicket_Redeems(t *testing.T) {
 	assert.Zero(errorLogsAfter - errorLogsBefore)
 	assert.Nil(err)
 	recipient.AssertCalled(t, "RedeemWinningTicket", mock.Anything, mock.Anything, mock.Anything)
+
+	// Pending redemptions are tracked until they complete
+	assert.Zero(n.ActiveWork())
+
+	// The credited ticket is recorded in the ledger
+	entries, err := dbh.LedgerEntries(nil)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(common.LedgerTicket, entries[0].Type)
+	assert.Equal(string(manifestID), entries[0].ManifestID)
+	assert.True(entries[0].Won)
+	assert.Empty(entries[0].Error)
+	assert.Zero(entries[0].Amount.Cmp(orch.node.Balances.Balance(entries[0].Sender, manifestID)))
 }
 
 func TestProcessPayment_GivenMultipleWinningTickets_RedeemsAll(t *testing.T) {
@@ -980,6 +1003,16 @@ func TestProcessPayment_GivenReceiveTicketError_ReturnsError(t *testing.T) {
 	assert.EqualError(err, errInvalidTicketSignature.Error())
 	recipient.AssertNumberOfCalls(t, "RedeemWinningTicket", 1)
 
+	// Both tickets are recorded in the ledger but neither was credited
+	entries, err := dbh.LedgerEntries(&common.DBLedgerFilter{Type: common.LedgerTicket})
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(string(manifestID), entries[0].ManifestID)
+	assert.False(entries[0].Won)
+	assert.Equal(errInvalidTicketSignature.Error(), entries[0].Error)
+	assert.True(entries[1].Won)
+	assert.Equal(errLedgerEarlierTicketFailed, entries[1].Error)
+
 	// Does not loop through tickets if won==false and error is a fatal receive error
 	recipient.On("ReceiveTicket", mock.Anything, mock.Anything, mock.Anything).Return("", false, pm.NewFatalReceiveErr(errors.New("ReceiveTicket error"))).Once()
 	err = orch.ProcessPayment(*defaultPaymentWithTickets(t, senderParams), manifestID)
@@ -1381,6 +1414,31 @@ func TestDebitFees(t *testing.T) {
 	assert.Zero(orch.node.Balances.Balance(addr, manifestID).Cmp(big.NewRat(0, 1)))
 }
 
+func TestDebitFees_RecordsLedgerEntry(t *testing.T) {
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(t, err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	n, _ := NewLivepeerNode(nil, "", dbh)
+	n.Balances = NewAddressBalances(5 * time.Second)
+	defer n.Balances.StopCleanup()
+	orch := NewOrchestrator(n, nil)
+	addr := pm.RandAddress()
+
+	orch.DebitFees(addr, ManifestID("some manifest"), &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 5}, 100)
+
+	entries, err := dbh.LedgerEntries(nil)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	assert := assert.New(t)
+	assert.Equal(common.LedgerDebit, entries[0].Type)
+	assert.Equal(addr, entries[0].Sender)
+	assert.Equal("some manifest", entries[0].ManifestID)
+	assert.Zero(entries[0].Amount.Cmp(big.NewRat(20, 1)))
+	assert.Equal(int64(100), entries[0].Pixels)
+}
+
 func TestDebitFees_OffChain_Returns(t *testing.T) {
 	price := &net.PriceInfo{
 		PricePerUnit:  1,
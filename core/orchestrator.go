@@ -64,13 +64,26 @@ func (orch *orchestrator) Sign(msg []byte) ([]byte, error) {
 	return orch.node.Eth.Sign(crypto.Keccak256(msg))
 }
 
+// SegmentSigner returns the key that is delegated to sign transcoded segments, if any
+func (orch *orchestrator) SegmentSigner() *SegmentSigner {
+	if orch.node == nil {
+		return nil
+	}
+	return orch.node.SegmentSigner
+}
+
 func (orch *orchestrator) VerifySig(addr ethcommon.Address, msg string, sig []byte) bool {
-	if orch.node == nil || orch.node.Eth == nil {
+	if !orch.VerifiesSigs() {
 		return true
 	}
 	return lpcrypto.VerifySig(addr, crypto.Keccak256([]byte(msg)), sig)
 }
 
+// VerifiesSigs returns whether VerifySig checks signatures. Signatures are only checked with an Eth client
+func (orch *orchestrator) VerifiesSigs() bool {
+	return orch.node != nil && orch.node.Eth != nil
+}
+
 func (orch *orchestrator) Address() ethcommon.Address {
 	return orch.address
 }
@@ -85,6 +98,10 @@ func (orch *orchestrator) CheckCapacity(mid ManifestID) error {
 	if _, ok := orch.node.SegmentChans[mid]; ok {
 		return nil
 	}
+	// Draining orchestrators finish active segment loops but do not start new ones
+	if orch.node.IsDraining() {
+		return ErrNodeDraining
+	}
 	if len(orch.node.SegmentChans) >= MaxSessions {
 		return ErrOrchCap
 	}
@@ -92,6 +109,17 @@ func (orch *orchestrator) CheckCapacity(mid ManifestID) error {
 }
 
 func (orch *orchestrator) TranscodeSeg(md *SegTranscodingMetadata, seg *stream.HLSSegment) (*TranscodeResult, error) {
+	orch.node.segmentMutex.RLock()
+	_, active := orch.node.SegmentChans[md.ManifestID]
+	orch.node.segmentMutex.RUnlock()
+	if active {
+		// Segments of active segment loops are transcoded while draining
+		orch.node.TrackWork()
+	} else if err := orch.node.BeginWork(); err != nil {
+		return nil, err
+	}
+	defer orch.node.EndWork()
+
 	return orch.node.sendToTranscodeLoop(md, seg)
 }
 
@@ -188,12 +216,31 @@ func (orch *orchestrator) ProcessPayment(payment net.Payment, manifestID Manifes
 			receiveErr = err
 		}
 
+		entry := &common.DBLedgerEntry{
+			Type:       common.LedgerTicket,
+			Sender:     sender,
+			ManifestID: string(manifestID),
+			Amount:     ticket.EV(),
+			FaceValue:  ticket.FaceValue,
+			Won:        won,
+			TicketHash: ticket.Hash(),
+		}
+
 		if receiveErr == nil {
 			// Add ticket EV to credit
 			ev := ticket.EV()
 			orch.node.Balances.Credit(sender, manifestID, ev)
 			totalEV.Add(totalEV, ev)
 			totalTickets++
+		} else if err != nil {
+			// The ticket was not credited
+			entry.Error = err.Error()
+		} else {
+			entry.Error = errLedgerEarlierTicketFailed
+		}
+
+		if err := orch.node.Database.InsertLedgerEntry(entry); err != nil {
+			glog.Errorf("Error recording ticket in ledger manifestID=%v recipientRandHash=%x senderNonce=%v: %v", manifestID, ticket.RecipientRandHash, ticket.SenderNonce, err)
 		}
 
 		if won {
@@ -201,7 +248,10 @@ func (orch *orchestrator) ProcessPayment(payment net.Payment, manifestID Manifes
 
 			totalWinningTickets++
 
+			// The ticket must be queued before a draining node checks for pending redemptions
+			orch.node.TrackWork()
 			go func(ticket *pm.Ticket, sig []byte, seed *big.Int) {
+				defer orch.node.EndWork()
 				if err := orch.node.Recipient.RedeemWinningTicket(ticket, sig, seed); err != nil {
 					glog.Errorf("error redeeming ticket manifestID=%v recipientRandHash=%x senderNonce=%v: %v", manifestID, ticket.RecipientRandHash, ticket.SenderNonce, err)
 				}
@@ -301,7 +351,19 @@ func (orch *orchestrator) DebitFees(addr ethcommon.Address, manifestID ManifestI
 		return
 	}
 	priceRat := big.NewRat(price.GetPricePerUnit(), price.GetPixelsPerUnit())
-	orch.node.Balances.Debit(addr, manifestID, priceRat.Mul(priceRat, big.NewRat(pixels, 1)))
+	fees := priceRat.Mul(priceRat, big.NewRat(pixels, 1))
+	orch.node.Balances.Debit(addr, manifestID, fees)
+
+	entry := &common.DBLedgerEntry{
+		Type:       common.LedgerDebit,
+		Sender:     addr,
+		ManifestID: string(manifestID),
+		Amount:     fees,
+		Pixels:     pixels,
+	}
+	if err := orch.node.Database.InsertLedgerEntry(entry); err != nil {
+		glog.Errorf("Error recording debit in ledger manifestID=%v: %v", manifestID, err)
+	}
 }
 
 func (orch *orchestrator) isActive() (bool, error) {
@@ -331,6 +393,10 @@ func NewOrchestrator(n *LivepeerNode, rm common.RoundsManager) *orchestrator {
 
 // LivepeerNode transcode methods
 
+// errLedgerEarlierTicketFailed is recorded for a ticket that was received but not
+// credited because an earlier ticket in the same payment failed
+const errLedgerEarlierTicketFailed = "not credited: earlier ticket in payment failed"
+
 var ErrOrchBusy = ogErrors.New("OrchestratorBusy")
 var ErrOrchCap = ogErrors.New("OrchestratorCapped")
 
@@ -548,7 +614,11 @@ func (n *LivepeerNode) transcodeSeg(config transcodeConfig, seg *stream.HLSSegme
 	}
 
 	segHash := crypto.Keccak256(segHashes...)
-	tr.Sig, tr.Err = n.Eth.Sign(segHash)
+	if n.SegmentSigner != nil {
+		tr.Sig, tr.Err = n.SegmentSigner.Sign(segHash)
+	} else {
+		tr.Sig, tr.Err = n.Eth.Sign(segHash)
+	}
 	if tr.Err != nil {
 		glog.Error("Unable to sign hash of transcoded segment hashes: ", tr.Err)
 	}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/livepeer/go-livepeer/eth"
+)
+
+// SegmentSigner is a key that the orchestrator account delegates to sign
+// transcoded segments so that the account key only needs to handle funds
+type SegmentSigner struct {
+	am  eth.AccountManager
+	sig []byte
+}
+
+// NewSegmentSigner creates a SegmentSigner for the unlocked account of am that
+// is authorized by a signature of the orchestrator account from client
+func NewSegmentSigner(am eth.AccountManager, client eth.LivepeerEthClient) (*SegmentSigner, error) {
+	addr := am.Account().Address
+	if addr == client.Account().Address {
+		return nil, fmt.Errorf("segment signer %v must be different from the orchestrator account", addr.Hex())
+	}
+
+	sig, err := client.Sign(crypto.Keccak256(SegmentSignerMsg(addr)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &SegmentSigner{am: am, sig: sig}, nil
+}
+
+// SegmentSignerMsg returns the message that the orchestrator account signs to
+// authorize signer to sign transcoded segments on its behalf
+func SegmentSignerMsg(signer ethcommon.Address) []byte {
+	return []byte(fmt.Sprintf("Livepeer segment signer %v", signer.Hex()))
+}
+
+// Address returns the address of the delegated key
+func (s *SegmentSigner) Address() ethcommon.Address {
+	return s.am.Account().Address
+}
+
+// Sig returns the signature of the orchestrator account authorizing the delegated key
+func (s *SegmentSigner) Sig() []byte {
+	return s.sig
+}
+
+// Sign signs msg with the delegated key
+func (s *SegmentSigner) Sign(msg []byte) ([]byte, error) {
+	return s.am.Sign(msg)
+}
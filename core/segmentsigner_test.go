@@ -0,0 +1,94 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	lpcrypto "github.com/livepeer/go-livepeer/crypto"
+	"github.com/livepeer/go-livepeer/eth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type keyAccountManager struct {
+	key *ecdsa.PrivateKey
+}
+
+func newKeyAccountManager(t *testing.T) *keyAccountManager {
+	key, err := crypto.GenerateKey()
+	require.Nil(t, err)
+	return &keyAccountManager{key: key}
+}
+
+func (am *keyAccountManager) Unlock(passphrase string) error { return nil }
+func (am *keyAccountManager) Lock() error                    { return nil }
+func (am *keyAccountManager) CreateTransactOpts(gasLimit uint64, gasPrice *big.Int) (*bind.TransactOpts, error) {
+	return nil, nil
+}
+func (am *keyAccountManager) SignTx(tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
+func (am *keyAccountManager) Sign(msg []byte) ([]byte, error) {
+	sig, err := crypto.Sign(accounts.TextHash(msg), am.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+func (am *keyAccountManager) Account() accounts.Account {
+	return accounts.Account{Address: crypto.PubkeyToAddress(am.key.PublicKey)}
+}
+
+type segmentSignerClient struct {
+	*eth.StubClient
+	am      *keyAccountManager
+	signErr error
+}
+
+func (c *segmentSignerClient) Account() accounts.Account { return c.am.Account() }
+func (c *segmentSignerClient) Sign(msg []byte) ([]byte, error) {
+	if c.signErr != nil {
+		return nil, c.signErr
+	}
+	return c.am.Sign(msg)
+}
+
+func TestSegmentSigner(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	client := &segmentSignerClient{StubClient: &eth.StubClient{}, am: newKeyAccountManager(t)}
+	am := newKeyAccountManager(t)
+
+	s, err := NewSegmentSigner(am, client)
+	require.Nil(err)
+	assert.Equal(am.Account().Address, s.Address())
+
+	// The orchestrator account authorizes the segment signer
+	recipient := client.Account().Address
+	assert.True(lpcrypto.VerifySig(recipient, crypto.Keccak256(SegmentSignerMsg(s.Address())), s.Sig()))
+	assert.False(lpcrypto.VerifySig(recipient, crypto.Keccak256(SegmentSignerMsg(ethcommon.HexToAddress("foo"))), s.Sig()))
+
+	// Segments are signed with the segment signer
+	hash := crypto.Keccak256([]byte("foo"))
+	sig, err := s.Sign(hash)
+	require.Nil(err)
+	assert.True(lpcrypto.VerifySig(s.Address(), hash, sig))
+	assert.False(lpcrypto.VerifySig(recipient, hash, sig))
+
+	// The segment signer must be different from the orchestrator account
+	_, err = NewSegmentSigner(client.am, client)
+	assert.EqualError(err, "segment signer "+recipient.Hex()+" must be different from the orchestrator account")
+
+	client.signErr = errors.New("Sign error")
+	_, err = NewSegmentSigner(am, client)
+	assert.EqualError(err, "Sign error")
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/golang/glog"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/go-livepeer/pm"
+)
+
+// SessionPool is a broadcaster wide pool of orchestrators with recently fetched info.
+// New streams start with orchestrators from the pool instead of discovering orchestrators.
+// Ticket params can only be used by one stream, so each info in the pool is handed out to
+// one stream only and the pool is refilled with new info from discovery or with the
+// refetched info of orchestrators that a finished stream used
+type SessionPool struct {
+	orchs  common.OrchestratorPool
+	sender pm.Sender
+	size   int
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	infos     map[string]*pooledInfo
+	refilling bool
+}
+
+type pooledInfo struct {
+	info  *net.OrchestratorInfo
+	added time.Time
+}
+
+// NewSessionPool creates a SessionPool that keeps the info of up to size orchestrators from orchs
+// for ttl. If sender is not nil, orchestrators with ticket params that sender does not accept are not used
+func NewSessionPool(orchs common.OrchestratorPool, sender pm.Sender, size int, ttl time.Duration) *SessionPool {
+	return &SessionPool{
+		orchs:  orchs,
+		sender: sender,
+		size:   size,
+		ttl:    ttl,
+		infos:  make(map[string]*pooledInfo),
+	}
+}
+
+// Get removes the info of up to n orchestrators in random order from the pool and returns it.
+// The pool is refilled in the background if it has fewer than its size of orchestrators
+func (p *SessionPool) Get(n int) []*net.OrchestratorInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune()
+
+	var infos []*net.OrchestratorInfo
+	for _, pi := range p.infos {
+		infos = append(infos, pi.info)
+	}
+	rand.Shuffle(len(infos), func(i, j int) { infos[i], infos[j] = infos[j], infos[i] })
+	if len(infos) > n {
+		infos = infos[:n]
+	}
+	for _, info := range infos {
+		delete(p.infos, info.Transcoder)
+	}
+
+	if len(p.infos) < p.size && !p.refilling {
+		p.refilling = true
+		go p.refill()
+	}
+
+	return infos
+}
+
+// Put adds the info of orchestrators to the pool or replaces their info in the pool. The
+// ticket params of the info must not have been used by a stream
+func (p *SessionPool) Put(infos ...*net.OrchestratorInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune()
+	for _, info := range infos {
+		if info == nil {
+			continue
+		}
+		if _, ok := p.infos[info.Transcoder]; !ok && len(p.infos) >= p.size {
+			continue
+		}
+		p.infos[info.Transcoder] = &pooledInfo{info: info, added: time.Now()}
+	}
+}
+
+// Remove removes an orchestrator from the pool
+func (p *SessionPool) Remove(transcoder string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.infos, transcoder)
+}
+
+// Fill adds orchestrators from discovery to the pool
+func (p *SessionPool) Fill() {
+	infos, err := p.orchs.GetOrchestrators(p.size)
+	if err != nil {
+		glog.Errorf("Unable to fill session pool err=%v", err)
+	}
+	p.Put(infos...)
+}
+
+func (p *SessionPool) refill() {
+	p.Fill()
+
+	p.mu.Lock()
+	p.refilling = false
+	p.mu.Unlock()
+}
+
+// prune removes orchestrators with info that is too old or has unacceptable ticket params.
+// The caller must hold p.mu
+func (p *SessionPool) prune() {
+	for transcoder, pi := range p.infos {
+		if time.Since(pi.added) >= p.ttl {
+			delete(p.infos, transcoder)
+			continue
+		}
+		if p.sender == nil || pi.info.TicketParams == nil {
+			continue
+		}
+		if err := p.sender.ValidateTicketParams(pmTicketParams(pi.info.TicketParams)); err != nil {
+			glog.V(common.DEBUG).Infof("Removing orchestrator from session pool orch=%v err=%v", transcoder, err)
+			delete(p.infos, transcoder)
+		}
+	}
+}
+
+func pmTicketParams(params *net.TicketParams) *pm.TicketParams {
+	return &pm.TicketParams{
+		Recipient:         ethcommon.BytesToAddress(params.Recipient),
+		FaceValue:         new(big.Int).SetBytes(params.FaceValue),
+		WinProb:           new(big.Int).SetBytes(params.WinProb),
+		RecipientRandHash: ethcommon.BytesToHash(params.RecipientRandHash),
+		Seed:              new(big.Int).SetBytes(params.Seed),
+		ExpirationBlock:   new(big.Int).SetBytes(params.ExpirationBlock),
+	}
+}
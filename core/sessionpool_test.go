@@ -0,0 +1,164 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/go-livepeer/pm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type stubOrchestratorPool struct {
+	mu    sync.Mutex
+	infos []*net.OrchestratorInfo
+	calls int
+	err   error
+}
+
+func (p *stubOrchestratorPool) GetURLs() []*url.URL {
+	return nil
+}
+
+func (p *stubOrchestratorPool) GetOrchestrators(n int) ([]*net.OrchestratorInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	infos := p.infos
+	if len(infos) > n {
+		infos = infos[:n]
+	}
+	return infos, p.err
+}
+
+func (p *stubOrchestratorPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.infos)
+}
+
+func (p *stubOrchestratorPool) numCalls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func stubPoolInfos(transcoders ...string) []*net.OrchestratorInfo {
+	var infos []*net.OrchestratorInfo
+	for _, t := range transcoders {
+		infos = append(infos, &net.OrchestratorInfo{
+			Transcoder:   t,
+			TicketParams: &net.TicketParams{ExpirationBlock: big.NewInt(100).Bytes()},
+		})
+	}
+	return infos
+}
+
+func transcoders(infos []*net.OrchestratorInfo) []string {
+	var res []string
+	for _, info := range infos {
+		res = append(res, info.Transcoder)
+	}
+	return res
+}
+
+func TestSessionPool_GetAndPut(t *testing.T) {
+	assert := assert.New(t)
+
+	orchs := &stubOrchestratorPool{infos: stubPoolInfos("a", "b", "c")}
+	p := NewSessionPool(orchs, nil, 2, time.Hour)
+	waitRefill := func() {
+		assert.Eventually(func() bool {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return !p.refilling
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	// An empty pool is refilled in the background
+	assert.Empty(p.Get(2))
+	assert.Eventually(func() bool { return poolSize(p) == 2 }, time.Second, 5*time.Millisecond)
+	waitRefill()
+
+	// A full pool is not refilled
+	calls := orchs.numCalls()
+	p.Get(0)
+	assert.Equal(calls, orchs.numCalls())
+
+	// Orchestrators are only handed out once and the pool is refilled afterwards
+	orchs.mu.Lock()
+	orchs.infos = nil
+	orchs.mu.Unlock()
+	infos := p.Get(1)
+	assert.Len(infos, 1)
+	waitRefill()
+	assert.Equal(calls+1, orchs.numCalls())
+	infos = append(infos, p.Get(2)...)
+	assert.ElementsMatch([]string{"a", "b"}, transcoders(infos))
+	assert.Empty(p.Get(2))
+	waitRefill()
+
+	// Orchestrators are not added to a full pool but their info is replaced
+	p.Put(stubPoolInfos("a", "b")...)
+	p.Put(&net.OrchestratorInfo{Transcoder: "c"}, &net.OrchestratorInfo{Transcoder: "a", PriceInfo: &net.PriceInfo{PricePerUnit: 5}})
+	infos = p.Get(2)
+	assert.ElementsMatch([]string{"a", "b"}, transcoders(infos))
+	for _, info := range infos {
+		if info.Transcoder == "a" {
+			assert.Equal(int64(5), info.PriceInfo.PricePerUnit)
+		}
+	}
+	waitRefill()
+
+	// Removed orchestrators are not used
+	p.Put(stubPoolInfos("a", "b")...)
+	p.Remove("a")
+	assert.Equal([]string{"b"}, transcoders(p.Get(2)))
+	waitRefill()
+
+	// Discovery errors do not empty the pool
+	p.Put(stubPoolInfos("b")...)
+	orchs.mu.Lock()
+	orchs.err = errors.New("discovery error")
+	orchs.mu.Unlock()
+	p.Get(0)
+	waitRefill()
+	assert.Equal([]string{"b"}, transcoders(p.Get(2)))
+}
+
+func poolSize(p *SessionPool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.infos)
+}
+
+func TestSessionPool_Prune(t *testing.T) {
+	assert := assert.New(t)
+
+	sender := &pm.MockSender{}
+	expired := func(params *pm.TicketParams) bool { return params.ExpirationBlock.Cmp(big.NewInt(100)) <= 0 }
+	sender.On("ValidateTicketParams", mock.MatchedBy(expired)).Return(pm.ErrTicketParamsExpired)
+	sender.On("ValidateTicketParams", mock.Anything).Return(nil)
+
+	p := NewSessionPool(&stubOrchestratorPool{}, sender, 10, time.Hour)
+
+	// Orchestrators with expired ticket params are removed
+	valid := &net.OrchestratorInfo{
+		Transcoder:   "b",
+		TicketParams: &net.TicketParams{ExpirationBlock: big.NewInt(200).Bytes()},
+	}
+	p.Put(stubPoolInfos("a")[0], valid, &net.OrchestratorInfo{Transcoder: "c"})
+	assert.ElementsMatch([]string{"b", "c"}, transcoders(p.Get(10)))
+
+	// Orchestrators with info older than the TTL are removed
+	p.Put(valid, &net.OrchestratorInfo{Transcoder: "c"})
+	p.mu.Lock()
+	p.infos["b"].added = time.Now().Add(-2 * time.Hour)
+	p.mu.Unlock()
+	assert.Equal([]string{"c"}, transcoders(p.Get(10)))
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/livepeer/go-livepeer/common"
+)
+
+// Budget types reported in a BudgetEvent
+const (
+	StreamBudget = "stream"
+	DailyBudget  = "daily"
+)
+
+// BudgetEvent describes a budget that was reached by a stream
+type BudgetEvent struct {
+	ManifestID ManifestID
+	Budget     string
+	Spent      *big.Rat
+	Limit      *big.Rat
+}
+
+// SpendTracker records a broadcaster's spending in the DB and keeps
+// running totals used to enforce per stream and per day (UTC) budgets.
+// Spending is measured as the EV of the tickets sent
+type SpendTracker struct {
+	db         *common.DB
+	onExceeded func(BudgetEvent)
+
+	mu           sync.Mutex
+	streamBudget *big.Rat
+	dailyBudget  *big.Rat
+	streams      map[ManifestID]*big.Rat
+	// streams for which the stream budget has been reported as reached
+	exceeded      map[ManifestID]bool
+	day           time.Time
+	dailySpent    *big.Rat
+	dailyExceeded bool
+
+	now func() time.Time
+}
+
+// NewSpendTracker creates a SpendTracker and loads the spending of the
+// current day from the DB. onExceeded, if not nil, is called whenever a
+// stream reaches a budget
+func NewSpendTracker(db *common.DB, onExceeded func(BudgetEvent)) (*SpendTracker, error) {
+	t := &SpendTracker{
+		db:         db,
+		onExceeded: onExceeded,
+		streams:    make(map[ManifestID]*big.Rat),
+		exceeded:   make(map[ManifestID]bool),
+		now:        time.Now,
+	}
+	if err := t.loadDay(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SetBudgets sets the stream and daily budgets in wei. A nil or zero
+// budget is unlimited
+func (t *SpendTracker) SetBudgets(stream, daily *big.Rat) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.streamBudget = budget(stream)
+	t.dailyBudget = budget(daily)
+	// Report budgets again that are reached under the new limits
+	t.exceeded = make(map[ManifestID]bool)
+	t.dailyExceeded = false
+}
+
+// Budgets returns the stream and daily budgets. A nil budget is unlimited
+func (t *SpendTracker) Budgets() (stream, daily *big.Rat) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return copyRat(t.streamBudget), copyRat(t.dailyBudget)
+}
+
+// Record adds an entry to the spending ledger and updates the totals,
+// reporting any budget that is reached as a result
+func (t *SpendTracker) Record(s *common.DBSpending) error {
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = t.now()
+	}
+	ev := s.EV
+	if ev == nil {
+		ev = big.NewRat(0, 1)
+	}
+	mid := ManifestID(s.ManifestID)
+
+	t.mu.Lock()
+	// Load the totals before inserting the entry so that it is not counted twice
+	if err := t.rollover(); err != nil {
+		glog.Errorf("Error loading daily spending err=%v", err)
+	}
+	spent, loadErr := t.streamSpent(mid)
+	if loadErr != nil {
+		glog.Errorf("Error loading spending manifestID=%s err=%v", mid, loadErr)
+		spent = new(big.Rat)
+	}
+
+	err := t.db.InsertSpending(s)
+	if err != nil {
+		glog.Errorf("Error recording spending manifestID=%s err=%v", s.ManifestID, err)
+	}
+	t.dailySpent.Add(t.dailySpent, ev)
+	spent.Add(spent, ev)
+
+	var events []BudgetEvent
+	if t.streamBudget != nil && spent.Cmp(t.streamBudget) >= 0 && !t.exceeded[mid] {
+		t.exceeded[mid] = true
+		events = append(events, BudgetEvent{ManifestID: mid, Budget: StreamBudget, Spent: copyRat(spent), Limit: copyRat(t.streamBudget)})
+	}
+	if t.dailyBudget != nil && t.dailySpent.Cmp(t.dailyBudget) >= 0 && !t.dailyExceeded {
+		t.dailyExceeded = true
+		events = append(events, BudgetEvent{ManifestID: mid, Budget: DailyBudget, Spent: copyRat(t.dailySpent), Limit: copyRat(t.dailyBudget)})
+	}
+	t.mu.Unlock()
+
+	for _, e := range events {
+		glog.Warningf("Budget reached budget=%s manifestID=%s spent=%v limit=%v", e.Budget, e.ManifestID, e.Spent.FloatString(0), e.Limit.FloatString(0))
+		if t.onExceeded != nil {
+			t.onExceeded(e)
+		}
+	}
+
+	return err
+}
+
+// BudgetExceeded returns whether the stream or daily budget has been
+// reached for a stream
+func (t *SpendTracker) BudgetExceeded(mid ManifestID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.rollover(); err != nil {
+		glog.Errorf("Error loading daily spending err=%v", err)
+	}
+	if t.dailyBudget != nil && t.dailySpent.Cmp(t.dailyBudget) >= 0 {
+		return true
+	}
+	if t.streamBudget == nil {
+		return false
+	}
+	spent, err := t.streamSpent(mid)
+	if err != nil {
+		glog.Errorf("Error loading spending manifestID=%s err=%v", mid, err)
+		return false
+	}
+	return spent.Cmp(t.streamBudget) >= 0
+}
+
+// Spent returns the total spending for a stream
+func (t *SpendTracker) Spent(mid ManifestID) (*big.Rat, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spent, err := t.streamSpent(mid)
+	if err != nil {
+		return nil, err
+	}
+	return copyRat(spent), nil
+}
+
+// DailySpent returns the total spending for the current day
+func (t *SpendTracker) DailySpent() *big.Rat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.rollover(); err != nil {
+		glog.Errorf("Error loading daily spending err=%v", err)
+	}
+	return copyRat(t.dailySpent)
+}
+
+// Forget drops the cached total for a stream, which is loaded from the DB
+// again if the stream comes back
+func (t *SpendTracker) Forget(mid ManifestID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.streams, mid)
+	delete(t.exceeded, mid)
+}
+
+// streamSpent returns the cached total for a stream, loading it from the DB
+// if needed. The caller must hold the lock
+func (t *SpendTracker) streamSpent(mid ManifestID) (*big.Rat, error) {
+	if spent, ok := t.streams[mid]; ok {
+		return spent, nil
+	}
+	entries, err := t.db.SpendingEntries(&common.DBSpendingFilter{ManifestID: string(mid)})
+	if err != nil {
+		return nil, err
+	}
+	spent := sumEV(entries)
+	t.streams[mid] = spent
+	return spent, nil
+}
+
+// rollover resets the daily total when the day changes. The caller must hold the lock
+func (t *SpendTracker) rollover() error {
+	if startOfDay(t.now()).Equal(t.day) {
+		return nil
+	}
+	return t.loadDay()
+}
+
+func (t *SpendTracker) loadDay() error {
+	day := startOfDay(t.now())
+	entries, err := t.db.SpendingEntries(&common.DBSpendingFilter{From: day})
+	if err != nil {
+		return err
+	}
+	t.day = day
+	t.dailySpent = sumEV(entries)
+	t.dailyExceeded = false
+	return nil
+}
+
+func sumEV(entries []*common.DBSpending) *big.Rat {
+	total := big.NewRat(0, 1)
+	for _, e := range entries {
+		if e.EV != nil {
+			total.Add(total, e.EV)
+		}
+	}
+	return total
+}
+
+func startOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func budget(b *big.Rat) *big.Rat {
+	if b == nil || b.Sign() <= 0 {
+		return nil
+	}
+	return copyRat(b)
+}
+
+func copyRat(r *big.Rat) *big.Rat {
+	if r == nil {
+		return nil
+	}
+	return new(big.Rat).Set(r)
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpendTracker_StreamBudget(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	var events []BudgetEvent
+	st, err := NewSpendTracker(dbh, func(e BudgetEvent) { events = append(events, e) })
+	require.Nil(err)
+
+	stream, daily := st.Budgets()
+	assert.Nil(stream)
+	assert.Nil(daily)
+
+	// Unlimited by default
+	require.Nil(st.Record(&common.DBSpending{ManifestID: "foo", Orchestrator: "o1", Tickets: 1, EV: big.NewRat(100, 1), Pixels: 10}))
+	assert.False(st.BudgetExceeded("foo"))
+
+	// Zero is unlimited
+	st.SetBudgets(big.NewRat(0, 1), nil)
+	stream, _ = st.Budgets()
+	assert.Nil(stream)
+
+	st.SetBudgets(big.NewRat(150, 1), nil)
+	require.Nil(st.Record(&common.DBSpending{ManifestID: "foo", Orchestrator: "o2", Pixels: 10}))
+	assert.False(st.BudgetExceeded("foo"))
+	assert.Empty(events)
+
+	require.Nil(st.Record(&common.DBSpending{ManifestID: "foo", Orchestrator: "o1", Tickets: 1, EV: big.NewRat(50, 1)}))
+	assert.True(st.BudgetExceeded("foo"))
+	assert.False(st.BudgetExceeded("bar"))
+	require.Len(events, 1)
+	assert.Equal(ManifestID("foo"), events[0].ManifestID)
+	assert.Equal(StreamBudget, events[0].Budget)
+	assert.Zero(events[0].Spent.Cmp(big.NewRat(150, 1)))
+	assert.Zero(events[0].Limit.Cmp(big.NewRat(150, 1)))
+
+	// The event fires once per stream
+	require.Nil(st.Record(&common.DBSpending{ManifestID: "foo", Orchestrator: "o1", Tickets: 1, EV: big.NewRat(50, 1)}))
+	assert.Len(events, 1)
+
+	spent, err := st.Spent("foo")
+	require.Nil(err)
+	assert.Zero(spent.Cmp(big.NewRat(200, 1)))
+
+	entries, err := dbh.SpendingEntries(&common.DBSpendingFilter{ManifestID: "foo"})
+	require.Nil(err)
+	assert.Len(entries, 4)
+
+	// Raising the budget lifts the limit
+	st.SetBudgets(big.NewRat(1000, 1), nil)
+	assert.False(st.BudgetExceeded("foo"))
+
+	// Totals are loaded from the DB
+	st, err = NewSpendTracker(dbh, nil)
+	require.Nil(err)
+	st.SetBudgets(big.NewRat(200, 1), nil)
+	assert.True(st.BudgetExceeded("foo"))
+	assert.Zero(st.DailySpent().Cmp(big.NewRat(200, 1)))
+
+	// Forget drops the cached total only
+	st.Forget("foo")
+	spent, err = st.Spent("foo")
+	require.Nil(err)
+	assert.Zero(spent.Cmp(big.NewRat(200, 1)))
+}
+
+func TestSpendTracker_DailyBudget(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	// Spending from the previous day does not count
+	now := time.Date(2020, 3, 1, 23, 0, 0, 0, time.UTC)
+	require.Nil(dbh.InsertSpending(&common.DBSpending{CreatedAt: now.Add(-24 * time.Hour), ManifestID: "foo", EV: big.NewRat(1000, 1)}))
+	require.Nil(dbh.InsertSpending(&common.DBSpending{CreatedAt: now.Add(-time.Hour), ManifestID: "foo", EV: big.NewRat(10, 1)}))
+
+	var events []BudgetEvent
+	st := &SpendTracker{
+		db:         dbh,
+		onExceeded: func(e BudgetEvent) { events = append(events, e) },
+		streams:    make(map[ManifestID]*big.Rat),
+		exceeded:   make(map[ManifestID]bool),
+		now:        func() time.Time { return now },
+	}
+	require.Nil(st.loadDay())
+	assert.Zero(st.DailySpent().Cmp(big.NewRat(10, 1)))
+
+	st.SetBudgets(nil, big.NewRat(100, 1))
+	require.Nil(st.Record(&common.DBSpending{ManifestID: "foo", EV: big.NewRat(50, 1)}))
+	require.Nil(st.Record(&common.DBSpending{ManifestID: "bar", EV: big.NewRat(40, 1)}))
+	assert.True(st.BudgetExceeded("foo"))
+	assert.True(st.BudgetExceeded("bar"))
+	assert.True(st.BudgetExceeded("baz"))
+	require.Len(events, 1)
+	assert.Equal(ManifestID("bar"), events[0].ManifestID)
+	assert.Equal(DailyBudget, events[0].Budget)
+	assert.Zero(events[0].Spent.Cmp(big.NewRat(100, 1)))
+
+	// The daily total resets at midnight UTC
+	now = now.Add(2 * time.Hour)
+	assert.False(st.BudgetExceeded("foo"))
+	assert.Zero(st.DailySpent().Sign())
+
+	require.Nil(st.Record(&common.DBSpending{ManifestID: "foo", EV: big.NewRat(100, 1)}))
+	assert.True(st.BudgetExceeded("foo"))
+	assert.Len(events, 2)
+}
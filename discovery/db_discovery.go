@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
@@ -35,6 +36,11 @@ type DBOrchestratorPoolCache struct {
 	ticketParamsValidator ticketParamsValidator
 	rm                    common.RoundsManager
 	bcast                 common.Broadcaster
+	latency               *LatencyTracker
+	filter                *common.OrchestratorFilter
+
+	cacheMu sync.RWMutex
+	cache   *OrchestratorInfoCache
 }
 
 func NewDBOrchestratorPoolCache(ctx context.Context, node *core.LivepeerNode, rm common.RoundsManager) (*DBOrchestratorPoolCache, error) {
@@ -65,7 +71,38 @@ func NewDBOrchestratorPoolCache(ctx context.Context, node *core.LivepeerNode, rm
 	return dbo, nil
 }
 
+// SetLatencyTracker makes the pool prefer the orchestrators with the lowest latency estimates in latency
+func (dbo *DBOrchestratorPoolCache) SetLatencyTracker(latency *LatencyTracker) {
+	dbo.latency = latency
+}
+
+// SetFilter makes the pool only use the orchestrators that filter allows
+func (dbo *DBOrchestratorPoolCache) SetFilter(filter *common.OrchestratorFilter) {
+	dbo.filter = filter
+}
+
+// SetInfoCache makes the pool use the orchestrator info in cache and adds the orchestrator info
+// that the pool polls to cache
+func (dbo *DBOrchestratorPoolCache) SetInfoCache(cache *OrchestratorInfoCache) {
+	dbo.cacheMu.Lock()
+	defer dbo.cacheMu.Unlock()
+	dbo.cache = cache
+}
+
+func (dbo *DBOrchestratorPoolCache) infoCache() *OrchestratorInfoCache {
+	dbo.cacheMu.RLock()
+	defer dbo.cacheMu.RUnlock()
+	return dbo.cache
+}
+
 func (dbo *DBOrchestratorPoolCache) getURLs() ([]*url.URL, error) {
+	uris, _, err := dbo.getRegisteredURLs()
+	return uris, err
+}
+
+// getRegisteredURLs returns the service URIs of the orchestrators in the DB as well
+// as the hosts that the orchestrators are registered with in the ServiceRegistry
+func (dbo *DBOrchestratorPoolCache) getRegisteredURLs() ([]*url.URL, map[ethcommon.Address]string, error) {
 	orchs, err := dbo.store.SelectOrchs(
 		&common.DBOrchFilter{
 			MaxPrice:     server.BroadcastCfg.MaxPrice(),
@@ -73,16 +110,20 @@ func (dbo *DBOrchestratorPoolCache) getURLs() ([]*url.URL, error) {
 		},
 	)
 	if err != nil || len(orchs) <= 0 {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var uris []*url.URL
+	hosts := make(map[ethcommon.Address]string)
 	for _, orch := range orchs {
 		if uri, err := url.Parse(orch.ServiceURI); err == nil {
 			uris = append(uris, uri)
 		}
+		if uri, err := parseURI(orch.ServiceURI); err == nil {
+			hosts[ethcommon.HexToAddress(orch.EthereumAddr)] = uri.Host
+		}
 	}
-	return uris, nil
+	return uris, hosts, nil
 }
 
 func (dbo *DBOrchestratorPoolCache) GetURLs() []*url.URL {
@@ -91,13 +132,12 @@ func (dbo *DBOrchestratorPoolCache) GetURLs() []*url.URL {
 }
 
 func (dbo *DBOrchestratorPoolCache) GetOrchestrators(numOrchestrators int) ([]*net.OrchestratorInfo, error) {
-	uris, err := dbo.getURLs()
+	uris, hosts, err := dbo.getRegisteredURLs()
 	if err != nil || len(uris) <= 0 {
 		return nil, err
 	}
 
 	pred := func(info *net.OrchestratorInfo) bool {
-
 		if err := dbo.ticketParamsValidator.ValidateTicketParams(pmTicketParams(info.TicketParams)); err != nil {
 			glog.V(common.DEBUG).Infof("invalid ticket params - orch=%v err=%v",
 				info.GetTranscoder(),
@@ -121,6 +161,23 @@ func (dbo *DBOrchestratorPoolCache) GetOrchestrators(numOrchestrators int) ([]*n
 	}
 
 	orchPool := NewOrchestratorPoolWithPred(dbo.bcast, uris, pred)
+	// The signed info must come from the URI that its ticket recipient registered in the ServiceRegistry.
+	// The transcoder URI in the info is signed by the recipient, so it can differ from the registered URI
+	orchPool.uriPred = func(uri *url.URL, info *net.OrchestratorInfo) bool {
+		recipient := ethcommon.BytesToAddress(info.GetTicketParams().GetRecipient())
+		registered, err := parseURI(uri.String())
+		if host, ok := hosts[recipient]; !ok || err != nil || registered.Host != host {
+			glog.Warningf("orchestrator is not registered for recipient - uri=%v recipient=%v",
+				uri,
+				recipient.Hex(),
+			)
+			return false
+		}
+		return true
+	}
+	orchPool.latency = dbo.latency
+	orchPool.cache = dbo.infoCache()
+	orchPool.filter = dbo.filter
 
 	orchInfos, err := orchPool.GetOrchestrators(numOrchestrators)
 	if err != nil || len(orchInfos) <= 0 {
@@ -241,6 +298,7 @@ func (dbo *DBOrchestratorPoolCache) cacheDBOrchs() error {
 		return fmt.Errorf("could not retrieve orchestrators from DB: %v", err)
 	}
 
+	cache := dbo.infoCache()
 	resc, errc := make(chan *common.DBOrch), make(chan error)
 	ctx, cancel := context.WithTimeout(context.Background(), getOrchestratorsTimeoutLoop)
 	defer cancel()
@@ -256,6 +314,9 @@ func (dbo *DBOrchestratorPoolCache) cacheDBOrchs() error {
 			errc <- err
 			return
 		}
+		if cache != nil {
+			cache.put(uri, info)
+		}
 		dbOrch.PricePerPixel, err = common.PriceToFixed(big.NewRat(info.PriceInfo.GetPricePerUnit(), info.PriceInfo.GetPixelsPerUnit()))
 		if err != nil {
 			errc <- err
@@ -5,8 +5,10 @@ import (
 	"math"
 	"math/rand"
 	"net/url"
+	"sync"
 	"time"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/monitor"
 	"github.com/livepeer/go-livepeer/net"
@@ -20,9 +22,14 @@ var getOrchestratorsTimeoutLoop = 3 * time.Second
 var serverGetOrchInfo = server.GetOrchestratorInfo
 
 type orchestratorPool struct {
-	uris  []*url.URL
-	pred  func(info *net.OrchestratorInfo) bool
-	bcast common.Broadcaster
+	uris []*url.URL
+	pred func(info *net.OrchestratorInfo) bool
+	// uriPred checks info against the URI that it was received from
+	uriPred func(uri *url.URL, info *net.OrchestratorInfo) bool
+	bcast   common.Broadcaster
+	latency *LatencyTracker
+	cache   *OrchestratorInfoCache
+	filter  *common.OrchestratorFilter
 }
 
 func NewOrchestratorPool(bcast common.Broadcaster, uris []*url.URL) *orchestratorPool {
@@ -40,21 +47,78 @@ func NewOrchestratorPoolWithPred(bcast common.Broadcaster, addresses []*url.URL,
 	return pool
 }
 
+// NewOrchestratorPoolWithLatency creates an orchestrator pool that prefers the orchestrators
+// with the lowest latency estimates in latency
+func NewOrchestratorPoolWithLatency(bcast common.Broadcaster, uris []*url.URL, latency *LatencyTracker) *orchestratorPool {
+	pool := NewOrchestratorPool(bcast, uris)
+	pool.latency = latency
+	return pool
+}
+
+// SetInfoCache makes the pool use the orchestrator info in cache instead of fetching it for every call
+func (o *orchestratorPool) SetInfoCache(cache *OrchestratorInfoCache) {
+	o.cache = cache
+}
+
+// SetFilter makes the pool only use the orchestrators that filter allows
+func (o *orchestratorPool) SetFilter(filter *common.OrchestratorFilter) {
+	o.filter = filter
+}
+
+// allowedURIs returns the URIs of the pool that the filter of the pool can allow
+func (o *orchestratorPool) allowedURIs() []*url.URL {
+	if o.filter == nil {
+		return o.uris
+	}
+	var uris []*url.URL
+	for _, uri := range o.uris {
+		if o.filter.AllowedURI(uri) {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// accept returns whether the orchestrator at uri can be used according to the predicate and filter of the pool
+func (o *orchestratorPool) accept(uri *url.URL, info *net.OrchestratorInfo) bool {
+	if o.pred != nil && !o.pred(info) {
+		return false
+	}
+	if o.uriPred != nil && !o.uriPred(uri, info) {
+		return false
+	}
+	return o.filter.Allowed(uri, ethcommon.BytesToAddress(info.GetTicketParams().GetRecipient()))
+}
+
+// getOrchInfo returns the orchestrator info from the info cache of the pool if it has one
+func (o *orchestratorPool) getOrchInfo(ctx context.Context, uri *url.URL) (*net.OrchestratorInfo, error) {
+	if o.cache != nil {
+		return o.cache.Get(ctx, o.bcast, uri)
+	}
+	return serverGetOrchInfo(ctx, o.bcast, uri)
+}
+
 func (o *orchestratorPool) GetURLs() []*url.URL {
 	return o.uris
 }
 
 func (o *orchestratorPool) GetOrchestrators(numOrchestrators int) ([]*net.OrchestratorInfo, error) {
-	numAvailableOrchs := len(o.uris)
+	if o.latency != nil {
+		infos, err := o.getNearestOrchestrators(numOrchestrators)
+		return o.streamInfos(infos), err
+	}
+
+	allowed := o.allowedURIs()
+	numAvailableOrchs := len(allowed)
 	numOrchestrators = int(math.Min(float64(numAvailableOrchs), float64(numOrchestrators)))
 	ctx, cancel := context.WithTimeout(context.Background(), getOrchestratorsTimeoutLoop)
 
-	infoCh := make(chan *net.OrchestratorInfo, len(o.uris))
-	errCh := make(chan error, len(o.uris))
+	infoCh := make(chan fetchedInfo, len(allowed))
+	errCh := make(chan error, len(allowed))
 	getOrchInfo := func(uri *url.URL) {
-		info, err := serverGetOrchInfo(ctx, o.bcast, uri)
-		if err == nil && (o.pred == nil || o.pred(info)) {
-			infoCh <- info
+		info, err := o.getOrchInfo(ctx, uri)
+		if err == nil && o.accept(uri, info) {
+			infoCh <- fetchedInfo{uri, info}
 			return
 		}
 		if err != nil && monitor.Enabled {
@@ -64,9 +128,9 @@ func (o *orchestratorPool) GetOrchestrators(numOrchestrators int) ([]*net.Orches
 	}
 
 	// Shuffle into new slice to avoid mutating underlying data
-	uris := make([]*url.URL, len(o.uris))
-	for i, j := range rand.Perm(len(o.uris)) {
-		uris[i] = o.uris[j]
+	uris := make([]*url.URL, len(allowed))
+	for i, j := range rand.Perm(len(allowed)) {
+		uris[i] = allowed[j]
 	}
 
 	for _, uri := range uris {
@@ -74,12 +138,12 @@ func (o *orchestratorPool) GetOrchestrators(numOrchestrators int) ([]*net.Orches
 	}
 
 	timeout := false
-	infos := []*net.OrchestratorInfo{}
+	infos := []fetchedInfo{}
 	nbResp := 0
 	for i := 0; i < len(uris) && len(infos) < numOrchestrators && !timeout; i++ {
 		select {
-		case info := <-infoCh:
-			infos = append(infos, info)
+		case fetched := <-infoCh:
+			infos = append(infos, fetched)
 			nbResp++
 		case <-errCh:
 			nbResp++
@@ -90,7 +154,65 @@ func (o *orchestratorPool) GetOrchestrators(numOrchestrators int) ([]*net.Orches
 	cancel()
 	glog.Infof("Done fetching orch info numOrch=%d responses=%d/%d timeout=%t",
 		len(infos), nbResp, len(uris), timeout)
-	return infos, nil
+	return o.streamInfos(infos), nil
+}
+
+// fetchedInfo is the info of an orchestrator with the URI that it was fetched from
+type fetchedInfo struct {
+	uri  *url.URL
+	info *net.OrchestratorInfo
+}
+
+// streamInfos returns the infos of the selected orchestrators with ticket params that are only used by
+// the caller. The info cache is only used to select orchestrators because ticket params that are used
+// by several streams make the streams reset each other's PM sessions. Orchestrators that fail to return
+// info or are no longer accepted are dropped
+func (o *orchestratorPool) streamInfos(fetched []fetchedInfo) []*net.OrchestratorInfo {
+	infos := make([]*net.OrchestratorInfo, len(fetched))
+	for i, f := range fetched {
+		infos[i] = f.info
+	}
+	if o.cache == nil || len(infos) == 0 {
+		return infos
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), getOrchestratorsTimeoutLoop)
+	defer cancel()
+
+	fresh := make([]*net.OrchestratorInfo, len(infos))
+	var wg sync.WaitGroup
+	for i, f := range fetched {
+		// Orchestrators without ticket params are not paid, so their info can be shared
+		if f.info.TicketParams == nil {
+			fresh[i] = f.info
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, f fetchedInfo) {
+			defer wg.Done()
+
+			// Ticket params are fetched from the URI that the cached info was fetched from,
+			// which is the URI that the orchestrator was accepted for
+			streamInfo, err := serverGetOrchInfo(ctx, o.bcast, f.uri)
+			if err != nil {
+				glog.Errorf("Error getting ticket params orch=%v err=%v", f.uri, err)
+				return
+			}
+			if o.accept(f.uri, streamInfo) {
+				fresh[i] = streamInfo
+			}
+		}(i, f)
+	}
+	wg.Wait()
+
+	var res []*net.OrchestratorInfo
+	for _, info := range fresh {
+		if info != nil {
+			res = append(res, info)
+		}
+	}
+	return res
 }
 
 func (o *orchestratorPool) Size() int {
@@ -2,6 +2,9 @@ package discovery
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"math"
@@ -15,6 +18,7 @@ import (
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/core"
 	"github.com/livepeer/go-livepeer/eth"
@@ -569,7 +573,6 @@ func TestCachedPool_GetOrchestrators_MaxBroadcastPriceNotSet(t *testing.T) {
 		PricePerUnit:  999,
 		PixelsPerUnit: 1,
 	}
-	expTranscoder := "transcoderFromTest"
 	expPricePerPixel, _ := common.PriceToFixed(big.NewRat(999, 1))
 
 	server.BroadcastCfg.SetMaxPrice(nil)
@@ -584,10 +587,9 @@ func TestCachedPool_GetOrchestrators_MaxBroadcastPriceNotSet(t *testing.T) {
 			first = false
 		}
 		mu.Unlock()
-		return &net.OrchestratorInfo{
-			Transcoder: expTranscoder,
-			PriceInfo:  expPriceInfo,
-		}, nil
+		return registeredInfo(&net.OrchestratorInfo{
+			PriceInfo: expPriceInfo,
+		}, orchestratorServer), nil
 	}
 
 	addresses := []string{}
@@ -651,7 +653,7 @@ func TestCachedPool_GetOrchestrators_MaxBroadcastPriceNotSet(t *testing.T) {
 	infos, err := pool.GetOrchestrators(50)
 	for _, info := range infos {
 		assert.Equal(info.PriceInfo, expPriceInfo)
-		assert.Equal(info.Transcoder, expTranscoder)
+		assert.Contains(addresses, info.Transcoder)
 	}
 
 	assert.Nil(err, "Should not be error")
@@ -689,10 +691,10 @@ func TestCachedPool_N_OrchestratorsGoodPricing_ReturnsNOrchestrators(t *testing.
 		mu.Unlock()
 		if i, _ := strconv.Atoi(orchestratorServer.Port()); i > 8960 {
 			// Return valid pricing
-			return goodTranscoder, nil
+			return registeredInfo(goodTranscoder, orchestratorServer), nil
 		}
 		// Return invalid pricing
-		return badTranscoder, nil
+		return registeredInfo(badTranscoder, orchestratorServer), nil
 	}
 	addresses := []string{}
 	for i := 0; i < 50; i++ {
@@ -765,7 +767,8 @@ func TestCachedPool_N_OrchestratorsGoodPricing_ReturnsNOrchestrators(t *testing.
 	assert.Nil(err, "Should not be error")
 	assert.Len(infos, 25)
 	for _, info := range infos {
-		assert.Equal(info.Transcoder, "goodPriceTranscoder")
+		assert.Contains(addresses[25:], info.Transcoder)
+		assert.Equal(goodTranscoder.PriceInfo, info.PriceInfo)
 	}
 }
 
@@ -778,14 +781,14 @@ func TestCachedPool_GetOrchestrators_TicketParamsValidation(t *testing.T) {
 	server.BroadcastCfg.SetMaxPrice(nil)
 
 	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, orchestratorServer *url.URL) (*net.OrchestratorInfo, error) {
-		return &net.OrchestratorInfo{
+		return registeredInfo(&net.OrchestratorInfo{
 			Transcoder:   "transcoder",
 			TicketParams: &net.TicketParams{},
 			PriceInfo: &net.PriceInfo{
 				PricePerUnit:  999,
 				PixelsPerUnit: 1,
 			},
-		}, nil
+		}, orchestratorServer), nil
 	}
 
 	addresses := []string{}
@@ -842,7 +845,6 @@ func TestCachedPool_GetOrchestrators_OnlyActiveOrchestrators(t *testing.T) {
 		PricePerUnit:  1,
 		PixelsPerUnit: 1,
 	}
-	expTranscoder := "transcoderFromTest"
 	expPricePricePixel, _ := common.PriceToFixed(big.NewRat(1, 1))
 
 	server.BroadcastCfg.SetMaxPrice(nil)
@@ -857,10 +859,9 @@ func TestCachedPool_GetOrchestrators_OnlyActiveOrchestrators(t *testing.T) {
 			first = false
 		}
 		mu.Unlock()
-		return &net.OrchestratorInfo{
-			Transcoder: expTranscoder,
-			PriceInfo:  expPriceInfo,
-		}, nil
+		return registeredInfo(&net.OrchestratorInfo{
+			PriceInfo: expPriceInfo,
+		}, orchestratorServer), nil
 	}
 
 	addresses := []string{}
@@ -931,13 +932,84 @@ func TestCachedPool_GetOrchestrators_OnlyActiveOrchestrators(t *testing.T) {
 	infos, err := pool.GetOrchestrators(50)
 	for _, info := range infos {
 		assert.Equal(info.PriceInfo, expPriceInfo)
-		assert.Equal(info.Transcoder, expTranscoder)
+		assert.Contains(addresses[:25], info.Transcoder)
 	}
 
 	assert.Nil(err, "Should not be error")
 	assert.Len(infos, 25)
 }
 
+func TestCachedPool_GetOrchestrators_ServiceRegistry(t *testing.T) {
+	gmp := runtime.GOMAXPROCS(50)
+	defer runtime.GOMAXPROCS(gmp)
+
+	server.BroadcastCfg.SetMaxPrice(nil)
+
+	addresses := []string{}
+	for i := 0; i < 50; i++ {
+		addresses = append(addresses, "https://127.0.0.1:"+strconv.Itoa(8936+i))
+	}
+
+	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, orchestratorServer *url.URL) (*net.OrchestratorInfo, error) {
+		info := registeredInfo(&net.OrchestratorInfo{PriceInfo: &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1}}, orchestratorServer)
+		port, _ := strconv.Atoi(orchestratorServer.Port())
+		if port > 8960 {
+			// Impersonate another registered orchestrator
+			info.TicketParams.Recipient = ethcommon.BytesToAddress([]byte(addresses[0])).Bytes()
+		} else if port > 8950 {
+			// The registered orchestrator signs a different transcoder URI
+			info.Transcoder = "https://127.0.0.2:" + orchestratorServer.Port()
+		}
+		return info, nil
+	}
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	defer dbh.Close()
+	defer dbraw.Close()
+	require.Nil(err)
+
+	sender := &pm.MockSender{}
+	node := &core.LivepeerNode{
+		Database: dbh,
+		Eth: &eth.StubClient{
+			Orchestrators: StubOrchestrators(addresses),
+		},
+		Sender: sender,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender.On("ValidateTicketParams", mock.Anything).Return(nil)
+
+	pool, err := NewDBOrchestratorPoolCache(ctx, node, &stubRoundsManager{})
+	require.NoError(err)
+
+	// Only orchestrators that are registered with the URI that was dialed are returned
+	infos, err := pool.GetOrchestrators(len(addresses))
+	assert.Nil(err)
+	assert.Len(infos, 25)
+	for _, info := range infos {
+		uri, err := url.Parse(info.Transcoder)
+		require.Nil(err)
+		port, _ := strconv.Atoi(uri.Port())
+		assert.True(port <= 8960)
+	}
+}
+
+// registeredInfo returns a copy of info sent by the stub orchestrator registered with uri
+func registeredInfo(info *net.OrchestratorInfo, uri *url.URL) *net.OrchestratorInfo {
+	info = proto.Clone(info).(*net.OrchestratorInfo)
+	info.Transcoder = uri.String()
+	if info.TicketParams == nil {
+		info.TicketParams = &net.TicketParams{}
+	}
+	info.TicketParams.Recipient = ethcommon.BytesToAddress([]byte(uri.String())).Bytes()
+	return info
+}
+
 func TestNewWHOrchestratorPoolCache(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
@@ -945,12 +1017,13 @@ func TestNewWHOrchestratorPoolCache(t *testing.T) {
 	// mock webhook and orchestrator info request
 	addresses := []string{"https://127.0.0.1:8936", "https://127.0.0.1:8937", "https://127.0.0.1:8938"}
 
-	getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, error) {
+	getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, string, error) {
 		var wh []webhookResponse
 		for _, addr := range addresses {
 			wh = append(wh, webhookResponse{Address: addr})
 		}
-		return json.Marshal(&wh)
+		body, err := json.Marshal(&wh)
+		return body, "", err
 	}
 
 	serverGetOrchInfo = func(c context.Context, b common.Broadcaster, s *url.URL) (*net.OrchestratorInfo, error) {
@@ -969,7 +1042,7 @@ func TestNewWHOrchestratorPoolCache(t *testing.T) {
 	assert.Len(orchInfo, 2)
 	assert.Equal(3, whpool.Size())
 
-	urls := whpool.pool.GetURLs()
+	urls := weightedURLs(whpool.orchs)
 	assert.Len(urls, 3)
 
 	for _, addr := range addresses {
@@ -986,7 +1059,7 @@ func TestNewWHOrchestratorPoolCache(t *testing.T) {
 	assert.Equal(3, whpool.Size())
 	assert.NotEqual(lastReq, whpool.lastRequest)
 
-	urls = whpool.pool.GetURLs()
+	urls = weightedURLs(whpool.orchs)
 	assert.Len(urls, 3)
 
 	for _, addr := range addresses {
@@ -1006,7 +1079,7 @@ func TestNewWHOrchestratorPoolCache(t *testing.T) {
 	assert.Equal(3, whpool.Size())
 	assert.Equal(lastReq, whpool.lastRequest)
 
-	urls = whpool.pool.GetURLs()
+	urls = weightedURLs(whpool.orchs)
 	assert.Len(urls, 3)
 
 	for _, addr := range addresses {
@@ -1023,7 +1096,7 @@ func TestNewWHOrchestratorPoolCache(t *testing.T) {
 	assert.Equal(3, whpool.Size())
 	assert.NotEqual(lastReq, whpool.lastRequest)
 
-	urls = whpool.pool.GetURLs()
+	urls = weightedURLs(whpool.orchs)
 	assert.Len(urls, 3)
 
 	for _, addr := range addresses {
@@ -1037,35 +1110,229 @@ func TestDeserializeWebhookJSON(t *testing.T) {
 
 	// assert input of webhookResponse address object returns correct address
 	resp, _ := json.Marshal(&[]webhookResponse{{Address: "https://127.0.0.1:8936"}})
-	urls, err := deserializeWebhookJSON(resp)
+	orchs, _, err := deserializeWebhookJSON(resp)
 	assert.Nil(err)
-	assert.Equal("https://127.0.0.1:8936", urls[0].String())
+	assert.Equal("https://127.0.0.1:8936", orchs[0].uri.String())
 
 	// assert input of empty byte array returns JSON error
-	urls, err = deserializeWebhookJSON([]byte{})
+	orchs, _, err = deserializeWebhookJSON([]byte{})
 	assert.Contains(err.Error(), "unexpected end of JSON input")
-	assert.Nil(urls)
+	assert.Nil(orchs)
 
 	// assert input of empty byte array returns empty object
 	resp, _ = json.Marshal(&[]webhookResponse{{}})
-	urls, err = deserializeWebhookJSON(resp)
+	orchs, _, err = deserializeWebhookJSON(resp)
 	assert.Nil(err)
-	assert.Empty(urls)
+	assert.Empty(orchs)
 
 	// assert input of invalid addresses returns invalid JSON error
-	urls, err = deserializeWebhookJSON(make([]byte, 64))
+	orchs, _, err = deserializeWebhookJSON(make([]byte, 64))
 	assert.Contains(err.Error(), "invalid character")
-	assert.Empty(urls)
+	assert.Empty(orchs)
 
 	// assert input of invalid JSON returns JSON unmarshal object error
-	urls, err = deserializeWebhookJSON([]byte(`{"name":false}`))
+	orchs, _, err = deserializeWebhookJSON([]byte(`{"name":false}`))
 	assert.Contains(err.Error(), "cannot unmarshal object")
-	assert.Empty(urls)
+	assert.Empty(orchs)
 
 	// assert input of invalid JSON returns JSON unmarshal number error
-	urls, err = deserializeWebhookJSON([]byte(`1112`))
+	orchs, _, err = deserializeWebhookJSON([]byte(`1112`))
 	assert.Contains(err.Error(), "cannot unmarshal number")
-	assert.Empty(urls)
+	assert.Empty(orchs)
+}
+
+func TestDeserializeWebhookJSON_Preferences(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	orchs, denied, err := deserializeWebhookJSON([]byte(`[
+		{"address": "https://127.0.0.1:8936", "weight": 2, "priority": 1, "maxPricePerUnit": 10, "pixelsPerUnit": 3, "tags": ["us-east"]},
+		{"address": "https://127.0.0.1:8937", "maxPricePerUnit": 10},
+		{"address": "https://127.0.0.1:8938", "deny": true},
+		{"address": "https://127.0.0.1:8939", "weight": -1},
+		{"address": "https://127.0.0.1:8940", "priority": -1},
+		{"address": "https://127.0.0.1:8941", "maxPricePerUnit": -1}
+	]`))
+	require.Nil(err)
+	require.Len(orchs, 2)
+
+	assert.Equal("https://127.0.0.1:8936", orchs[0].uri.String())
+	assert.Equal(2, orchs[0].weight)
+	assert.Equal(1, orchs[0].priority)
+	assert.Equal(big.NewRat(10, 3), orchs[0].maxPrice)
+	assert.Equal([]string{"us-east"}, orchs[0].tags)
+
+	// Pixels per unit defaults to 1
+	assert.Equal("https://127.0.0.1:8937", orchs[1].uri.String())
+	assert.Equal(big.NewRat(10, 1), orchs[1].maxPrice)
+	assert.Equal(0, orchs[1].priority)
+
+	// Denied orchestrators are returned separately
+	require.Len(denied, 1)
+	assert.Equal("https://127.0.0.1:8938", denied[0].String())
+}
+
+func TestFilterTags(t *testing.T) {
+	assert := assert.New(t)
+
+	orchs := []*weightedOrch{
+		{tags: []string{"us-east"}},
+		{tags: []string{"eu-west", "us-west"}},
+		{},
+	}
+
+	assert.Equal(orchs, filterTags(orchs, nil))
+	assert.Equal([]*weightedOrch{orchs[0], orchs[2]}, filterTags(orchs, []string{"us-east"}))
+	assert.Equal([]*weightedOrch{orchs[1], orchs[2]}, filterTags(orchs, []string{"ap-south", "us-west"}))
+	assert.Equal([]*weightedOrch{orchs[2]}, filterTags(orchs, []string{"ap-south"}))
+}
+
+func TestWebhookPool_Signature(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	secret := []byte("secret")
+	body := []byte(`[{"address": "https://127.0.0.1:8936"}]`)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	validSig := hex.EncodeToString(mac.Sum(nil))
+
+	var mu sync.Mutex
+	sig := validSig
+	oldGetURLs := getURLsfromWebhook
+	defer func() { getURLsfromWebhook = oldGetURLs }()
+	getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return body, sig, nil
+	}
+
+	assert.True(verifyWebhookSig(secret, body, validSig))
+	assert.True(verifyWebhookSig(secret, body, "0x"+validSig))
+	assert.False(verifyWebhookSig([]byte("other"), body, validSig))
+	assert.False(verifyWebhookSig(secret, []byte("[]"), validSig))
+	assert.False(verifyWebhookSig(secret, body, "foo"))
+	assert.False(verifyWebhookSig(secret, body, ""))
+
+	whURL, _ := url.ParseRequestURI("https://livepeer.live/api/orchestrator")
+	whpool := NewWebhookPoolWithConfig(nil, whURL, WebhookPoolConfig{Secret: secret})
+	orchs, err := whpool.getOrchs()
+	require.Nil(err)
+	require.Len(orchs, 1)
+	assert.Equal("https://127.0.0.1:8936", orchs[0].uri.String())
+
+	// Responses with an invalid signature are rejected
+	mu.Lock()
+	sig = "foo"
+	mu.Unlock()
+	whpool = NewWebhookPoolWithConfig(nil, whURL, WebhookPoolConfig{Secret: secret})
+	_, err = whpool.getOrchs()
+	assert.Equal(errWebhookSig, err)
+	_, err = whpool.GetOrchestrators(1)
+	assert.Equal(errWebhookSig, err)
+	assert.Equal(0, whpool.Size())
+
+	// Responses are not verified without a secret
+	whpool = NewWebhookPool(nil, whURL)
+	assert.Equal(1, whpool.Size())
+}
+
+func TestWebhookPool_RefreshInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	var mu sync.Mutex
+	requests := 0
+	oldGetURLs := getURLsfromWebhook
+	defer func() { getURLsfromWebhook = oldGetURLs }()
+	getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if cbUrl.Path == "/api/refresh" {
+			requests++
+		}
+		return []byte(`[{"address": "https://127.0.0.1:8936"}]`), "", nil
+	}
+	numRequests := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+
+	whURL, _ := url.ParseRequestURI("https://livepeer.live/api/refresh")
+	whpool := &webhookPool{
+		callback: whURL,
+		cfg:      WebhookPoolConfig{RefreshInterval: 100 * time.Millisecond},
+		mu:       &sync.RWMutex{},
+	}
+	assert.Equal(1, whpool.Size())
+	assert.Equal(1, numRequests())
+
+	// The webhook is not requested again until the refresh interval passes
+	assert.Equal(1, whpool.Size())
+	assert.Equal(1, numRequests())
+
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(1, whpool.Size())
+	assert.Equal(2, numRequests())
+
+	// The default refresh interval is used if it is not set
+	whpool = NewWebhookPoolWithConfig(nil, whURL, WebhookPoolConfig{})
+	assert.Equal(whRefreshInterval, whpool.cfg.RefreshInterval)
+}
+
+func TestWebhookPool_GetOrchestrators(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	oldGetURLs := getURLsfromWebhook
+	defer func() { getURLsfromWebhook = oldGetURLs }()
+	getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, string, error) {
+		return []byte(`[
+			{"address": "https://127.0.0.1:1", "priority": 1},
+			{"address": "https://127.0.0.1:2", "maxPricePerUnit": 5, "pixelsPerUnit": 1},
+			{"address": "https://127.0.0.1:3", "maxPricePerUnit": 20, "pixelsPerUnit": 2},
+			{"address": "https://127.0.0.1:4", "tags": ["eu-west"]},
+			{"address": "https://127.0.0.1:5", "tags": ["us-east"], "priority": 2},
+			{"address": "https://127.0.0.1:6", "deny": true}
+		]`), "", nil
+	}
+
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, server *url.URL) (*net.OrchestratorInfo, error) {
+		return &net.OrchestratorInfo{
+			Transcoder: server.String(),
+			PriceInfo:  &net.PriceInfo{PricePerUnit: 10, PixelsPerUnit: 1},
+		}, nil
+	}
+
+	whURL, _ := url.ParseRequestURI("https://livepeer.live/api/orchestrator")
+	whpool := NewWebhookPoolWithConfig(nil, whURL, WebhookPoolConfig{Tags: []string{"us-east"}})
+	assert.Equal(4, whpool.Size())
+
+	// Orchestrators above their max price are skipped and lower priorities are used first
+	infos, err := whpool.GetOrchestrators(1)
+	require.Nil(err)
+	require.Len(infos, 1)
+	assert.Equal("https://127.0.0.1:3", infos[0].Transcoder)
+
+	infos, err = whpool.GetOrchestrators(10)
+	require.Nil(err)
+	require.Len(infos, 3)
+	assert.Equal("https://127.0.0.1:3", infos[0].Transcoder)
+	assert.Equal("https://127.0.0.1:1", infos[1].Transcoder)
+	assert.Equal("https://127.0.0.1:5", infos[2].Transcoder)
+
+	// Orchestrators that the webhook denies are not used by other pools with the same filter
+	filter, err := common.NewOrchestratorFilter(nil)
+	require.Nil(err)
+	whpool.SetFilter(filter)
+	pool := NewOrchestratorPool(nil, parseURLs(t, "https://127.0.0.1:1", "https://127.0.0.1:6"))
+	pool.SetFilter(filter)
+	infos, err = pool.GetOrchestrators(2)
+	require.Nil(err)
+	require.Len(infos, 1)
+	assert.Equal("https://127.0.0.1:1", infos[0].Transcoder)
 }
 
 func TestEthOrchToDBOrch(t *testing.T) {
@@ -1303,3 +1570,81 @@ func TestOrchestratorPool_GetOrchestratorTimeout(t *testing.T) {
 	assert.False(timedOut(start, end), "Timed out")
 	assert.True(responsesDrained(), "Did not drain responses in time")
 }
+
+func TestOrchestratorPool_Filter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var mu sync.Mutex
+	queried := make(map[string]bool)
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, server *url.URL) (*net.OrchestratorInfo, error) {
+		mu.Lock()
+		queried[server.String()] = true
+		mu.Unlock()
+		return &net.OrchestratorInfo{
+			Transcoder:   server.String(),
+			TicketParams: &net.TicketParams{Recipient: ethcommon.HexToAddress("0x" + server.Port()).Bytes()},
+		}, nil
+	}
+	transcoders := func(infos []*net.OrchestratorInfo) []string {
+		var res []string
+		for _, info := range infos {
+			res = append(res, info.Transcoder)
+		}
+		return res
+	}
+
+	addr2 := ethcommon.HexToAddress("0x2").Hex()
+	filter, err := common.NewOrchestratorFilter(nil)
+	require.Nil(err)
+	require.Nil(filter.Add(common.OrchDenyList, "https://127.0.0.1:1"))
+	require.Nil(filter.Add(common.OrchDenyList, addr2))
+
+	pool := NewOrchestratorPool(nil, stringsToURIs([]string{"https://127.0.0.1:1", "https://127.0.0.1:2", "https://127.0.0.1:3"}))
+	pool.SetFilter(filter)
+
+	// Orchestrators that are denied by URI are not queried and orchestrators that are denied by address are not used
+	infos, err := pool.GetOrchestrators(3)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:3"}, transcoders(infos))
+	assert.False(queried["https://127.0.0.1:1"])
+	assert.True(queried["https://127.0.0.1:2"])
+
+	// Pools that prefer nearby orchestrators use the filter
+	pool.latency = NewLatencyTracker()
+	infos, err = pool.GetOrchestrators(3)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:3"}, transcoders(infos))
+	pool.latency = nil
+
+	// Only orchestrators on the allowlist are used
+	require.Nil(filter.Remove(common.OrchDenyList, addr2))
+	require.Nil(filter.Add(common.OrchAllowList, addr2))
+	infos, err = pool.GetOrchestrators(3)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:2"}, transcoders(infos))
+
+	// Webhook pools use the filter
+	oldGetURLs := getURLsfromWebhook
+	defer func() { getURLsfromWebhook = oldGetURLs }()
+	getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, string, error) {
+		return []byte(`[
+			{"address": "https://127.0.0.1:1"},
+			{"address": "https://127.0.0.1:2"},
+			{"address": "https://127.0.0.1:3"}
+		]`), "", nil
+	}
+	whURL, _ := url.ParseRequestURI("https://livepeer.live/api/orchestrator")
+	whpool := NewWebhookPool(nil, whURL)
+	whpool.SetFilter(filter)
+	infos, err = whpool.GetOrchestrators(3)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:2"}, transcoders(infos))
+
+	require.Nil(filter.Remove(common.OrchAllowList, addr2))
+	infos, err = whpool.GetOrchestrators(3)
+	require.Nil(err)
+	assert.ElementsMatch([]string{"https://127.0.0.1:2", "https://127.0.0.1:3"}, transcoders(infos))
+}
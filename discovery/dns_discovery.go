@@ -0,0 +1,285 @@
+package discovery
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	gonet "net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+
+	"github.com/golang/glog"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var (
+	// The time until records are resolved again is the lowest TTL of the records clamped to these bounds
+	dnsMinRefreshInterval = 10 * time.Second
+	dnsMaxRefreshInterval = 10 * time.Minute
+	// dnsRetryInterval is the time until records are resolved again after a failed or empty lookup
+	dnsRetryInterval = 10 * time.Second
+	dnsQueryTimeout  = 3 * time.Second
+
+	dnsDefaultPort  = "8935"
+	dnsResolvConf   = "/etc/resolv.conf"
+	dnsMaxUDPLength = 4096
+)
+
+// dnsPool is an orchestrator pool that is resolved from the DNS SRV records of a name, or from
+// its A and AAAA records if it does not have SRV records. The records are resolved again when
+// their TTL expires
+type dnsPool struct {
+	name   string
+	port   string
+	server string
+	bcast  common.Broadcaster
+	filter *common.OrchestratorFilter
+
+	refreshMu sync.Mutex
+	mu        *sync.RWMutex
+	orchs     []*weightedOrch
+	expiry    time.Time
+}
+
+// NewDNSPool creates an orchestrator pool from the DNS records of name, which is either the
+// name of SRV records such as _livepeer._tcp.example.com or a host name with an optional port
+// to use for its A and AAAA records. Records are resolved with the DNS server at server, or
+// the first nameserver in /etc/resolv.conf if server is empty
+func NewDNSPool(bcast common.Broadcaster, name, server string) *dnsPool {
+	port := dnsDefaultPort
+	if host, p, err := gonet.SplitHostPort(name); err == nil {
+		name, port = host, p
+	}
+	if server == "" {
+		server = defaultDNSServer()
+	} else if _, _, err := gonet.SplitHostPort(server); err != nil {
+		server = gonet.JoinHostPort(server, "53")
+	}
+
+	p := &dnsPool{
+		name:   name,
+		port:   port,
+		server: server,
+		bcast:  bcast,
+		mu:     &sync.RWMutex{},
+	}
+	go p.getOrchs()
+	return p
+}
+
+func (p *dnsPool) getOrchs() []*weightedOrch {
+	p.mu.RLock()
+	orchs, expiry := p.orchs, p.expiry
+	p.mu.RUnlock()
+	if time.Now().Before(expiry) {
+		return orchs
+	}
+
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	// Another caller may have refreshed the records while waiting for the lock
+	p.mu.RLock()
+	orchs, expiry = p.orchs, p.expiry
+	p.mu.RUnlock()
+	if time.Now().Before(expiry) {
+		return orchs
+	}
+
+	resolved, ttl, err := p.resolve()
+	if err != nil {
+		// Keep the previous orchestrators until the records can be resolved
+		glog.Errorf("Unable to resolve orchestrators name=%v server=%v err=%v", p.name, p.server, err)
+		ttl = dnsRetryInterval
+	} else {
+		if len(resolved) == 0 {
+			glog.Errorf("No orchestrator records found name=%v server=%v", p.name, p.server)
+			ttl = dnsRetryInterval
+		}
+		orchs = resolved
+	}
+
+	p.mu.Lock()
+	p.orchs = orchs
+	p.expiry = time.Now().Add(ttl)
+	p.mu.Unlock()
+
+	return orchs
+}
+
+// resolve returns the orchestrators of the SRV records of the pool's name, or of its A and AAAA
+// records if there are no SRV records, and the time until the records expire
+func (p *dnsPool) resolve() ([]*weightedOrch, time.Duration, error) {
+	var ttl uint32
+	updateTTL := func(h dnsmessage.ResourceHeader) {
+		if ttl == 0 || h.TTL < ttl {
+			ttl = h.TTL
+		}
+	}
+
+	srvs, err := dnsQuery(p.server, p.name, dnsmessage.TypeSRV)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var orchs []*weightedOrch
+	for _, r := range srvs {
+		srv := r.Body.(*dnsmessage.SRVResource)
+		target := strings.TrimSuffix(srv.Target.String(), ".")
+		// A target of "." means that the service is not available at this name
+		if target == "" {
+			continue
+		}
+		updateTTL(r.Header)
+		orchs = append(orchs, &weightedOrch{
+			uri:      &url.URL{Scheme: "https", Host: gonet.JoinHostPort(target, strconv.Itoa(int(srv.Port)))},
+			weight:   int(srv.Weight),
+			priority: int(srv.Priority),
+		})
+	}
+
+	if len(srvs) == 0 {
+		for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+			rs, err := dnsQuery(p.server, p.name, qtype)
+			if err != nil {
+				return nil, 0, err
+			}
+			for _, r := range rs {
+				var ip gonet.IP
+				switch body := r.Body.(type) {
+				case *dnsmessage.AResource:
+					ip = gonet.IP(body.A[:])
+				case *dnsmessage.AAAAResource:
+					ip = gonet.IP(body.AAAA[:])
+				}
+				updateTTL(r.Header)
+				orchs = append(orchs, &weightedOrch{
+					uri: &url.URL{Scheme: "https", Host: gonet.JoinHostPort(ip.String(), p.port)},
+				})
+			}
+		}
+	}
+
+	refresh := time.Duration(ttl) * time.Second
+	if refresh < dnsMinRefreshInterval {
+		refresh = dnsMinRefreshInterval
+	}
+	if refresh > dnsMaxRefreshInterval {
+		refresh = dnsMaxRefreshInterval
+	}
+
+	return orchs, refresh, nil
+}
+
+// dnsQuery sends a recursive query for the records of name with type qtype to the DNS server
+// at server and returns the answers with that type. A name that does not exist has no answers
+func dnsQuery(server, name string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	qname, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(dnsMaxUDPLength, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	query := dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: uint16(rand.Intn(1 << 16)), RecursionDesired: true},
+		Questions:   []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+		Additionals: []dnsmessage.Resource{{Header: opt, Body: &dnsmessage.OPTResource{}}},
+	}
+	req, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := gonet.DialTimeout("udp", server, dnsQueryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(dnsQueryTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, dnsMaxUDPLength)
+	var resp dnsmessage.Message
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Ignore responses to other queries
+		if err := resp.Unpack(buf[:n]); err == nil && resp.Response && resp.ID == query.ID {
+			break
+		}
+	}
+
+	if resp.Truncated {
+		return nil, fmt.Errorf("truncated DNS response for %v %v", qtype, name)
+	}
+	switch resp.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("DNS query for %v %v failed: %v", qtype, name, resp.RCode)
+	}
+
+	var answers []dnsmessage.Resource
+	for _, r := range resp.Answers {
+		if r.Header.Type == qtype {
+			answers = append(answers, r)
+		}
+	}
+	return answers, nil
+}
+
+// defaultDNSServer returns the first nameserver in resolv.conf
+func defaultDNSServer() string {
+	f, err := os.Open(dnsResolvConf)
+	if err != nil {
+		return "127.0.0.1:53"
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return gonet.JoinHostPort(fields[1], "53")
+		}
+	}
+	return "127.0.0.1:53"
+}
+
+func (p *dnsPool) GetURLs() []*url.URL {
+	return weightedURLs(p.getOrchs())
+}
+
+// SetFilter makes the pool only use the orchestrators that filter allows
+func (p *dnsPool) SetFilter(filter *common.OrchestratorFilter) {
+	p.filter = filter
+}
+
+func (p *dnsPool) Size() int {
+	return len(p.getOrchs())
+}
+
+// GetOrchestrators returns up to numOrchestrators orchestrators by the priority and weight of their SRV records
+func (p *dnsPool) GetOrchestrators(numOrchestrators int) ([]*net.OrchestratorInfo, error) {
+	return getWeightedOrchestrators(p.bcast, p.filter, p.getOrchs(), numOrchestrators), nil
+}
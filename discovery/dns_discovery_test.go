@@ -0,0 +1,303 @@
+package discovery
+
+import (
+	"context"
+	"io/ioutil"
+	gonet "net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubDNSServer is a local DNS server that answers queries from its records
+type stubDNSServer struct {
+	conn gonet.PacketConn
+
+	mu      sync.Mutex
+	records map[dnsmessage.Type][]dnsmessage.Resource
+	rcode   dnsmessage.RCode
+	queries int
+}
+
+func newStubDNSServer(t *testing.T) *stubDNSServer {
+	conn, err := gonet.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	s := &stubDNSServer{conn: conn, records: make(map[dnsmessage.Type][]dnsmessage.Resource)}
+	go s.serve()
+	return s
+}
+
+func (s *stubDNSServer) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *stubDNSServer) close() {
+	s.conn.Close()
+}
+
+func (s *stubDNSServer) setRecords(records ...dnsmessage.Resource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = make(map[dnsmessage.Type][]dnsmessage.Resource)
+	for _, r := range records {
+		s.records[r.Header.Type] = append(s.records[r.Header.Type], r)
+	}
+}
+
+func (s *stubDNSServer) setRCode(rcode dnsmessage.RCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcode = rcode
+}
+
+func (s *stubDNSServer) numQueries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries
+}
+
+func (s *stubDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		var req dnsmessage.Message
+		if err := req.Unpack(buf[:n]); err != nil || len(req.Questions) != 1 {
+			continue
+		}
+		q := req.Questions[0]
+
+		s.mu.Lock()
+		s.queries++
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: req.ID, Response: true, RCode: s.rcode},
+			Questions: req.Questions,
+		}
+		if s.rcode == dnsmessage.RCodeSuccess {
+			for _, r := range s.records[q.Type] {
+				if r.Header.Name == q.Name {
+					resp.Answers = append(resp.Answers, r)
+				}
+			}
+		}
+		s.mu.Unlock()
+
+		b, err := resp.Pack()
+		if err != nil {
+			continue
+		}
+		s.conn.WriteTo(b, addr)
+	}
+}
+
+func dnsHeader(name string, qtype dnsmessage.Type, ttl uint32) dnsmessage.ResourceHeader {
+	return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET, TTL: ttl}
+}
+
+func srvRecord(name string, ttl uint32, priority, weight, port uint16, target string) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsHeader(name, dnsmessage.TypeSRV, ttl),
+		Body:   &dnsmessage.SRVResource{Priority: priority, Weight: weight, Port: port, Target: dnsmessage.MustNewName(target)},
+	}
+}
+
+func aRecord(name string, ttl uint32, ip string) dnsmessage.Resource {
+	var a [4]byte
+	copy(a[:], gonet.ParseIP(ip).To4())
+	return dnsmessage.Resource{Header: dnsHeader(name, dnsmessage.TypeA, ttl), Body: &dnsmessage.AResource{A: a}}
+}
+
+func aaaaRecord(name string, ttl uint32, ip string) dnsmessage.Resource {
+	var aaaa [16]byte
+	copy(aaaa[:], gonet.ParseIP(ip))
+	return dnsmessage.Resource{Header: dnsHeader(name, dnsmessage.TypeAAAA, ttl), Body: &dnsmessage.AAAAResource{AAAA: aaaa}}
+}
+
+func urlStrings(uris []*url.URL) []string {
+	var res []string
+	for _, uri := range uris {
+		res = append(res, uri.String())
+	}
+	return res
+}
+
+func TestDNSPool_SRV(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords(
+		srvRecord("_livepeer._tcp.example.com.", 60, 0, 10, 8935, "orch1.example.com."),
+		srvRecord("_livepeer._tcp.example.com.", 60, 0, 20, 8936, "orch2.example.com."),
+		srvRecord("_livepeer._tcp.example.com.", 60, 1, 0, 8937, "orch3.example.com."),
+		srvRecord("_livepeer._tcp.other.com.", 60, 0, 0, 8935, "other.example.com."),
+	)
+
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, server *url.URL) (*net.OrchestratorInfo, error) {
+		return &net.OrchestratorInfo{Transcoder: server.String()}, nil
+	}
+
+	pool := NewDNSPool(nil, "_livepeer._tcp.example.com", server.addr())
+	assert.ElementsMatch([]string{
+		"https://orch1.example.com:8935",
+		"https://orch2.example.com:8936",
+		"https://orch3.example.com:8937",
+	}, urlStrings(pool.GetURLs()))
+	assert.Equal(3, pool.Size())
+	require.Len(pool.getOrchs(), 3)
+	orch := pool.getOrchs()[1]
+	assert.Equal(20, orch.weight)
+	assert.Equal(0, orch.priority)
+
+	// SRV priorities are used to select orchestrators
+	infos, err := pool.GetOrchestrators(2)
+	require.Nil(err)
+	var transcoders []string
+	for _, info := range infos {
+		transcoders = append(transcoders, info.Transcoder)
+	}
+	assert.ElementsMatch([]string{"https://orch1.example.com:8935", "https://orch2.example.com:8936"}, transcoders)
+
+	// A target of "." is skipped
+	server.setRecords(
+		srvRecord("_livepeer._tcp.example.com.", 60, 0, 0, 0, "."),
+	)
+	pool = NewDNSPool(nil, "_livepeer._tcp.example.com", server.addr())
+	assert.Empty(pool.GetURLs())
+}
+
+func TestDNSPool_AddressFallback(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords(
+		aRecord("orchs.example.com.", 60, "10.0.0.1"),
+		aRecord("orchs.example.com.", 60, "10.0.0.2"),
+		aaaaRecord("orchs.example.com.", 60, "2001:db8::1"),
+	)
+
+	pool := NewDNSPool(nil, "orchs.example.com", server.addr())
+	assert.ElementsMatch([]string{
+		"https://10.0.0.1:8935",
+		"https://10.0.0.2:8935",
+		"https://[2001:db8::1]:8935",
+	}, urlStrings(pool.GetURLs()))
+
+	// The port of the name is used for address records
+	pool = NewDNSPool(nil, "orchs.example.com:9000", server.addr())
+	assert.ElementsMatch([]string{
+		"https://10.0.0.1:9000",
+		"https://10.0.0.2:9000",
+		"https://[2001:db8::1]:9000",
+	}, urlStrings(pool.GetURLs()))
+
+	// Names that do not exist have no orchestrators
+	server.setRCode(dnsmessage.RCodeNameError)
+	pool = NewDNSPool(nil, "missing.example.com", server.addr())
+	assert.Empty(pool.GetURLs())
+}
+
+func TestDNSPool_Refresh(t *testing.T) {
+	assert := assert.New(t)
+
+	oldMin, oldMax, oldRetry := dnsMinRefreshInterval, dnsMaxRefreshInterval, dnsRetryInterval
+	defer func() {
+		dnsMinRefreshInterval, dnsMaxRefreshInterval, dnsRetryInterval = oldMin, oldMax, oldRetry
+	}()
+	dnsMinRefreshInterval = 0
+	dnsMaxRefreshInterval = 100 * time.Millisecond
+	dnsRetryInterval = 100 * time.Millisecond
+
+	server := newStubDNSServer(t)
+	defer server.close()
+	server.setRecords(srvRecord("_livepeer._tcp.example.com.", 3600, 0, 0, 8935, "orch1.example.com."))
+
+	pool := &dnsPool{name: "_livepeer._tcp.example.com", port: dnsDefaultPort, server: server.addr(), mu: &sync.RWMutex{}}
+	assert.Equal([]string{"https://orch1.example.com:8935"}, urlStrings(pool.GetURLs()))
+	queries := server.numQueries()
+
+	// Records are cached until they expire
+	server.setRecords(srvRecord("_livepeer._tcp.example.com.", 3600, 0, 0, 8935, "orch2.example.com."))
+	assert.Equal([]string{"https://orch1.example.com:8935"}, urlStrings(pool.GetURLs()))
+	assert.Equal(queries, server.numQueries())
+
+	// The TTL is capped by the max refresh interval
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal([]string{"https://orch2.example.com:8935"}, urlStrings(pool.GetURLs()))
+
+	// The previous orchestrators are kept if the records cannot be resolved
+	server.setRCode(dnsmessage.RCodeServerFailure)
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal([]string{"https://orch2.example.com:8935"}, urlStrings(pool.GetURLs()))
+	queries = server.numQueries()
+
+	// Failed lookups are retried after the retry interval
+	server.setRCode(dnsmessage.RCodeSuccess)
+	assert.Equal([]string{"https://orch2.example.com:8935"}, urlStrings(pool.GetURLs()))
+	assert.Equal(queries, server.numQueries())
+	time.Sleep(150 * time.Millisecond)
+	server.setRecords(srvRecord("_livepeer._tcp.example.com.", 0, 0, 0, 8935, "orch3.example.com."))
+	assert.Equal([]string{"https://orch3.example.com:8935"}, urlStrings(pool.GetURLs()))
+
+	// Records with a TTL below the min refresh interval are cached for the min refresh interval
+	dnsMinRefreshInterval = time.Hour
+	dnsMaxRefreshInterval = 2 * time.Hour
+	server.setRecords(srvRecord("_livepeer._tcp.example.com.", 0, 0, 0, 8935, "orch4.example.com."))
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal([]string{"https://orch4.example.com:8935"}, urlStrings(pool.GetURLs()))
+	server.setRecords(srvRecord("_livepeer._tcp.example.com.", 0, 0, 0, 8935, "orch5.example.com."))
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal([]string{"https://orch4.example.com:8935"}, urlStrings(pool.GetURLs()))
+}
+
+func TestDNSQuery_Timeout(t *testing.T) {
+	assert := assert.New(t)
+
+	oldTimeout := dnsQueryTimeout
+	defer func() { dnsQueryTimeout = oldTimeout }()
+	dnsQueryTimeout = 50 * time.Millisecond
+
+	// A server that does not respond
+	conn, err := gonet.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	_, err = dnsQuery(conn.LocalAddr().String(), "example.com", dnsmessage.TypeSRV)
+	assert.NotNil(err)
+}
+
+func TestDefaultDNSServer(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "resolvconf")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	oldResolvConf := dnsResolvConf
+	defer func() { dnsResolvConf = oldResolvConf }()
+
+	dnsResolvConf = filepath.Join(dir, "resolv.conf")
+	require.Nil(t, ioutil.WriteFile(dnsResolvConf, []byte("# comment\nsearch example.com\nnameserver 10.0.0.53\nnameserver 10.0.0.54\n"), 0644))
+	assert.Equal("10.0.0.53:53", defaultDNSServer())
+
+	require.Nil(t, ioutil.WriteFile(dnsResolvConf, []byte("nameserver 2001:db8::53\n"), 0644))
+	assert.Equal("[2001:db8::53]:53", defaultDNSServer())
+
+	dnsResolvConf = filepath.Join(dir, "missing")
+	assert.Equal("127.0.0.1:53", defaultDNSServer())
+}
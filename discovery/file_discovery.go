@@ -0,0 +1,201 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+
+	"github.com/golang/glog"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var fileRefreshInterval = 5 * time.Second
+
+// FileOrchestrator is an entry of an orchestrator file
+type FileOrchestrator struct {
+	// Address is the URI of the orchestrator
+	Address string `json:"address" yaml:"address"`
+	// EthereumAddress is the ticket recipient that the orchestrator is expected to advertise
+	EthereumAddress string `json:"ethereumAddress,omitempty" yaml:"ethereumAddress,omitempty"`
+	// Weight is the relative likelihood of selecting the orchestrator among orchestrators with the same priority
+	Weight int `json:"weight,omitempty" yaml:"weight,omitempty"`
+	// Priority is the tier of the orchestrator. Orchestrators with a lower priority are selected first
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty"`
+	// Tags are labels for the orchestrator
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// fileOrchestratorPool is an orchestrator pool that is read from a JSON or YAML file.
+// The file is polled for changes and the pool is replaced when the file is modified
+type fileOrchestratorPool struct {
+	path   string
+	bcast  common.Broadcaster
+	filter *common.OrchestratorFilter
+
+	mu      sync.RWMutex
+	orchs   []*weightedOrch
+	modTime time.Time
+	size    int64
+}
+
+// NewFileOrchestratorPool creates an orchestrator pool from the file at path and reloads
+// the pool whenever the file changes until ctx is done
+func NewFileOrchestratorPool(ctx context.Context, bcast common.Broadcaster, path string) (*fileOrchestratorPool, error) {
+	p := &fileOrchestratorPool{
+		path:  path,
+		bcast: bcast,
+	}
+	if _, err := p.reload(); err != nil {
+		return nil, err
+	}
+
+	go p.watch(ctx)
+
+	return p, nil
+}
+
+func (p *fileOrchestratorPool) watch(ctx context.Context) {
+	ticker := time.NewTicker(fileRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := p.reload()
+			if err != nil {
+				glog.Errorf("Unable to reload orchestrator file path=%v err=%v", p.path, err)
+				continue
+			}
+			if reloaded {
+				glog.Infof("Reloaded orchestrator file path=%v numOrch=%v", p.path, p.Size())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reload replaces the pool with the contents of the file if the file changed since it was last read.
+// If the file cannot be read or is invalid the current pool is kept
+func (p *fileOrchestratorPool) reload() (bool, error) {
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.RLock()
+	unchanged := p.orchs != nil && fi.ModTime().Equal(p.modTime) && fi.Size() == p.size
+	p.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return false, err
+	}
+	orchs, err := parseOrchestratorFile(p.path, data)
+	if err != nil {
+		return false, err
+	}
+	if len(orchs) == 0 {
+		glog.Errorf("Orchestrator file does not have any orchestrators path=%v", p.path)
+	}
+	for _, o := range orchs {
+		glog.V(common.DEBUG).Infof("Loaded orchestrator from file uri=%v recipient=%v weight=%v priority=%v tags=%v",
+			o.uri, o.recipient, o.weight, o.priority, o.tags)
+	}
+
+	p.mu.Lock()
+	p.orchs = orchs
+	p.modTime = fi.ModTime()
+	p.size = fi.Size()
+	p.mu.Unlock()
+
+	return true, nil
+}
+
+func parseOrchestratorFile(path string, data []byte) ([]*weightedOrch, error) {
+	var entries []FileOrchestrator
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.UnmarshalStrict(data, &entries); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported orchestrator file extension %q", ext)
+	}
+
+	orchs := make([]*weightedOrch, 0, len(entries))
+	for i, e := range entries {
+		o := &weightedOrch{weight: e.Weight, priority: e.Priority, tags: e.Tags}
+
+		addr := strings.TrimSpace(e.Address)
+		if !strings.HasPrefix(addr, "http") {
+			addr = "https://" + addr
+		}
+		uri, err := url.ParseRequestURI(addr)
+		if err != nil || uri.Host == "" {
+			return nil, fmt.Errorf("invalid address for orchestrator %d: %q", i, e.Address)
+		}
+		o.uri = uri
+
+		if e.EthereumAddress != "" {
+			if !ethcommon.IsHexAddress(e.EthereumAddress) {
+				return nil, fmt.Errorf("invalid ethereumAddress for orchestrator %d: %q", i, e.EthereumAddress)
+			}
+			recipient := ethcommon.HexToAddress(e.EthereumAddress)
+			o.recipient = &recipient
+		}
+
+		if e.Weight < 0 {
+			return nil, fmt.Errorf("invalid weight for orchestrator %d: %d", i, e.Weight)
+		}
+		if e.Priority < 0 {
+			return nil, fmt.Errorf("invalid priority for orchestrator %d: %d", i, e.Priority)
+		}
+
+		orchs = append(orchs, o)
+	}
+
+	return orchs, nil
+}
+
+func (p *fileOrchestratorPool) getOrchs() []*weightedOrch {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.orchs
+}
+
+func (p *fileOrchestratorPool) GetURLs() []*url.URL {
+	return weightedURLs(p.getOrchs())
+}
+
+// SetFilter makes the pool only use the orchestrators that filter allows
+func (p *fileOrchestratorPool) SetFilter(filter *common.OrchestratorFilter) {
+	p.filter = filter
+}
+
+func (p *fileOrchestratorPool) Size() int {
+	return len(p.getOrchs())
+}
+
+// GetOrchestrators returns up to numOrchestrators orchestrators from the file by priority and weight
+func (p *fileOrchestratorPool) GetOrchestrators(numOrchestrators int) ([]*net.OrchestratorInfo, error) {
+	return getWeightedOrchestrators(p.bcast, p.filter, p.getOrchs(), numOrchestrators), nil
+}
@@ -0,0 +1,263 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOrchFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	require.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	return path
+}
+
+func TestParseOrchestratorFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	addr := ethcommon.BytesToAddress([]byte("foo"))
+
+	orchs, err := parseOrchestratorFile("orchs.json", []byte(`[
+		{"address": "https://127.0.0.1:8935", "ethereumAddress": "`+addr.Hex()+`", "weight": 2, "priority": 1, "tags": ["private"]},
+		{"address": "127.0.0.1:8936"}
+	]`))
+	require.Nil(err)
+	require.Len(orchs, 2)
+	assert.Equal("https://127.0.0.1:8935", orchs[0].uri.String())
+	assert.Equal(addr, *orchs[0].recipient)
+	assert.Equal(2, orchs[0].weight)
+	assert.Equal(1, orchs[0].priority)
+	assert.Equal([]string{"private"}, orchs[0].tags)
+	assert.Equal("https://127.0.0.1:8936", orchs[1].uri.String())
+	assert.Nil(orchs[1].recipient)
+
+	orchs, err = parseOrchestratorFile("orchs.yaml", []byte(`
+- address: https://127.0.0.1:8935
+  ethereumAddress: "`+addr.Hex()+`"
+  weight: 2
+  priority: 1
+  tags: [private]
+- address: https://127.0.0.1:8936
+`))
+	require.Nil(err)
+	require.Len(orchs, 2)
+	assert.Equal("https://127.0.0.1:8935", orchs[0].uri.String())
+	assert.Equal(addr, *orchs[0].recipient)
+	assert.Equal(2, orchs[0].weight)
+	assert.Equal(1, orchs[0].priority)
+	assert.Equal([]string{"private"}, orchs[0].tags)
+
+	// Unknown keys are rejected in YAML
+	_, err = parseOrchestratorFile("orchs.yml", []byte("- addres: https://127.0.0.1:8935\n"))
+	assert.NotNil(err)
+
+	_, err = parseOrchestratorFile("orchs.txt", []byte(`[]`))
+	assert.EqualError(err, `unsupported orchestrator file extension ".txt"`)
+
+	_, err = parseOrchestratorFile("orchs.json", []byte(`{}`))
+	assert.NotNil(err)
+
+	_, err = parseOrchestratorFile("orchs.json", []byte(`[{"address": ""}]`))
+	assert.EqualError(err, `invalid address for orchestrator 0: ""`)
+
+	_, err = parseOrchestratorFile("orchs.json", []byte(`[{"address": "127.0.0.1:8935", "ethereumAddress": "foo"}]`))
+	assert.EqualError(err, `invalid ethereumAddress for orchestrator 0: "foo"`)
+
+	_, err = parseOrchestratorFile("orchs.json", []byte(`[{"address": "127.0.0.1:8935", "weight": -1}]`))
+	assert.EqualError(err, "invalid weight for orchestrator 0: -1")
+
+	_, err = parseOrchestratorFile("orchs.json", []byte(`[{"address": "127.0.0.1:8935", "priority": -1}]`))
+	assert.EqualError(err, "invalid priority for orchestrator 0: -1")
+}
+
+func TestFileOrchestratorPool_Reload(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	oldInterval := fileRefreshInterval
+	defer func() { fileRefreshInterval = oldInterval }()
+	fileRefreshInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "orchfile")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Missing and invalid files are an error
+	_, err = NewFileOrchestratorPool(ctx, nil, filepath.Join(dir, "missing.json"))
+	assert.NotNil(err)
+	_, err = NewFileOrchestratorPool(ctx, nil, writeOrchFile(t, dir, "invalid.json", `[{"address": ""}]`))
+	assert.NotNil(err)
+
+	path := writeOrchFile(t, dir, "orchs.json", `[{"address": "https://127.0.0.1:8935"}]`)
+	pool, err := NewFileOrchestratorPool(ctx, nil, path)
+	require.Nil(err)
+	require.Equal(1, pool.Size())
+	assert.Equal("https://127.0.0.1:8935", pool.GetURLs()[0].String())
+
+	// The pool is replaced when the file changes
+	writeOrchFile(t, dir, "orchs.json", `[{"address": "https://127.0.0.1:8936"}, {"address": "https://127.0.0.1:8937"}]`)
+	assert.Eventually(func() bool { return pool.Size() == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal("https://127.0.0.1:8936", pool.GetURLs()[0].String())
+	assert.Equal("https://127.0.0.1:8937", pool.GetURLs()[1].String())
+
+	// The pool is kept when the file becomes invalid
+	writeOrchFile(t, dir, "orchs.json", `[{"address": "https://127.0.0.1:8938"}, {"address": ""}]`)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(2, pool.Size())
+	assert.Equal("https://127.0.0.1:8936", pool.GetURLs()[0].String())
+
+	// The pool is kept when the file is removed
+	require.Nil(os.Remove(path))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(2, pool.Size())
+
+	// The pool is not reloaded after the context is done
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	writeOrchFile(t, dir, "orchs.json", `[{"address": "https://127.0.0.1:8939"}]`)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(2, pool.Size())
+}
+
+func TestFileOrchestratorPool_GetOrchestrators(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "orchfile")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	recipient := ethcommon.BytesToAddress([]byte("foo"))
+	path := writeOrchFile(t, dir, "orchs.yaml", `
+- address: https://127.0.0.1:1
+  priority: 1
+- address: https://127.0.0.1:2
+  priority: 1
+- address: https://127.0.0.1:3
+- address: https://127.0.0.1:4
+- address: https://127.0.0.1:5
+  priority: 2
+- address: https://127.0.0.1:6
+  ethereumAddress: "`+recipient.Hex()+`"
+- address: https://127.0.0.1:7
+  ethereumAddress: "`+recipient.Hex()+`"
+  priority: 1
+`)
+
+	var mu sync.Mutex
+	queried := make(map[string]bool)
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, server *url.URL) (*net.OrchestratorInfo, error) {
+		mu.Lock()
+		queried[server.String()] = true
+		mu.Unlock()
+		switch server.Port() {
+		case "4":
+			return nil, errors.New("unavailable")
+		case "6":
+			// Advertises a recipient other than the expected address
+			return &net.OrchestratorInfo{Transcoder: server.String(), TicketParams: &net.TicketParams{Recipient: []byte("bar")}}, nil
+		case "7":
+			return &net.OrchestratorInfo{Transcoder: server.String(), TicketParams: &net.TicketParams{Recipient: recipient.Bytes()}}, nil
+		}
+		return &net.OrchestratorInfo{Transcoder: server.String()}, nil
+	}
+	resetQueried := func() {
+		mu.Lock()
+		queried = make(map[string]bool)
+		mu.Unlock()
+	}
+	transcoders := func(infos []*net.OrchestratorInfo) []string {
+		var res []string
+		for _, info := range infos {
+			res = append(res, info.Transcoder)
+		}
+		return res
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool, err := NewFileOrchestratorPool(ctx, nil, path)
+	require.Nil(err)
+	assert.Equal(7, pool.Size())
+
+	// Only the lowest priority is queried if it has enough orchestrators
+	infos, err := pool.GetOrchestrators(1)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:3"}, transcoders(infos))
+	assert.Len(queried, 3)
+	assert.False(queried["https://127.0.0.1:1"])
+
+	// Unavailable orchestrators and orchestrators with an unexpected recipient are skipped
+	resetQueried()
+	infos, err = pool.GetOrchestrators(4)
+	require.Nil(err)
+	require.Len(infos, 4)
+	assert.Equal("https://127.0.0.1:3", infos[0].Transcoder)
+	assert.ElementsMatch([]string{"https://127.0.0.1:1", "https://127.0.0.1:2", "https://127.0.0.1:7"}, transcoders(infos[1:]))
+	assert.Len(queried, 6)
+	assert.False(queried["https://127.0.0.1:5"])
+
+	// All priorities are used if needed
+	resetQueried()
+	infos, err = pool.GetOrchestrators(10)
+	require.Nil(err)
+	require.Len(infos, 5)
+	assert.Equal("https://127.0.0.1:5", infos[4].Transcoder)
+	assert.Len(queried, 7)
+}
+
+func TestFileOrchestratorPool_GetOrchestratorsTimeout(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "orchfile")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	path := writeOrchFile(t, dir, "orchs.json", `[{"address": "https://127.0.0.1:1"}, {"address": "https://127.0.0.1:2"}]`)
+
+	oldTimeout := getOrchestratorsTimeoutLoop
+	defer func() { getOrchestratorsTimeoutLoop = oldTimeout }()
+	getOrchestratorsTimeoutLoop = 50 * time.Millisecond
+
+	timedOut := make(chan struct{})
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, server *url.URL) (*net.OrchestratorInfo, error) {
+		if server.Port() == "2" {
+			<-ctx.Done()
+			close(timedOut)
+			return nil, ctx.Err()
+		}
+		return &net.OrchestratorInfo{Transcoder: server.String()}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool, err := NewFileOrchestratorPool(ctx, nil, path)
+	require.Nil(err)
+
+	// Responses received before the timeout are returned
+	infos, err := pool.GetOrchestrators(2)
+	require.Nil(err)
+	require.Len(infos, 1)
+	assert.Equal("https://127.0.0.1:1", infos[0].Transcoder)
+	<-timedOut
+}
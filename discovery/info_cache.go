@@ -0,0 +1,162 @@
+package discovery
+
+import (
+	"context"
+	"math/big"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+
+	"github.com/golang/glog"
+)
+
+type blockWatcher interface {
+	LastSeenBlock() *big.Int
+}
+
+// OrchestratorInfoCache caches the OrchestratorInfo of orchestrators so that the orchestrator
+// pools of all streams share the GetOrchestrator calls to select orchestrators. Ticket params are
+// only valid for one stream, so pools fetch the info of the orchestrators that they select again
+// for each stream.
+//
+// Cached info is used until it is older than the TTL or its ticket params expire. Info that is
+// older than half of the TTL or whose ticket params expire in the next block is refreshed in
+// the background while the cached info is still used
+type OrchestratorInfoCache struct {
+	ttl    time.Duration
+	blocks blockWatcher
+
+	mu      sync.Mutex
+	entries map[string]*infoCacheEntry
+}
+
+type infoCacheEntry struct {
+	info    *net.OrchestratorInfo
+	fetched time.Time
+	// fetch is the in-flight GetOrchestrator call for the entry, if any
+	fetch *infoFetch
+}
+
+type infoFetch struct {
+	done chan struct{}
+	info *net.OrchestratorInfo
+	err  error
+}
+
+// NewOrchestratorInfoCache creates an OrchestratorInfoCache that caches info for ttl.
+// If blocks is not nil, info with expired ticket params is not used
+func NewOrchestratorInfoCache(ttl time.Duration, blocks blockWatcher) *OrchestratorInfoCache {
+	return &OrchestratorInfoCache{
+		ttl:     ttl,
+		blocks:  blocks,
+		entries: make(map[string]*infoCacheEntry),
+	}
+}
+
+// Get returns the cached info of the orchestrator at uri, or fetches the info if there is no
+// usable cached info. Concurrent fetches of the same orchestrator share one GetOrchestrator call
+func (c *OrchestratorInfoCache) Get(ctx context.Context, bcast common.Broadcaster, uri *url.URL) (*net.OrchestratorInfo, error) {
+	c.mu.Lock()
+	e, ok := c.entries[uri.String()]
+	if !ok {
+		e = &infoCacheEntry{}
+		c.entries[uri.String()] = e
+	}
+
+	if e.info != nil && c.usable(e) {
+		info := e.info
+		if e.fetch == nil && c.refreshDue(e) {
+			glog.V(common.DEBUG).Infof("Refreshing cached orchestrator info uri=%v", uri)
+			c.startFetch(e, bcast, uri)
+		}
+		c.mu.Unlock()
+		return info, nil
+	}
+
+	if e.fetch == nil {
+		c.startFetch(e, bcast, uri)
+	}
+	fetch := e.fetch
+	c.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		return fetch.info, fetch.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// put caches info that was fetched outside of the cache
+func (c *OrchestratorInfoCache) put(uri *url.URL, info *net.OrchestratorInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[uri.String()]
+	if !ok {
+		e = &infoCacheEntry{}
+		c.entries[uri.String()] = e
+	}
+	e.info = info
+	e.fetched = time.Now()
+}
+
+// startFetch starts a GetOrchestrator call for an entry. The caller must hold c.mu
+func (c *OrchestratorInfoCache) startFetch(e *infoCacheEntry, bcast common.Broadcaster, uri *url.URL) {
+	fetch := &infoFetch{done: make(chan struct{})}
+	e.fetch = fetch
+
+	go func() {
+		// The fetch is shared by all callers so it is not cancelled with the caller's context
+		ctx, cancel := context.WithTimeout(context.Background(), getOrchestratorsTimeoutLoop)
+		defer cancel()
+
+		info, err := serverGetOrchInfo(ctx, bcast, uri)
+
+		c.mu.Lock()
+		if err == nil {
+			e.info = info
+			e.fetched = time.Now()
+		}
+		e.fetch = nil
+		c.mu.Unlock()
+
+		fetch.info, fetch.err = info, err
+		close(fetch.done)
+	}()
+}
+
+// usable returns whether cached info is recent enough and has unexpired ticket params
+func (c *OrchestratorInfoCache) usable(e *infoCacheEntry) bool {
+	if time.Since(e.fetched) >= c.ttl {
+		return false
+	}
+	remaining, ok := c.blocksRemaining(e.info)
+	return !ok || remaining.Sign() > 0
+}
+
+// refreshDue returns whether cached info should be refreshed before it stops being usable
+func (c *OrchestratorInfoCache) refreshDue(e *infoCacheEntry) bool {
+	if time.Since(e.fetched) >= c.ttl/2 {
+		return true
+	}
+	remaining, ok := c.blocksRemaining(e.info)
+	return ok && remaining.Cmp(big.NewInt(1)) <= 0
+}
+
+// blocksRemaining returns the number of blocks until the ticket params of info expire
+// and whether the ticket params expire
+func (c *OrchestratorInfoCache) blocksRemaining(info *net.OrchestratorInfo) (*big.Int, bool) {
+	if c.blocks == nil || info.GetTicketParams() == nil {
+		return nil, false
+	}
+	expiration := new(big.Int).SetBytes(info.TicketParams.ExpirationBlock)
+	lastSeen := c.blocks.LastSeenBlock()
+	if expiration.Sign() == 0 || lastSeen == nil {
+		return nil, false
+	}
+	return new(big.Int).Sub(expiration, lastSeen), true
+}
@@ -0,0 +1,304 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/go-livepeer/pm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubBlockWatcher struct {
+	mu    sync.Mutex
+	block *big.Int
+}
+
+func (w *stubBlockWatcher) LastSeenBlock() *big.Int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.block
+}
+
+func (w *stubBlockWatcher) setBlock(block int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.block = big.NewInt(block)
+}
+
+type stubOrchInfoServer struct {
+	mu         sync.Mutex
+	calls      int
+	price      int64
+	expiration int64
+	err        error
+	release    chan struct{}
+	// transcoder, if set, is returned as the transcoder URI instead of the requested URI
+	transcoder string
+	requested  []string
+}
+
+func (s *stubOrchInfoServer) getOrchInfo(ctx context.Context, bcast common.Broadcaster, uri *url.URL) (*net.OrchestratorInfo, error) {
+	s.mu.Lock()
+	s.calls++
+	s.requested = append(s.requested, uri.String())
+	price, expiration, err, release, transcoder := s.price, s.expiration, s.err, s.release, s.transcoder
+	s.mu.Unlock()
+
+	if release != nil {
+		<-release
+	}
+	if err != nil {
+		return nil, err
+	}
+	if transcoder == "" {
+		transcoder = uri.String()
+	}
+	return &net.OrchestratorInfo{
+		Transcoder: transcoder,
+		PriceInfo:  &net.PriceInfo{PricePerUnit: price, PixelsPerUnit: 1},
+		TicketParams: &net.TicketParams{
+			ExpirationBlock:   big.NewInt(expiration).Bytes(),
+			RecipientRandHash: pm.RandHash().Bytes(),
+		},
+	}, nil
+}
+
+func (s *stubOrchInfoServer) numCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *stubOrchInfoServer) set(price, expiration int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.price, s.expiration, s.err = price, expiration, err
+}
+
+func TestOrchestratorInfoCache_Get(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := &stubOrchInfoServer{price: 1, expiration: 100}
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = srv.getOrchInfo
+
+	blocks := &stubBlockWatcher{}
+	blocks.setBlock(10)
+	c := NewOrchestratorInfoCache(time.Hour, blocks)
+	uris := parseURLs(t, "https://127.0.0.1:1", "https://127.0.0.1:2")
+
+	// Info is fetched once and then cached
+	info, err := c.Get(context.Background(), nil, uris[0])
+	require.Nil(err)
+	assert.Equal(int64(1), info.PriceInfo.PricePerUnit)
+	info, err = c.Get(context.Background(), nil, uris[0])
+	require.Nil(err)
+	assert.Equal(int64(1), info.PriceInfo.PricePerUnit)
+	assert.Equal(1, srv.numCalls())
+
+	// Info is cached per orchestrator
+	info, err = c.Get(context.Background(), nil, uris[1])
+	require.Nil(err)
+	assert.Equal(uris[1].String(), info.Transcoder)
+	assert.Equal(2, srv.numCalls())
+
+	// Info with expired ticket params is fetched again
+	srv.set(2, 200, nil)
+	blocks.setBlock(100)
+	info, err = c.Get(context.Background(), nil, uris[0])
+	require.Nil(err)
+	assert.Equal(int64(2), info.PriceInfo.PricePerUnit)
+	assert.Equal(3, srv.numCalls())
+
+	// Errors are not cached
+	srv.set(3, 200, errors.New("unavailable"))
+	blocks.setBlock(200)
+	_, err = c.Get(context.Background(), nil, uris[0])
+	assert.EqualError(err, "unavailable")
+	srv.set(3, 300, nil)
+	info, err = c.Get(context.Background(), nil, uris[0])
+	require.Nil(err)
+	assert.Equal(int64(3), info.PriceInfo.PricePerUnit)
+	assert.Equal(5, srv.numCalls())
+
+	// Info older than the TTL is fetched again
+	c.ttl = 0
+	srv.set(4, 300, nil)
+	info, err = c.Get(context.Background(), nil, uris[0])
+	require.Nil(err)
+	assert.Equal(int64(4), info.PriceInfo.PricePerUnit)
+	assert.Equal(6, srv.numCalls())
+}
+
+func TestOrchestratorInfoCache_SharedFetch(t *testing.T) {
+	assert := assert.New(t)
+
+	srv := &stubOrchInfoServer{price: 1, release: make(chan struct{})}
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = srv.getOrchInfo
+
+	c := NewOrchestratorInfoCache(time.Hour, nil)
+	uri := parseURLs(t, "https://127.0.0.1:1")[0]
+
+	// Concurrent callers share one fetch
+	var wg sync.WaitGroup
+	infos := make([]*net.OrchestratorInfo, 10)
+	for i := range infos {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			infos[i], _ = c.Get(context.Background(), nil, uri)
+		}(i)
+	}
+	assert.Eventually(func() bool { return srv.numCalls() == 1 }, time.Second, 5*time.Millisecond)
+	close(srv.release)
+	wg.Wait()
+
+	assert.Equal(1, srv.numCalls())
+	for _, info := range infos {
+		assert.Equal(uri.String(), info.Transcoder)
+	}
+
+	// A caller that gives up does not cancel the fetch for other callers
+	c = NewOrchestratorInfoCache(time.Hour, nil)
+	srv.mu.Lock()
+	srv.release = make(chan struct{})
+	srv.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.Get(ctx, nil, uri)
+	assert.Equal(context.Canceled, err)
+
+	done := make(chan *net.OrchestratorInfo)
+	go func() {
+		info, _ := c.Get(context.Background(), nil, uri)
+		done <- info
+	}()
+	close(srv.release)
+	select {
+	case info := <-done:
+		assert.Equal(uri.String(), info.Transcoder)
+	case <-time.After(time.Second):
+		t.Error("fetch was not shared")
+	}
+}
+
+func TestOrchestratorInfoCache_BackgroundRefresh(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := &stubOrchInfoServer{price: 1, expiration: 100}
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = srv.getOrchInfo
+
+	blocks := &stubBlockWatcher{}
+	blocks.setBlock(10)
+	c := NewOrchestratorInfoCache(time.Hour, blocks)
+	uri := parseURLs(t, "https://127.0.0.1:1")[0]
+
+	_, err := c.Get(context.Background(), nil, uri)
+	require.Nil(err)
+
+	// Info with ticket params that expire in the next block is used while it is refreshed
+	srv.set(2, 200, nil)
+	blocks.setBlock(99)
+	info, err := c.Get(context.Background(), nil, uri)
+	require.Nil(err)
+	assert.Equal(int64(1), info.PriceInfo.PricePerUnit)
+	assert.Eventually(func() bool {
+		info, _ := c.Get(context.Background(), nil, uri)
+		return info.PriceInfo.PricePerUnit == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(2, srv.numCalls())
+
+	// Info older than half of the TTL is used while it is refreshed
+	srv.set(3, 200, nil)
+	c.mu.Lock()
+	c.entries[uri.String()].fetched = time.Now().Add(-45 * time.Minute)
+	c.mu.Unlock()
+	info, err = c.Get(context.Background(), nil, uri)
+	require.Nil(err)
+	assert.Equal(int64(2), info.PriceInfo.PricePerUnit)
+	assert.Eventually(func() bool {
+		info, _ := c.Get(context.Background(), nil, uri)
+		return info.PriceInfo.PricePerUnit == 3
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(3, srv.numCalls())
+}
+
+func TestOrchestratorPool_InfoCache(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := &stubOrchInfoServer{price: 1}
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = srv.getOrchInfo
+
+	uris := parseURLs(t, "https://127.0.0.1:1", "https://127.0.0.1:2", "https://127.0.0.1:3")
+	cache := NewOrchestratorInfoCache(time.Hour, nil)
+
+	// Pools share the info in the cache to select orchestrators, but the selected orchestrators
+	// return ticket params for each stream
+	recipientRandHashes := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		pool := NewOrchestratorPool(nil, uris)
+		pool.SetInfoCache(cache)
+		infos, err := pool.GetOrchestrators(2)
+		require.Nil(err)
+		require.Len(infos, 2)
+		for _, info := range infos {
+			recipientRandHashes[string(info.TicketParams.RecipientRandHash)] = true
+		}
+	}
+	assert.Equal(3+3*2, srv.numCalls())
+	assert.Len(recipientRandHashes, 3*2)
+
+	// Pools that prefer nearby orchestrators use the cache
+	pool := NewOrchestratorPoolWithLatency(nil, uris, NewLatencyTracker())
+	pool.SetInfoCache(cache)
+	infos, err := pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Len(infos, 2)
+	assert.Equal(3+4*2, srv.numCalls())
+
+	// Ticket params are fetched from the URI that the orchestrator was accepted for,
+	// even if its transcoder URI is a different host
+	srv.mu.Lock()
+	srv.transcoder = "https://transcoder.example.com:8935"
+	srv.requested = nil
+	srv.mu.Unlock()
+	pool = NewOrchestratorPool(nil, uris[:1])
+	pool.SetInfoCache(NewOrchestratorInfoCache(time.Hour, nil))
+	pool.uriPred = func(uri *url.URL, info *net.OrchestratorInfo) bool {
+		return uri.String() == uris[0].String()
+	}
+	infos, err = pool.GetOrchestrators(1)
+	require.Nil(err)
+	require.Len(infos, 1)
+	assert.Equal("https://transcoder.example.com:8935", infos[0].Transcoder)
+	srv.mu.Lock()
+	assert.Equal([]string{uris[0].String(), uris[0].String()}, srv.requested)
+	srv.transcoder = ""
+	srv.mu.Unlock()
+
+	// Orchestrators that fail to return ticket params are not used
+	srv.set(1, 0, errors.New("unavailable"))
+	pool = NewOrchestratorPool(nil, uris)
+	pool.SetInfoCache(cache)
+	infos, err = pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Empty(infos)
+}
@@ -0,0 +1,212 @@
+package discovery
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/livepeer/go-livepeer/monitor"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/go-livepeer/server"
+
+	"github.com/golang/glog"
+)
+
+var (
+	serverPingOrchestrator = server.PingOrchestrator
+
+	// latencyAlpha is the weight of a new RTT sample in the rolling latency estimate
+	latencyAlpha = 0.3
+	// latencyExploreRate is the probability of replacing each nearest orchestrator with another
+	// orchestrator so that the latency of orchestrators that are not selected is still measured
+	latencyExploreRate = 0.2
+	// latencyProbeTimeout is the RTT sample that is recorded when a probe fails
+	latencyProbeTimeout = 3 * time.Second
+)
+
+// LatencyTracker keeps a rolling estimate of the round trip time to orchestrators that
+// orchestrator pools use to prefer nearby orchestrators
+type LatencyTracker struct {
+	mu   sync.RWMutex
+	rtts map[string]time.Duration
+}
+
+// NewLatencyTracker creates a LatencyTracker without any estimates
+func NewLatencyTracker() *LatencyTracker {
+	return &LatencyTracker{rtts: make(map[string]time.Duration)}
+}
+
+// observe adds an RTT sample for an orchestrator to its rolling estimate
+func (l *LatencyTracker) observe(uri *url.URL, rtt time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	est, ok := l.rtts[uri.String()]
+	if !ok {
+		l.rtts[uri.String()] = rtt
+		return
+	}
+	l.rtts[uri.String()] = time.Duration(latencyAlpha*float64(rtt) + (1-latencyAlpha)*float64(est))
+}
+
+// Estimate returns the rolling RTT estimate for an orchestrator and whether it has been measured
+func (l *LatencyTracker) Estimate(uri *url.URL) (time.Duration, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	rtt, ok := l.rtts[uri.String()]
+	return rtt, ok
+}
+
+// Probe pings the orchestrators returned by getURLs every interval to update their estimates until ctx is done
+func (l *LatencyTracker) Probe(ctx context.Context, getURLs func() []*url.URL, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		l.probeAll(ctx, getURLs())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *LatencyTracker) probeAll(ctx context.Context, uris []*url.URL) {
+	ctx, cancel := context.WithTimeout(ctx, latencyProbeTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, uri := range uris {
+		wg.Add(1)
+		go func(uri *url.URL) {
+			defer wg.Done()
+			rtt, err := serverPingOrchestrator(ctx, uri)
+			if err != nil {
+				// Only penalize orchestrators that failed before probing was cancelled
+				if ctx.Err() != context.Canceled {
+					glog.V(6).Infof("Unable to probe orchestrator latency uri=%v err=%v", uri, err)
+					l.observe(uri, latencyProbeTimeout)
+				}
+				return
+			}
+			l.observe(uri, rtt)
+		}(uri)
+	}
+	wg.Wait()
+}
+
+// order returns uris ordered by estimated RTT. Orchestrators without an estimate are ordered after
+// orchestrators with an estimate and orchestrators with the same estimate are ordered randomly.
+// Each orchestrator is swapped with a random later orchestrator with probability latencyExploreRate
+func (l *LatencyTracker) order(uris []*url.URL) []*url.URL {
+	ordered := make([]*url.URL, len(uris))
+	for i, j := range rand.Perm(len(uris)) {
+		ordered[i] = uris[j]
+	}
+
+	rtts := make(map[*url.URL]time.Duration, len(ordered))
+	for _, uri := range ordered {
+		rtt, ok := l.Estimate(uri)
+		if !ok {
+			rtt = time.Duration(math.MaxInt64)
+		}
+		rtts[uri] = rtt
+	}
+	sort.SliceStable(ordered, func(i, j int) bool { return rtts[ordered[i]] < rtts[ordered[j]] })
+
+	for i := 0; i < len(ordered)-1; i++ {
+		if rand.Float64() < latencyExploreRate {
+			j := i + 1 + rand.Intn(len(ordered)-i-1)
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		}
+	}
+
+	return ordered
+}
+
+// getNearestOrchestrators queries all orchestrators of the pool and returns the numOrchestrators
+// nearest orchestrators that respond. Responses from farther orchestrators are only used
+// once the nearer orchestrators responded with an error
+func (o *orchestratorPool) getNearestOrchestrators(numOrchestrators int) ([]fetchedInfo, error) {
+	uris := o.latency.order(o.allowedURIs())
+	numOrchestrators = int(math.Min(float64(len(uris)), float64(numOrchestrators)))
+	ctx, cancel := context.WithTimeout(context.Background(), getOrchestratorsTimeoutLoop)
+	defer cancel()
+
+	type orchResp struct {
+		i    int
+		info *net.OrchestratorInfo
+	}
+	respCh := make(chan orchResp, len(uris))
+	getOrchInfo := func(i int, uri *url.URL) {
+		start := time.Now()
+		info, err := o.getOrchInfo(ctx, uri)
+		// Cached info does not measure the latency of the orchestrator
+		if err == nil && o.cache == nil {
+			o.latency.observe(uri, time.Since(start))
+		}
+		if err == nil && o.accept(uri, info) {
+			respCh <- orchResp{i, info}
+			return
+		}
+		if err != nil && monitor.Enabled {
+			monitor.LogDiscoveryError(err.Error())
+		}
+		respCh <- orchResp{i, nil}
+	}
+
+	for i, uri := range uris {
+		go getOrchInfo(i, uri)
+	}
+
+	responded := make([]bool, len(uris))
+	infos := make([]*net.OrchestratorInfo, len(uris))
+	// selected returns the infos of the orchestrators that responded before the first
+	// orchestrator that has not responded yet
+	selected := func() []fetchedInfo {
+		sel := []fetchedInfo{}
+		for i := 0; i < len(uris) && len(sel) < numOrchestrators; i++ {
+			if !responded[i] {
+				break
+			}
+			if infos[i] != nil {
+				sel = append(sel, fetchedInfo{uris[i], infos[i]})
+			}
+		}
+		return sel
+	}
+
+	timeout := false
+	nbResp := 0
+	for nbResp < len(uris) && !timeout {
+		select {
+		case resp := <-respCh:
+			responded[resp.i] = true
+			infos[resp.i] = resp.info
+			nbResp++
+			if sel := selected(); len(sel) >= numOrchestrators {
+				glog.Infof("Done fetching nearest orch info numOrch=%d responses=%d/%d", len(sel), nbResp, len(uris))
+				return sel, nil
+			}
+		case <-ctx.Done():
+			timeout = true
+		}
+	}
+
+	// Use the nearest orchestrators that responded
+	sel := []fetchedInfo{}
+	for i := 0; i < len(uris) && len(sel) < numOrchestrators; i++ {
+		if infos[i] != nil {
+			sel = append(sel, fetchedInfo{uris[i], infos[i]})
+		}
+	}
+	glog.Infof("Done fetching nearest orch info numOrch=%d responses=%d/%d timeout=%t", len(sel), nbResp, len(uris), timeout)
+	return sel, nil
+}
@@ -0,0 +1,224 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseURLs(t *testing.T, addrs ...string) []*url.URL {
+	var uris []*url.URL
+	for _, addr := range addrs {
+		uri, err := url.ParseRequestURI(addr)
+		require.Nil(t, err)
+		uris = append(uris, uri)
+	}
+	return uris
+}
+
+func TestLatencyTracker_Observe(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLatencyTracker()
+	uri := parseURLs(t, "https://127.0.0.1:8935")[0]
+
+	_, ok := l.Estimate(uri)
+	assert.False(ok)
+
+	// The first sample is the estimate
+	l.observe(uri, 100*time.Millisecond)
+	rtt, ok := l.Estimate(uri)
+	assert.True(ok)
+	assert.Equal(100*time.Millisecond, rtt)
+
+	// Later samples are averaged into the estimate
+	l.observe(uri, 200*time.Millisecond)
+	rtt, _ = l.Estimate(uri)
+	assert.Equal(130*time.Millisecond, rtt)
+
+	// Estimates are keyed by URI
+	rtt, ok = l.Estimate(parseURLs(t, "https://127.0.0.1:8935")[0])
+	assert.True(ok)
+	assert.Equal(130*time.Millisecond, rtt)
+}
+
+func TestLatencyTracker_Order(t *testing.T) {
+	assert := assert.New(t)
+
+	oldRate := latencyExploreRate
+	defer func() { latencyExploreRate = oldRate }()
+
+	l := NewLatencyTracker()
+	uris := parseURLs(t, "https://127.0.0.1:1", "https://127.0.0.1:2", "https://127.0.0.1:3", "https://127.0.0.1:4")
+	l.observe(uris[0], 300*time.Millisecond)
+	l.observe(uris[2], 100*time.Millisecond)
+	l.observe(uris[3], 200*time.Millisecond)
+
+	// Orchestrators are ordered by estimate and orchestrators without an estimate are last
+	latencyExploreRate = 0
+	ordered := l.order(uris)
+	assert.Equal([]*url.URL{uris[2], uris[3], uris[0], uris[1]}, ordered)
+	assert.Equal("https://127.0.0.1:1", uris[0].String())
+
+	// Other orchestrators are explored
+	latencyExploreRate = 1
+	ordered = l.order(uris)
+	assert.Len(ordered, 4)
+	assert.NotEqual(uris[2], ordered[0])
+	assert.ElementsMatch(uris, ordered)
+
+	assert.Empty(l.order(nil))
+}
+
+func TestLatencyTracker_Probe(t *testing.T) {
+	assert := assert.New(t)
+
+	oldPing := serverPingOrchestrator
+	defer func() { serverPingOrchestrator = oldPing }()
+	var mu sync.Mutex
+	probes := 0
+	serverPingOrchestrator = func(ctx context.Context, uri *url.URL) (time.Duration, error) {
+		mu.Lock()
+		probes++
+		mu.Unlock()
+		if uri.Port() == "2" {
+			return 0, errors.New("unreachable")
+		}
+		return 50 * time.Millisecond, nil
+	}
+	numProbes := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return probes
+	}
+
+	l := NewLatencyTracker()
+	uris := parseURLs(t, "https://127.0.0.1:1", "https://127.0.0.1:2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.Probe(ctx, func() []*url.URL { return uris }, 20*time.Millisecond)
+		close(done)
+	}()
+
+	// Orchestrators are probed immediately and then every interval
+	assert.Eventually(func() bool { return numProbes() >= 4 }, time.Second, 5*time.Millisecond)
+	rtt, ok := l.Estimate(uris[0])
+	assert.True(ok)
+	assert.Equal(50*time.Millisecond, rtt)
+
+	// Failed probes are recorded as the probe timeout
+	rtt, ok = l.Estimate(uris[1])
+	assert.True(ok)
+	assert.Equal(latencyProbeTimeout, rtt)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("probing did not stop")
+	}
+}
+
+func TestOrchestratorPool_GetNearestOrchestrators(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	oldRate := latencyExploreRate
+	defer func() { latencyExploreRate = oldRate }()
+	latencyExploreRate = 0
+
+	oldTimeout := getOrchestratorsTimeoutLoop
+	defer func() { getOrchestratorsTimeoutLoop = oldTimeout }()
+	getOrchestratorsTimeoutLoop = 200 * time.Millisecond
+
+	uris := parseURLs(t, "https://127.0.0.1:1", "https://127.0.0.1:2", "https://127.0.0.1:3", "https://127.0.0.1:4")
+	l := NewLatencyTracker()
+	resetEstimates := func() {
+		l.mu.Lock()
+		l.rtts = make(map[string]time.Duration)
+		l.mu.Unlock()
+		for i, uri := range uris {
+			l.observe(uri, time.Duration(i+1)*10*time.Millisecond)
+		}
+	}
+
+	var mu sync.Mutex
+	delays := map[string]time.Duration{}
+	fail := map[string]bool{}
+	oldOrchInfo := serverGetOrchInfo
+	defer func() { serverGetOrchInfo = oldOrchInfo }()
+	serverGetOrchInfo = func(ctx context.Context, bcast common.Broadcaster, server *url.URL) (*net.OrchestratorInfo, error) {
+		mu.Lock()
+		delay, failed := delays[server.Port()], fail[server.Port()]
+		mu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if failed {
+			return nil, errors.New("unavailable")
+		}
+		return &net.OrchestratorInfo{Transcoder: server.String()}, nil
+	}
+	set := func(d map[string]time.Duration, f map[string]bool) {
+		resetEstimates()
+		mu.Lock()
+		defer mu.Unlock()
+		delays, fail = d, f
+	}
+	transcoders := func(infos []*net.OrchestratorInfo) []string {
+		var res []string
+		for _, info := range infos {
+			res = append(res, info.Transcoder)
+		}
+		return res
+	}
+
+	pool := NewOrchestratorPoolWithLatency(nil, uris, l)
+
+	// The nearest orchestrators are used even if farther orchestrators respond first
+	set(map[string]time.Duration{"1": 30 * time.Millisecond, "2": 20 * time.Millisecond}, nil)
+	infos, err := pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:1", "https://127.0.0.1:2"}, transcoders(infos))
+
+	// Farther orchestrators are used if nearer orchestrators fail
+	set(nil, map[string]bool{"1": true})
+	infos, err = pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:2", "https://127.0.0.1:3"}, transcoders(infos))
+
+	// Orchestrators that do not match the predicate are skipped
+	pool.pred = func(info *net.OrchestratorInfo) bool { return info.Transcoder != "https://127.0.0.1:2" }
+	set(nil, nil)
+	infos, err = pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:1", "https://127.0.0.1:3"}, transcoders(infos))
+	pool.pred = nil
+
+	// Orchestrators that respond before the timeout are used in order of latency
+	set(map[string]time.Duration{"1": time.Second}, nil)
+	infos, err = pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:2", "https://127.0.0.1:3"}, transcoders(infos))
+
+	// Responses update the latency estimates
+	set(nil, nil)
+	before, _ := l.Estimate(uris[3])
+	infos, err = pool.GetOrchestrators(10)
+	require.Nil(err)
+	assert.Len(infos, 4)
+	after, _ := l.Estimate(uris[3])
+	assert.True(after < before)
+}
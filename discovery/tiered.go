@@ -0,0 +1,143 @@
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+)
+
+// PaymentMode is how a broadcaster pays the orchestrators of a pool tier
+type PaymentMode string
+
+const (
+	// PaymentTickets pays orchestrators with probabilistic micropayment tickets
+	PaymentTickets PaymentMode = "tickets"
+	// PaymentNone uses orchestrators without paying them, e.g. trusted orchestrators that are run by the broadcaster
+	PaymentNone PaymentMode = "none"
+)
+
+// ParsePaymentMode returns the PaymentMode named s
+func ParsePaymentMode(s string) (PaymentMode, error) {
+	switch PaymentMode(s) {
+	case PaymentTickets, PaymentNone:
+		return PaymentMode(s), nil
+	}
+	return "", fmt.Errorf("invalid payment mode %q", s)
+}
+
+// PoolTier is an orchestrator pool with a priority and a payment mode. Tiers with a lower
+// priority value are used first
+type PoolTier struct {
+	Pool     common.OrchestratorPool
+	Priority int
+	Payment  PaymentMode
+}
+
+// TieredPool is an orchestrator pool that composes several pools in tiers. Orchestrators are
+// taken from the tier with the highest priority first and lower tiers are only used for the
+// orchestrators that higher tiers could not provide
+type TieredPool struct {
+	tiers []PoolTier
+
+	mu     sync.RWMutex
+	unpaid map[string]bool
+}
+
+// NewTieredPool creates a TieredPool from tiers. Tiers with the same priority are used in the given order
+func NewTieredPool(tiers ...PoolTier) *TieredPool {
+	sorted := make([]PoolTier, len(tiers))
+	copy(sorted, tiers)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority < sorted[j].Priority })
+
+	return &TieredPool{
+		tiers:  sorted,
+		unpaid: make(map[string]bool),
+	}
+}
+
+// GetURLs returns the URLs of the orchestrators of all tiers
+func (p *TieredPool) GetURLs() []*url.URL {
+	var uris []*url.URL
+	seen := make(map[string]bool)
+	for _, tier := range p.tiers {
+		for _, uri := range tier.Pool.GetURLs() {
+			if seen[uri.String()] {
+				continue
+			}
+			seen[uri.String()] = true
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// GetOrchestrators returns up to n orchestrators, starting with the tier with the highest priority.
+// An orchestrator that is in several tiers is used with the payment mode of its highest tier
+func (p *TieredPool) GetOrchestrators(n int) ([]*net.OrchestratorInfo, error) {
+	var (
+		infos   []*net.OrchestratorInfo
+		lastErr error
+	)
+	seen := make(map[string]bool)
+	for _, tier := range p.tiers {
+		if len(infos) >= n {
+			break
+		}
+		tinfos, err := tier.Pool.GetOrchestrators(n - len(infos))
+		if err != nil {
+			glog.Errorf("Error getting orchestrators from tier priority=%v err=%v", tier.Priority, err)
+			lastErr = err
+		}
+
+		p.mu.Lock()
+		for _, info := range tinfos {
+			if info == nil || seen[info.Transcoder] || len(infos) >= n {
+				continue
+			}
+			seen[info.Transcoder] = true
+			p.unpaid[info.Transcoder] = tier.Payment == PaymentNone
+			infos = append(infos, info)
+		}
+		p.mu.Unlock()
+	}
+
+	if len(infos) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return infos, nil
+}
+
+// Size returns the size of the tier with the highest priority that has orchestrators, so that
+// streams only use as many orchestrators as the preferred tier can provide and lower tiers are
+// used when orchestrators of the preferred tier fail
+func (p *TieredPool) Size() int {
+	for _, tier := range p.tiers {
+		if size := tier.Pool.Size(); size > 0 {
+			return size
+		}
+	}
+	return 0
+}
+
+// Unpaid returns whether the orchestrator with the transcoder URI transcoder is used without payments
+func (p *TieredPool) Unpaid(transcoder string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.unpaid[transcoder]
+}
+
+// SetFilter sets the orchestrator allowlist and denylist of the pools of all tiers
+func (p *TieredPool) SetFilter(filter *common.OrchestratorFilter) {
+	for _, tier := range p.tiers {
+		if pool, ok := tier.Pool.(interface {
+			SetFilter(*common.OrchestratorFilter)
+		}); ok {
+			pool.SetFilter(filter)
+		}
+	}
+}
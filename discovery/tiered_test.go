@@ -0,0 +1,125 @@
+package discovery
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubTierPool struct {
+	uris   []*url.URL
+	err    error
+	asked  int
+	filter *common.OrchestratorFilter
+}
+
+func (p *stubTierPool) GetURLs() []*url.URL {
+	return p.uris
+}
+
+func (p *stubTierPool) GetOrchestrators(n int) ([]*net.OrchestratorInfo, error) {
+	p.asked = n
+	if p.err != nil {
+		return nil, p.err
+	}
+	var infos []*net.OrchestratorInfo
+	for _, uri := range p.uris {
+		if len(infos) >= n {
+			break
+		}
+		infos = append(infos, &net.OrchestratorInfo{Transcoder: uri.String()})
+	}
+	return infos, nil
+}
+
+func (p *stubTierPool) Size() int {
+	return len(p.uris)
+}
+
+func (p *stubTierPool) SetFilter(filter *common.OrchestratorFilter) {
+	p.filter = filter
+}
+
+func TestTieredPool_GetOrchestrators(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	trusted := &stubTierPool{uris: parseURLs(t, "https://127.0.0.1:1", "https://127.0.0.1:2")}
+	public := &stubTierPool{uris: parseURLs(t, "https://127.0.0.1:2", "https://127.0.0.1:3", "https://127.0.0.1:4")}
+	pool := NewTieredPool(
+		PoolTier{Pool: public, Priority: 1, Payment: PaymentTickets},
+		PoolTier{Pool: trusted, Priority: 0, Payment: PaymentNone},
+	)
+
+	// The size is the size of the tier with the highest priority
+	assert.Equal(2, pool.Size())
+	assert.Len(pool.GetURLs(), 4)
+
+	// Lower tiers are not used if higher tiers have enough orchestrators
+	public.asked = 0
+	infos, err := pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:1", "https://127.0.0.1:2"}, transcoders(infos))
+	assert.Equal(0, public.asked)
+	assert.True(pool.Unpaid("https://127.0.0.1:1"))
+	assert.True(pool.Unpaid("https://127.0.0.1:2"))
+
+	// Lower tiers are asked for the remaining orchestrators. Orchestrators are used with the
+	// payment mode of their highest tier
+	infos, err = pool.GetOrchestrators(4)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:1", "https://127.0.0.1:2", "https://127.0.0.1:3"}, transcoders(infos))
+	assert.Equal(2, public.asked)
+	assert.True(pool.Unpaid("https://127.0.0.1:2"))
+	assert.False(pool.Unpaid("https://127.0.0.1:3"))
+	assert.False(pool.Unpaid("https://127.0.0.1:4"))
+
+	// Lower tiers are used when higher tiers fail
+	trusted.err = errors.New("unavailable")
+	infos, err = pool.GetOrchestrators(2)
+	require.Nil(err)
+	assert.Equal([]string{"https://127.0.0.1:2", "https://127.0.0.1:3"}, transcoders(infos))
+	assert.False(pool.Unpaid("https://127.0.0.1:2"))
+
+	// An error is returned if no tier has orchestrators
+	public.err = errors.New("public unavailable")
+	_, err = pool.GetOrchestrators(2)
+	assert.EqualError(err, "public unavailable")
+
+	// The size falls back to lower tiers without orchestrators in higher tiers
+	trusted.uris = nil
+	assert.Equal(3, pool.Size())
+
+	// Filters are set for all tiers
+	f, err := common.NewOrchestratorFilter(nil)
+	require.Nil(err)
+	pool.SetFilter(f)
+	assert.Equal(f, trusted.filter)
+	assert.Equal(f, public.filter)
+}
+
+func TestParsePaymentMode(t *testing.T) {
+	assert := assert.New(t)
+
+	mode, err := ParsePaymentMode("none")
+	assert.Nil(err)
+	assert.Equal(PaymentNone, mode)
+	mode, err = ParsePaymentMode("tickets")
+	assert.Nil(err)
+	assert.Equal(PaymentTickets, mode)
+	_, err = ParsePaymentMode("credit")
+	assert.EqualError(err, `invalid payment mode "credit"`)
+}
+
+func transcoders(infos []*net.OrchestratorInfo) []string {
+	var res []string
+	for _, info := range infos {
+		res = append(res, info.Transcoder)
+	}
+	return res
+}
@@ -0,0 +1,158 @@
+package discovery
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"net/url"
+	"sort"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/monitor"
+	"github.com/livepeer/go-livepeer/net"
+
+	"github.com/golang/glog"
+)
+
+// weightedOrch is an orchestrator that is selected by priority and weight, like a DNS SRV record
+type weightedOrch struct {
+	uri *url.URL
+	// recipient is the ticket recipient that the orchestrator is expected to advertise, if any
+	recipient *ethcommon.Address
+	// maxPrice is the max price per pixel to pay the orchestrator, if any
+	maxPrice *big.Rat
+	weight   int
+	priority int
+	tags     []string
+}
+
+type weightedOrchInfo struct {
+	orch *weightedOrch
+	info *net.OrchestratorInfo
+}
+
+func weightedURLs(orchs []*weightedOrch) []*url.URL {
+	uris := make([]*url.URL, len(orchs))
+	for i, o := range orchs {
+		uris[i] = o.uri
+	}
+	return uris
+}
+
+// getWeightedOrchestrators returns up to numOrchestrators orchestrators starting with the lowest priority.
+// All orchestrators with the same priority are queried and the responsive orchestrators are selected
+// at random in proportion to their weight. Orchestrators with a higher priority are only queried if
+// the orchestrators with a lower priority cannot fill the request. Only orchestrators that filter
+// allows are used
+func getWeightedOrchestrators(bcast common.Broadcaster, filter *common.OrchestratorFilter, orchs []*weightedOrch, numOrchestrators int) []*net.OrchestratorInfo {
+	var allowed []*weightedOrch
+	for _, o := range orchs {
+		if !filter.AllowedURI(o.uri) || (o.recipient != nil && !filter.Allowed(o.uri, *o.recipient)) {
+			continue
+		}
+		allowed = append(allowed, o)
+	}
+	orchs = allowed
+	sort.SliceStable(orchs, func(i, j int) bool { return orchs[i].priority < orchs[j].priority })
+
+	infos := []*net.OrchestratorInfo{}
+	for start := 0; start < len(orchs) && len(infos) < numOrchestrators; {
+		end := start
+		for end < len(orchs) && orchs[end].priority == orchs[start].priority {
+			end++
+		}
+
+		resps := getTierInfos(bcast, filter, orchs[start:end])
+		for _, resp := range selectWeighted(resps, numOrchestrators-len(infos)) {
+			infos = append(infos, resp.info)
+		}
+
+		start = end
+	}
+
+	return infos
+}
+
+func getTierInfos(bcast common.Broadcaster, filter *common.OrchestratorFilter, orchs []*weightedOrch) []weightedOrchInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), getOrchestratorsTimeoutLoop)
+	defer cancel()
+
+	respCh := make(chan *weightedOrchInfo, len(orchs))
+	getOrchInfo := func(o *weightedOrch) {
+		info, err := serverGetOrchInfo(ctx, bcast, o.uri)
+		if err != nil {
+			if monitor.Enabled {
+				monitor.LogDiscoveryError(err.Error())
+			}
+			respCh <- nil
+			return
+		}
+		if o.recipient != nil && ethcommon.BytesToAddress(info.GetTicketParams().GetRecipient()) != *o.recipient {
+			glog.Errorf("Orchestrator ticket recipient does not match the expected address uri=%v expected=%v", o.uri, o.recipient.Hex())
+			respCh <- nil
+			return
+		}
+		if !filter.Allowed(o.uri, ethcommon.BytesToAddress(info.GetTicketParams().GetRecipient())) {
+			glog.V(common.DEBUG).Infof("Orchestrator is not allowed uri=%v", o.uri)
+			respCh <- nil
+			return
+		}
+		if o.maxPrice != nil {
+			price, err := common.RatPriceInfo(info.GetPriceInfo())
+			if err != nil || (price != nil && price.Cmp(o.maxPrice) > 0) {
+				glog.Errorf("Orchestrator price is above the max price uri=%v price=%v maxPrice=%v err=%v", o.uri, price, o.maxPrice.FloatString(3), err)
+				respCh <- nil
+				return
+			}
+		}
+		respCh <- &weightedOrchInfo{orch: o, info: info}
+	}
+
+	for _, o := range orchs {
+		go getOrchInfo(o)
+	}
+
+	resps := []weightedOrchInfo{}
+	for i := 0; i < len(orchs); i++ {
+		select {
+		case resp := <-respCh:
+			if resp != nil {
+				resps = append(resps, *resp)
+			}
+		case <-ctx.Done():
+			return resps
+		}
+	}
+	return resps
+}
+
+// selectWeighted selects up to n responses at random without replacement in proportion to the
+// weight of their orchestrator. Orchestrators without a weight have a weight of 1
+func selectWeighted(resps []weightedOrchInfo, n int) []weightedOrchInfo {
+	weight := func(r weightedOrchInfo) int {
+		if r.orch.weight == 0 {
+			return 1
+		}
+		return r.orch.weight
+	}
+
+	remaining := make([]weightedOrchInfo, len(resps))
+	copy(remaining, resps)
+
+	var selected []weightedOrchInfo
+	for len(selected) < n && len(remaining) > 0 {
+		total := 0
+		for _, r := range remaining {
+			total += weight(r)
+		}
+		x := rand.Intn(total)
+		i := 0
+		for ; x >= weight(remaining[i]); i++ {
+			x -= weight(remaining[i])
+		}
+		selected = append(selected, remaining[i])
+		remaining = append(remaining[:i], remaining[i+1:]...)
+	}
+	return selected
+}
@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectWeighted(t *testing.T) {
+	assert := assert.New(t)
+
+	resps := []weightedOrchInfo{
+		{orch: &weightedOrch{weight: 1}},
+		{orch: &weightedOrch{weight: 1000}},
+		{orch: &weightedOrch{}},
+	}
+
+	assert.Len(selectWeighted(resps, 3), 3)
+	assert.Len(selectWeighted(resps, 5), 3)
+	assert.Empty(selectWeighted(nil, 1))
+	assert.Empty(selectWeighted(resps, 0))
+
+	// Each response is selected once
+	selected := selectWeighted(resps, 3)
+	assert.ElementsMatch([]*weightedOrch{resps[0].orch, resps[1].orch, resps[2].orch},
+		[]*weightedOrch{selected[0].orch, selected[1].orch, selected[2].orch})
+
+	// Heavier orchestrators are selected first more often
+	heavy := 0
+	for i := 0; i < 100; i++ {
+		if selectWeighted(resps, 1)[0].orch == resps[1].orch {
+			heavy++
+		}
+	}
+	assert.True(heavy > 90)
+}
@@ -1,10 +1,16 @@
 package discovery
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,124 +24,237 @@ import (
 
 var whRefreshInterval = 1 * time.Minute
 
+// WebhookSignatureHeader is the header of a webhook response that contains the hex encoded
+// HMAC-SHA256 of the response body
+const WebhookSignatureHeader = "X-Livepeer-Signature"
+
+var errWebhookSig = errors.New("webhook response signature verification failed")
+
 type webhookResponse struct {
 	Address string
+	// Weight is the relative likelihood of selecting the orchestrator among orchestrators with the same priority
+	Weight int `json:"weight,omitempty"`
+	// Priority is the tier of the orchestrator. Orchestrators with a lower priority are selected first
+	Priority int `json:"priority,omitempty"`
+	// MaxPricePerUnit and PixelsPerUnit are the max price that will be paid to the orchestrator
+	MaxPricePerUnit int64 `json:"maxPricePerUnit,omitempty"`
+	PixelsPerUnit   int64 `json:"pixelsPerUnit,omitempty"`
+	// Tags are the regions of the orchestrator
+	Tags []string `json:"tags,omitempty"`
+	// Deny excludes the orchestrator from discovery by all orchestrator pools
+	Deny bool `json:"deny,omitempty"`
+}
+
+// WebhookPoolConfig configures a webhook orchestrator pool
+type WebhookPoolConfig struct {
+	// RefreshInterval is the minimum time between webhook requests
+	RefreshInterval time.Duration
+	// Secret is the key of the HMAC-SHA256 signature of webhook responses. If it is empty
+	// responses are not verified
+	Secret []byte
+	// Tags are the regions of the broadcaster. If set, orchestrators with tags are only used
+	// if they have one of these tags
+	Tags []string
 }
 
 type webhookPool struct {
-	pool         *orchestratorPool
+	orchs        []*weightedOrch
+	denied       []*url.URL
 	callback     *url.URL
+	cfg          WebhookPoolConfig
 	responseHash ethcommon.Hash
 	lastRequest  time.Time
 	mu           *sync.RWMutex
 	bcast        common.Broadcaster
+	filter       *common.OrchestratorFilter
 }
 
 func NewWebhookPool(bcast common.Broadcaster, callback *url.URL) *webhookPool {
+	return NewWebhookPoolWithConfig(bcast, callback, WebhookPoolConfig{})
+}
+
+func NewWebhookPoolWithConfig(bcast common.Broadcaster, callback *url.URL, cfg WebhookPoolConfig) *webhookPool {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = whRefreshInterval
+	}
 	p := &webhookPool{
 		callback: callback,
+		cfg:      cfg,
 		mu:       &sync.RWMutex{},
 		bcast:    bcast,
 	}
-	go p.getURLs()
+	go p.getOrchs()
 	return p
 }
 
-func (w *webhookPool) getURLs() ([]*url.URL, error) {
+func (w *webhookPool) getOrchs() ([]*weightedOrch, error) {
 	w.mu.RLock()
 	lastReq := w.lastRequest
+	orchs := w.orchs
 	w.mu.RUnlock()
 
 	// retrive addrs from cache if time since lastRequest is less than the refresh interval
-	if time.Since(lastReq) < whRefreshInterval {
-		return w.pool.GetURLs(), nil
+	if time.Since(lastReq) < w.cfg.RefreshInterval {
+		return orchs, nil
 	}
 
 	// retrive addrs from webhook if time since lastRequest is more than the refresh interval
-	body, err := getURLsfromWebhook(w.callback)
+	body, sig, err := getURLsfromWebhook(w.callback)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(w.cfg.Secret) > 0 && !verifyWebhookSig(w.cfg.Secret, body, sig) {
+		glog.Errorf("Unable to verify webhook response signature url=%v", w.callback)
+		return nil, errWebhookSig
+	}
+
 	hash := ethcommon.BytesToHash(crypto.Keccak256(body))
 	if hash == w.responseHash {
 		w.mu.Lock()
 		w.lastRequest = time.Now()
 		w.mu.Unlock()
-		return w.pool.GetURLs(), nil
+		return orchs, nil
 	}
 
-	addrs, err := deserializeWebhookJSON(body)
+	orchs, denied, err := deserializeWebhookJSON(body)
 	if err != nil {
 		return nil, err
 	}
-
-	pool := NewOrchestratorPool(w.bcast, addrs)
+	orchs = filterTags(orchs, w.cfg.Tags)
 
 	w.mu.Lock()
 	w.responseHash = hash
-	w.pool = pool
+	w.orchs = orchs
+	w.denied = denied
 	w.lastRequest = time.Now()
+	w.filter.SetDenied(w.callback.String(), denied)
 	w.mu.Unlock()
 
-	return addrs, nil
+	return orchs, nil
 }
 
 func (w *webhookPool) GetURLs() []*url.URL {
-	uris, _ := w.getURLs()
-	return uris
+	orchs, _ := w.getOrchs()
+	return weightedURLs(orchs)
+}
+
+// SetFilter makes the pool only use the orchestrators that filter allows. The orchestrators
+// that the webhook denies are denied by filter, so that other pools with filter do not use them either
+func (w *webhookPool) SetFilter(filter *common.OrchestratorFilter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.filter = filter
+	filter.SetDenied(w.callback.String(), w.denied)
 }
 
 func (w *webhookPool) Size() int {
 	return len(w.GetURLs())
 }
 
+// GetOrchestrators returns up to numOrchestrators orchestrators from the webhook by priority and weight
 func (w *webhookPool) GetOrchestrators(numOrchestrators int) ([]*net.OrchestratorInfo, error) {
-	_, err := w.getURLs()
+	orchs, err := w.getOrchs()
 	if err != nil {
 		return nil, err
 	}
 
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-
-	return w.pool.GetOrchestrators(numOrchestrators)
+	return getWeightedOrchestrators(w.bcast, w.filter, orchs, numOrchestrators), nil
 }
 
-var getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, error) {
+var getURLsfromWebhook = func(cbUrl *url.URL) ([]byte, string, error) {
 	var httpc = &http.Client{
 		Timeout: 3 * time.Second,
 	}
 	resp, err := httpc.Get(cbUrl.String())
 	if err != nil {
 		glog.Error("Unable to make webhook request ", err)
-		return nil, err
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		glog.Error("Unable to read response body ", err)
-		return nil, err
+		return nil, "", err
 	}
 
-	return body, nil
+	return body, resp.Header.Get(WebhookSignatureHeader), nil
+}
+
+func verifyWebhookSig(secret, body []byte, sig string) bool {
+	sigBytes, err := hex.DecodeString(strings.TrimPrefix(sig, "0x"))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(sigBytes, mac.Sum(nil))
 }
 
-func deserializeWebhookJSON(body []byte) ([]*url.URL, error) {
+// deserializeWebhookJSON returns the orchestrators of a webhook response and the URIs of the orchestrators that it denies
+func deserializeWebhookJSON(body []byte) ([]*weightedOrch, []*url.URL, error) {
 	var addrs []webhookResponse
 	if err := json.Unmarshal(body, &addrs); err != nil {
 		glog.Error("Unable to unmarshal JSON ", err)
-		return nil, err
+		return nil, nil, err
 	}
-	var urls []*url.URL
+	var orchs []*weightedOrch
+	var denied []*url.URL
 	for _, addr := range addrs {
 		uri, err := url.ParseRequestURI(addr.Address)
 		if err != nil {
-			glog.Errorf("Unable to parse address  %s : %s", addr, err)
+			glog.Errorf("Unable to parse address  %s : %s", addr.Address, err)
+			continue
+		}
+		if addr.Deny {
+			denied = append(denied, uri)
 			continue
 		}
-		urls = append(urls, uri)
+		if addr.Weight < 0 || addr.Priority < 0 || addr.MaxPricePerUnit < 0 || addr.PixelsPerUnit < 0 {
+			glog.Errorf("Invalid weight, priority or price for address %s", addr.Address)
+			continue
+		}
+		o := &weightedOrch{
+			uri:      uri,
+			weight:   addr.Weight,
+			priority: addr.Priority,
+			tags:     addr.Tags,
+		}
+		if addr.MaxPricePerUnit > 0 {
+			pixelsPerUnit := addr.PixelsPerUnit
+			if pixelsPerUnit == 0 {
+				pixelsPerUnit = 1
+			}
+			o.maxPrice = big.NewRat(addr.MaxPricePerUnit, pixelsPerUnit)
+		}
+		orchs = append(orchs, o)
 	}
 
-	return urls, nil
+	return orchs, denied, nil
+}
+
+// filterTags returns the orchestrators that do not have tags or have one of tags
+func filterTags(orchs []*weightedOrch, tags []string) []*weightedOrch {
+	if len(tags) == 0 {
+		return orchs
+	}
+
+	var filtered []*weightedOrch
+	for _, o := range orchs {
+		if len(o.tags) == 0 || hasTag(o.tags, tags) {
+			filtered = append(filtered, o)
+		}
+	}
+	return filtered
+}
+
+func hasTag(orchTags, tags []string) bool {
+	for _, ot := range orchTags {
+		for _, t := range tags {
+			if ot == t {
+				return true
+			}
+		}
+	}
+	return false
 }
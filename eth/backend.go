@@ -11,7 +11,6 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/golang/glog"
 	"github.com/livepeer/go-livepeer/eth/contracts"
 )
@@ -42,23 +41,26 @@ type Backend interface {
 }
 
 type backend struct {
-	*ethclient.Client
+	Backend
 	abiMap       map[string]*abi.ABI
 	nonceManager *NonceManager
 	signer       types.Signer
+	txManager    *TransactionManager
 }
 
-func NewBackend(client *ethclient.Client, signer types.Signer) (Backend, error) {
+// NewBackend creates a Backend that manages nonces and logs transactions for client,
+// which is an ethclient.Client for a single endpoint or a MultiClient
+func NewBackend(client Backend, signer types.Signer) (Backend, error) {
 	abiMap, err := makeABIMap()
 	if err != nil {
 		return nil, err
 	}
 
 	return &backend{
-		client,
-		abiMap,
-		NewNonceManager(client),
-		signer,
+		Backend:      client,
+		abiMap:       abiMap,
+		nonceManager: NewNonceManager(client),
+		signer:       signer,
 	}, nil
 }
 
@@ -70,7 +72,7 @@ func (b *backend) PendingNonceAt(ctx context.Context, account common.Address) (u
 }
 
 func (b *backend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
-	sendErr := b.Client.SendTransaction(ctx, tx)
+	sendErr := b.Backend.SendTransaction(ctx, tx)
 
 	// update local nonce
 	msg, err := tx.AsMessage(b.signer)
@@ -94,6 +96,10 @@ func (b *backend) SendTransaction(ctx context.Context, tx *types.Transaction) er
 
 	glog.Infof("\n%vEth Transaction%v\n\nInvoking transaction: \"%v\". Inputs: \"%v\"  Hash: \"%v\". \n\n%v\n", strings.Repeat("*", 30), strings.Repeat("*", 30), txLog.method, txLog.inputs, tx.Hash().String(), strings.Repeat("*", 75))
 
+	if b.txManager != nil {
+		b.txManager.Track(tx, sender, txLog.method)
+	}
+
 	return nil
 }
 
@@ -133,13 +139,13 @@ func (b *backend) newTxLog(tx *types.Transaction) (txLog, error) {
 
 func (b *backend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	return b.retryRemoteCall(func() ([]byte, error) {
-		return b.Client.CallContract(ctx, msg, blockNumber)
+		return b.Backend.CallContract(ctx, msg, blockNumber)
 	})
 }
 
 func (b *backend) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
 	return b.retryRemoteCall(func() ([]byte, error) {
-		return b.Client.PendingCallContract(ctx, msg)
+		return b.Backend.PendingCallContract(ctx, msg)
 	})
 }
 
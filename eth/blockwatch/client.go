@@ -23,10 +23,21 @@ type Client interface {
 	FilterLogs(q ethereum.FilterQuery) ([]types.Log, error)
 }
 
+// RPCCaller performs raw JSON-RPC calls. It is implemented by rpc.Client.
+type RPCCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// HeaderLogReader fetches headers and logs. It is implemented by ethclient.Client.
+type HeaderLogReader interface {
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
 // RPCClient is a Client for fetching Ethereum blocks from a specific JSON-RPC endpoint.
 type RPCClient struct {
-	rpcClient      *rpc.Client
-	client         *ethclient.Client
+	rpcClient      RPCCaller
+	client         HeaderLogReader
 	requestTimeout time.Duration
 }
 
@@ -44,6 +55,12 @@ func NewRPCClient(rpcURL string, requestTimeout time.Duration) (*RPCClient, erro
 	return &RPCClient{rpcClient: rpcClient, client: ethClient, requestTimeout: requestTimeout}, nil
 }
 
+// NewRPCClientWithCaller returns a new Client for fetching Ethereum blocks using
+// the given caller and reader, e.g. a client that fails over between multiple endpoints.
+func NewRPCClientWithCaller(rpcClient RPCCaller, client HeaderLogReader, requestTimeout time.Duration) *RPCClient {
+	return &RPCClient{rpcClient: rpcClient, client: client, requestTimeout: requestTimeout}
+}
+
 type getBlockByNumberResponse struct {
 	Hash       common.Hash `json:"hash"`
 	ParentHash common.Hash `json:"parentHash"`
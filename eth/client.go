@@ -26,7 +26,6 @@ import (
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/golang/glog"
 	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/eth/contracts"
@@ -114,6 +113,7 @@ type LivepeerEthClient interface {
 	Sign([]byte) ([]byte, error)
 	GetGasInfo() (uint64, *big.Int)
 	SetGasInfo(uint64, *big.Int) error
+	SetTransactionManager(*TransactionManager)
 }
 
 type client struct {
@@ -144,22 +144,33 @@ type client struct {
 	gasPrice *big.Int
 
 	txTimeout time.Duration
+
+	txManager *TransactionManager
 }
 
-func NewClient(accountAddr ethcommon.Address, keystoreDir string, eth *ethclient.Client, controllerAddr ethcommon.Address, txTimeout time.Duration) (LivepeerEthClient, error) {
+func NewClient(accountAddr ethcommon.Address, keystoreDir string, eth Backend, controllerAddr ethcommon.Address, txTimeout time.Duration) (LivepeerEthClient, error) {
 	chainID, err := eth.ChainID(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	signer := types.NewEIP155Signer(chainID)
+	am, err := NewAccountManager(accountAddr, keystoreDir, types.NewEIP155Signer(chainID))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientWithAccountManager(am, eth, controllerAddr, txTimeout)
+}
 
-	backend, err := NewBackend(eth, signer)
+// NewClientWithAccountManager creates a client that uses am to sign transactions
+// and messages, e.g. an account manager backed by a remote signer
+func NewClientWithAccountManager(am AccountManager, eth Backend, controllerAddr ethcommon.Address, txTimeout time.Duration) (LivepeerEthClient, error) {
+	chainID, err := eth.ChainID(context.Background())
 	if err != nil {
 		return nil, err
 	}
 
-	am, err := NewAccountManager(accountAddr, keystoreDir, signer)
+	backend, err := NewBackend(eth, types.NewEIP155Signer(chainID))
 	if err != nil {
 		return nil, err
 	}
@@ -735,11 +746,30 @@ func (c *client) ContractAddresses() map[string]ethcommon.Address {
 	return addrMap
 }
 
+// SetTransactionManager sets the TransactionManager that tracks all transactions
+// submitted by the client and that CheckTx waits on
+func (c *client) SetTransactionManager(tm *TransactionManager) {
+	c.txManager = tm
+	if b, ok := c.backend.(*backend); ok {
+		b.txManager = tm
+	}
+}
+
 func (c *client) CheckTx(tx *types.Transaction) error {
 	ctx, cancel := context.WithTimeout(context.Background(), c.txTimeout)
 	defer cancel()
 
-	receipt, err := bind.WaitMined(ctx, c.backend, tx)
+	var (
+		receipt *types.Receipt
+		err     = errTxNotTracked
+	)
+	// Wait for the transaction or any of its replacements to be mined
+	if c.txManager != nil {
+		receipt, err = c.txManager.Wait(ctx, tx.Hash())
+	}
+	if err == errTxNotTracked {
+		receipt, err = bind.WaitMined(ctx, c.backend, tx)
+	}
 	if err != nil {
 		return err
 	}
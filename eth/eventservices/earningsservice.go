@@ -0,0 +1,192 @@
+package eventservices
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/eth"
+)
+
+var (
+	ErrEarningsServiceStarted = fmt.Errorf("earnings service already started")
+	ErrEarningsServiceStopped = fmt.Errorf("earnings service already stopped")
+)
+
+// GasPricer returns the current gas price
+type GasPricer interface {
+	GasPrice() *big.Int
+}
+
+// EarningsServiceConfig describes when the earnings service claims earnings and withdraws fees
+type EarningsServiceConfig struct {
+	// PollingInterval is the interval at which earnings and fees are checked
+	PollingInterval time.Duration
+
+	// Earnings are claimed once at least MinClaimRounds rounds are unclaimed,
+	// through at most MaxClaimRounds rounds per check
+	MinClaimRounds int64
+	MaxClaimRounds int64
+
+	// Fees are withdrawn once they are at least FeeGasMultiplier times the cost
+	// of WithdrawGas at the current gas price
+	WithdrawGas      uint64
+	FeeGasMultiplier int64
+}
+
+// EarningsService periodically claims earnings in bounded chunks of rounds and
+// withdraws fees once they justify the gas cost of the withdrawal
+type EarningsService struct {
+	client       eth.LivepeerEthClient
+	gasPricer    GasPricer
+	cfg          EarningsServiceConfig
+	working      bool
+	cancelWorker context.CancelFunc
+}
+
+func NewEarningsService(client eth.LivepeerEthClient, gasPricer GasPricer, cfg EarningsServiceConfig) *EarningsService {
+	return &EarningsService{
+		client:    client,
+		gasPricer: gasPricer,
+		cfg:       cfg,
+	}
+}
+
+func (s *EarningsService) Start(ctx context.Context) error {
+	if s.working {
+		return ErrEarningsServiceStarted
+	}
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	s.cancelWorker = cancel
+
+	tickCh := time.NewTicker(s.cfg.PollingInterval).C
+
+	go func(ctx context.Context) {
+		for {
+			select {
+			case <-tickCh:
+				if err := s.tryClaimEarnings(); err != nil {
+					glog.Errorf("Error trying to claim earnings: %v", err)
+					continue
+				}
+				if err := s.tryWithdrawFees(); err != nil {
+					glog.Errorf("Error trying to withdraw fees: %v", err)
+				}
+			case <-ctx.Done():
+				glog.V(5).Infof("Earnings service done")
+				return
+			}
+		}
+	}(cancelCtx)
+
+	s.working = true
+
+	return nil
+}
+
+func (s *EarningsService) Stop() error {
+	if !s.working {
+		return ErrEarningsServiceStopped
+	}
+
+	s.cancelWorker()
+	s.working = false
+
+	return nil
+}
+
+func (s *EarningsService) IsWorking() bool {
+	return s.working
+}
+
+// unclaimedRounds returns the number of rounds that earnings have not been claimed for
+func (s *EarningsService) unclaimedRounds() (*big.Int, *big.Int, error) {
+	currentRound, err := s.client.CurrentRound()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d, err := s.client.GetDelegator(s.client.Account().Address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Earnings can only be claimed while bonded
+	if d.Status != "Bonded" {
+		return big.NewInt(0), d.LastClaimRound, nil
+	}
+
+	return new(big.Int).Sub(currentRound, d.LastClaimRound), d.LastClaimRound, nil
+}
+
+func (s *EarningsService) tryClaimEarnings() error {
+	unclaimed, lastClaimRound, err := s.unclaimedRounds()
+	if err != nil {
+		return err
+	}
+
+	if unclaimed.Sign() <= 0 || unclaimed.Cmp(big.NewInt(s.cfg.MinClaimRounds)) < 0 {
+		return nil
+	}
+
+	// Bound the number of rounds claimed through at once to bound the gas cost
+	endRound := new(big.Int).Add(lastClaimRound, unclaimed)
+	if unclaimed.Cmp(big.NewInt(s.cfg.MaxClaimRounds)) > 0 {
+		endRound = new(big.Int).Add(lastClaimRound, big.NewInt(s.cfg.MaxClaimRounds))
+	}
+
+	if err := s.client.ClaimEarnings(endRound); err != nil {
+		return err
+	}
+
+	glog.Infof("Claimed earnings from round %v through %v", lastClaimRound, endRound)
+
+	return nil
+}
+
+func (s *EarningsService) tryWithdrawFees() error {
+	unclaimed, _, err := s.unclaimedRounds()
+	if err != nil {
+		return err
+	}
+
+	// Withdrawing fees claims earnings through the current round so wait until
+	// the remaining rounds can be claimed in a single chunk
+	if unclaimed.Cmp(big.NewInt(s.cfg.MaxClaimRounds)) > 0 {
+		return nil
+	}
+
+	d, err := s.client.GetDelegator(s.client.Account().Address)
+	if err != nil {
+		return err
+	}
+
+	if d.PendingFees == nil || d.PendingFees.Sign() <= 0 {
+		return nil
+	}
+
+	gasPrice := s.gasPricer.GasPrice()
+	minFees := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(s.cfg.WithdrawGas))
+	minFees.Mul(minFees, big.NewInt(s.cfg.FeeGasMultiplier))
+	if d.PendingFees.Cmp(minFees) < 0 {
+		glog.V(common.DEBUG).Infof("Not withdrawing fees - fees %v are below %v at gas price %v", eth.FormatUnits(d.PendingFees, "ETH"), eth.FormatUnits(minFees, "ETH"), gasPrice)
+		return nil
+	}
+
+	tx, err := s.client.WithdrawFees()
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.CheckTx(tx); err != nil {
+		return err
+	}
+
+	glog.Infof("Withdrew fees %v", eth.FormatUnits(d.PendingFees, "ETH"))
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package eventservices
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/livepeer/go-livepeer/eth"
+	lpTypes "github.com/livepeer/go-livepeer/eth/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type earningsClient struct {
+	*eth.StubClient
+	round        *big.Int
+	delegator    *lpTypes.Delegator
+	delegatorErr error
+	claimErr     error
+	claims       []*big.Int
+	withdrawals  int
+}
+
+func (c *earningsClient) CurrentRound() (*big.Int, error) { return c.round, nil }
+
+func (c *earningsClient) GetDelegator(addr common.Address) (*lpTypes.Delegator, error) {
+	if c.delegatorErr != nil {
+		return nil, c.delegatorErr
+	}
+	d := *c.delegator
+	return &d, nil
+}
+
+func (c *earningsClient) ClaimEarnings(endRound *big.Int) error {
+	if c.claimErr != nil {
+		return c.claimErr
+	}
+	c.claims = append(c.claims, endRound)
+	c.delegator.LastClaimRound = endRound
+	return nil
+}
+
+func (c *earningsClient) WithdrawFees() (*types.Transaction, error) {
+	c.withdrawals++
+	c.delegator.PendingFees = big.NewInt(0)
+	return nil, nil
+}
+
+type stubGasPricer struct {
+	gasPrice *big.Int
+}
+
+func (g *stubGasPricer) GasPrice() *big.Int { return g.gasPrice }
+
+func newEarningsClient() *earningsClient {
+	return &earningsClient{
+		StubClient: &eth.StubClient{},
+		round:      big.NewInt(100),
+		delegator: &lpTypes.Delegator{
+			Status:         "Bonded",
+			LastClaimRound: big.NewInt(70),
+			PendingFees:    big.NewInt(0),
+		},
+	}
+}
+
+func TestEarningsService_ClaimEarnings(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	client := newEarningsClient()
+	s := NewEarningsService(client, &stubGasPricer{big.NewInt(1)}, EarningsServiceConfig{MinClaimRounds: 10, MaxClaimRounds: 20})
+
+	// Claims are bounded by MaxClaimRounds
+	require.Nil(s.tryClaimEarnings())
+	require.Len(client.claims, 1)
+	assert.Equal(big.NewInt(90), client.claims[0])
+
+	require.Nil(s.tryClaimEarnings())
+	require.Len(client.claims, 2)
+	assert.Equal(big.NewInt(100), client.claims[1])
+
+	// Fewer than MinClaimRounds unclaimed rounds are not claimed
+	client.round = big.NewInt(105)
+	require.Nil(s.tryClaimEarnings())
+	assert.Len(client.claims, 2)
+
+	client.round = big.NewInt(110)
+	require.Nil(s.tryClaimEarnings())
+	require.Len(client.claims, 3)
+	assert.Equal(big.NewInt(110), client.claims[2])
+
+	// Earnings are not claimed unless bonded
+	client.round = big.NewInt(200)
+	client.delegator.Status = "Unbonded"
+	require.Nil(s.tryClaimEarnings())
+	assert.Len(client.claims, 3)
+
+	client.delegator.Status = "Bonded"
+	client.claimErr = errors.New("ClaimEarnings error")
+	assert.EqualError(s.tryClaimEarnings(), "ClaimEarnings error")
+
+	client.delegatorErr = errors.New("GetDelegator error")
+	assert.EqualError(s.tryClaimEarnings(), "GetDelegator error")
+}
+
+func TestEarningsService_WithdrawFees(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	client := newEarningsClient()
+	gp := &stubGasPricer{big.NewInt(10)}
+	s := NewEarningsService(client, gp, EarningsServiceConfig{MinClaimRounds: 1, MaxClaimRounds: 20, WithdrawGas: 1000, FeeGasMultiplier: 5})
+
+	// No fees
+	require.Nil(s.tryWithdrawFees())
+	assert.Zero(client.withdrawals)
+
+	// Fees below the gas cost times the multiplier
+	client.delegator.PendingFees = big.NewInt(49999)
+	require.Nil(s.tryWithdrawFees())
+	assert.Zero(client.withdrawals)
+
+	// Too many unclaimed rounds to withdraw in a single claim
+	client.delegator.PendingFees = big.NewInt(50000)
+	require.Nil(s.tryWithdrawFees())
+	assert.Zero(client.withdrawals)
+
+	client.delegator.LastClaimRound = big.NewInt(80)
+	require.Nil(s.tryWithdrawFees())
+	assert.Equal(1, client.withdrawals)
+
+	// A higher gas price raises the minimum fees
+	gp.gasPrice = big.NewInt(20)
+	client.delegator.PendingFees = big.NewInt(50000)
+	require.Nil(s.tryWithdrawFees())
+	assert.Equal(1, client.withdrawals)
+}
+
+func TestEarningsService_StartStop(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewEarningsService(newEarningsClient(), &stubGasPricer{big.NewInt(1)}, EarningsServiceConfig{PollingInterval: time.Hour})
+	assert.False(s.IsWorking())
+	assert.Nil(s.Start(context.Background()))
+	assert.True(s.IsWorking())
+	assert.Equal(ErrEarningsServiceStarted, s.Start(context.Background()))
+
+	assert.Nil(s.Stop())
+	assert.False(s.IsWorking())
+	assert.Equal(ErrEarningsServiceStopped, s.Stop())
+}
@@ -17,6 +17,7 @@ var (
 
 type RewardService struct {
 	client          eth.LivepeerEthClient
+	txManager       *eth.TransactionManager
 	pendingTx       *types.Transaction
 	working         bool
 	cancelWorker    context.CancelFunc
@@ -30,6 +31,12 @@ func NewRewardService(client eth.LivepeerEthClient, pollingInterval time.Duratio
 	}
 }
 
+// SetTransactionManager sets the TransactionManager that replaces the reward transaction when
+// it is not mined in time. The service then waits for the pending transaction instead of replacing it
+func (s *RewardService) SetTransactionManager(tm *eth.TransactionManager) {
+	s.txManager = tm
+}
+
 func (s *RewardService) Start(ctx context.Context) error {
 	if s.working {
 		return ErrRewardServiceStarted
@@ -102,7 +109,11 @@ func (s *RewardService) tryReward() error {
 			err error
 		)
 
-		if s.pendingTx != nil {
+		if s.pendingTx != nil && s.txManager != nil {
+			// Previous attempt to call reward() still pending
+			// The transaction manager replaces it so wait for it or its replacement
+			tx = s.pendingTx
+		} else if s.pendingTx != nil {
 			// Previous attempt to call reward() still pending
 			// Replace pending tx by bumping gas price
 			tx, err = s.client.ReplaceTransaction(s.pendingTx, "reward", nil)
@@ -130,6 +141,9 @@ func (s *RewardService) tryReward() error {
 				// Tx did not confirm within defined time window
 				// Store pending tx
 				s.pendingTx = tx
+			} else if err == eth.ErrTxDropped {
+				// The nonce was used by another tx so call reward() again next time
+				s.pendingTx = nil
 			}
 
 			return err
@@ -29,10 +29,12 @@ type GasPriceMonitor struct {
 	// pollingMu protects access to polling related fields
 	pollingMu sync.Mutex
 
-	// gasPriceMu protects access to gasPrice
+	// gasPriceMu protects access to gasPrice and lastUpdate
 	gasPriceMu sync.RWMutex
 	// gasPrice is the current gas price to be returned to users
 	gasPrice *big.Int
+	// lastUpdate is the time of the last successful gas price fetch
+	lastUpdate time.Time
 
 	// update is a channel used to send notifications to a listener
 	// when the gas price is updated
@@ -56,6 +58,20 @@ func (gpm *GasPriceMonitor) GasPrice() *big.Int {
 	return gpm.gasPrice
 }
 
+// LastUpdated returns the time of the last successful gas price fetch.
+// The zero time is returned if the gas price has never been fetched
+func (gpm *GasPriceMonitor) LastUpdated() time.Time {
+	gpm.gasPriceMu.RLock()
+	defer gpm.gasPriceMu.RUnlock()
+
+	return gpm.lastUpdate
+}
+
+// PollingInterval returns the interval at which the gas price is polled
+func (gpm *GasPriceMonitor) PollingInterval() time.Duration {
+	return gpm.pollingInterval
+}
+
 // Start starts polling for gas price updates and returns a channel to receive
 // notifications of gas price changes
 func (gpm *GasPriceMonitor) Start(ctx context.Context) (chan struct{}, error) {
@@ -141,4 +157,5 @@ func (gpm *GasPriceMonitor) updateGasPrice(gasPrice *big.Int) {
 	defer gpm.gasPriceMu.Unlock()
 
 	gpm.gasPrice = gasPrice
+	gpm.lastUpdate = time.Now()
 }
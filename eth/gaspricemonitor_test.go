@@ -61,6 +61,8 @@ func TestStart(t *testing.T) {
 	gpm := NewGasPriceMonitor(gpo, 1*time.Hour)
 
 	assert := assert.New(t)
+	assert.True(gpm.LastUpdated().IsZero())
+	assert.Equal(1*time.Hour, gpm.PollingInterval())
 
 	// Test error from first attempt to fetch gas price
 
@@ -81,6 +83,8 @@ func TestStart(t *testing.T) {
 	defer gpm.Stop()
 
 	assert.Equal(gasPrice, gpm.GasPrice())
+	assert.False(gpm.LastUpdated().IsZero())
+	assert.True(time.Since(gpm.LastUpdated()) < time.Minute)
 
 	// Test error when already polling
 
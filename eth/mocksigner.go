@@ -0,0 +1,123 @@
+package eth
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errMockSignerRejected = errors.New("request denied")
+
+// MockSigner is a local remote signer for testing that serves the account_list,
+// account_signTransaction and account_signData requests of clef over HTTP
+type MockSigner struct {
+	URL string
+
+	// Reject causes the signer to deny all signing requests
+	Reject bool
+
+	key    *ecdsa.PrivateKey
+	signer types.Signer
+	server *httptest.Server
+}
+
+// NewMockSigner starts a MockSigner that signs with a new key using signer
+func NewMockSigner(signer types.Signer) (*MockSigner, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MockSigner{
+		key:    key,
+		signer: signer,
+	}
+
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("account", &mockSignerAPI{m}); err != nil {
+		return nil, err
+	}
+	m.server = httptest.NewServer(rpcServer)
+	m.URL = m.server.URL
+
+	return m, nil
+}
+
+// Address returns the address of the account of the signer
+func (m *MockSigner) Address() ethcommon.Address {
+	return crypto.PubkeyToAddress(m.key.PublicKey)
+}
+
+// Close stops the signer
+func (m *MockSigner) Close() {
+	m.server.Close()
+}
+
+type mockSignerAPI struct {
+	m *MockSigner
+}
+
+func (api *mockSignerAPI) List() []ethcommon.Address {
+	return []ethcommon.Address{api.m.Address()}
+}
+
+// SignTransaction uses types that the rpc server accepts as the unexported request
+// types are not registered as methods
+func (api *mockSignerAPI) SignTransaction(rawArgs json.RawMessage) (map[string]hexutil.Bytes, error) {
+	if api.m.Reject {
+		return nil, errMockSignerRejected
+	}
+
+	var args remoteSignTxArgs
+	if err := json.Unmarshal(rawArgs, &args); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if args.Data != nil {
+		data = *args.Data
+	}
+	var tx *types.Transaction
+	if args.To == nil {
+		tx = types.NewContractCreation(uint64(args.Nonce), args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), data)
+	} else {
+		tx = types.NewTransaction(uint64(args.Nonce), args.To.Address(), args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), data)
+	}
+
+	signed, err := types.SignTx(tx, api.m.signer, api.m.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]hexutil.Bytes{"raw": raw}, nil
+}
+
+func (api *mockSignerAPI) SignData(contentType string, addr ethcommon.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if api.m.Reject {
+		return nil, errMockSignerRejected
+	}
+	if contentType != accounts.MimetypeTextPlain || addr.Address() != api.m.Address() {
+		return nil, errMockSignerRejected
+	}
+
+	sig, err := crypto.Sign(accounts.TextHash(data), api.m.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+
+	return sig, nil
+}
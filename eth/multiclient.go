@@ -0,0 +1,474 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/glog"
+)
+
+// JSON-RPC error code used by providers to indicate that a request was rate limited
+const rateLimitErrCode = -32005
+
+var errNoEndpoints = errors.New("no Ethereum RPC endpoints available")
+
+// MultiClientConfig contains the parameters for health checking the endpoints of a MultiClient
+type MultiClientConfig struct {
+	// Interval at which the endpoints are health checked
+	HealthCheckInterval time.Duration
+	// Timeout for a health check of an endpoint
+	HealthCheckTimeout time.Duration
+	// Number of blocks that an endpoint can be behind the highest block seen
+	// by any endpoint before it is considered unhealthy
+	MaxBlockLag uint64
+}
+
+type rpcEndpoint struct {
+	url    string
+	rpc    *rpc.Client
+	client *ethclient.Client
+
+	// The following fields are protected by the MultiClient mutex
+	healthy bool
+	head    uint64
+	latency time.Duration
+}
+
+// MultiClient is a Backend that is backed by multiple Ethereum JSON-RPC endpoints.
+// Reads are routed to the healthiest endpoint and fail over to the next endpoint
+// if an endpoint is unavailable or rate limits requests. Transaction submission and
+// reads of pending state are pinned to a single endpoint at a time so that the
+// transactions of the node are visible to its nonce and pending state reads
+type MultiClient struct {
+	endpoints []*rpcEndpoint
+	cfg       MultiClientConfig
+
+	mu sync.RWMutex
+	// pinned is the endpoint used to submit transactions
+	pinned *rpcEndpoint
+
+	pollingMu sync.Mutex
+	cancel    context.CancelFunc
+}
+
+// NewMultiClient creates a MultiClient for the endpoints at urls. All endpoints are
+// initially considered healthy until they are health checked
+func NewMultiClient(urls []string, cfg MultiClientConfig) (*MultiClient, error) {
+	m := &MultiClient{cfg: cfg}
+	for _, url := range urls {
+		c, err := rpc.Dial(url)
+		if err != nil {
+			glog.Errorf("Unable to connect to Ethereum RPC endpoint url=%v err=%v", url, err)
+			continue
+		}
+		m.endpoints = append(m.endpoints, &rpcEndpoint{
+			url:     url,
+			rpc:     c,
+			client:  ethclient.NewClient(c),
+			healthy: true,
+		})
+	}
+	if len(m.endpoints) == 0 {
+		return nil, errNoEndpoints
+	}
+	m.pinned = m.endpoints[0]
+
+	return m, nil
+}
+
+// Start health checks the endpoints and starts checking them periodically. An
+// error is returned if the endpoints are connected to different chains
+func (m *MultiClient) Start(ctx context.Context) error {
+	m.pollingMu.Lock()
+	defer m.pollingMu.Unlock()
+
+	if m.cancel != nil {
+		return errors.New("already polling")
+	}
+
+	if err := m.checkChainIDs(ctx); err != nil {
+		return err
+	}
+	m.checkHealth(ctx)
+
+	cctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(m.cfg.HealthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.checkHealth(cctx)
+			case <-cctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops health checking the endpoints
+func (m *MultiClient) Stop() error {
+	m.pollingMu.Lock()
+	defer m.pollingMu.Unlock()
+
+	if m.cancel == nil {
+		return errors.New("not polling")
+	}
+
+	m.cancel()
+	m.cancel = nil
+
+	return nil
+}
+
+func (m *MultiClient) checkChainIDs(ctx context.Context) error {
+	var expected *big.Int
+	var expectedURL string
+	for _, e := range m.endpoints {
+		cctx, cancel := context.WithTimeout(ctx, m.cfg.HealthCheckTimeout)
+		chainID, err := e.client.ChainID(cctx)
+		cancel()
+		if err != nil {
+			// The endpoint is marked as unhealthy by the health check
+			continue
+		}
+		if expected == nil {
+			expected, expectedURL = chainID, e.url
+		} else if expected.Cmp(chainID) != 0 {
+			return fmt.Errorf("Ethereum RPC endpoint %v has chainID=%v but %v has chainID=%v", e.url, chainID, expectedURL, expected)
+		}
+	}
+	return nil
+}
+
+// checkHealth fetches the latest block number of every endpoint. Endpoints that
+// fail or lag behind the highest block number are marked as unhealthy
+func (m *MultiClient) checkHealth(ctx context.Context) {
+	type result struct {
+		head    uint64
+		latency time.Duration
+		err     error
+	}
+
+	results := make([]result, len(m.endpoints))
+	var wg sync.WaitGroup
+	for i, e := range m.endpoints {
+		wg.Add(1)
+		go func(i int, e *rpcEndpoint) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, m.cfg.HealthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			var head hexutil.Uint64
+			err := e.rpc.CallContext(cctx, &head, "eth_blockNumber")
+			results[i] = result{head: uint64(head), latency: time.Since(start), err: err}
+		}(i, e)
+	}
+	wg.Wait()
+
+	var maxHead uint64
+	for _, res := range results {
+		if res.err == nil && res.head > maxHead {
+			maxHead = res.head
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, e := range m.endpoints {
+		res := results[i]
+		healthy := res.err == nil && res.head+m.cfg.MaxBlockLag >= maxHead
+		if healthy != e.healthy {
+			if healthy {
+				glog.Infof("Ethereum RPC endpoint is healthy url=%v head=%v", e.url, res.head)
+			} else {
+				glog.Errorf("Ethereum RPC endpoint is unhealthy url=%v head=%v maxHead=%v err=%v", e.url, res.head, maxHead, res.err)
+			}
+		}
+		e.healthy = healthy
+		if res.err == nil {
+			e.head = res.head
+			e.latency = res.latency
+		}
+	}
+
+	if !m.pinned.healthy {
+		m.repin()
+	}
+}
+
+// ranked returns the endpoints ordered from the healthiest to the least healthy.
+// Healthy endpoints are ordered by the highest block and then the lowest latency.
+// Unhealthy endpoints are still returned last in case they recovered
+func (m *MultiClient) ranked() []*rpcEndpoint {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ranked := make([]*rpcEndpoint, len(m.endpoints))
+	copy(ranked, m.endpoints)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.healthy != b.healthy {
+			return a.healthy
+		}
+		if a.head != b.head {
+			return a.head > b.head
+		}
+		return a.latency < b.latency
+	})
+	return ranked
+}
+
+// pinnedFirst returns the endpoints with the pinned endpoint first followed by
+// the other endpoints ordered by health
+func (m *MultiClient) pinnedFirst() []*rpcEndpoint {
+	m.mu.RLock()
+	pinned := m.pinned
+	m.mu.RUnlock()
+
+	endpoints := []*rpcEndpoint{pinned}
+	for _, e := range m.ranked() {
+		if e != pinned {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// repin pins transaction submission to the healthiest endpoint. The caller must hold the mutex
+func (m *MultiClient) repin() {
+	var best *rpcEndpoint
+	for _, e := range m.endpoints {
+		if !e.healthy {
+			continue
+		}
+		if best == nil || e.head > best.head || (e.head == best.head && e.latency < best.latency) {
+			best = e
+		}
+	}
+	if best == nil || best == m.pinned {
+		return
+	}
+
+	glog.Infof("Pinning Ethereum transaction submission to RPC endpoint url=%v previous=%v", best.url, m.pinned.url)
+	m.pinned = best
+}
+
+// markUnhealthy marks an endpoint that failed a request as unhealthy until it
+// passes the next health check
+func (m *MultiClient) markUnhealthy(e *rpcEndpoint, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e.healthy {
+		glog.Errorf("Ethereum RPC endpoint failed url=%v err=%v", e.url, err)
+	}
+	e.healthy = false
+	if e == m.pinned {
+		m.repin()
+	}
+}
+
+// PinnedURL returns the URL of the endpoint used to submit transactions
+func (m *MultiClient) PinnedURL() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.pinned.url
+}
+
+// call runs fn against the endpoints in order until it succeeds or fails with
+// an error that is not caused by the endpoint being unavailable
+func (m *MultiClient) call(ctx context.Context, endpoints []*rpcEndpoint, fn func(*rpcEndpoint) error) error {
+	err := errNoEndpoints
+	for _, e := range endpoints {
+		err = fn(e)
+		if !shouldFailover(err) || ctx.Err() != nil {
+			return err
+		}
+		m.markUnhealthy(e, err)
+	}
+	return err
+}
+
+func (m *MultiClient) read(ctx context.Context, fn func(*ethclient.Client) error) error {
+	return m.call(ctx, m.ranked(), func(e *rpcEndpoint) error { return fn(e.client) })
+}
+
+func (m *MultiClient) pending(ctx context.Context, fn func(*ethclient.Client) error) error {
+	return m.call(ctx, m.pinnedFirst(), func(e *rpcEndpoint) error { return fn(e.client) })
+}
+
+// shouldFailover returns whether err indicates that the endpoint is unavailable
+// rather than a failure of the request itself
+func shouldFailover(err error) bool {
+	if err == nil || err == ethereum.NotFound || err == context.Canceled {
+		return false
+	}
+	if rpcErr, ok := err.(rpc.Error); ok {
+		return rpcErr.ErrorCode() == rateLimitErrCode
+	}
+	return true
+}
+
+// CallContext performs a raw JSON-RPC call against the healthiest endpoint
+func (m *MultiClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return m.call(ctx, m.ranked(), func(e *rpcEndpoint) error {
+		return e.rpc.CallContext(ctx, result, method, args...)
+	})
+}
+
+// SendTransaction submits a transaction to the pinned endpoint
+func (m *MultiClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return m.pending(ctx, func(c *ethclient.Client) error { return c.SendTransaction(ctx, tx) })
+}
+
+func (m *MultiClient) ChainID(ctx context.Context) (id *big.Int, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { id, err = c.ChainID(ctx); return })
+	return
+}
+
+func (m *MultiClient) BlockByHash(ctx context.Context, hash common.Hash) (b *types.Block, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { b, err = c.BlockByHash(ctx, hash); return })
+	return
+}
+
+func (m *MultiClient) BlockByNumber(ctx context.Context, number *big.Int) (b *types.Block, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { b, err = c.BlockByNumber(ctx, number); return })
+	return
+}
+
+func (m *MultiClient) HeaderByHash(ctx context.Context, hash common.Hash) (h *types.Header, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { h, err = c.HeaderByHash(ctx, hash); return })
+	return
+}
+
+func (m *MultiClient) HeaderByNumber(ctx context.Context, number *big.Int) (h *types.Header, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { h, err = c.HeaderByNumber(ctx, number); return })
+	return
+}
+
+func (m *MultiClient) TransactionCount(ctx context.Context, blockHash common.Hash) (n uint, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { n, err = c.TransactionCount(ctx, blockHash); return })
+	return
+}
+
+func (m *MultiClient) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (tx *types.Transaction, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { tx, err = c.TransactionInBlock(ctx, blockHash, index); return })
+	return
+}
+
+// TransactionByHash is read from the pinned endpoint because pending transactions
+// might not have propagated to other endpoints
+func (m *MultiClient) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { tx, isPending, err = c.TransactionByHash(ctx, hash); return })
+	return
+}
+
+func (m *MultiClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (r *types.Receipt, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { r, err = c.TransactionReceipt(ctx, txHash); return })
+	return
+}
+
+func (m *MultiClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (b *big.Int, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { b, err = c.BalanceAt(ctx, account, blockNumber); return })
+	return
+}
+
+func (m *MultiClient) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) (b []byte, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { b, err = c.StorageAt(ctx, account, key, blockNumber); return })
+	return
+}
+
+func (m *MultiClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) (b []byte, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { b, err = c.CodeAt(ctx, account, blockNumber); return })
+	return
+}
+
+func (m *MultiClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (n uint64, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { n, err = c.NonceAt(ctx, account, blockNumber); return })
+	return
+}
+
+func (m *MultiClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) (logs []types.Log, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { logs, err = c.FilterLogs(ctx, q); return })
+	return
+}
+
+// SubscribeFilterLogs subscribes on the healthiest endpoint. The subscription does
+// not fail over and the caller must resubscribe if it fails
+func (m *MultiClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (sub ethereum.Subscription, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { sub, err = c.SubscribeFilterLogs(ctx, q, ch); return })
+	return
+}
+
+// SubscribeNewHead subscribes on the healthiest endpoint. The subscription does
+// not fail over and the caller must resubscribe if it fails
+func (m *MultiClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (sub ethereum.Subscription, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { sub, err = c.SubscribeNewHead(ctx, ch); return })
+	return
+}
+
+func (m *MultiClient) PendingBalanceAt(ctx context.Context, account common.Address) (b *big.Int, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { b, err = c.PendingBalanceAt(ctx, account); return })
+	return
+}
+
+func (m *MultiClient) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) (b []byte, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { b, err = c.PendingStorageAt(ctx, account, key); return })
+	return
+}
+
+func (m *MultiClient) PendingCodeAt(ctx context.Context, account common.Address) (b []byte, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { b, err = c.PendingCodeAt(ctx, account); return })
+	return
+}
+
+func (m *MultiClient) PendingNonceAt(ctx context.Context, account common.Address) (n uint64, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { n, err = c.PendingNonceAt(ctx, account); return })
+	return
+}
+
+func (m *MultiClient) PendingTransactionCount(ctx context.Context) (n uint, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { n, err = c.PendingTransactionCount(ctx); return })
+	return
+}
+
+func (m *MultiClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (b []byte, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { b, err = c.CallContract(ctx, msg, blockNumber); return })
+	return
+}
+
+func (m *MultiClient) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) (b []byte, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { b, err = c.PendingCallContract(ctx, msg); return })
+	return
+}
+
+func (m *MultiClient) SuggestGasPrice(ctx context.Context) (p *big.Int, err error) {
+	err = m.read(ctx, func(c *ethclient.Client) (err error) { p, err = c.SuggestGasPrice(ctx); return })
+	return
+}
+
+func (m *MultiClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (gas uint64, err error) {
+	err = m.pending(ctx, func(c *ethclient.Client) (err error) { gas, err = c.EstimateGas(ctx, msg); return })
+	return
+}
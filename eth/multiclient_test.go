@@ -0,0 +1,308 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rpcCodeError struct {
+	code int
+}
+
+func (e rpcCodeError) Error() string  { return "rpc error" }
+func (e rpcCodeError) ErrorCode() int { return e.code }
+
+// fakeEthAPI implements the eth namespace methods used by the tests
+type fakeEthAPI struct {
+	mu       sync.Mutex
+	head     uint64
+	chainID  int64
+	balance  int64
+	nonce    uint64
+	callErr  error
+	sent     []common.Hash
+	requests map[string]int
+}
+
+func (api *fakeEthAPI) count(method string) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.requests[method]++
+}
+
+func (api *fakeEthAPI) BlockNumber() hexutil.Uint64 {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return hexutil.Uint64(api.head)
+}
+
+func (api *fakeEthAPI) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(api.chainID))
+}
+
+func (api *fakeEthAPI) GetBalance(addr common.Address, block string) *hexutil.Big {
+	api.count("eth_getBalance")
+	return (*hexutil.Big)(big.NewInt(api.balance))
+}
+
+func (api *fakeEthAPI) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 {
+	api.count("eth_getTransactionCount")
+	return hexutil.Uint64(api.nonce)
+}
+
+func (api *fakeEthAPI) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	api.count("eth_call")
+	return hexutil.Bytes{}, api.callErr
+}
+
+func (api *fakeEthAPI) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	api.count("eth_sendRawTransaction")
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return common.Hash{}, err
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.sent = append(api.sent, tx.Hash())
+	return tx.Hash(), nil
+}
+
+// fakeEthServer is a local JSON-RPC server that can be taken down or rate limited
+type fakeEthServer struct {
+	*httptest.Server
+	api *fakeEthAPI
+
+	mu     sync.Mutex
+	status int
+}
+
+func newFakeEthServer(t *testing.T, head uint64, balance int64) *fakeEthServer {
+	api := &fakeEthAPI{head: head, chainID: 1337, balance: balance, requests: make(map[string]int)}
+	rpcServer := rpc.NewServer()
+	require.Nil(t, rpcServer.RegisterName("eth", api))
+
+	s := &fakeEthServer{api: api}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status := s.status
+		s.mu.Unlock()
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		rpcServer.ServeHTTP(w, r)
+	}))
+	return s
+}
+
+// setStatus makes the server fail all requests with status or recover if status is 0
+func (s *fakeEthServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *fakeEthServer) requests(method string) int {
+	s.api.mu.Lock()
+	defer s.api.mu.Unlock()
+	return s.api.requests[method]
+}
+
+func newTestMultiClient(t *testing.T, servers ...*fakeEthServer) *MultiClient {
+	var urls []string
+	for _, s := range servers {
+		urls = append(urls, s.URL)
+	}
+	m, err := NewMultiClient(urls, MultiClientConfig{HealthCheckInterval: time.Hour, HealthCheckTimeout: time.Second, MaxBlockLag: 1})
+	require.Nil(t, err)
+	return m
+}
+
+func TestMultiClient_ReadsHealthiest(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	lagging := newFakeEthServer(t, 90, 1)
+	defer lagging.Close()
+	behind := newFakeEthServer(t, 99, 2)
+	defer behind.Close()
+	best := newFakeEthServer(t, 100, 3)
+	defer best.Close()
+
+	m := newTestMultiClient(t, lagging, behind, best)
+	require.Nil(m.Start(context.Background()))
+	defer m.Stop()
+
+	// Reads go to the endpoint with the highest block
+	balance, err := m.BalanceAt(context.Background(), common.Address{}, nil)
+	require.Nil(err)
+	assert.Equal(big.NewInt(3), balance)
+
+	// Fail over to the next healthiest endpoint if the endpoint is down
+	best.setStatus(http.StatusServiceUnavailable)
+	balance, err = m.BalanceAt(context.Background(), common.Address{}, nil)
+	require.Nil(err)
+	assert.Equal(big.NewInt(2), balance)
+	assert.Equal(1, best.requests("eth_getBalance"))
+
+	// The failed endpoint is not used until it passes a health check
+	best.setStatus(0)
+	balance, err = m.BalanceAt(context.Background(), common.Address{}, nil)
+	require.Nil(err)
+	assert.Equal(big.NewInt(2), balance)
+	assert.Equal(1, best.requests("eth_getBalance"))
+
+	m.checkHealth(context.Background())
+	balance, err = m.BalanceAt(context.Background(), common.Address{}, nil)
+	require.Nil(err)
+	assert.Equal(big.NewInt(3), balance)
+
+	// Fail over if the endpoint rate limits requests
+	best.setStatus(http.StatusTooManyRequests)
+	balance, err = m.BalanceAt(context.Background(), common.Address{}, nil)
+	require.Nil(err)
+	assert.Equal(big.NewInt(2), balance)
+
+	// Lagging endpoints are used if all other endpoints fail
+	behind.setStatus(http.StatusServiceUnavailable)
+	balance, err = m.BalanceAt(context.Background(), common.Address{}, nil)
+	require.Nil(err)
+	assert.Equal(big.NewInt(1), balance)
+
+	lagging.setStatus(http.StatusServiceUnavailable)
+	_, err = m.BalanceAt(context.Background(), common.Address{}, nil)
+	require.NotNil(err)
+	assert.Contains(err.Error(), "503 Service Unavailable")
+}
+
+func TestMultiClient_RequestErrorsDoNotFailOver(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	s1 := newFakeEthServer(t, 100, 1)
+	defer s1.Close()
+	s2 := newFakeEthServer(t, 100, 2)
+	defer s2.Close()
+
+	m := newTestMultiClient(t, s1, s2)
+
+	s1.api.callErr = errors.New("execution reverted")
+	s2.api.callErr = errors.New("execution reverted")
+	_, err := m.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+	assert.EqualError(err, "execution reverted")
+	assert.Equal(1, s1.requests("eth_call")+s2.requests("eth_call"))
+
+	// Both endpoints are still healthy
+	for _, e := range m.endpoints {
+		assert.True(e.healthy)
+	}
+
+	// Raw calls fail over too
+	s1.setStatus(http.StatusServiceUnavailable)
+	s2.setStatus(http.StatusServiceUnavailable)
+	var head hexutil.Uint64
+	err = m.CallContext(context.Background(), &head, "eth_blockNumber")
+	require.NotNil(err)
+	s2.setStatus(0)
+	m.checkHealth(context.Background())
+	require.Nil(m.CallContext(context.Background(), &head, "eth_blockNumber"))
+	assert.Equal(hexutil.Uint64(100), head)
+}
+
+func TestMultiClient_PinsTransactions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	s1 := newFakeEthServer(t, 100, 1)
+	defer s1.Close()
+	s2 := newFakeEthServer(t, 100, 2)
+	defer s2.Close()
+	s1.api.nonce = 5
+	s2.api.nonce = 3
+
+	m := newTestMultiClient(t, s1, s2)
+	assert.Equal(s1.URL, m.PinnedURL())
+
+	tx1 := types.NewTransaction(5, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	require.Nil(m.SendTransaction(context.Background(), tx1))
+	nonce, err := m.PendingNonceAt(context.Background(), common.Address{})
+	require.Nil(err)
+	assert.Equal(uint64(5), nonce)
+
+	// Transaction submission moves to another endpoint if the pinned endpoint fails
+	s1.setStatus(http.StatusServiceUnavailable)
+	tx2 := types.NewTransaction(6, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	require.Nil(m.SendTransaction(context.Background(), tx2))
+	assert.Equal(s2.URL, m.PinnedURL())
+	assert.Equal([]common.Hash{tx1.Hash()}, s1.api.sent)
+	assert.Equal([]common.Hash{tx2.Hash()}, s2.api.sent)
+
+	// Transaction submission stays pinned after the previous endpoint recovers
+	s1.setStatus(0)
+	m.checkHealth(context.Background())
+	tx3 := types.NewTransaction(7, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	require.Nil(m.SendTransaction(context.Background(), tx3))
+	assert.Equal(s2.URL, m.PinnedURL())
+	assert.Equal([]common.Hash{tx2.Hash(), tx3.Hash()}, s2.api.sent)
+	nonce, err = m.PendingNonceAt(context.Background(), common.Address{})
+	require.Nil(err)
+	assert.Equal(uint64(3), nonce)
+
+	// The pinned endpoint moves if it fails a health check
+	s2.api.mu.Lock()
+	s2.api.head = 90
+	s2.api.mu.Unlock()
+	m.checkHealth(context.Background())
+	assert.Equal(s1.URL, m.PinnedURL())
+}
+
+func TestMultiClient_Start(t *testing.T) {
+	assert := assert.New(t)
+
+	s1 := newFakeEthServer(t, 100, 1)
+	defer s1.Close()
+	s2 := newFakeEthServer(t, 100, 2)
+	defer s2.Close()
+
+	m := newTestMultiClient(t, s1, s2)
+	assert.Nil(m.Start(context.Background()))
+	assert.EqualError(m.Start(context.Background()), "already polling")
+	assert.Nil(m.Stop())
+	assert.EqualError(m.Stop(), "not polling")
+
+	// Endpoints must be connected to the same chain
+	s2.api.chainID = 1
+	m = newTestMultiClient(t, s1, s2)
+	err := m.Start(context.Background())
+	assert.Contains(err.Error(), "has chainID=1")
+
+	_, err = NewMultiClient(nil, MultiClientConfig{})
+	assert.Equal(errNoEndpoints, err)
+}
+
+func TestShouldFailover(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(shouldFailover(nil))
+	assert.False(shouldFailover(ethereum.NotFound))
+	assert.False(shouldFailover(context.Canceled))
+	assert.False(shouldFailover(rpcCodeError{-32000}))
+	assert.True(shouldFailover(rpcCodeError{rateLimitErrCode}))
+	assert.True(shouldFailover(errors.New("429 Too Many Requests")))
+	assert.True(shouldFailover(context.DeadlineExceeded))
+}
@@ -66,17 +66,15 @@ func (m *NonceManager) Next(addr ethcommon.Address) (uint64, error) {
 	return localNonce, nil
 }
 
-// Update uses the last nonce for the provided address to update the next transaction nonce
+// Update uses the last nonce for the provided address to update the next transaction nonce.
+// The next nonce is never lowered so that replacing a transaction or filling a nonce gap
+// does not cause the nonces of pending transactions to be reused
 func (m *NonceManager) Update(addr ethcommon.Address, lastNonce uint64) {
 	nonceLock := m.getNonceLock(addr)
-	localNonce := nonceLock.nonce
 
-	if lastNonce == localNonce {
-		nonceLock.nonce = localNonce + 1
-		return
+	if lastNonce >= nonceLock.nonce {
+		nonceLock.nonce = lastNonce + 1
 	}
-
-	nonceLock.nonce = lastNonce + 1
 }
 
 func (m *NonceManager) getNonceLock(addr ethcommon.Address) *nonceLock {
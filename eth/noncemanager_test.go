@@ -117,6 +117,23 @@ func TestUpdate_LastNonceNotEqualsLocalNonce(t *testing.T) {
 	assert.Equal(t, uint64(11), nonce)
 }
 
+func TestUpdate_LastNonceLowerThanLocalNonce(t *testing.T) {
+	r := &mockRemoteNonceReader{}
+	nm := NewNonceManager(r)
+	addr := pm.RandAddress()
+
+	r.On("PendingNonceAt", mock.Anything, addr).Return(uint64(0), nil)
+
+	nm.Update(addr, uint64(10))
+	// Replacing an earlier transaction does not lower the next nonce
+	nm.Update(addr, uint64(5))
+
+	nonce, err := nm.Next(addr)
+	require.Nil(t, err)
+
+	assert.Equal(t, uint64(11), nonce)
+}
+
 func TestNextAndUpdate_ConcurrentMultipleAddrs(t *testing.T) {
 	r := &mockRemoteNonceReader{}
 	nm := NewNonceManager(r)
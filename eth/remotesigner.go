@@ -0,0 +1,227 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang/glog"
+)
+
+// The timeout for requests to a remote signer
+var remoteSignerTimeout = 30 * time.Second
+
+// remoteSignTxArgs are the arguments of the account_signTransaction request of a remote signer
+type remoteSignTxArgs struct {
+	From     ethcommon.MixedcaseAddress  `json:"from"`
+	To       *ethcommon.MixedcaseAddress `json:"to"`
+	Gas      hexutil.Uint64              `json:"gas"`
+	GasPrice hexutil.Big                 `json:"gasPrice"`
+	Value    hexutil.Big                 `json:"value"`
+	Nonce    hexutil.Uint64              `json:"nonce"`
+	Data     *hexutil.Bytes              `json:"data"`
+}
+
+// remoteSignTxResult is the result of the account_signTransaction request of a remote signer
+type remoteSignTxResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// remoteAccountManager is an AccountManager that holds no keys and sends signing
+// requests to an external signer using the JSON-RPC API of clef
+// (account_list, account_signTransaction and account_signData)
+type remoteAccountManager struct {
+	url      string
+	rpc      *rpc.Client
+	account  accounts.Account
+	signer   types.Signer
+	unlocked bool
+}
+
+// NewRemoteAccountManager creates an AccountManager backed by the external signer
+// at url, which can be an HTTP(S) or WebSocket URL or an IPC path. If
+// accountAddr is not set, the first account of the signer is used
+func NewRemoteAccountManager(url string, accountAddr ethcommon.Address, signer types.Signer) (AccountManager, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteSignerTimeout)
+	defer cancel()
+
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to remote signer %v: %v", url, err)
+	}
+
+	am := &remoteAccountManager{
+		url:    url,
+		rpc:    client,
+		signer: signer,
+	}
+
+	addrs, err := am.list()
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("remote signer %v has no accounts", url)
+	}
+
+	acct := accounts.Account{Address: addrs[0]}
+	if (accountAddr != ethcommon.Address{}) {
+		found := false
+		for _, addr := range addrs {
+			if addr == accountAddr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, ErrAccountNotFound
+		}
+		acct.Address = accountAddr
+	}
+	am.account = acct
+
+	glog.Infof("Using Ethereum account %v of remote signer %v", acct.Address.Hex(), url)
+
+	return am, nil
+}
+
+// Unlock checks that the remote signer is available. The passphrase is not used
+// because the remote signer manages the key
+func (am *remoteAccountManager) Unlock(passphrase string) error {
+	if _, err := am.list(); err != nil {
+		return err
+	}
+
+	am.unlocked = true
+
+	return nil
+}
+
+// Lock stops the account manager from signing
+func (am *remoteAccountManager) Lock() error {
+	am.unlocked = false
+
+	return nil
+}
+
+// Create transact opts for client use - account must be unlocked
+// Can optionally set gas limit and gas price used
+func (am *remoteAccountManager) CreateTransactOpts(gasLimit uint64, gasPrice *big.Int) (*bind.TransactOpts, error) {
+	if !am.unlocked {
+		return nil, ErrLocked
+	}
+
+	return newTransactOpts(am.account.Address, gasLimit, gasPrice, am.SignTx), nil
+}
+
+// SignTx requests the remote signer to sign a transaction and checks that the
+// signed transaction matches the request
+func (am *remoteAccountManager) SignTx(tx *types.Transaction) (*types.Transaction, error) {
+	if !am.unlocked {
+		return nil, ErrLocked
+	}
+
+	data := hexutil.Bytes(tx.Data())
+	args := remoteSignTxArgs{
+		From:     ethcommon.NewMixedcaseAddress(am.account.Address),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     &data,
+	}
+	if tx.To() != nil {
+		to := ethcommon.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+
+	var res remoteSignTxResult
+	// Pointers are used for the arguments because MarshalJSON of MixedcaseAddress has a pointer receiver
+	if err := am.call(&res, "account_signTransaction", &args); err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(res.Raw, signed); err != nil {
+		return nil, fmt.Errorf("invalid transaction from remote signer %v: %v", am.url, err)
+	}
+	if am.signer.Hash(signed) != am.signer.Hash(tx) {
+		return nil, fmt.Errorf("remote signer %v signed a different transaction than requested", am.url)
+	}
+	sender, err := types.Sender(am.signer, signed)
+	if err != nil || sender != am.account.Address {
+		return nil, fmt.Errorf("remote signer %v returned a transaction signature that is invalid for the account or chain", am.url)
+	}
+
+	return signed, nil
+}
+
+// Sign requests the remote signer to sign a message with the Ethereum signed message prefix
+func (am *remoteAccountManager) Sign(msg []byte) ([]byte, error) {
+	if !am.unlocked {
+		return nil, ErrLocked
+	}
+
+	var sig hexutil.Bytes
+	addr := ethcommon.NewMixedcaseAddress(am.account.Address)
+	err := am.call(&sig, "account_signData", accounts.MimetypeTextPlain, &addr, hexutil.Encode(msg))
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("remote signer %v returned an invalid signature length %v", am.url, len(sig))
+	}
+
+	// Return the signature in the [R || S || V] format where V is 27 or 28
+	v := sig[64]
+	if v == byte(0) || v == byte(1) {
+		v += 27
+	}
+	sig = append(sig[:64:64], v)
+
+	// Check that the signature is valid for the account
+	recoverSig := append(sig[:64:64], v-27)
+	pub, err := crypto.SigToPub(accounts.TextHash(msg), recoverSig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != am.account.Address {
+		return nil, fmt.Errorf("remote signer %v returned an invalid signature", am.url)
+	}
+
+	return sig, nil
+}
+
+func (am *remoteAccountManager) Account() accounts.Account {
+	return am.account
+}
+
+func (am *remoteAccountManager) list() ([]ethcommon.Address, error) {
+	var addrs []ethcommon.Address
+	if err := am.call(&addrs, "account_list"); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+// call sends a request to the remote signer and distinguishes requests that
+// the signer rejected from a signer that is unavailable
+func (am *remoteAccountManager) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteSignerTimeout)
+	defer cancel()
+
+	err := am.rpc.CallContext(ctx, result, method, args...)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(rpc.Error); ok {
+		return fmt.Errorf("remote signer %v rejected %v: %v", am.url, method, err)
+	}
+	return fmt.Errorf("remote signer %v unavailable for %v: %v", am.url, method, err)
+}
@@ -0,0 +1,143 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/livepeer/go-livepeer/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteAccountManager_Account(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	signer := types.NewEIP155Signer(big.NewInt(1337))
+	ms, err := NewMockSigner(signer)
+	require.Nil(err)
+	defer ms.Close()
+
+	// Defaults to the first account of the signer
+	am, err := NewRemoteAccountManager(ms.URL, ethcommon.Address{}, signer)
+	require.Nil(err)
+	assert.Equal(ms.Address(), am.Account().Address)
+
+	am, err = NewRemoteAccountManager(ms.URL, ms.Address(), signer)
+	require.Nil(err)
+	assert.Equal(ms.Address(), am.Account().Address)
+
+	_, err = NewRemoteAccountManager(ms.URL, ethcommon.HexToAddress("foo"), signer)
+	assert.Equal(ErrAccountNotFound, err)
+}
+
+func TestRemoteAccountManager_Sign(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	signer := types.NewEIP155Signer(big.NewInt(1337))
+	ms, err := NewMockSigner(signer)
+	require.Nil(err)
+	defer ms.Close()
+
+	am, err := NewRemoteAccountManager(ms.URL, ethcommon.Address{}, signer)
+	require.Nil(err)
+
+	msg := []byte("foo")
+	_, err = am.Sign(msg)
+	assert.Equal(ErrLocked, err)
+
+	require.Nil(am.Unlock(""))
+	sig, err := am.Sign(msg)
+	require.Nil(err)
+	require.Len(sig, 65)
+	assert.True(sig[64] == 27 || sig[64] == 28)
+	assert.True(crypto.VerifySig(ms.Address(), msg, sig))
+
+	ms.Reject = true
+	_, err = am.Sign(msg)
+	require.NotNil(err)
+	assert.Contains(err.Error(), "rejected account_signData")
+
+	require.Nil(am.Lock())
+	_, err = am.Sign(msg)
+	assert.Equal(ErrLocked, err)
+}
+
+func TestRemoteAccountManager_SignTx(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	signer := types.NewEIP155Signer(big.NewInt(1337))
+	ms, err := NewMockSigner(signer)
+	require.Nil(err)
+	defer ms.Close()
+
+	am, err := NewRemoteAccountManager(ms.URL, ethcommon.Address{}, signer)
+	require.Nil(err)
+
+	tx := types.NewTransaction(5, ethcommon.HexToAddress("foo"), big.NewInt(100), 21000, big.NewInt(10), []byte("bar"))
+	_, err = am.SignTx(tx)
+	assert.Equal(ErrLocked, err)
+
+	require.Nil(am.Unlock(""))
+	signed, err := am.SignTx(tx)
+	require.Nil(err)
+	assert.Equal(signer.Hash(tx), signer.Hash(signed))
+	sender, err := types.Sender(signer, signed)
+	require.Nil(err)
+	assert.Equal(ms.Address(), sender)
+
+	// Transact opts sign through the remote signer
+	opts, err := am.CreateTransactOpts(100, big.NewInt(10))
+	require.Nil(err)
+	assert.Equal(ms.Address(), opts.From)
+	signed, err = opts.Signer(signer, ms.Address(), tx)
+	require.Nil(err)
+	assert.Equal(signer.Hash(tx), signer.Hash(signed))
+	_, err = opts.Signer(signer, ethcommon.HexToAddress("foo"), tx)
+	assert.EqualError(err, "not authorized to sign this account")
+
+	// A transaction signed for a different chain is not accepted
+	otherSigner := types.NewEIP155Signer(big.NewInt(1))
+	am, err = NewRemoteAccountManager(ms.URL, ethcommon.Address{}, otherSigner)
+	require.Nil(err)
+	require.Nil(am.Unlock(""))
+	_, err = am.SignTx(tx)
+	require.NotNil(err)
+	assert.Contains(err.Error(), "invalid for the account or chain")
+
+	ms.Reject = true
+	_, err = am.SignTx(tx)
+	require.NotNil(err)
+	assert.Contains(err.Error(), "rejected account_signTransaction")
+}
+
+func TestRemoteAccountManager_Unavailable(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	signer := types.NewEIP155Signer(big.NewInt(1337))
+	ms, err := NewMockSigner(signer)
+	require.Nil(err)
+
+	am, err := NewRemoteAccountManager(ms.URL, ethcommon.Address{}, signer)
+	require.Nil(err)
+	require.Nil(am.Unlock(""))
+
+	ms.Close()
+
+	_, err = am.Sign([]byte("foo"))
+	require.NotNil(err)
+	assert.Contains(err.Error(), "unavailable for account_signData")
+
+	err = am.Unlock("")
+	require.NotNil(err)
+	assert.Contains(err.Error(), "unavailable for account_list")
+
+	_, err = NewRemoteAccountManager(ms.URL, ethcommon.Address{}, signer)
+	require.NotNil(err)
+	assert.Contains(err.Error(), "unavailable")
+}
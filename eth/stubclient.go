@@ -329,6 +329,7 @@ func (c *StubClient) ReplaceTransaction(tx *types.Transaction, method string, ga
 func (c *StubClient) Sign(msg []byte) ([]byte, error)   { return msg, c.Err }
 func (c *StubClient) GetGasInfo() (uint64, *big.Int)    { return 0, nil }
 func (c *StubClient) SetGasInfo(uint64, *big.Int) error { return nil }
+func (c *StubClient) SetTransactionManager(*TransactionManager) {}
 
 // Faucet
 func (c *StubClient) NextValidRequest(common.Address) (*big.Int, error) { return nil, nil }
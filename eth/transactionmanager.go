@@ -0,0 +1,460 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/glog"
+	"github.com/livepeer/go-livepeer/common"
+)
+
+// ErrTxDropped is returned when waiting for a transaction whose nonce was used by another transaction
+var ErrTxDropped = errors.New("transaction dropped")
+
+var errTxNotTracked = errors.New("transaction not tracked")
+
+// How long a mined or dropped transaction can still be waited for
+var txRetention = 1 * time.Hour
+
+// The gas used by a 0 ETH transfer used to fill a nonce gap
+var nonceGapGas = uint64(21000)
+
+// TxStore persists the transactions tracked by a TransactionManager
+type TxStore interface {
+	StoreTx(tx *common.DBTx) error
+	Txs(filter *common.DBTxFilter) ([]*common.DBTx, error)
+}
+
+// TxReplacer submits a transaction that replaces a pending transaction with the same nonce
+type TxReplacer interface {
+	ReplaceTransaction(tx *types.Transaction, method string, gasPrice *big.Int) (*types.Transaction, error)
+}
+
+// TxChainReader describes the methods used to check the state of transactions on-chain
+type TxChainReader interface {
+	TransactionReceipt(ctx context.Context, txHash ethcommon.Hash) (*types.Receipt, error)
+	NonceAt(ctx context.Context, account ethcommon.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// TxManagerConfig describes when a TransactionManager replaces pending transactions
+type TxManagerConfig struct {
+	// PollingInterval is the interval at which pending transactions are checked
+	PollingInterval time.Duration
+
+	// ReplaceInterval is the time a transaction can be pending before it is
+	// replaced with a higher gas price
+	ReplaceInterval time.Duration
+
+	// MaxGasPrice is the gas price that replacements do not exceed. Nil or zero is no ceiling
+	MaxGasPrice *big.Int
+}
+
+type txKey struct {
+	sender ethcommon.Address
+	nonce  uint64
+}
+
+// managedTx is a transaction tracked by a TransactionManager together with
+// all of the versions submitted with the same nonce
+type managedTx struct {
+	record *common.DBTx
+	tx     *types.Transaction
+	hashes []ethcommon.Hash
+	sentAt time.Time
+
+	receipt     *types.Receipt
+	finalizedAt time.Time
+	done        chan struct{}
+}
+
+// TransactionManager tracks all submitted transactions in the DB, replaces
+// transactions that are not mined within a time window with a higher gas
+// price and fills nonce gaps that prevent later transactions from being mined
+type TransactionManager struct {
+	reader   TxChainReader
+	replacer TxReplacer
+	store    TxStore
+	cfg      TxManagerConfig
+
+	mu sync.Mutex
+	// pending transactions by sender and nonce
+	pending map[txKey]*managedTx
+	// tracked transactions by the hash of each submitted version
+	hashes map[ethcommon.Hash]*managedTx
+
+	quit chan struct{}
+}
+
+// NewTransactionManager creates a TransactionManager
+func NewTransactionManager(reader TxChainReader, replacer TxReplacer, store TxStore, cfg TxManagerConfig) *TransactionManager {
+	return &TransactionManager{
+		reader:   reader,
+		replacer: replacer,
+		store:    store,
+		cfg:      cfg,
+		pending:  make(map[txKey]*managedTx),
+		hashes:   make(map[ethcommon.Hash]*managedTx),
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start loads the pending transactions from the DB and starts checking pending transactions
+func (m *TransactionManager) Start() error {
+	if err := m.load(); err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(m.cfg.PollingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.check()
+			case <-m.quit:
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops checking pending transactions
+func (m *TransactionManager) Stop() {
+	close(m.quit)
+}
+
+// Track records a submitted transaction. A transaction with the same sender
+// and nonce as a pending transaction is recorded as its replacement
+func (m *TransactionManager) Track(tx *types.Transaction, sender ethcommon.Address, method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := txKey{sender, tx.Nonce()}
+	mtx, ok := m.pending[key]
+	if !ok {
+		mtx = &managedTx{
+			record: &common.DBTx{
+				Hash:   tx.Hash(),
+				Sender: sender,
+				Nonce:  tx.Nonce(),
+				Method: method,
+				Status: common.TxPending,
+			},
+			done: make(chan struct{}),
+		}
+		m.pending[key] = mtx
+	}
+
+	mtx.tx = tx
+	mtx.hashes = append(mtx.hashes, tx.Hash())
+	mtx.sentAt = time.Now()
+	m.hashes[tx.Hash()] = mtx
+
+	mtx.record.CurrentHash = tx.Hash()
+	mtx.record.GasPrice = tx.GasPrice()
+	mtx.record.Attempts++
+	mtx.record.Error = ""
+	if raw, err := rlp.EncodeToBytes(tx); err == nil {
+		mtx.record.RawTx = raw
+	}
+	m.storeTx(mtx.record)
+}
+
+// Wait blocks until the transaction, or a transaction that replaced it, is
+// mined and returns its receipt. ErrTxDropped is returned if the nonce of the
+// transaction was used by another transaction
+func (m *TransactionManager) Wait(ctx context.Context, hash ethcommon.Hash) (*types.Receipt, error) {
+	m.mu.Lock()
+	mtx, ok := m.hashes[hash]
+	m.mu.Unlock()
+	if !ok {
+		return nil, errTxNotTracked
+	}
+
+	select {
+	case <-mtx.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if mtx.receipt == nil {
+		return nil, ErrTxDropped
+	}
+	return mtx.receipt, nil
+}
+
+// load tracks the pending transactions stored in the DB
+func (m *TransactionManager) load() error {
+	records, err := m.store.Txs(&common.DBTxFilter{Status: common.TxPending})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range records {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(r.RawTx, tx); err != nil {
+			glog.Errorf("Unable to decode pending tx hash=%v err=%v", r.Hash.Hex(), err)
+			continue
+		}
+		mtx := &managedTx{
+			record: r,
+			tx:     tx,
+			hashes: []ethcommon.Hash{r.Hash},
+			sentAt: r.UpdatedAt,
+			done:   make(chan struct{}),
+		}
+		if r.CurrentHash != r.Hash {
+			mtx.hashes = append(mtx.hashes, r.CurrentHash)
+		}
+		m.pending[txKey{r.Sender, r.Nonce}] = mtx
+		for _, h := range mtx.hashes {
+			m.hashes[h] = mtx
+		}
+	}
+
+	return nil
+}
+
+// check updates the status of pending transactions, replaces transactions
+// that have been pending for too long and fills nonce gaps
+func (m *TransactionManager) check() {
+	for _, mtx := range m.pendingTxs() {
+		if err := m.checkTx(mtx); err != nil {
+			glog.Errorf("Error checking tx hash=%v err=%v", mtx.record.Hash.Hex(), err)
+		}
+	}
+
+	m.fillNonceGaps()
+	m.cleanup()
+}
+
+func (m *TransactionManager) checkTx(mtx *managedTx) error {
+	m.mu.Lock()
+	tx := mtx.tx
+	hashes := append([]ethcommon.Hash{}, mtx.hashes...)
+	sentAt := mtx.sentAt
+	sender := mtx.record.Sender
+	method := mtx.record.Method
+	m.mu.Unlock()
+
+	mined, err := m.findReceipt(mtx, hashes)
+	if err != nil || mined {
+		return err
+	}
+
+	// If the nonce has been used and none of the versions were mined,
+	// another transaction with the same nonce was mined
+	nonce, err := m.reader.NonceAt(context.Background(), sender, nil)
+	if err != nil {
+		return err
+	}
+	if nonce > tx.Nonce() {
+		// Check again in case a version was mined after the first check
+		mined, err := m.findReceipt(mtx, hashes)
+		if err != nil || mined {
+			return err
+		}
+		m.finalize(mtx, nil)
+		return nil
+	}
+
+	if time.Since(sentAt) < m.cfg.ReplaceInterval {
+		return nil
+	}
+
+	gasPrice := m.replacementGasPrice(tx.GasPrice())
+	if gasPrice == nil {
+		glog.V(common.DEBUG).Infof("Not replacing tx hash=%v - gas price %v cannot be bumped above max gas price %v", tx.Hash().Hex(), tx.GasPrice(), m.cfg.MaxGasPrice)
+		return nil
+	}
+
+	glog.Infof("Replacing tx not mined within %v hash=%v method=%v nonce=%v gasPrice=%v", m.cfg.ReplaceInterval, tx.Hash().Hex(), method, tx.Nonce(), gasPrice)
+	if _, err := m.replacer.ReplaceTransaction(tx, method, gasPrice); err != nil {
+		if err == ErrReplacingMinedTx {
+			return nil
+		}
+		m.mu.Lock()
+		mtx.record.Error = err.Error()
+		m.storeTx(mtx.record)
+		m.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// findReceipt finalizes a transaction if any of its versions was mined
+func (m *TransactionManager) findReceipt(mtx *managedTx, hashes []ethcommon.Hash) (bool, error) {
+	for i := len(hashes) - 1; i >= 0; i-- {
+		receipt, err := m.reader.TransactionReceipt(context.Background(), hashes[i])
+		if err == ethereum.NotFound || (err == nil && receipt == nil) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		m.finalize(mtx, receipt)
+		return true, nil
+	}
+	return false, nil
+}
+
+// finalize records the outcome of a transaction. A nil receipt means that the transaction was dropped
+func (m *TransactionManager) finalize(mtx *managedTx, receipt *types.Receipt) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pending, txKey{mtx.record.Sender, mtx.record.Nonce})
+
+	switch {
+	case receipt == nil:
+		mtx.record.Status = common.TxDropped
+		glog.Infof("Tx dropped hash=%v method=%v nonce=%v", mtx.record.Hash.Hex(), mtx.record.Method, mtx.record.Nonce)
+	case receipt.Status == types.ReceiptStatusFailed:
+		mtx.record.Status = common.TxFailed
+		mtx.record.CurrentHash = receipt.TxHash
+	default:
+		mtx.record.Status = common.TxMined
+		mtx.record.CurrentHash = receipt.TxHash
+	}
+	mtx.record.Error = ""
+	m.storeTx(mtx.record)
+
+	mtx.receipt = receipt
+	mtx.finalizedAt = time.Now()
+	close(mtx.done)
+}
+
+// fillNonceGaps submits a 0 ETH transfer to the sender for every nonce below
+// the lowest pending nonce of a sender that is not used on-chain once the
+// lowest pending transaction has been pending for ReplaceInterval. Such a
+// gap is left by a transaction that failed to submit or was evicted from the
+// transaction pool and prevents all later transactions from being mined
+func (m *TransactionManager) fillNonceGaps() {
+	lowest := make(map[ethcommon.Address]*managedTx)
+	m.mu.Lock()
+	for key, mtx := range m.pending {
+		if l, ok := lowest[key.sender]; !ok || key.nonce < l.tx.Nonce() {
+			lowest[key.sender] = mtx
+		}
+	}
+	m.mu.Unlock()
+
+	for sender, mtx := range lowest {
+		m.mu.Lock()
+		sentAt := mtx.sentAt
+		lowestNonce := mtx.tx.Nonce()
+		m.mu.Unlock()
+		if time.Since(sentAt) < m.cfg.ReplaceInterval {
+			continue
+		}
+
+		nonce, err := m.reader.NonceAt(context.Background(), sender, nil)
+		if err != nil {
+			glog.Errorf("Error reading nonce for sender=%v err=%v", sender.Hex(), err)
+			continue
+		}
+		for ; nonce < lowestNonce; nonce++ {
+			if err := m.fillNonceGap(sender, nonce); err != nil {
+				glog.Errorf("Error filling nonce gap sender=%v nonce=%v err=%v", sender.Hex(), nonce, err)
+				break
+			}
+		}
+	}
+}
+
+func (m *TransactionManager) fillNonceGap(sender ethcommon.Address, nonce uint64) error {
+	gasPrice := m.replacementGasPrice(big.NewInt(0))
+	if gasPrice == nil {
+		return errors.New("max gas price too low")
+	}
+
+	glog.Infof("Filling nonce gap sender=%v nonce=%v gasPrice=%v", sender.Hex(), nonce, gasPrice)
+	// The missing transaction is replaced with a 0 ETH transfer to the sender
+	gap := types.NewTransaction(nonce, sender, big.NewInt(0), nonceGapGas, big.NewInt(0), nil)
+	tx, err := m.replacer.ReplaceTransaction(gap, "fillNonceGap", gasPrice)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if mtx, ok := m.hashes[tx.Hash()]; ok {
+		mtx.record.Method = "fillNonceGap"
+		m.storeTx(mtx.record)
+	}
+	m.mu.Unlock()
+
+	return nil
+}
+
+// replacementGasPrice returns the gas price for a replacement of a transaction
+// with the given gas price. The gas price is bumped by the 10% most clients
+// require for replacements or set to the suggested gas price if it is higher,
+// without exceeding MaxGasPrice. Nil is returned if the gas price cannot be
+// bumped without exceeding MaxGasPrice
+func (m *TransactionManager) replacementGasPrice(gasPrice *big.Int) *big.Int {
+	minGasPrice := new(big.Int).Add(gasPrice, new(big.Int).Div(gasPrice, big.NewInt(10)))
+	minGasPrice.Add(minGasPrice, big.NewInt(10))
+
+	newGasPrice := minGasPrice
+	suggested, err := m.reader.SuggestGasPrice(context.Background())
+	if err != nil {
+		glog.Errorf("Error fetching suggested gas price err=%v", err)
+	} else if suggested.Cmp(newGasPrice) > 0 {
+		newGasPrice = suggested
+	}
+
+	maxGasPrice := m.cfg.MaxGasPrice
+	if maxGasPrice != nil && maxGasPrice.Sign() > 0 && newGasPrice.Cmp(maxGasPrice) > 0 {
+		if minGasPrice.Cmp(maxGasPrice) > 0 {
+			return nil
+		}
+		newGasPrice = new(big.Int).Set(maxGasPrice)
+	}
+
+	return newGasPrice
+}
+
+func (m *TransactionManager) pendingTxs() []*managedTx {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txs := make([]*managedTx, 0, len(m.pending))
+	for _, mtx := range m.pending {
+		txs = append(txs, mtx)
+	}
+	return txs
+}
+
+// cleanup stops tracking transactions that were finalized more than txRetention ago
+func (m *TransactionManager) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for h, mtx := range m.hashes {
+		if !mtx.finalizedAt.IsZero() && time.Since(mtx.finalizedAt) > txRetention {
+			delete(m.hashes, h)
+		}
+	}
+}
+
+// storeTx persists a transaction record. The caller must hold the lock
+func (m *TransactionManager) storeTx(record *common.DBTx) {
+	if err := m.store.StoreTx(record); err != nil {
+		glog.Errorf("Error storing tx hash=%v err=%v", record.Hash.Hex(), err)
+	}
+}
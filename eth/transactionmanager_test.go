@@ -0,0 +1,351 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/pm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubTxChainReader struct {
+	mu         sync.Mutex
+	receipts   map[ethcommon.Hash]*types.Receipt
+	nonce      uint64
+	gasPrice   *big.Int
+	nonceErr   error
+	receiptErr error
+}
+
+func (r *stubTxChainReader) TransactionReceipt(ctx context.Context, txHash ethcommon.Hash) (*types.Receipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.receiptErr != nil {
+		return nil, r.receiptErr
+	}
+	receipt, ok := r.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (r *stubTxChainReader) NonceAt(ctx context.Context, account ethcommon.Address, blockNumber *big.Int) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.nonce, r.nonceErr
+}
+
+func (r *stubTxChainReader) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return r.gasPrice, nil
+}
+
+func (r *stubTxChainReader) mine(hash ethcommon.Hash, status uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.receipts[hash] = &types.Receipt{TxHash: hash, Status: status}
+}
+
+// stubTxReplacer submits replacements to the TransactionManager like the backend does
+type stubTxReplacer struct {
+	tm       *TransactionManager
+	sender   ethcommon.Address
+	replaced []*types.Transaction
+	err      error
+}
+
+func (r *stubTxReplacer) ReplaceTransaction(tx *types.Transaction, method string, gasPrice *big.Int) (*types.Transaction, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	newTx := types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), gasPrice, tx.Data())
+	r.replaced = append(r.replaced, newTx)
+	r.tm.Track(newTx, r.sender, "unknown")
+	return newTx, nil
+}
+
+func newTxManagerFixture(t *testing.T, cfg TxManagerConfig) (*TransactionManager, *stubTxChainReader, *stubTxReplacer, *common.DB, func()) {
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(t, err)
+
+	// Transactions in the tests use nonce 3 so there are no nonce gaps by default
+	reader := &stubTxChainReader{receipts: make(map[ethcommon.Hash]*types.Receipt), nonce: 3, gasPrice: big.NewInt(50)}
+	replacer := &stubTxReplacer{sender: pm.RandAddress()}
+	tm := NewTransactionManager(reader, replacer, dbh, cfg)
+	replacer.tm = tm
+
+	return tm, reader, replacer, dbh, func() {
+		dbh.Close()
+		dbraw.Close()
+	}
+}
+
+func TestTransactionManager_Mined(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tm, reader, replacer, dbh, cleanup := newTxManagerFixture(t, TxManagerConfig{ReplaceInterval: time.Hour})
+	defer cleanup()
+
+	sender := replacer.sender
+	tx := types.NewTransaction(3, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), []byte("reward"))
+	tm.Track(tx, sender, "reward")
+
+	txs, err := dbh.Txs(nil)
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal(tx.Hash(), txs[0].Hash)
+	assert.Equal(sender, txs[0].Sender)
+	assert.Equal(uint64(3), txs[0].Nonce)
+	assert.Equal("reward", txs[0].Method)
+	assert.Equal(common.TxPending, txs[0].Status)
+	assert.Equal(1, txs[0].Attempts)
+
+	// Not mined and not stale yet
+	tm.check()
+	assert.Empty(replacer.replaced)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = tm.Wait(ctx, tx.Hash())
+	assert.Equal(context.DeadlineExceeded, err)
+
+	reader.mine(tx.Hash(), types.ReceiptStatusSuccessful)
+	tm.check()
+
+	receipt, err := tm.Wait(context.Background(), tx.Hash())
+	require.Nil(err)
+	assert.Equal(tx.Hash(), receipt.TxHash)
+
+	txs, err = dbh.Txs(nil)
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal(common.TxMined, txs[0].Status)
+
+	_, err = tm.Wait(context.Background(), pm.RandHash())
+	assert.Equal(errTxNotTracked, err)
+}
+
+func TestTransactionManager_Failed(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tm, reader, replacer, dbh, cleanup := newTxManagerFixture(t, TxManagerConfig{ReplaceInterval: time.Hour})
+	defer cleanup()
+
+	tx := types.NewTransaction(3, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), nil)
+	tm.Track(tx, replacer.sender, "bond")
+	reader.mine(tx.Hash(), types.ReceiptStatusFailed)
+	tm.check()
+
+	receipt, err := tm.Wait(context.Background(), tx.Hash())
+	require.Nil(err)
+	assert.Equal(types.ReceiptStatusFailed, receipt.Status)
+
+	txs, err := dbh.Txs(&common.DBTxFilter{Status: common.TxFailed})
+	require.Nil(err)
+	assert.Len(txs, 1)
+}
+
+func TestTransactionManager_Replace(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tm, reader, replacer, dbh, cleanup := newTxManagerFixture(t, TxManagerConfig{})
+	defer cleanup()
+
+	tx := types.NewTransaction(3, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), nil)
+	tm.Track(tx, replacer.sender, "redeemWinningTicket")
+
+	// The gas price is bumped by 10% if the suggested gas price is lower
+	tm.check()
+	require.Len(replacer.replaced, 1)
+	replacement := replacer.replaced[0]
+	assert.Equal(big.NewInt(120), replacement.GasPrice())
+	assert.Equal(uint64(3), replacement.Nonce())
+
+	// The suggested gas price is used if it is higher
+	reader.gasPrice = big.NewInt(500)
+	tm.check()
+	require.Len(replacer.replaced, 2)
+	assert.Equal(big.NewInt(500), replacer.replaced[1].GasPrice())
+
+	txs, err := dbh.Txs(nil)
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal(tx.Hash(), txs[0].Hash)
+	assert.Equal(replacer.replaced[1].Hash(), txs[0].CurrentHash)
+	assert.Equal(big.NewInt(500), txs[0].GasPrice)
+	assert.Equal(3, txs[0].Attempts)
+	assert.Equal("redeemWinningTicket", txs[0].Method)
+
+	// Waiting on the original transaction returns once a replacement is mined
+	reader.mine(replacement.Hash(), types.ReceiptStatusSuccessful)
+	tm.check()
+	receipt, err := tm.Wait(context.Background(), tx.Hash())
+	require.Nil(err)
+	assert.Equal(replacement.Hash(), receipt.TxHash)
+
+	txs, err = dbh.Txs(nil)
+	require.Nil(err)
+	assert.Equal(common.TxMined, txs[0].Status)
+	assert.Equal(replacement.Hash(), txs[0].CurrentHash)
+}
+
+func TestTransactionManager_Replace_MaxGasPrice(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tm, reader, replacer, dbh, cleanup := newTxManagerFixture(t, TxManagerConfig{MaxGasPrice: big.NewInt(200)})
+	defer cleanup()
+
+	// The gas price is capped at the max gas price
+	reader.gasPrice = big.NewInt(500)
+	tx := types.NewTransaction(3, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), nil)
+	tm.Track(tx, replacer.sender, "reward")
+	tm.check()
+	require.Len(replacer.replaced, 1)
+	assert.Equal(big.NewInt(200), replacer.replaced[0].GasPrice())
+
+	// The gas price cannot be bumped any further
+	tm.check()
+	assert.Len(replacer.replaced, 1)
+
+	// Replacement errors are recorded
+	tm.cfg.MaxGasPrice = nil
+	replacer.err = errors.New("replacement transaction underpriced")
+	tm.check()
+	txs, err := dbh.Txs(nil)
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal("replacement transaction underpriced", txs[0].Error)
+	assert.Equal(common.TxPending, txs[0].Status)
+}
+
+func TestTransactionManager_Dropped(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tm, reader, replacer, dbh, cleanup := newTxManagerFixture(t, TxManagerConfig{ReplaceInterval: time.Hour})
+	defer cleanup()
+
+	tx := types.NewTransaction(3, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), nil)
+	tm.Track(tx, replacer.sender, "reward")
+
+	// The nonce was used by another transaction
+	reader.nonce = 4
+	tm.check()
+
+	_, err := tm.Wait(context.Background(), tx.Hash())
+	assert.Equal(ErrTxDropped, err)
+
+	txs, err := dbh.Txs(nil)
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal(common.TxDropped, txs[0].Status)
+}
+
+func TestTransactionManager_FillNonceGaps(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tm, reader, replacer, dbh, cleanup := newTxManagerFixture(t, TxManagerConfig{ReplaceInterval: time.Hour})
+	defer cleanup()
+
+	sender := replacer.sender
+	tx := types.NewTransaction(5, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), nil)
+	tm.Track(tx, sender, "reward")
+	reader.nonce = 3
+
+	// Gaps are not filled until the lowest pending transaction is stale
+	tm.fillNonceGaps()
+	assert.Empty(replacer.replaced)
+
+	tm.cfg.ReplaceInterval = 0
+	tm.fillNonceGaps()
+	require.Len(replacer.replaced, 2)
+	for i, gap := range replacer.replaced {
+		assert.Equal(uint64(3+i), gap.Nonce())
+		assert.Equal(sender, *gap.To())
+		assert.Zero(gap.Value().Sign())
+		assert.Equal(big.NewInt(50), gap.GasPrice())
+	}
+
+	txs, err := dbh.Txs(&common.DBTxFilter{Status: common.TxPending})
+	require.Nil(err)
+	require.Len(txs, 3)
+	methods := make(map[uint64]string)
+	for _, t := range txs {
+		methods[t.Nonce] = t.Method
+	}
+	assert.Equal(map[uint64]string{3: "fillNonceGap", 4: "fillNonceGap", 5: "reward"}, methods)
+}
+
+func TestTransactionManager_Load(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tm, reader, replacer, dbh, cleanup := newTxManagerFixture(t, TxManagerConfig{ReplaceInterval: time.Hour})
+	defer cleanup()
+
+	tx := types.NewTransaction(3, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), nil)
+	raw, err := rlp.EncodeToBytes(tx)
+	require.Nil(err)
+	require.Nil(dbh.StoreTx(&common.DBTx{
+		Hash:        pm.RandHash(),
+		Sender:      replacer.sender,
+		Nonce:       3,
+		Method:      "reward",
+		CurrentHash: tx.Hash(),
+		GasPrice:    big.NewInt(100),
+		Attempts:    2,
+		Status:      common.TxPending,
+		RawTx:       raw,
+	}))
+
+	require.Nil(tm.load())
+	pending := tm.pendingTxs()
+	require.Len(pending, 1)
+	assert.Equal(tx.Hash(), pending[0].tx.Hash())
+
+	reader.mine(tx.Hash(), types.ReceiptStatusSuccessful)
+	tm.check()
+	receipt, err := tm.Wait(context.Background(), tx.Hash())
+	require.Nil(err)
+	assert.Equal(tx.Hash(), receipt.TxHash)
+
+	txs, err := dbh.Txs(nil)
+	require.Nil(err)
+	require.Len(txs, 1)
+	assert.Equal(common.TxMined, txs[0].Status)
+	assert.Equal(2, txs[0].Attempts)
+}
+
+func TestTransactionManager_StartStop(t *testing.T) {
+	assert := assert.New(t)
+
+	tm, reader, replacer, _, cleanup := newTxManagerFixture(t, TxManagerConfig{PollingInterval: 5 * time.Millisecond, ReplaceInterval: time.Hour})
+	defer cleanup()
+
+	assert.Nil(tm.Start())
+	defer tm.Stop()
+
+	tx := types.NewTransaction(3, pm.RandAddress(), big.NewInt(0), 100000, big.NewInt(100), nil)
+	tm.Track(tx, replacer.sender, "reward")
+	reader.mine(tx.Hash(), types.ReceiptStatusSuccessful)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	receipt, err := tm.Wait(ctx, tx.Hash())
+	assert.Nil(err)
+	assert.Equal(tx.Hash(), receipt.TxHash)
+}
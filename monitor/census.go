@@ -71,6 +71,7 @@ type (
 		kSender                       tag.Key
 		kRecipient                    tag.Key
 		kManifestID                   tag.Key
+		kReason                       tag.Key
 		mSegmentSourceAppeared        *stats.Int64Measure
 		mSegmentEmerged               *stats.Int64Measure
 		mSegmentEmergedUnprocessed    *stats.Int64Measure
@@ -108,6 +109,13 @@ type (
 		mTicketsSent        *stats.Int64Measure
 		mPaymentCreateError *stats.Int64Measure
 
+		// Metrics for funding the broadcaster's deposit and reserve
+		mDeposit        *stats.Float64Measure
+		mReserve        *stats.Float64Measure
+		mETHBalance     *stats.Float64Measure
+		mValueFunded    *stats.Float64Measure
+		mFundingWarning *stats.Int64Measure
+
 		// Metrics for receiving payments
 		mTicketValueRecv       *stats.Float64Measure
 		mTicketsRecv           *stats.Int64Measure
@@ -173,6 +181,7 @@ func InitCensus(nodeType, nodeID, version string) {
 	census.kSender = tag.MustNewKey("sender")
 	census.kRecipient = tag.MustNewKey("recipient")
 	census.kManifestID = tag.MustNewKey("manifestID")
+	census.kReason = tag.MustNewKey("reason")
 	census.ctx, err = tag.New(ctx, tag.Insert(census.kNodeType, nodeType), tag.Insert(census.kNodeID, nodeID))
 	if err != nil {
 		glog.Fatal("Error creating context", err)
@@ -216,6 +225,13 @@ func InitCensus(nodeType, nodeID, version string) {
 	census.mTicketsSent = stats.Int64("tickets_sent", "TicketsSent", "tot")
 	census.mPaymentCreateError = stats.Int64("payment_create_errors", "PaymentCreateError", "tot")
 
+	// Metrics for funding the broadcaster's deposit and reserve
+	census.mDeposit = stats.Float64("deposit", "Deposit", "gwei")
+	census.mReserve = stats.Float64("reserve", "Reserve", "gwei")
+	census.mETHBalance = stats.Float64("eth_balance", "ETHBalance", "gwei")
+	census.mValueFunded = stats.Float64("value_funded", "ValueFunded", "gwei")
+	census.mFundingWarning = stats.Int64("funding_warnings", "FundingWarning", "tot")
+
 	// Metrics for receiving payments
 	census.mTicketValueRecv = stats.Float64("ticket_value_recv", "TicketValueRecv", "gwei")
 	census.mTicketsRecv = stats.Int64("tickets_recv", "TicketsRecv", "tot")
@@ -522,6 +538,41 @@ func InitCensus(nodeType, nodeID, version string) {
 			TagKeys:     append([]tag.Key{census.kSender}, baseTags...),
 			Aggregation: view.Sum(),
 		},
+		{
+			Name:        "deposit",
+			Measure:     census.mDeposit,
+			Description: "Broadcaster's deposit",
+			TagKeys:     baseTags,
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        "reserve",
+			Measure:     census.mReserve,
+			Description: "Broadcaster's reserve",
+			TagKeys:     baseTags,
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        "eth_balance",
+			Measure:     census.mETHBalance,
+			Description: "ETH balance of the broadcaster's account",
+			TagKeys:     baseTags,
+			Aggregation: view.LastValue(),
+		},
+		{
+			Name:        "value_funded",
+			Measure:     census.mValueFunded,
+			Description: "Value added to the broadcaster's deposit and reserve by automatic funding",
+			TagKeys:     baseTags,
+			Aggregation: view.Sum(),
+		},
+		{
+			Name:        "funding_warnings",
+			Measure:     census.mFundingWarning,
+			Description: "Warnings that the broadcaster's deposit or reserve could not be topped up",
+			TagKeys:     append([]tag.Key{census.kReason}, baseTags...),
+			Aggregation: view.Sum(),
+		},
 		{
 			Name:        "suggested_gas_price",
 			Measure:     census.mSuggestedGasPrice,
@@ -1203,6 +1254,40 @@ func TicketRedemptionError(sender string) {
 	stats.Record(ctx, census.mTicketRedemptionError.M(1))
 }
 
+// SenderFunds records the broadcaster's deposit, reserve and ETH balance
+func SenderFunds(deposit, reserve, ethBalance *big.Int) {
+	census.lock.Lock()
+	defer census.lock.Unlock()
+
+	stats.Record(census.ctx,
+		census.mDeposit.M(wei2gwei(deposit)),
+		census.mReserve.M(wei2gwei(reserve)),
+		census.mETHBalance.M(wei2gwei(ethBalance)),
+	)
+}
+
+// ValueFunded records value added to the broadcaster's deposit and reserve
+func ValueFunded(value *big.Int) {
+	census.lock.Lock()
+	defer census.lock.Unlock()
+
+	stats.Record(census.ctx, census.mValueFunded.M(wei2gwei(value)))
+}
+
+// FundingWarning records a warning that the broadcaster's deposit or reserve
+// could not be topped up
+func FundingWarning(reason string) {
+	census.lock.Lock()
+	defer census.lock.Unlock()
+
+	ctx, err := tag.New(census.ctx, tag.Insert(census.kReason, reason))
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	stats.Record(ctx, census.mFundingWarning.M(1))
+}
+
 // SuggestedGasPrice records the last suggested gas price
 func SuggestedGasPrice(gasPrice *big.Int) {
 	census.lock.Lock()
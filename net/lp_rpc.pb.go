@@ -97,8 +97,11 @@ func (m *PingPong) GetValue() []byte {
 type OrchestratorRequest struct {
 	// Ethereum address of the broadcaster
 	Address []byte `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	// Broadcaster's signature over its address
-	Sig                  []byte   `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
+	// Broadcaster's signature over its address and timestamp
+	Sig []byte `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
+	// Unix time in nanoseconds at which the request was signed. Older
+	// broadcasters do not set it and only sign their address.
+	Timestamp            int64    `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -143,9 +146,15 @@ func (m *OrchestratorRequest) GetSig() []byte {
 	return nil
 }
 
-//
-//OSInfo needed to negotiate storages that will be used.
-//It carries info needed to write to the storage.
+func (m *OrchestratorRequest) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+// OSInfo needed to negotiate storages that will be used.
+// It carries info needed to write to the storage.
 type OSInfo struct {
 	// Storage type: direct, s3, ipfs.
 	StorageType          OSInfo_StorageType `protobuf:"varint,1,opt,name=storageType,proto3,enum=net.OSInfo_StorageType" json:"storageType,omitempty"`
@@ -339,6 +348,15 @@ type OrchestratorInfo struct {
 	TicketParams *TicketParams `protobuf:"bytes,2,opt,name=ticket_params,json=ticketParams,proto3" json:"ticket_params,omitempty"`
 	// Price Info containing the price per pixel to transcode
 	PriceInfo *PriceInfo `protobuf:"bytes,3,opt,name=price_info,json=priceInfo,proto3" json:"price_info,omitempty"`
+	// Address of a key that the ticket recipient delegates to sign transcoded
+	// segments, if any. Otherwise segments are signed by the ticket recipient.
+	SegmentSigner []byte `protobuf:"bytes,4,opt,name=segment_signer,json=segmentSigner,proto3" json:"segment_signer,omitempty"`
+	// Ticket recipient signature authorizing segment_signer
+	SegmentSignerSig []byte `protobuf:"bytes,5,opt,name=segment_signer_sig,json=segmentSignerSig,proto3" json:"segment_signer_sig,omitempty"`
+	// SHA-256 hash of the DER encoded TLS certificate of the orchestrator
+	TlsCertHash []byte `protobuf:"bytes,6,opt,name=tls_cert_hash,json=tlsCertHash,proto3" json:"tls_cert_hash,omitempty"`
+	// Ticket recipient signature over all other fields of the message
+	Sig []byte `protobuf:"bytes,7,opt,name=sig,proto3" json:"sig,omitempty"`
 	// Orchestrator returns info about own input object storage, if it wants it to be used.
 	Storage              []*OSInfo `protobuf:"bytes,32,rep,name=storage,proto3" json:"storage,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
@@ -392,6 +410,34 @@ func (m *OrchestratorInfo) GetPriceInfo() *PriceInfo {
 	return nil
 }
 
+func (m *OrchestratorInfo) GetSegmentSigner() []byte {
+	if m != nil {
+		return m.SegmentSigner
+	}
+	return nil
+}
+
+func (m *OrchestratorInfo) GetSegmentSignerSig() []byte {
+	if m != nil {
+		return m.SegmentSignerSig
+	}
+	return nil
+}
+
+func (m *OrchestratorInfo) GetTlsCertHash() []byte {
+	if m != nil {
+		return m.TlsCertHash
+	}
+	return nil
+}
+
+func (m *OrchestratorInfo) GetSig() []byte {
+	if m != nil {
+		return m.Sig
+	}
+	return nil
+}
+
 func (m *OrchestratorInfo) GetStorage() []*OSInfo {
 	if m != nil {
 		return m.Storage
@@ -1192,79 +1238,83 @@ func init() {
 func init() { proto.RegisterFile("net/lp_rpc.proto", fileDescriptor_034e29c79f9ba827) }
 
 var fileDescriptor_034e29c79f9ba827 = []byte{
-	// 1139 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0xcf, 0x6e, 0xdb, 0x46,
-	0x13, 0x0f, 0x2d, 0x59, 0xb6, 0x46, 0x92, 0x2d, 0x6f, 0x1c, 0x87, 0xf1, 0xf7, 0x35, 0x50, 0x88,
-	0x04, 0x70, 0x0e, 0x71, 0x0b, 0x1b, 0x09, 0x90, 0x5b, 0x93, 0xc6, 0x88, 0x0d, 0x14, 0xb1, 0xb0,
-	0x72, 0x02, 0xf4, 0x24, 0xac, 0xc8, 0x91, 0xbc, 0xb1, 0xbc, 0x64, 0x96, 0xab, 0xc6, 0x0a, 0xfa,
-	0x22, 0xed, 0xa1, 0x87, 0x02, 0x7d, 0x8f, 0xbe, 0x44, 0x4f, 0x7d, 0x99, 0x62, 0x67, 0x97, 0x14,
-	0x65, 0xfb, 0x10, 0xf4, 0xb6, 0xf3, 0x9b, 0xd9, 0xd9, 0xf9, 0xf3, 0x9b, 0x21, 0xa1, 0xab, 0xd0,
-	0x7c, 0x3b, 0xcd, 0x86, 0x3a, 0x8b, 0xf7, 0x33, 0x9d, 0x9a, 0x94, 0xd5, 0x14, 0x9a, 0xa8, 0x07,
-	0xeb, 0x7d, 0xa9, 0x26, 0xfd, 0x54, 0x4d, 0xd8, 0x36, 0xac, 0xfe, 0x2c, 0xa6, 0x33, 0x0c, 0x83,
-	0x5e, 0xb0, 0xd7, 0xe6, 0x4e, 0x88, 0x5e, 0xc1, 0xdd, 0x53, 0x1d, 0x9f, 0x63, 0x6e, 0xb4, 0x30,
-	0xa9, 0xe6, 0xf8, 0x69, 0x86, 0xb9, 0x61, 0x21, 0xac, 0x89, 0x24, 0xd1, 0x98, 0xe7, 0xde, 0xbc,
-	0x10, 0x59, 0x17, 0x6a, 0xb9, 0x9c, 0x84, 0x2b, 0x84, 0xda, 0x63, 0xf4, 0x6b, 0x00, 0x8d, 0xd3,
-	0xc1, 0x89, 0x1a, 0xa7, 0xec, 0x25, 0xb4, 0x72, 0x93, 0x6a, 0x31, 0xc1, 0xb3, 0x79, 0xe6, 0x5e,
-	0xda, 0x38, 0xb8, 0xbf, 0xaf, 0xd0, 0xec, 0x3b, 0x8b, 0xfd, 0xc1, 0x42, 0xcd, 0xab, 0xb6, 0xec,
-	0x09, 0x34, 0xf2, 0x43, 0xa9, 0xc6, 0x69, 0xd8, 0xed, 0x05, 0x7b, 0xad, 0x83, 0x0e, 0xdd, 0x1a,
-	0x1c, 0xba, 0x7b, 0xdc, 0x2b, 0xa3, 0x67, 0xd0, 0xaa, 0xb8, 0x60, 0x00, 0x8d, 0x37, 0x27, 0xfc,
-	0xe8, 0x87, 0xb3, 0xee, 0x1d, 0xd6, 0x80, 0x95, 0xc1, 0x61, 0x37, 0xb0, 0xd8, 0xdb, 0xd3, 0xd3,
-	0xb7, 0x3f, 0x1e, 0x75, 0x57, 0xa2, 0x3f, 0x02, 0x58, 0x2f, 0x7c, 0x30, 0x06, 0xf5, 0xf3, 0x34,
-	0x37, 0x14, 0x56, 0x93, 0xd3, 0xd9, 0xa6, 0x73, 0x81, 0x73, 0x4a, 0xa7, 0xc9, 0xed, 0x91, 0xed,
-	0x40, 0x23, 0x4b, 0xa7, 0x32, 0x9e, 0x87, 0x35, 0x02, 0xbd, 0xc4, 0xfe, 0x0f, 0xcd, 0x5c, 0x4e,
-	0x94, 0x30, 0x33, 0x8d, 0x61, 0x9d, 0x54, 0x0b, 0x80, 0x3d, 0x04, 0x88, 0x35, 0x26, 0xa8, 0x8c,
-	0x14, 0xd3, 0x70, 0x95, 0xd4, 0x15, 0x84, 0xed, 0xc2, 0xfa, 0xd5, 0xab, 0xcb, 0x2f, 0x6f, 0x84,
-	0xc1, 0xb0, 0x41, 0xda, 0x52, 0x8e, 0xde, 0x43, 0xb3, 0xaf, 0x65, 0x8c, 0x14, 0x64, 0x04, 0xed,
-	0xcc, 0x0a, 0x7d, 0xd4, 0xef, 0x95, 0x74, 0xc1, 0xd6, 0xf8, 0x12, 0xc6, 0x1e, 0x43, 0x27, 0x93,
-	0x57, 0x38, 0xcd, 0x0b, 0xa3, 0x15, 0x32, 0x5a, 0x06, 0xa3, 0xbf, 0x02, 0xe8, 0x56, 0x7b, 0x4b,
-	0xee, 0x1f, 0x02, 0x18, 0x2d, 0x54, 0x1e, 0xa7, 0x09, 0x6a, 0x5f, 0x89, 0x0a, 0xc2, 0x5e, 0x40,
-	0xc7, 0xc8, 0xf8, 0x02, 0xcd, 0x30, 0x13, 0x5a, 0x5c, 0xe6, 0xe4, 0xba, 0x75, 0xb0, 0x45, 0xdd,
-	0x38, 0x23, 0x4d, 0x9f, 0x14, 0xbc, 0x6d, 0x2a, 0x12, 0x7b, 0x06, 0x40, 0x21, 0x0e, 0xa9, 0x85,
-	0x35, 0xba, 0xb4, 0x41, 0x97, 0xca, 0xd4, 0x78, 0x33, 0x2b, 0xb3, 0x7c, 0x02, 0x6b, 0xbe, 0xf9,
-	0x61, 0xaf, 0x57, 0xdb, 0x6b, 0x1d, 0xb4, 0x2a, 0x24, 0xe1, 0x85, 0x2e, 0xfa, 0x27, 0x80, 0xb5,
-	0x01, 0x4e, 0xde, 0x08, 0x23, 0x6c, 0xe4, 0x97, 0x42, 0xc9, 0x31, 0xe6, 0xe6, 0x24, 0xf1, 0xac,
-	0xac, 0x20, 0x44, 0x4c, 0xfc, 0xe4, 0x4b, 0x61, 0x8f, 0xd4, 0x6f, 0x91, 0x9f, 0x53, 0x34, 0x6d,
-	0x4e, 0x67, 0xdb, 0x87, 0x4c, 0xa7, 0x63, 0x39, 0xc5, 0x9c, 0x9a, 0xd8, 0xe6, 0xa5, 0x5c, 0x50,
-	0x7b, 0xb5, 0xa4, 0xf6, 0x57, 0x86, 0xc9, 0x9e, 0x43, 0x7b, 0x3c, 0x9b, 0x4e, 0xfb, 0x85, 0xe3,
-	0x47, 0x64, 0xeb, 0x6a, 0xf6, 0x41, 0x26, 0x98, 0x7a, 0x0d, 0x5f, 0x32, 0x8b, 0x7e, 0x81, 0x76,
-	0x55, 0x6b, 0xe3, 0x55, 0xe2, 0x12, 0x69, 0x00, 0x9a, 0x9c, 0xce, 0x76, 0x6a, 0x3f, 0xcb, 0xc4,
-	0x9c, 0x87, 0x5b, 0xbd, 0x60, 0x6f, 0x95, 0x3b, 0xc1, 0x72, 0xf4, 0x1c, 0xe5, 0xe4, 0xdc, 0x84,
-	0x8c, 0x60, 0x2f, 0xd9, 0xb1, 0x1d, 0x49, 0xdb, 0x6d, 0x0c, 0xef, 0x92, 0xa2, 0x10, 0x6d, 0x6e,
-	0xe3, 0x2c, 0x0f, 0xb7, 0x7b, 0xc1, 0x5e, 0x87, 0xdb, 0x63, 0xf4, 0x0a, 0xee, 0x9d, 0x15, 0x7d,
-	0x4f, 0x06, 0x38, 0xb9, 0x44, 0x65, 0xa8, 0xd0, 0x5d, 0xa8, 0xcd, 0xf4, 0xd4, 0x73, 0xc3, 0x1e,
-	0x69, 0x24, 0x88, 0x5a, 0xbe, 0xba, 0x5e, 0x8a, 0x7e, 0x82, 0x4e, 0xe9, 0x82, 0xae, 0xbe, 0x80,
-	0xf5, 0xdc, 0x79, 0xb2, 0x7b, 0xc3, 0x16, 0x61, 0xd7, 0x11, 0xe7, 0xb6, 0x87, 0x78, 0x69, 0x7b,
-	0xcb, 0x52, 0xf9, 0x2d, 0x80, 0xcd, 0xf2, 0x16, 0xc7, 0x7c, 0x36, 0x35, 0x45, 0x87, 0x83, 0x45,
-	0x87, 0x77, 0x60, 0x15, 0xb5, 0x4e, 0xb5, 0x9b, 0xdf, 0xe3, 0x3b, 0xdc, 0x89, 0x6c, 0x0f, 0xea,
-	0x89, 0x30, 0xc2, 0xf3, 0x90, 0x2d, 0xc7, 0x60, 0xdf, 0x3e, 0xbe, 0xc3, 0xc9, 0x82, 0x3d, 0x85,
-	0x7a, 0x65, 0xe9, 0xdc, 0x73, 0xed, 0xbd, 0x36, 0x34, 0x9c, 0x4c, 0x5e, 0xaf, 0x43, 0x43, 0x53,
-	0x20, 0xd1, 0x11, 0x6c, 0x72, 0x9c, 0xc8, 0xdc, 0x60, 0xb9, 0x30, 0x77, 0xa0, 0x91, 0x63, 0xac,
-	0xb1, 0xd8, 0x2e, 0x5e, 0xb2, 0x7c, 0x8b, 0x45, 0x26, 0x62, 0x69, 0xe6, 0xbe, 0x78, 0xa5, 0x1c,
-	0xfd, 0x1e, 0x40, 0xe7, 0x5d, 0x6a, 0xe4, 0x78, 0xee, 0xab, 0x72, 0x4b, 0xe9, 0xbb, 0x50, 0xfb,
-	0x98, 0x8e, 0x8a, 0xfd, 0xf4, 0x31, 0x1d, 0xd9, 0x97, 0x8c, 0xc8, 0x2f, 0x4e, 0x12, 0x8a, 0xb9,
-	0xc6, 0xbd, 0xb4, 0xc4, 0xec, 0xad, 0x6b, 0xcc, 0xfe, 0x8f, 0x04, 0xfd, 0x3b, 0x80, 0x76, 0x75,
-	0xe6, 0xed, 0x0e, 0xd4, 0x18, 0xcb, 0x4c, 0xa2, 0x32, 0x7e, 0x04, 0x17, 0x00, 0xfb, 0x06, 0x60,
-	0x2c, 0x62, 0x1c, 0xba, 0xcf, 0x8c, 0x6b, 0x66, 0xd3, 0x22, 0x1f, 0x2c, 0xc0, 0x1e, 0xc0, 0xfa,
-	0x67, 0xa9, 0x86, 0x99, 0x4e, 0x47, 0x7e, 0x24, 0xd7, 0x3e, 0x4b, 0xd5, 0xd7, 0xe9, 0x88, 0xed,
-	0xc3, 0xdd, 0xd2, 0xcd, 0x50, 0x0b, 0x95, 0x0c, 0x69, 0x70, 0xdd, 0x80, 0x6e, 0x95, 0x2a, 0x2e,
-	0x54, 0x72, 0x6c, 0xa7, 0x98, 0x41, 0x3d, 0x47, 0x4c, 0xfc, 0xa8, 0xd2, 0x99, 0x3d, 0x85, 0x2e,
-	0x5e, 0x65, 0x52, 0x0b, 0x23, 0x53, 0x35, 0x1c, 0x4d, 0xd3, 0xf8, 0x82, 0x36, 0x6d, 0x9b, 0x6f,
-	0x2e, 0xf0, 0xd7, 0x16, 0x8e, 0x4e, 0x80, 0xb9, 0xb4, 0x06, 0xa8, 0x12, 0xd4, 0x3e, 0xb9, 0x47,
-	0xd0, 0xce, 0x49, 0x1e, 0xaa, 0x54, 0xc5, 0xee, 0xeb, 0xd5, 0xe1, 0x2d, 0x87, 0xbd, 0xb3, 0xd0,
-	0x2d, 0x3c, 0xfd, 0x02, 0x3b, 0xce, 0xd5, 0x51, 0xf9, 0x86, 0x77, 0xf7, 0x04, 0x36, 0x62, 0x8d,
-	0x2e, 0x1a, 0x9d, 0xce, 0x54, 0xe2, 0x89, 0xdb, 0x29, 0x50, 0x6e, 0x41, 0xf6, 0x12, 0x1e, 0x2c,
-	0x9b, 0xb9, 0xd0, 0x5d, 0x01, 0xdc, 0x43, 0x3b, 0x4b, 0x37, 0x28, 0x05, 0x5b, 0x85, 0xe8, 0xcf,
-	0x15, 0x58, 0xeb, 0x8b, 0x39, 0x31, 0xe7, 0xc6, 0xde, 0x0e, 0xbe, 0x6e, 0x6f, 0x13, 0x6f, 0x6d,
-	0x82, 0xfe, 0x2d, 0x2f, 0xb1, 0x63, 0xd8, 0xaa, 0x54, 0xd3, 0xfb, 0x74, 0xe3, 0xf4, 0xbf, 0x8a,
-	0xcf, 0xeb, 0x59, 0xf3, 0x4a, 0x0f, 0xfc, 0x0b, 0x27, 0xb0, 0xed, 0x23, 0xf3, 0xd5, 0xf5, 0xce,
-	0xea, 0xc4, 0xc1, 0xfb, 0x15, 0x67, 0xd5, 0x6e, 0x70, 0x66, 0x6e, 0x76, 0xe8, 0x39, 0x6c, 0xe0,
-	0x55, 0x86, 0xb1, 0xc1, 0x64, 0x48, 0xdf, 0x12, 0x22, 0xc0, 0xcd, 0x0f, 0x4d, 0xa7, 0xb0, 0x22,
-	0xe8, 0xe0, 0x0a, 0xda, 0xd5, 0x91, 0x66, 0xaf, 0x61, 0xf3, 0x2d, 0x9a, 0x25, 0x28, 0xbc, 0x31,
-	0xf8, 0x7e, 0xb0, 0x77, 0x6f, 0x5f, 0x09, 0xec, 0x31, 0xd4, 0xed, 0x9f, 0x15, 0x73, 0xbf, 0x29,
-	0xc5, 0x4f, 0xd6, 0xee, 0xb2, 0x78, 0xf0, 0x0e, 0xe0, 0x6c, 0xf1, 0x6d, 0xfd, 0x1e, 0x58, 0xb1,
-	0x36, 0x2a, 0xe8, 0x36, 0x5d, 0xb9, 0xb6, 0x4f, 0x76, 0xdd, 0xce, 0x5a, 0xda, 0x0e, 0xdf, 0x05,
-	0xa3, 0x06, 0xfd, 0xdb, 0x1d, 0xfe, 0x1b, 0x00, 0x00, 0xff, 0xff, 0x43, 0x5f, 0xac, 0xdd, 0xef,
-	0x09, 0x00, 0x00,
+	// 1209 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x56, 0x4d, 0x6f, 0xdb, 0x46,
+	0x13, 0x0e, 0x2d, 0x59, 0xb6, 0x46, 0x92, 0x2d, 0x6f, 0x1c, 0x87, 0xf1, 0xfb, 0x36, 0x50, 0x88,
+	0x18, 0x50, 0x80, 0xc6, 0x2d, 0x6c, 0x24, 0x40, 0x6e, 0xcd, 0x87, 0x11, 0x1b, 0x28, 0x62, 0x61,
+	0xe5, 0x04, 0xe8, 0x89, 0x58, 0x91, 0x23, 0x69, 0x63, 0x8a, 0x64, 0x76, 0x57, 0x8d, 0x15, 0xf4,
+	0x8f, 0xb4, 0x87, 0x1e, 0x0a, 0xf4, 0xd7, 0xf4, 0xdc, 0x53, 0xff, 0x4c, 0xb1, 0x1f, 0xa4, 0x28,
+	0xdb, 0x87, 0xb6, 0x27, 0xed, 0x3c, 0x33, 0x3b, 0x9c, 0x8f, 0x67, 0x66, 0x05, 0xdd, 0x14, 0xd5,
+	0x37, 0x49, 0x1e, 0x8a, 0x3c, 0x3a, 0xcc, 0x45, 0xa6, 0x32, 0x52, 0x4b, 0x51, 0x05, 0x3d, 0xd8,
+	0x1c, 0xf0, 0x74, 0x32, 0xc8, 0xd2, 0x09, 0xd9, 0x85, 0xf5, 0x1f, 0x59, 0x32, 0x47, 0xdf, 0xeb,
+	0x79, 0xfd, 0x36, 0xb5, 0x42, 0x10, 0xc2, 0xdd, 0x73, 0x11, 0x4d, 0x51, 0x2a, 0xc1, 0x54, 0x26,
+	0x28, 0x7e, 0x9a, 0xa3, 0x54, 0xc4, 0x87, 0x0d, 0x16, 0xc7, 0x02, 0xa5, 0x74, 0xe6, 0x85, 0x48,
+	0xba, 0x50, 0x93, 0x7c, 0xe2, 0xaf, 0x19, 0x54, 0x1f, 0xc9, 0xff, 0xa1, 0xa9, 0xf8, 0x0c, 0xa5,
+	0x62, 0xb3, 0xdc, 0xaf, 0xf5, 0xbc, 0x7e, 0x8d, 0x2e, 0x81, 0xe0, 0x67, 0x0f, 0x1a, 0xe7, 0xc3,
+	0xb3, 0x74, 0x9c, 0x91, 0x17, 0xd0, 0x92, 0x2a, 0x13, 0x6c, 0x82, 0x17, 0x8b, 0xdc, 0xc6, 0xb1,
+	0x75, 0x74, 0xff, 0x30, 0x45, 0x75, 0x68, 0x2d, 0x0e, 0x87, 0x4b, 0x35, 0xad, 0xda, 0x92, 0x03,
+	0x68, 0xc8, 0x63, 0x9e, 0x8e, 0x33, 0xbf, 0xdb, 0xf3, 0xfa, 0xad, 0xa3, 0x8e, 0xb9, 0x35, 0x3c,
+	0xb6, 0xf7, 0xa8, 0x53, 0x06, 0x4f, 0xa1, 0x55, 0x71, 0x41, 0x00, 0x1a, 0x6f, 0xce, 0xe8, 0xc9,
+	0xeb, 0x8b, 0xee, 0x1d, 0xd2, 0x80, 0xb5, 0xe1, 0x71, 0xd7, 0xd3, 0xd8, 0xdb, 0xf3, 0xf3, 0xb7,
+	0xdf, 0x9f, 0x74, 0xd7, 0x82, 0xdf, 0x3c, 0xd8, 0x2c, 0x7c, 0x10, 0x02, 0xf5, 0x69, 0x26, 0x95,
+	0x09, 0xab, 0x49, 0xcd, 0x59, 0x27, 0x7b, 0x89, 0x0b, 0x93, 0x6c, 0x93, 0xea, 0x23, 0xd9, 0x83,
+	0x46, 0x9e, 0x25, 0x3c, 0x5a, 0x98, 0x4c, 0x9b, 0xd4, 0x49, 0xba, 0x08, 0x92, 0x4f, 0x52, 0xa6,
+	0xe6, 0x02, 0xfd, 0xba, 0x51, 0x2d, 0x01, 0xf2, 0x10, 0x20, 0x12, 0x18, 0x63, 0xaa, 0x38, 0x4b,
+	0xfc, 0x75, 0xa3, 0xae, 0x20, 0x64, 0x1f, 0x36, 0xaf, 0x5e, 0xce, 0xbe, 0xbc, 0x61, 0x0a, 0xfd,
+	0x86, 0xd1, 0x96, 0x72, 0xf0, 0x1e, 0x9a, 0x03, 0xc1, 0x23, 0x34, 0x41, 0x06, 0xd0, 0xce, 0xb5,
+	0x30, 0x40, 0xf1, 0x3e, 0xe5, 0x36, 0xd8, 0x1a, 0x5d, 0xc1, 0xc8, 0x63, 0xe8, 0xe4, 0xfc, 0x0a,
+	0x13, 0x59, 0x18, 0xad, 0x19, 0xa3, 0x55, 0x30, 0xf8, 0x63, 0x0d, 0xba, 0xd5, 0xce, 0x1b, 0xf7,
+	0x0f, 0x01, 0x94, 0x60, 0xa9, 0x8c, 0xb2, 0x18, 0x85, 0xab, 0x44, 0x05, 0x21, 0xcf, 0xa1, 0xa3,
+	0x78, 0x74, 0x89, 0x2a, 0xcc, 0x99, 0x60, 0x33, 0x69, 0x5c, 0xb7, 0x8e, 0x76, 0x4c, 0x37, 0x2e,
+	0x8c, 0x66, 0x60, 0x14, 0xb4, 0xad, 0x2a, 0x12, 0x79, 0x0a, 0x60, 0x42, 0x0c, 0x4d, 0x0b, 0x6b,
+	0xe6, 0xd2, 0x96, 0xb9, 0x54, 0xa6, 0x46, 0x9b, 0x79, 0x99, 0xe5, 0x01, 0x6c, 0x49, 0x9c, 0xcc,
+	0x30, 0x55, 0xa1, 0xae, 0x21, 0x0a, 0x53, 0xd1, 0x36, 0xed, 0x38, 0x74, 0x68, 0x40, 0xf2, 0x35,
+	0x90, 0x55, 0x33, 0xfd, 0x63, 0xaa, 0xdb, 0xa6, 0xdd, 0x15, 0xd3, 0x21, 0x9f, 0x90, 0x00, 0x3a,
+	0x2a, 0x91, 0x61, 0x84, 0x42, 0x85, 0x53, 0x26, 0xa7, 0xa6, 0xd0, 0x6d, 0xda, 0x52, 0x89, 0x7c,
+	0x8d, 0x42, 0x9d, 0x32, 0x39, 0x2d, 0xc8, 0xbd, 0xb1, 0x24, 0xf7, 0x01, 0x6c, 0x38, 0x1e, 0xfa,
+	0xbd, 0x5e, 0xad, 0xdf, 0x3a, 0x6a, 0x55, 0xf8, 0x4a, 0x0b, 0x5d, 0xf0, 0x97, 0x07, 0x1b, 0x43,
+	0x9c, 0xbc, 0x61, 0x8a, 0xe9, 0x22, 0xce, 0x58, 0xca, 0xc7, 0x28, 0xd5, 0x59, 0xec, 0xc6, 0xa7,
+	0x82, 0x98, 0x8f, 0xe0, 0x27, 0xd7, 0x15, 0x7d, 0x34, 0xd4, 0xd3, 0x11, 0xd5, 0x8c, 0xad, 0x39,
+	0x6b, 0x4a, 0xe4, 0x22, 0x1b, 0xf3, 0x04, 0xa5, 0xcb, 0xbe, 0x94, 0x8b, 0x30, 0xd7, 0xff, 0x6d,
+	0x98, 0xe4, 0x19, 0xb4, 0xc7, 0xf3, 0x24, 0x19, 0x14, 0x8e, 0x1f, 0xf5, 0x6a, 0x65, 0xfb, 0x3e,
+	0xf0, 0x18, 0x33, 0xa7, 0xa1, 0x2b, 0x66, 0xc1, 0x4f, 0xd0, 0xae, 0x6a, 0x75, 0xbc, 0x29, 0x9b,
+	0xa1, 0x99, 0xc5, 0x26, 0x35, 0x67, 0xbd, 0x5e, 0x3e, 0xf3, 0x58, 0x4d, 0xfd, 0x9d, 0x9e, 0xd7,
+	0x5f, 0xa7, 0x56, 0xd0, 0xe3, 0x32, 0x45, 0x3e, 0x99, 0x2a, 0x9f, 0x18, 0xd8, 0x49, 0x7a, 0xbf,
+	0x8c, 0xb8, 0x26, 0x1e, 0xfa, 0x77, 0x8d, 0xa2, 0x10, 0x75, 0x6e, 0xe3, 0x5c, 0xfa, 0xbb, 0x3d,
+	0xaf, 0xdf, 0xa1, 0xfa, 0x18, 0xbc, 0x84, 0x7b, 0x17, 0x05, 0x05, 0xe3, 0xa1, 0x6d, 0xab, 0x29,
+	0x74, 0x17, 0x6a, 0x73, 0x91, 0x38, 0x9a, 0xea, 0xa3, 0x99, 0x4e, 0xc3, 0x72, 0x57, 0x5d, 0x27,
+	0x05, 0x3f, 0x40, 0xa7, 0x74, 0x61, 0xae, 0x3e, 0x87, 0x4d, 0x47, 0x10, 0xbd, 0xe0, 0x74, 0x11,
+	0xf6, 0x2d, 0x87, 0x6f, 0xfb, 0x10, 0x2d, 0x6d, 0x6f, 0x6e, 0xbf, 0xe0, 0x17, 0x0f, 0xb6, 0xcb,
+	0x5b, 0x14, 0xe5, 0x3c, 0x51, 0x45, 0x87, 0xbd, 0x65, 0x87, 0xf7, 0x60, 0x1d, 0x85, 0xc8, 0x84,
+	0x5d, 0x25, 0xa7, 0x77, 0xa8, 0x15, 0x49, 0x1f, 0xea, 0x31, 0x53, 0xcc, 0x8d, 0x04, 0x59, 0x8d,
+	0x41, 0x7f, 0xfb, 0xf4, 0x0e, 0x35, 0x16, 0xe4, 0x09, 0xd4, 0x2b, 0xfb, 0xef, 0x9e, 0x6d, 0xef,
+	0xb5, 0xf9, 0xa5, 0xc6, 0xe4, 0xd5, 0x26, 0x34, 0x84, 0x09, 0x24, 0x38, 0x81, 0x6d, 0x8a, 0x13,
+	0x2e, 0x15, 0x96, 0x9b, 0x7d, 0x0f, 0x1a, 0x12, 0x23, 0x81, 0xc5, 0xa2, 0x73, 0x92, 0xe6, 0x5b,
+	0xc4, 0x72, 0x16, 0x71, 0xb5, 0x70, 0xc5, 0x2b, 0xe5, 0xe0, 0x57, 0x0f, 0x3a, 0xef, 0x32, 0xc5,
+	0xc7, 0x0b, 0x57, 0x95, 0x5b, 0x4a, 0xdf, 0x85, 0xda, 0xc7, 0x6c, 0x54, 0xac, 0xca, 0x8f, 0xd9,
+	0x48, 0x7f, 0x49, 0x31, 0x79, 0x79, 0x16, 0x9b, 0x98, 0x6b, 0xd4, 0x49, 0x2b, 0xcc, 0xde, 0xb9,
+	0xc6, 0xec, 0xff, 0x48, 0xd0, 0x3f, 0x3d, 0x68, 0x57, 0xd7, 0x8f, 0x5e, 0xc7, 0x02, 0x23, 0x9e,
+	0x73, 0x4c, 0x95, 0x1b, 0xc1, 0x25, 0x40, 0xbe, 0x02, 0x18, 0xb3, 0x08, 0x43, 0xfb, 0x1e, 0xda,
+	0x66, 0x36, 0x35, 0xf2, 0x41, 0x03, 0xe4, 0x01, 0x6c, 0x7e, 0xe6, 0x69, 0x98, 0x8b, 0x6c, 0xe4,
+	0x46, 0x72, 0xe3, 0x33, 0x4f, 0x07, 0x22, 0x1b, 0x91, 0x43, 0xb8, 0x5b, 0xba, 0x09, 0x05, 0x4b,
+	0x63, 0xbb, 0x4a, 0xec, 0x80, 0xee, 0x94, 0x2a, 0xca, 0xd2, 0xd8, 0x2c, 0x14, 0x02, 0x75, 0x89,
+	0x18, 0xbb, 0x51, 0x35, 0x67, 0xf2, 0x04, 0xba, 0x78, 0x95, 0x73, 0xc1, 0x14, 0xcf, 0xd2, 0x70,
+	0x94, 0x64, 0xd1, 0xa5, 0xdb, 0x45, 0xdb, 0x4b, 0xfc, 0x95, 0x86, 0x83, 0x33, 0x20, 0x36, 0xad,
+	0x21, 0xa6, 0x31, 0x0a, 0x97, 0xdc, 0x23, 0x68, 0x4b, 0x23, 0x87, 0x69, 0x96, 0x46, 0xf6, 0x21,
+	0xed, 0xd0, 0x96, 0xc5, 0xde, 0x69, 0xe8, 0x16, 0x9e, 0x7e, 0x81, 0x3d, 0xeb, 0xea, 0xa4, 0xfc,
+	0x86, 0x73, 0x77, 0x00, 0x5b, 0x91, 0x40, 0x1b, 0x8d, 0xc8, 0xe6, 0x69, 0xec, 0x88, 0xdb, 0x29,
+	0x50, 0xaa, 0x41, 0xf2, 0x02, 0x1e, 0xac, 0x9a, 0xd9, 0xd0, 0x6d, 0x01, 0xec, 0x87, 0xf6, 0x56,
+	0x6e, 0x98, 0x14, 0x74, 0x15, 0x82, 0xdf, 0xd7, 0x60, 0x63, 0xc0, 0x16, 0x86, 0x39, 0x37, 0x9e,
+	0x10, 0xef, 0x9f, 0x3d, 0x21, 0x86, 0xb7, 0x3a, 0x41, 0xf7, 0x2d, 0x27, 0x91, 0x53, 0xd8, 0xa9,
+	0x54, 0xd3, 0xf9, 0xb4, 0xe3, 0xf4, 0xbf, 0x8a, 0xcf, 0xeb, 0x59, 0xd3, 0x2e, 0x5e, 0x43, 0xc8,
+	0x19, 0xec, 0xba, 0xc8, 0x5c, 0x75, 0x9d, 0xb3, 0xba, 0xe1, 0xe0, 0xfd, 0x8a, 0xb3, 0x6a, 0x37,
+	0x28, 0x51, 0x37, 0x3b, 0xf4, 0x0c, 0xb6, 0xf0, 0x2a, 0xc7, 0x48, 0x61, 0x1c, 0x9a, 0x67, 0xcd,
+	0x5f, 0xbf, 0xf5, 0xcd, 0xeb, 0x14, 0x56, 0x06, 0x3a, 0xba, 0x82, 0x76, 0x75, 0xa4, 0xc9, 0x2b,
+	0xd8, 0x7e, 0x8b, 0x6a, 0x05, 0xf2, 0x6f, 0x0c, 0xbe, 0x1b, 0xec, 0xfd, 0xdb, 0x57, 0x02, 0x79,
+	0x0c, 0x75, 0xfd, 0x17, 0x90, 0xd8, 0x7f, 0x4c, 0xc5, 0xbf, 0xc1, 0xfd, 0x55, 0xf1, 0xe8, 0x1d,
+	0xc0, 0xc5, 0xf2, 0x99, 0xff, 0x0e, 0x48, 0xb1, 0x36, 0x2a, 0xe8, 0xae, 0xb9, 0x72, 0x6d, 0x9f,
+	0xec, 0xdb, 0x9d, 0xb5, 0xb2, 0x1d, 0xbe, 0xf5, 0x46, 0x0d, 0xf3, 0x27, 0xf4, 0xf8, 0xef, 0x01,
+	0x00, 0x7b, 0xc6, 0x54, 0x86, 0x98, 0x0a, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
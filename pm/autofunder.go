@@ -0,0 +1,355 @@
+package pm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/golang/glog"
+	"github.com/livepeer/go-livepeer/monitor"
+)
+
+// Reasons reported in a FundingWarning
+const (
+	// FundingLowBalance indicates that the ETH balance of the account does not cover a top up
+	FundingLowBalance = "lowBalance"
+	// FundingSpendCap indicates that a top up would exceed the maximum amount to fund
+	FundingSpendCap = "spendCap"
+	// FundingUnlocking indicates that the deposit and reserve are unlocking and are not topped up
+	FundingUnlocking = "unlocking"
+)
+
+// AutoFundConfig describes when and how much an AutoFunder funds.
+// The deposit (reserve) is topped up to DepositTarget (ReserveTarget)
+// when it falls below DepositThreshold (ReserveThreshold), which defaults
+// to the target. A zero target disables funding the deposit (reserve)
+type AutoFundConfig struct {
+	DepositThreshold *big.Int
+	DepositTarget    *big.Int
+	ReserveThreshold *big.Int
+	ReserveTarget    *big.Int
+
+	// MaxSpend is the maximum total amount funded. Zero is unlimited
+	MaxSpend *big.Int
+
+	// MinBalance is the ETH balance kept in the account to pay for gas
+	MinBalance *big.Int
+}
+
+// FundingWarning describes why the deposit and reserve cannot be topped up
+type FundingWarning struct {
+	Reason  string
+	Deposit *big.Int
+	Reserve *big.Int
+	// Required is the amount needed for the current or next top up
+	Required *big.Int
+	// Available is the amount that can be funded given the ETH balance and MaxSpend
+	Available *big.Int
+}
+
+// BalanceReader reads the ETH balance of an account
+type BalanceReader interface {
+	BalanceAt(ctx context.Context, account ethcommon.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// FundingStore persists the total amount funded for an account so that
+// MaxSpend applies across restarts
+type FundingStore interface {
+	FundedAmount(addr ethcommon.Address) (*big.Int, error)
+	SetFundedAmount(addr ethcommon.Address, amount *big.Int) error
+}
+
+// AutoFunder tops up a sender's deposit and reserve on new blocks using the
+// deposit and reserve tracked by a SenderManager
+type AutoFunder struct {
+	addr      ethcommon.Address
+	cfg       AutoFundConfig
+	broker    Broker
+	smgr      SenderManager
+	tm        TimeManager
+	bal       BalanceReader
+	store     FundingStore
+	onWarning func(FundingWarning)
+
+	mu      sync.Mutex
+	spent   *big.Int
+	funding bool
+
+	// warned holds the reasons that have been reported and still apply.
+	// It is only accessed by fund()
+	warned map[string]bool
+
+	quit chan struct{}
+}
+
+// NewAutoFunder validates the config and returns an AutoFunder for addr.
+// If store is not nil, the total amount funded is loaded from and saved to it.
+// onWarning, if not nil, is called when a top up is not possible
+func NewAutoFunder(addr ethcommon.Address, cfg AutoFundConfig, broker Broker, smgr SenderManager, tm TimeManager, bal BalanceReader, store FundingStore, onWarning func(FundingWarning)) (*AutoFunder, error) {
+	cfg.DepositThreshold = orZero(cfg.DepositThreshold)
+	cfg.DepositTarget = orZero(cfg.DepositTarget)
+	cfg.ReserveThreshold = orZero(cfg.ReserveThreshold)
+	cfg.ReserveTarget = orZero(cfg.ReserveTarget)
+	cfg.MaxSpend = orZero(cfg.MaxSpend)
+	cfg.MinBalance = orZero(cfg.MinBalance)
+
+	for _, v := range []*big.Int{cfg.DepositThreshold, cfg.DepositTarget, cfg.ReserveThreshold, cfg.ReserveTarget, cfg.MaxSpend, cfg.MinBalance} {
+		if v.Sign() < 0 {
+			return nil, errors.New("funding amounts must not be negative")
+		}
+	}
+	if cfg.DepositThreshold.Sign() == 0 {
+		cfg.DepositThreshold.Set(cfg.DepositTarget)
+	}
+	if cfg.ReserveThreshold.Sign() == 0 {
+		cfg.ReserveThreshold.Set(cfg.ReserveTarget)
+	}
+	if cfg.DepositTarget.Sign() > 0 && cfg.DepositTarget.Cmp(cfg.DepositThreshold) < 0 {
+		return nil, fmt.Errorf("deposit target %v is below the deposit threshold %v", cfg.DepositTarget, cfg.DepositThreshold)
+	}
+	if cfg.ReserveTarget.Sign() > 0 && cfg.ReserveTarget.Cmp(cfg.ReserveThreshold) < 0 {
+		return nil, fmt.Errorf("reserve target %v is below the reserve threshold %v", cfg.ReserveTarget, cfg.ReserveThreshold)
+	}
+
+	spent := big.NewInt(0)
+	if store != nil {
+		funded, err := store.FundedAmount(addr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load funded amount: %v", err)
+		}
+		if funded != nil {
+			spent.Set(funded)
+		}
+	}
+
+	return &AutoFunder{
+		addr:      addr,
+		cfg:       cfg,
+		broker:    broker,
+		smgr:      smgr,
+		tm:        tm,
+		bal:       bal,
+		store:     store,
+		onWarning: onWarning,
+		spent:     spent,
+		warned:    make(map[string]bool),
+		quit:      make(chan struct{}),
+	}, nil
+}
+
+// Start checks the deposit and reserve on every new block
+func (af *AutoFunder) Start() {
+	blocks := make(chan *big.Int, 10)
+	sub := af.tm.SubscribeBlocks(blocks)
+
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case <-af.quit:
+				return
+			case err := <-sub.Err():
+				glog.Errorf("Block subscription error err=%v", err)
+			case <-blocks:
+				// Skip the block if a top up is still being confirmed
+				if !af.startFunding() {
+					continue
+				}
+				go func() {
+					defer af.endFunding()
+					if err := af.fund(); err != nil {
+						glog.Errorf("Error funding deposit and reserve err=%v", err)
+					}
+				}()
+			}
+		}
+	}()
+}
+
+// Stop stops checking the deposit and reserve
+func (af *AutoFunder) Stop() {
+	close(af.quit)
+}
+
+// Spent returns the total amount funded
+func (af *AutoFunder) Spent() *big.Int {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	return new(big.Int).Set(af.spent)
+}
+
+func (af *AutoFunder) startFunding() bool {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	if af.funding {
+		return false
+	}
+	af.funding = true
+	return true
+}
+
+func (af *AutoFunder) endFunding() {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	af.funding = false
+}
+
+// fund tops up the deposit and reserve if they are below their thresholds
+// as far as the ETH balance and MaxSpend allow, and reports a warning if
+// the current or next top up is not covered
+func (af *AutoFunder) fund() error {
+	info, err := af.smgr.GetSenderInfo(af.addr)
+	if err != nil {
+		return err
+	}
+	balance, err := af.bal.BalanceAt(context.Background(), af.addr, nil)
+	if err != nil {
+		return err
+	}
+	if monitor.Enabled {
+		monitor.SenderFunds(info.Deposit, info.Reserve.FundsRemaining, balance)
+	}
+
+	warnings := make(map[string]bool)
+	defer func() { af.warned = warnings }()
+
+	if info.WithdrawRound.Int64() != 0 {
+		af.warn(warnings, FundingUnlocking, info, big.NewInt(0), big.NewInt(0))
+		return nil
+	}
+
+	depositNeed := topUp(info.Deposit, af.cfg.DepositThreshold, af.cfg.DepositTarget)
+	reserveNeed := topUp(info.Reserve.FundsRemaining, af.cfg.ReserveThreshold, af.cfg.ReserveTarget)
+	need := new(big.Int).Add(depositNeed, reserveNeed)
+
+	// Warn ahead of time if the next top up from the thresholds will not be covered
+	required := new(big.Int).Add(
+		new(big.Int).Sub(af.cfg.DepositTarget, minInt(af.cfg.DepositThreshold, af.cfg.DepositTarget)),
+		new(big.Int).Sub(af.cfg.ReserveTarget, minInt(af.cfg.ReserveThreshold, af.cfg.ReserveTarget)),
+	)
+	if need.Cmp(required) > 0 {
+		required = need
+	}
+
+	available := new(big.Int).Sub(balance, af.cfg.MinBalance)
+	if available.Sign() < 0 {
+		available = big.NewInt(0)
+	}
+	reason := FundingLowBalance
+	if af.cfg.MaxSpend.Sign() > 0 {
+		capLeft := new(big.Int).Sub(af.cfg.MaxSpend, af.Spent())
+		if capLeft.Cmp(available) < 0 {
+			available = capLeft
+			reason = FundingSpendCap
+		}
+	}
+	if available.Cmp(required) < 0 {
+		af.warn(warnings, reason, info, required, available)
+	}
+
+	if need.Sign() == 0 || available.Sign() <= 0 {
+		return nil
+	}
+
+	// The deposit is topped up before the reserve if both cannot be covered
+	depositAmount := minInt(depositNeed, available)
+	reserveAmount := minInt(reserveNeed, new(big.Int).Sub(available, depositAmount))
+
+	return af.submit(depositAmount, reserveAmount)
+}
+
+func (af *AutoFunder) submit(depositAmount, reserveAmount *big.Int) error {
+	var (
+		tx  *types.Transaction
+		err error
+	)
+	switch {
+	case depositAmount.Sign() > 0 && reserveAmount.Sign() > 0:
+		tx, err = af.broker.FundDepositAndReserve(depositAmount, reserveAmount)
+	case depositAmount.Sign() > 0:
+		tx, err = af.broker.FundDeposit(depositAmount)
+	default:
+		tx, err = af.broker.FundReserve(reserveAmount)
+	}
+	if err != nil {
+		return err
+	}
+
+	total := new(big.Int).Add(depositAmount, reserveAmount)
+	af.mu.Lock()
+	af.spent.Add(af.spent, total)
+	spent := new(big.Int).Set(af.spent)
+	af.mu.Unlock()
+
+	if af.store != nil {
+		if err := af.store.SetFundedAmount(af.addr, spent); err != nil {
+			glog.Errorf("Error saving funded amount spent=%v err=%v", spent, err)
+		}
+	}
+
+	glog.Infof("Funding deposit=%v reserve=%v tx=%v", depositAmount, reserveAmount, tx.Hash().Hex())
+	if err := af.broker.CheckTx(tx); err != nil {
+		return err
+	}
+	if monitor.Enabled {
+		monitor.ValueFunded(total)
+	}
+
+	// Refresh the cached deposit and reserve so that the next check does
+	// not race with the processing of the funding events
+	af.smgr.Clear(af.addr)
+
+	return nil
+}
+
+func (af *AutoFunder) warn(warnings map[string]bool, reason string, info *SenderInfo, required, available *big.Int) {
+	warnings[reason] = true
+	// Only report a warning when it starts to apply
+	if af.warned[reason] {
+		return
+	}
+
+	glog.Warningf("Unable to top up deposit and reserve reason=%v deposit=%v reserve=%v required=%v available=%v",
+		reason, info.Deposit, info.Reserve.FundsRemaining, required, available)
+	if monitor.Enabled {
+		monitor.FundingWarning(reason)
+	}
+	if af.onWarning != nil {
+		af.onWarning(FundingWarning{
+			Reason:    reason,
+			Deposit:   new(big.Int).Set(info.Deposit),
+			Reserve:   new(big.Int).Set(info.Reserve.FundsRemaining),
+			Required:  required,
+			Available: available,
+		})
+	}
+}
+
+// topUp returns the amount needed to bring a value to target if it is below threshold
+func topUp(val, threshold, target *big.Int) *big.Int {
+	if target.Sign() == 0 || val.Cmp(threshold) >= 0 || val.Cmp(target) >= 0 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Sub(target, val)
+}
+
+func minInt(x, y *big.Int) *big.Int {
+	if x.Cmp(y) < 0 {
+		return new(big.Int).Set(x)
+	}
+	return new(big.Int).Set(y)
+}
+
+func orZero(x *big.Int) *big.Int {
+	if x == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(x)
+}
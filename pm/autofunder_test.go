@@ -0,0 +1,297 @@
+package pm
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAutoFunder(t *testing.T, cfg AutoFundConfig) (*AutoFunder, *stubBroker, *stubSenderManager, *stubBalanceReader, *[]FundingWarning) {
+	broker := newStubBroker()
+	smgr := newStubSenderManager()
+	bal := &stubBalanceReader{balance: big.NewInt(1000)}
+	var warnings []FundingWarning
+	af, err := NewAutoFunder(RandAddress(), cfg, broker, smgr, &stubTimeManager{}, bal, nil, func(w FundingWarning) {
+		warnings = append(warnings, w)
+	})
+	require.Nil(t, err)
+	return af, broker, smgr, bal, &warnings
+}
+
+func senderInfo(deposit, reserve int64) *SenderInfo {
+	return &SenderInfo{
+		Deposit:       big.NewInt(deposit),
+		WithdrawRound: big.NewInt(0),
+		Reserve: &ReserveInfo{
+			FundsRemaining:        big.NewInt(reserve),
+			ClaimedInCurrentRound: big.NewInt(0),
+		},
+	}
+}
+
+func TestNewAutoFunder_InvalidConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewAutoFunder(RandAddress(), AutoFundConfig{MaxSpend: big.NewInt(-1)}, nil, nil, nil, nil, nil, nil)
+	assert.EqualError(err, "funding amounts must not be negative")
+
+	_, err = NewAutoFunder(RandAddress(), AutoFundConfig{DepositThreshold: big.NewInt(10), DepositTarget: big.NewInt(5)}, nil, nil, nil, nil, nil, nil)
+	assert.EqualError(err, "deposit target 5 is below the deposit threshold 10")
+
+	_, err = NewAutoFunder(RandAddress(), AutoFundConfig{ReserveThreshold: big.NewInt(10), ReserveTarget: big.NewInt(5)}, nil, nil, nil, nil, nil, nil)
+	assert.EqualError(err, "reserve target 5 is below the reserve threshold 10")
+
+	// Thresholds without targets are allowed and disable funding
+	_, err = NewAutoFunder(RandAddress(), AutoFundConfig{DepositThreshold: big.NewInt(10)}, nil, nil, nil, nil, nil, nil)
+	assert.Nil(err)
+}
+
+func TestAutoFunder_Fund(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg := AutoFundConfig{
+		DepositThreshold: big.NewInt(50),
+		DepositTarget:    big.NewInt(100),
+		ReserveThreshold: big.NewInt(100),
+		ReserveTarget:    big.NewInt(200),
+	}
+	af, broker, smgr, _, warnings := newTestAutoFunder(t, cfg)
+
+	// Above the thresholds nothing is funded
+	smgr.info[af.addr] = senderInfo(50, 100)
+	require.Nil(af.fund())
+	assert.Empty(broker.getFundings())
+
+	// Deposit only
+	smgr.info[af.addr] = senderInfo(49, 100)
+	require.Nil(af.fund())
+	fundings := broker.getFundings()
+	require.Len(fundings, 1)
+	assert.Equal(big.NewInt(51), fundings[0].deposit)
+	assert.Equal(big.NewInt(0), fundings[0].reserve)
+	assert.Equal(big.NewInt(51), af.Spent())
+	// The cached sender info is cleared after funding
+	assert.NotContains(smgr.info, af.addr)
+
+	// Reserve only
+	smgr.info[af.addr] = senderInfo(60, 0)
+	require.Nil(af.fund())
+	fundings = broker.getFundings()
+	require.Len(fundings, 2)
+	assert.Equal(big.NewInt(0), fundings[1].deposit)
+	assert.Equal(big.NewInt(200), fundings[1].reserve)
+
+	// Deposit and reserve
+	smgr.info[af.addr] = senderInfo(10, 90)
+	require.Nil(af.fund())
+	fundings = broker.getFundings()
+	require.Len(fundings, 3)
+	assert.Equal(big.NewInt(90), fundings[2].deposit)
+	assert.Equal(big.NewInt(110), fundings[2].reserve)
+	assert.Equal(big.NewInt(451), af.Spent())
+
+	assert.Empty(*warnings)
+}
+
+func TestAutoFunder_Fund_LowBalance(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg := AutoFundConfig{
+		DepositThreshold: big.NewInt(50),
+		DepositTarget:    big.NewInt(100),
+		ReserveThreshold: big.NewInt(100),
+		ReserveTarget:    big.NewInt(200),
+		MinBalance:       big.NewInt(20),
+	}
+	af, broker, smgr, bal, warnings := newTestAutoFunder(t, cfg)
+
+	// The next top up of 150 is not covered by a balance of 160 - 20
+	bal.balance = big.NewInt(160)
+	smgr.info[af.addr] = senderInfo(100, 200)
+	require.Nil(af.fund())
+	assert.Empty(broker.getFundings())
+	require.Len(*warnings, 1)
+	w := (*warnings)[0]
+	assert.Equal(FundingLowBalance, w.Reason)
+	assert.Equal(big.NewInt(150), w.Required)
+	assert.Equal(big.NewInt(140), w.Available)
+
+	// A warning is only reported once while it applies
+	smgr.info[af.addr] = senderInfo(100, 200)
+	require.Nil(af.fund())
+	assert.Len(*warnings, 1)
+
+	// The deposit is topped up first with what is available
+	smgr.info[af.addr] = senderInfo(0, 0)
+	require.Nil(af.fund())
+	fundings := broker.getFundings()
+	require.Len(fundings, 1)
+	assert.Equal(big.NewInt(100), fundings[0].deposit)
+	assert.Equal(big.NewInt(40), fundings[0].reserve)
+	assert.Len(*warnings, 1)
+
+	// Nothing is funded when the balance is below the minimum
+	bal.balance = big.NewInt(10)
+	smgr.info[af.addr] = senderInfo(0, 0)
+	require.Nil(af.fund())
+	assert.Len(broker.getFundings(), 1)
+
+	// The warning is reported again after it stopped applying
+	bal.balance = big.NewInt(1000)
+	smgr.info[af.addr] = senderInfo(100, 200)
+	require.Nil(af.fund())
+	bal.balance = big.NewInt(10)
+	require.Nil(af.fund())
+	assert.Len(*warnings, 2)
+}
+
+func TestAutoFunder_Fund_SpendCap(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg := AutoFundConfig{
+		DepositThreshold: big.NewInt(50),
+		DepositTarget:    big.NewInt(100),
+		MaxSpend:         big.NewInt(120),
+	}
+	af, broker, smgr, _, warnings := newTestAutoFunder(t, cfg)
+
+	smgr.info[af.addr] = senderInfo(0, 0)
+	require.Nil(af.fund())
+	assert.Empty(*warnings)
+
+	// Only 20 is left under the cap
+	smgr.info[af.addr] = senderInfo(0, 0)
+	require.Nil(af.fund())
+	fundings := broker.getFundings()
+	require.Len(fundings, 2)
+	assert.Equal(big.NewInt(20), fundings[1].deposit)
+	require.Len(*warnings, 1)
+	assert.Equal(FundingSpendCap, (*warnings)[0].Reason)
+	assert.Equal(big.NewInt(100), (*warnings)[0].Required)
+	assert.Equal(big.NewInt(20), (*warnings)[0].Available)
+
+	smgr.info[af.addr] = senderInfo(0, 0)
+	require.Nil(af.fund())
+	assert.Len(broker.getFundings(), 2)
+	assert.Equal(big.NewInt(120), af.Spent())
+}
+
+func TestAutoFunder_Fund_SpendCapPersisted(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfg := AutoFundConfig{
+		DepositThreshold: big.NewInt(50),
+		DepositTarget:    big.NewInt(100),
+		MaxSpend:         big.NewInt(120),
+	}
+	addr := RandAddress()
+	broker := newStubBroker()
+	smgr := newStubSenderManager()
+	bal := &stubBalanceReader{balance: big.NewInt(1000)}
+	store := newStubFundingStore()
+
+	store.loadErr = errors.New("FundedAmount error")
+	_, err := NewAutoFunder(addr, cfg, broker, smgr, &stubTimeManager{}, bal, store, nil)
+	assert.EqualError(err, "unable to load funded amount: FundedAmount error")
+	store.loadErr = nil
+
+	af, err := NewAutoFunder(addr, cfg, broker, smgr, &stubTimeManager{}, bal, store, nil)
+	require.Nil(err)
+	smgr.info[addr] = senderInfo(0, 0)
+	require.Nil(af.fund())
+	assert.Equal(big.NewInt(100), store.funded[addr])
+
+	// A restarted funder only funds what is left under the cap
+	af, err = NewAutoFunder(addr, cfg, broker, smgr, &stubTimeManager{}, bal, store, nil)
+	require.Nil(err)
+	assert.Equal(big.NewInt(100), af.Spent())
+	smgr.info[addr] = senderInfo(0, 0)
+	require.Nil(af.fund())
+	fundings := broker.getFundings()
+	require.Len(fundings, 2)
+	assert.Equal(big.NewInt(20), fundings[1].deposit)
+	assert.Equal(big.NewInt(120), store.funded[addr])
+
+	// Failing to save the amount does not fail the top up
+	store.saveErr = errors.New("SetFundedAmount error")
+	af, err = NewAutoFunder(addr, AutoFundConfig{DepositTarget: big.NewInt(100)}, broker, smgr, &stubTimeManager{}, bal, store, nil)
+	require.Nil(err)
+	smgr.info[addr] = senderInfo(0, 0)
+	assert.Nil(af.fund())
+	assert.Equal(big.NewInt(220), af.Spent())
+}
+
+func TestAutoFunder_Fund_Unlocking(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	af, broker, smgr, _, warnings := newTestAutoFunder(t, AutoFundConfig{DepositTarget: big.NewInt(100)})
+
+	info := senderInfo(0, 0)
+	info.WithdrawRound = big.NewInt(5)
+	smgr.info[af.addr] = info
+	require.Nil(af.fund())
+	assert.Empty(broker.getFundings())
+	require.Len(*warnings, 1)
+	assert.Equal(FundingUnlocking, (*warnings)[0].Reason)
+}
+
+func TestAutoFunder_Fund_Errors(t *testing.T) {
+	assert := assert.New(t)
+
+	af, broker, smgr, bal, _ := newTestAutoFunder(t, AutoFundConfig{DepositTarget: big.NewInt(100)})
+	smgr.info[af.addr] = senderInfo(0, 0)
+
+	smgr.err = errors.New("GetSenderInfo error")
+	assert.EqualError(af.fund(), "GetSenderInfo error")
+	smgr.err = nil
+
+	bal.err = errors.New("BalanceAt error")
+	assert.EqualError(af.fund(), "BalanceAt error")
+	bal.err = nil
+
+	broker.fundErr = errors.New("FundDeposit error")
+	assert.EqualError(af.fund(), "FundDeposit error")
+	assert.Equal(big.NewInt(0), af.Spent())
+	broker.fundErr = nil
+
+	// A submitted transaction counts towards the spend cap even if it fails
+	broker.checkTxErr = errors.New("CheckTx error")
+	assert.EqualError(af.fund(), "CheckTx error")
+	assert.Equal(big.NewInt(100), af.Spent())
+}
+
+func TestAutoFunder_StartStop(t *testing.T) {
+	assert := assert.New(t)
+
+	broker := newStubBroker()
+	smgr := newStubSenderManager()
+	tm := &stubTimeManager{}
+	addr := RandAddress()
+	smgr.info[addr] = senderInfo(0, 0)
+	af, err := NewAutoFunder(addr, AutoFundConfig{DepositTarget: big.NewInt(100)}, broker, smgr, tm, &stubBalanceReader{balance: big.NewInt(1000)}, nil, nil)
+	require.Nil(t, err)
+
+	af.Start()
+	tm.blockNumSink <- big.NewInt(1)
+
+	assert.Eventually(func() bool { return len(broker.getFundings()) == 1 }, time.Second, 10*time.Millisecond)
+	// Wait for the top up to complete
+	assert.Eventually(af.startFunding, time.Second, 10*time.Millisecond)
+	af.endFunding()
+
+	// Blocks are ignored after stopping
+	af.Stop()
+	time.Sleep(20 * time.Millisecond)
+	tm.blockNumSink <- big.NewInt(2)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(broker.getFundings(), 1)
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"sync/atomic"
 
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/golang/glog"
 	"github.com/livepeer/go-livepeer/monitor"
@@ -20,11 +22,17 @@ var ErrTicketParamsExpired = errors.New("TicketParams expired")
 
 var errInsufficientSenderReserve = errors.New("insufficient sender reserve")
 
+var errRedemptionDeferred = errors.New("redemption deferred")
+
 // maxWinProb = 2^256 - 1
 var maxWinProb = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
 
 var paramsExpirationBlock = big.NewInt(5)
 
+// ticketValidityPeriod is the number of rounds, starting with its creation
+// round, that a ticket can be redeemed in
+var ticketValidityPeriod = big.NewInt(2)
+
 // Recipient is an interface which describes an object capable
 // of receiving tickets
 type Recipient interface {
@@ -67,6 +75,15 @@ type TicketParamsConfig struct {
 	// TxCostMultiplier is the desired multiplier of the transaction
 	// cost for redemption
 	TxCostMultiplier int
+
+	// RedeemMaxGasPrice is the gas price above which redemptions are
+	// deferred. Nil or zero is no ceiling
+	RedeemMaxGasPrice *big.Int
+
+	// RedeemMaxCostRatio is the ratio of the redemption transaction cost
+	// to the ticket face value above which redemptions are deferred.
+	// Nil or zero is no limit
+	RedeemMaxCostRatio *big.Rat
 }
 
 // GasPriceMonitor defines methods for monitoring gas prices
@@ -94,6 +111,13 @@ type recipient struct {
 
 	cfg TicketParamsConfig
 
+	// pending is the number of winning tickets that are queued or being redeemed
+	pending int32
+
+	// deferred holds the hashes of the tickets that are persisted in the
+	// store because their redemption was deferred
+	deferred sync.Map
+
 	quit chan struct{}
 }
 
@@ -130,8 +154,10 @@ func NewRecipientWithSecret(addr ethcommon.Address, broker Broker, val Validator
 	}
 }
 
-// Start initiates the helper goroutines for the recipient
+// Start queues the tickets that were deferred before the node restarted
+// and initiates the helper goroutines for the recipient
 func (r *recipient) Start() {
+	r.queueDeferredTickets()
 	go r.redeemManager()
 }
 
@@ -193,7 +219,7 @@ func (r *recipient) RedeemWinningTickets(sessionIDs []string) error {
 	}
 
 	for i := 0; i < len(tickets); i++ {
-		r.sm.QueueTicket(tickets[i].Sender, &SignedTicket{tickets[i], sigs[i], recipientRands[i]})
+		r.queueTicket(&SignedTicket{tickets[i], sigs[i], recipientRands[i]})
 	}
 
 	return nil
@@ -202,10 +228,21 @@ func (r *recipient) RedeemWinningTickets(sessionIDs []string) error {
 // RedeemWinningTicket redeems a single winning ticket
 func (r *recipient) RedeemWinningTicket(ticket *Ticket, sig []byte, seed *big.Int) error {
 	recipientRand := r.rand(seed, ticket.Sender, ticket.FaceValue, ticket.WinProb, ticket.ParamsExpirationBlock, ticket.PricePerPixel)
-	r.sm.QueueTicket(ticket.Sender, &SignedTicket{ticket, sig, recipientRand})
+	r.queueTicket(&SignedTicket{ticket, sig, recipientRand})
 	return nil
 }
 
+// PendingRedemptions returns the number of winning tickets that are queued or being redeemed
+func (r *recipient) PendingRedemptions() int {
+	return int(atomic.LoadInt32(&r.pending))
+}
+
+// queueTicket adds a winning ticket to the redemption queue of its sender
+func (r *recipient) queueTicket(ticket *SignedTicket) {
+	atomic.AddInt32(&r.pending, 1)
+	r.sm.QueueTicket(ticket.Sender, ticket)
+}
+
 // TicketParams returns the recipient's currently accepted ticket parameters
 func (r *recipient) TicketParams(sender ethcommon.Address, price *big.Rat) (*TicketParams, error) {
 	randBytes := RandBytes(32)
@@ -312,6 +349,15 @@ func (r *recipient) TxCostMultiplier(sender ethcommon.Address) (*big.Rat, error)
 }
 
 func (r *recipient) redeemWinningTicket(ticket *Ticket, sig []byte, recipientRand *big.Int) error {
+	// If redeeming the ticket is too expensive right now, queue the ticket
+	// to be retried later
+	if err := r.checkRedemptionCost(ticket); err != nil {
+		signedTicket := &SignedTicket{ticket, sig, recipientRand}
+		r.storeDeferredTicket(signedTicket)
+		r.queueTicket(signedTicket)
+		return err
+	}
+
 	maxFloat, err := r.sm.MaxFloat(ticket.Sender)
 	if err != nil {
 		return err
@@ -319,14 +365,14 @@ func (r *recipient) redeemWinningTicket(ticket *Ticket, sig []byte, recipientRan
 
 	// if max float is zero, there is no claimable reserve left or reserve is 0
 	if maxFloat.Cmp(big.NewInt(0)) == 0 {
-		r.sm.QueueTicket(ticket.Sender, &SignedTicket{ticket, sig, recipientRand})
+		r.queueTicket(&SignedTicket{ticket, sig, recipientRand})
 		return errors.Errorf("max float is zero")
 	}
 
 	// If max float is insufficient to cover the ticket face value, queue
 	// the ticket to be retried later
 	if maxFloat.Cmp(ticket.FaceValue) < 0 {
-		r.sm.QueueTicket(ticket.Sender, &SignedTicket{ticket, sig, recipientRand})
+		r.queueTicket(&SignedTicket{ticket, sig, recipientRand})
 		return fmt.Errorf("insufficient max float - faceValue=%v maxFloat=%v", ticket.FaceValue, maxFloat)
 	}
 
@@ -353,11 +399,15 @@ func (r *recipient) redeemWinningTicket(ticket *Ticket, sig []byte, recipientRan
 	// Assume that that this call will return immediately if there
 	// is an error in transaction submission
 	tx, err := r.broker.RedeemWinningTicket(ticket, sig, recipientRand)
+	// The ticket is no longer deferred once its redemption has been attempted
+	r.removeDeferredTicket(ticket)
 	if err != nil {
 		if monitor.Enabled {
 			monitor.TicketRedemptionError(ticket.Sender.String())
 		}
 
+		r.storeRedemption(ticket, nil, err)
+
 		return err
 	}
 
@@ -375,6 +425,8 @@ func (r *recipient) redeemWinningTicket(ticket *Ticket, sig []byte, recipientRan
 			monitor.TicketRedemptionError(ticket.Sender.String())
 		}
 
+		r.storeRedemption(ticket, tx, err)
+
 		return err
 	}
 
@@ -384,9 +436,111 @@ func (r *recipient) redeemWinningTicket(ticket *Ticket, sig []byte, recipientRan
 		monitor.ValueRedeemed(ticket.Sender.String(), ticket.FaceValue)
 	}
 
+	r.storeRedemption(ticket, tx, nil)
+
 	return nil
 }
 
+// checkRedemptionCost returns an error if the redemption of a ticket should be
+// deferred because the gas price exceeds RedeemMaxGasPrice or the transaction
+// cost exceeds RedeemMaxCostRatio of the face value. In the last round that
+// the ticket can be redeemed in, the redemption is only deferred if the
+// transaction cost exceeds the face value
+func (r *recipient) checkRedemptionCost(ticket *Ticket) error {
+	gasPrice := r.gpm.GasPrice()
+	txCost := new(big.Int).Mul(big.NewInt(int64(r.cfg.RedeemGas)), gasPrice)
+
+	if r.lastRedeemableRound(ticket) {
+		if txCost.Cmp(ticket.FaceValue) >= 0 {
+			return errors.Wrapf(errRedemptionDeferred, "txCost=%v exceeds faceValue=%v", txCost, ticket.FaceValue)
+		}
+		return nil
+	}
+
+	maxGasPrice := r.cfg.RedeemMaxGasPrice
+	if maxGasPrice != nil && maxGasPrice.Sign() > 0 && gasPrice.Cmp(maxGasPrice) > 0 {
+		return errors.Wrapf(errRedemptionDeferred, "gasPrice=%v exceeds maxGasPrice=%v", gasPrice, maxGasPrice)
+	}
+
+	maxRatio := r.cfg.RedeemMaxCostRatio
+	if maxRatio != nil && maxRatio.Sign() > 0 {
+		maxCost := new(big.Rat).Mul(new(big.Rat).SetInt(ticket.FaceValue), maxRatio)
+		if new(big.Rat).SetInt(txCost).Cmp(maxCost) > 0 {
+			return errors.Wrapf(errRedemptionDeferred, "txCost=%v exceeds maxTxCost=%v for faceValue=%v", txCost, maxCost.FloatString(0), ticket.FaceValue)
+		}
+	}
+
+	return nil
+}
+
+// storeDeferredTicket persists a ticket whose redemption is deferred so that
+// it is not lost if the node restarts before the ticket is redeemed
+func (r *recipient) storeDeferredTicket(ticket *SignedTicket) {
+	hash := ticket.Hash()
+	if _, ok := r.deferred.LoadOrStore(hash, true); ok {
+		return
+	}
+	if err := r.store.StoreDeferredTicket(ticket); err != nil {
+		r.deferred.Delete(hash)
+		glog.Errorf("error storing deferred ticket - sender=%x recipientRandHash=%x senderNonce=%v err=%v", ticket.Sender, ticket.RecipientRandHash, ticket.SenderNonce, err)
+	}
+}
+
+func (r *recipient) removeDeferredTicket(ticket *Ticket) {
+	hash := ticket.Hash()
+	if _, ok := r.deferred.Load(hash); !ok {
+		return
+	}
+	r.deferred.Delete(hash)
+	if err := r.store.RemoveDeferredTicket(ticket); err != nil {
+		glog.Errorf("error removing deferred ticket - sender=%x recipientRandHash=%x senderNonce=%v err=%v", ticket.Sender, ticket.RecipientRandHash, ticket.SenderNonce, err)
+	}
+}
+
+// queueDeferredTickets queues the persisted deferred tickets for redemption
+func (r *recipient) queueDeferredTickets() {
+	tickets, err := r.store.LoadDeferredTickets()
+	if err != nil {
+		glog.Errorf("error loading deferred tickets err=%v", err)
+		return
+	}
+	for _, ticket := range tickets {
+		r.deferred.Store(ticket.Hash(), true)
+		r.queueTicket(ticket)
+	}
+	if len(tickets) > 0 {
+		glog.Infof("Queued deferred tickets for redemption count=%v", len(tickets))
+	}
+}
+
+// lastRedeemableRound returns whether the last initialized round is the last
+// round that a ticket can be redeemed in
+func (r *recipient) lastRedeemableRound(ticket *Ticket) bool {
+	// Tickets without a creation round do not expire
+	if ticket.CreationRound == 0 {
+		return false
+	}
+	round := r.tm.LastInitializedRound()
+	if round == nil {
+		return false
+	}
+	lastRound := new(big.Int).Add(big.NewInt(ticket.CreationRound), ticketValidityPeriod)
+	lastRound.Sub(lastRound, big.NewInt(1))
+	return round.Cmp(lastRound) >= 0
+}
+
+// storeRedemption records the outcome of a redemption so that it can be
+// reconciled with the tickets received from the sender
+func (r *recipient) storeRedemption(ticket *Ticket, tx *types.Transaction, redeemErr error) {
+	var txHash ethcommon.Hash
+	if tx != nil {
+		txHash = tx.Hash()
+	}
+	if err := r.store.StoreRedemption(ticket, txHash, redeemErr); err != nil {
+		glog.Errorf("error storing ticket redemption - sender=%x recipientRandHash=%x senderNonce=%v err=%v", ticket.Sender, ticket.RecipientRandHash, ticket.SenderNonce, err)
+	}
+}
+
 func (r *recipient) rand(seed *big.Int, sender ethcommon.Address, faceValue *big.Int, winProb *big.Int, expirationBlock *big.Int, price *big.Rat) *big.Int {
 	h := hmac.New(sha256.New, r.secret[:])
 	msg := append(seed.Bytes(), sender.Bytes()...)
@@ -435,7 +589,15 @@ func (r *recipient) redeemManager() {
 	for {
 		select {
 		case ticket := <-r.sm.Redeemable():
-			if err := r.redeemWinningTicket(ticket.Ticket, ticket.Sig, ticket.RecipientRand); err != nil {
+			err := r.redeemWinningTicket(ticket.Ticket, ticket.Sig, ticket.RecipientRand)
+			// Tickets that are queued again are counted again
+			atomic.AddInt32(&r.pending, -1)
+			if err != nil {
+				// Deferred tickets are retried on every block so only log them verbosely
+				if errors.Cause(err) == errRedemptionDeferred {
+					glog.V(5).Infof("Deferring ticket redemption - sender=%x recipientRandHash=%x senderNonce=%v reason=%v", ticket.Sender, ticket.RecipientRandHash, ticket.SenderNonce, err)
+					continue
+				}
 				glog.Errorf("error redeeming ticket - sender=%x recipientRandHash=%x senderNonce=%v err=%v", ticket.Sender, ticket.RecipientRandHash, ticket.SenderNonce, err)
 			}
 		case <-r.quit:
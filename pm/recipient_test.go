@@ -543,6 +543,13 @@ func TestRedeemWinningTicket_SingleTicket_RedeemError(t *testing.T) {
 	err = r.redeemWinningTicket(ticket, sig, recipientRand)
 	assert.EqualError(err, "stub broker redeem error")
 
+	// The failed redemption is recorded
+	redemptions := ts.getRedemptions()
+	require.Len(t, redemptions, 1)
+	assert.Equal(ticket, redemptions[0].ticket)
+	assert.Equal(ethcommon.Hash{}, redemptions[0].txHash)
+	assert.EqualError(redemptions[0].redeemErr, "stub broker redeem error")
+
 	used, err := b.IsUsedTicket(ticket)
 	assert.NoError(err)
 	assert.False(used)
@@ -582,6 +589,10 @@ func TestRedeemWinningTicket_SingleTicket_CheckTxError(t *testing.T) {
 
 	err = r.redeemWinningTicket(ticket, sig, recipientRand)
 	assert.EqualError(err, b.checkTxErr.Error())
+
+	redemptions := ts.getRedemptions()
+	require.Len(redemptions, 1)
+	assert.Equal(b.checkTxErr, redemptions[0].redeemErr)
 }
 
 func TestRedeemWinningTicket_SingleTicket(t *testing.T) {
@@ -620,6 +631,12 @@ func TestRedeemWinningTicket_SingleTicket(t *testing.T) {
 
 	_, ok = r.senderNonces[recipientRand.String()]
 	assert.False(ok)
+
+	// The successful redemption is recorded
+	redemptions := ts.getRedemptions()
+	require.Len(t, redemptions, 1)
+	assert.Equal(ticket, redemptions[0].ticket)
+	assert.Nil(redemptions[0].redeemErr)
 }
 
 func TestRedeemWinningTickets_MultipleTickets(t *testing.T) {
@@ -844,6 +861,132 @@ func TestRedeemWinningTicket(t *testing.T) {
 	assert.False(ok)
 }
 
+func TestRedeemWinningTicket_GasPriceAboveMax_DefersRedemption(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender, b, v, ts, gm, sm, tm, cfg, sig := newRecipientFixtureOrFatal(t)
+	cfg.RedeemMaxGasPrice = big.NewInt(50)
+	secret := [32]byte{3}
+	r := NewRecipientWithSecret(RandAddress(), b, v, ts, gm, sm, tm, secret, cfg).(*recipient)
+
+	params := ticketParamsOrFatal(t, r, sender)
+	ticket := newTicket(sender, params, 1)
+	recipientRand := genRecipientRand(sender, secret, params)
+
+	err := r.redeemWinningTicket(ticket, sig, recipientRand)
+	assert.EqualError(err, "gasPrice=100 exceeds maxGasPrice=50: redemption deferred")
+	assert.Equal(errRedemptionDeferred, errors.Cause(err))
+	require.Len(sm.queued, 1)
+	assert.Equal(&SignedTicket{ticket, sig, recipientRand}, sm.queued[0])
+	used, err := b.IsUsedTicket(ticket)
+	require.Nil(err)
+	assert.False(used)
+
+	// The deferred ticket is persisted so that it survives a restart
+	deferred := ts.getDeferred()
+	require.Len(deferred, 1)
+	assert.Equal(&SignedTicket{ticket, sig, recipientRand}, deferred[ticket.Hash()])
+
+	// The ticket is redeemed once the gas price drops
+	gm.gasPrice = big.NewInt(50)
+	require.Nil(r.redeemWinningTicket(ticket, sig, recipientRand))
+	used, err = b.IsUsedTicket(ticket)
+	require.Nil(err)
+	assert.True(used)
+	assert.Empty(ts.getDeferred())
+}
+
+func TestRecipientStart_QueuesDeferredTickets(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender, b, v, ts, gm, sm, tm, cfg, sig := newRecipientFixtureOrFatal(t)
+	secret := [32]byte{3}
+	r := NewRecipientWithSecret(RandAddress(), b, v, ts, gm, sm, tm, secret, cfg).(*recipient)
+
+	params := ticketParamsOrFatal(t, r, sender)
+	ticket := newTicket(sender, params, 1)
+	recipientRand := genRecipientRand(sender, secret, params)
+	signedTicket := &SignedTicket{ticket, sig, recipientRand}
+	require.Nil(ts.StoreDeferredTicket(signedTicket))
+
+	// Tickets deferred before a restart are queued on start
+	r.Start()
+	defer r.Stop()
+	require.Len(sm.queued, 1)
+	assert.Equal(signedTicket, sm.queued[0])
+	assert.Equal(1, r.PendingRedemptions())
+
+	// and removed from the store once redeemed
+	require.Nil(r.redeemWinningTicket(ticket, sig, recipientRand))
+	used, err := b.IsUsedTicket(ticket)
+	require.Nil(err)
+	assert.True(used)
+	assert.Empty(ts.getDeferred())
+
+	// A failure to load deferred tickets does not prevent the recipient from starting
+	ts.loadShouldFail = true
+	r = NewRecipientWithSecret(RandAddress(), b, v, ts, gm, sm, tm, secret, cfg).(*recipient)
+	r.Start()
+	defer r.Stop()
+	assert.Equal(0, r.PendingRedemptions())
+}
+
+func TestRedeemWinningTicket_CostRatioAboveMax_DefersRedemption(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender, b, v, ts, gm, sm, tm, cfg, sig := newRecipientFixtureOrFatal(t)
+	// txCost = 100 * 10000 = 1000000 and faceValue = txCost * 100
+	cfg.RedeemMaxCostRatio = big.NewRat(1, 200)
+	secret := [32]byte{3}
+	r := NewRecipientWithSecret(RandAddress(), b, v, ts, gm, sm, tm, secret, cfg).(*recipient)
+
+	params := ticketParamsOrFatal(t, r, sender)
+	ticket := newTicket(sender, params, 1)
+	recipientRand := genRecipientRand(sender, secret, params)
+
+	err := r.redeemWinningTicket(ticket, sig, recipientRand)
+	assert.EqualError(err, "txCost=1000000 exceeds maxTxCost=500000 for faceValue=100000000: redemption deferred")
+	assert.Len(sm.queued, 1)
+
+	r.cfg.RedeemMaxCostRatio = big.NewRat(1, 100)
+	require.Nil(r.redeemWinningTicket(ticket, sig, recipientRand))
+	used, err := b.IsUsedTicket(ticket)
+	require.Nil(err)
+	assert.True(used)
+}
+
+func TestRedeemWinningTicket_LastRedeemableRound(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender, b, v, ts, gm, sm, tm, cfg, sig := newRecipientFixtureOrFatal(t)
+	cfg.RedeemMaxGasPrice = big.NewInt(50)
+	secret := [32]byte{3}
+	r := NewRecipientWithSecret(RandAddress(), b, v, ts, gm, sm, tm, secret, cfg).(*recipient)
+
+	params := ticketParamsOrFatal(t, r, sender)
+	ticket := newTicket(sender, params, 1)
+	recipientRand := genRecipientRand(sender, secret, params)
+
+	// The redemption is deferred if the tx cost exceeds the face value
+	tm.round = big.NewInt(2)
+	faceValue := ticket.FaceValue
+	ticket.FaceValue = big.NewInt(1000000)
+	err := r.redeemWinningTicket(ticket, sig, recipientRand)
+	assert.EqualError(err, "txCost=1000000 exceeds faceValue=1000000: redemption deferred")
+	assert.Len(sm.queued, 1)
+
+	// The gas price ceiling is ignored in the last round of the ticket's validity period
+	ticket.FaceValue = faceValue
+	require.Nil(r.redeemWinningTicket(ticket, sig, recipientRand))
+	used, err := b.IsUsedTicket(ticket)
+	require.Nil(err)
+	assert.True(used)
+}
+
 func TestRedeemManager_Error(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -923,6 +1066,43 @@ func TestRedeemManager(t *testing.T) {
 	assert.False(ok)
 }
 
+func TestRedeemManager_PendingRedemptions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender, b, v, ts, gm, sm, tm, cfg, sig := newRecipientFixtureOrFatal(t)
+	secret := [32]byte{3}
+	r := NewRecipientWithSecret(RandAddress(), b, v, ts, gm, sm, tm, secret, cfg)
+	r.Start()
+	defer r.Stop()
+
+	params := ticketParamsOrFatal(t, r, sender)
+	ticket := newTicket(sender, params, 1)
+	_, _, err := r.ReceiveTicket(ticket, sig, params.Seed)
+	require.Nil(err)
+
+	// Queued tickets are pending
+	require.Nil(r.RedeemWinningTicket(ticket, sig, params.Seed))
+	pending := r.(*recipient).PendingRedemptions
+	assert.Equal(1, pending())
+	require.Len(sm.queued, 1)
+
+	// Tickets that are queued again are still pending
+	maxFloat := sm.maxFloat
+	sm.maxFloat = big.NewInt(0)
+	sm.redeemable <- sm.queued[0]
+	assert.Eventually(func() bool { return len(sm.queued) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(1, pending())
+
+	// Redeemed tickets are no longer pending
+	sm.maxFloat = maxFloat
+	sm.redeemable <- sm.queued[1]
+	assert.Eventually(func() bool { return pending() == 0 }, time.Second, 5*time.Millisecond)
+	used, err := b.IsUsedTicket(ticket)
+	require.Nil(err)
+	assert.True(used)
+}
+
 func TestTicketParams(t *testing.T) {
 	sender, b, v, ts, gm, sm, tm, cfg, _ := newRecipientFixtureOrFatal(t)
 	recipient := RandAddress()
@@ -0,0 +1,248 @@
+package pm
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// SenderPool is a Sender that pays from multiple sender accounts, each with its own
+// deposit and reserve, to increase the number of recipients that can be paid concurrently.
+//
+// Ticket params are only valid for tickets from the sender that requested them, so the
+// account that requests ticket params from a recipient is chosen with SelectSender and
+// the params are bound to it with BindTicketParams. StartSession then starts the session
+// for that account. Sessions for unbound ticket params use the account with the most headroom
+type SenderPool struct {
+	senders       []*sender
+	senderManager SenderManager
+	timeManager   TimeManager
+
+	mu sync.Mutex
+	// sessions maps a session ID, which is the recipientRandHash of the
+	// session's ticket params, to the account that pays for the session
+	sessions map[string]*poolSession
+}
+
+// poolSession is a session of a SenderPool, or ticket params bound to an account
+// that have not been used to start a session yet
+type poolSession struct {
+	sender *sender
+	// recipient and expirationBlock are set when the session is started
+	recipient       ethcommon.Address
+	expirationBlock *big.Int
+	started         bool
+	bound           time.Time
+}
+
+// unstartedSessionTTL is how long ticket params bound to an account are kept without starting a session
+var unstartedSessionTTL = 10 * time.Minute
+
+// NewSenderPool creates a SenderPool that pays from the accounts of signers
+func NewSenderPool(signers []Signer, timeManager TimeManager, senderManager SenderManager, maxEV *big.Rat, depositMultiplier int) *SenderPool {
+	p := &SenderPool{
+		senderManager: senderManager,
+		timeManager:   timeManager,
+		sessions:      make(map[string]*poolSession),
+	}
+	for _, signer := range signers {
+		s := NewSender(signer, timeManager, senderManager, maxEV, depositMultiplier).(*sender)
+		p.senders = append(p.senders, s)
+	}
+	return p
+}
+
+// Accounts returns the addresses of the sender accounts
+func (p *SenderPool) Accounts() []ethcommon.Address {
+	addrs := make([]ethcommon.Address, len(p.senders))
+	for i, s := range p.senders {
+		addrs[i] = s.signer.Account().Address
+	}
+	return addrs
+}
+
+// SelectSender returns the signer of the account with the most headroom, which is
+// the sum of its deposit and reserve divided by the number of recipients it would
+// pay if it started paying another recipient. Accounts that cannot send tickets
+// are only selected if no other account can
+func (p *SenderPool) SelectSender() Signer {
+	return p.selectSender().signer
+}
+
+func (p *SenderPool) selectSender() *sender {
+	funds := make([]*big.Int, len(p.senders))
+	for i, s := range p.senders {
+		if err := s.validateSender(); err != nil {
+			glog.V(5).Infof("Not selecting sender=%v err=%v", s.signer.Account().Address.Hex(), err)
+			continue
+		}
+		info, err := p.senderManager.GetSenderInfo(s.signer.Account().Address)
+		if err != nil || info == nil {
+			continue
+		}
+		funds[i] = new(big.Int).Set(info.Deposit)
+		if info.Reserve != nil && info.Reserve.FundsRemaining != nil {
+			funds[i].Add(funds[i], info.Reserve.FundsRemaining)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune()
+	recipients := make(map[*sender]map[ethcommon.Address]bool)
+	for _, sess := range p.sessions {
+		if !sess.started {
+			continue
+		}
+		if recipients[sess.sender] == nil {
+			recipients[sess.sender] = make(map[ethcommon.Address]bool)
+		}
+		recipients[sess.sender][sess.recipient] = true
+	}
+
+	best := p.senders[0]
+	var bestHeadroom *big.Rat
+	for i, s := range p.senders {
+		if funds[i] == nil {
+			continue
+		}
+		headroom := new(big.Rat).SetFrac(funds[i], big.NewInt(int64(len(recipients[s])+1)))
+		if bestHeadroom == nil || headroom.Cmp(bestHeadroom) > 0 {
+			best, bestHeadroom = s, headroom
+		}
+	}
+	return best
+}
+
+// BindTicketParams binds the ticket params with recipientRandHash to the account
+// that requested them so that their session is started for that account
+func (p *SenderPool) BindTicketParams(account ethcommon.Address, recipientRandHash ethcommon.Hash) {
+	for _, s := range p.senders {
+		if s.signer.Account().Address == account {
+			p.mu.Lock()
+			p.prune()
+			p.sessions[recipientRandHash.Hex()] = &poolSession{sender: s, bound: time.Now()}
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// SessionAccount returns the address of the account that pays for a session
+func (p *SenderPool) SessionAccount(sessionID string) (ethcommon.Address, error) {
+	s, err := p.sessionSender(sessionID)
+	if err != nil {
+		return ethcommon.Address{}, err
+	}
+	return s.signer.Account().Address, nil
+}
+
+// SessionSigner returns the signer of the account that pays for a session
+func (p *SenderPool) SessionSigner(sessionID string) (Signer, error) {
+	s, err := p.sessionSender(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.signer, nil
+}
+
+// StartSession creates a session for a given set of ticket params with the account
+// that the params are bound to, or the account with the most headroom if they are not bound
+func (p *SenderPool) StartSession(ticketParams TicketParams) string {
+	sessionID := ticketParams.RecipientRandHash.Hex()
+
+	p.mu.Lock()
+	sess, ok := p.sessions[sessionID]
+	p.mu.Unlock()
+	var s *sender
+	if ok {
+		s = sess.sender
+	} else {
+		s = p.selectSender()
+	}
+
+	p.mu.Lock()
+	p.sessions[sessionID] = &poolSession{
+		sender:          s,
+		recipient:       ticketParams.Recipient,
+		expirationBlock: ticketParams.ExpirationBlock,
+		started:         true,
+	}
+	p.mu.Unlock()
+
+	return s.StartSession(ticketParams)
+}
+
+// EndSession removes a session that is no longer used, e.g. because it was replaced
+// by a session with new ticket params, so that it does not count towards the headroom of its account
+func (p *SenderPool) EndSession(sessionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.endSession(sessionID)
+}
+
+// prune removes sessions with expired ticket params and bound ticket params that were not
+// used to start a session in time. The caller must hold p.mu
+func (p *SenderPool) prune() {
+	latestBlock := p.timeManager.LastSeenBlock()
+	for sessionID, sess := range p.sessions {
+		expired := sess.started && sess.expirationBlock != nil && sess.expirationBlock.Sign() > 0 &&
+			latestBlock != nil && sess.expirationBlock.Cmp(latestBlock) <= 0
+		unused := !sess.started && time.Since(sess.bound) > unstartedSessionTTL
+		if expired || unused {
+			p.endSession(sessionID)
+		}
+	}
+}
+
+// endSession removes a session. The caller must hold p.mu
+func (p *SenderPool) endSession(sessionID string) {
+	if sess, ok := p.sessions[sessionID]; ok {
+		sess.sender.sessions.Delete(sessionID)
+		delete(p.sessions, sessionID)
+	}
+}
+
+// CreateTicketBatch returns a ticket batch of the specified size from the account of the session
+func (p *SenderPool) CreateTicketBatch(sessionID string, size int) (*TicketBatch, error) {
+	s, err := p.sessionSender(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.CreateTicketBatch(sessionID, size)
+}
+
+// ValidateTicketParams checks if ticket params are acceptable for the account that they are bound to
+func (p *SenderPool) ValidateTicketParams(ticketParams *TicketParams) error {
+	s, err := p.sessionSender(ticketParams.RecipientRandHash.Hex())
+	if err != nil {
+		s = p.selectSender()
+	}
+	return s.ValidateTicketParams(ticketParams)
+}
+
+// EV returns the ticket EV for a session
+func (p *SenderPool) EV(sessionID string) (*big.Rat, error) {
+	s, err := p.sessionSender(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.EV(sessionID)
+}
+
+func (p *SenderPool) sessionSender(sessionID string) (*sender, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sess, ok := p.sessions[sessionID]
+	if !ok {
+		return nil, errors.Errorf("error loading session: %x", sessionID)
+	}
+	return sess.sender, nil
+}
@@ -0,0 +1,165 @@
+package pm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func defaultSenderPool(t *testing.T, deposits ...int64) (*SenderPool, *stubSenderManager) {
+	tm := &stubTimeManager{round: big.NewInt(5), blkHash: [32]byte{5}, lastSeenBlock: big.NewInt(0)}
+	sm := newStubSenderManager()
+	var signers []Signer
+	for _, deposit := range deposits {
+		account := accounts.Account{Address: RandAddress()}
+		signers = append(signers, &stubSigner{account: account})
+		sm.info[account.Address] = &SenderInfo{
+			Deposit:       big.NewInt(deposit),
+			WithdrawRound: big.NewInt(0),
+			Reserve:       &ReserveInfo{FundsRemaining: big.NewInt(0), ClaimedInCurrentRound: big.NewInt(0)},
+		}
+	}
+	return NewSenderPool(signers, tm, sm, big.NewRat(100, 1), 2), sm
+}
+
+func TestSenderPool_SelectSender(t *testing.T) {
+	assert := assert.New(t)
+
+	pool, sm := defaultSenderPool(t, 100000, 300000)
+	accts := pool.Accounts()
+	require.Len(t, accts, 2)
+
+	// Select the account with the most funds
+	assert.Equal(accts[1], pool.SelectSender().Account().Address)
+
+	// Reserve counts towards headroom
+	sm.info[accts[0]].Reserve.FundsRemaining = big.NewInt(400000)
+	assert.Equal(accts[0], pool.SelectSender().Account().Address)
+
+	// Headroom is shared between the recipients that an account pays
+	for i := 0; i < 2; i++ {
+		params := defaultTicketParams(t, RandAddress())
+		pool.BindTicketParams(accts[0], params.RecipientRandHash)
+		pool.StartSession(params)
+	}
+	assert.Equal(accts[1], pool.SelectSender().Account().Address)
+
+	// Accounts that are unlocking are not selected
+	sm.info[accts[1]].WithdrawRound = big.NewInt(5)
+	assert.Equal(accts[0], pool.SelectSender().Account().Address)
+
+	// Fall back to the first account if no account can send tickets
+	sm.info[accts[0]].WithdrawRound = big.NewInt(5)
+	assert.Equal(accts[0], pool.SelectSender().Account().Address)
+}
+
+func TestSenderPool_StartSession(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	pool, _ := defaultSenderPool(t, 100000, 300000)
+	accts := pool.Accounts()
+
+	// Bound ticket params use the account that requested them
+	params := defaultTicketParams(t, RandAddress())
+	pool.BindTicketParams(accts[0], params.RecipientRandHash)
+	sessionID := pool.StartSession(params)
+	batch, err := pool.CreateTicketBatch(sessionID, 1)
+	require.Nil(err)
+	assert.Equal(accts[0], batch.Sender)
+
+	// Unbound ticket params use the account with the most headroom
+	params = defaultTicketParams(t, RandAddress())
+	sessionID = pool.StartSession(params)
+	batch, err = pool.CreateTicketBatch(sessionID, 1)
+	require.Nil(err)
+	assert.Equal(accts[1], batch.Sender)
+
+	ev, err := pool.EV(sessionID)
+	require.Nil(err)
+	assert.Equal(big.NewRat(0, 1), ev)
+	assert.Nil(pool.ValidateTicketParams(&params))
+
+	addr, err := pool.SessionAccount(sessionID)
+	require.Nil(err)
+	assert.Equal(accts[1], addr)
+	signer, err := pool.SessionSigner(sessionID)
+	require.Nil(err)
+	assert.Equal(accts[1], signer.Account().Address)
+
+	// Binding to an unknown account is ignored
+	params = defaultTicketParams(t, RandAddress())
+	pool.BindTicketParams(RandAddress(), params.RecipientRandHash)
+	sessionID = pool.StartSession(params)
+	batch, err = pool.CreateTicketBatch(sessionID, 1)
+	require.Nil(err)
+	assert.Equal(accts[1], batch.Sender)
+}
+
+func TestSenderPool_MissingSession(t *testing.T) {
+	assert := assert.New(t)
+
+	pool, _ := defaultSenderPool(t, 100000)
+	sessionID := ethcommon.Hash{1}.Hex()
+
+	_, err := pool.CreateTicketBatch(sessionID, 1)
+	assert.Contains(err.Error(), "error loading session")
+	_, err = pool.EV(sessionID)
+	assert.Contains(err.Error(), "error loading session")
+	_, err = pool.SessionAccount(sessionID)
+	assert.Contains(err.Error(), "error loading session")
+	_, err = pool.SessionSigner(sessionID)
+	assert.Contains(err.Error(), "error loading session")
+}
+
+func TestSenderPool_PruneSessions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	pool, _ := defaultSenderPool(t, 300000, 200000)
+	accts := pool.Accounts()
+	tm := pool.timeManager.(*stubTimeManager)
+
+	startSession := func() string {
+		params := defaultTicketParams(t, RandAddress())
+		pool.BindTicketParams(accts[0], params.RecipientRandHash)
+		return pool.StartSession(params)
+	}
+
+	// Replaced sessions do not count towards headroom
+	sessionID := startSession()
+	assert.Equal(accts[1], pool.SelectSender().Account().Address)
+	pool.EndSession(sessionID)
+	assert.Equal(accts[0], pool.SelectSender().Account().Address)
+	_, err := pool.SessionAccount(sessionID)
+	assert.Contains(err.Error(), "error loading session")
+
+	// Sessions with expired ticket params are removed
+	startSession()
+	assert.Equal(accts[1], pool.SelectSender().Account().Address)
+	tm.lastSeenBlock = big.NewInt(100)
+	assert.Equal(accts[0], pool.SelectSender().Account().Address)
+	assert.Len(pool.sessions, 0)
+
+	// Expired ticket params are still reported as expired
+	params := defaultTicketParams(t, RandAddress())
+	params.ExpirationBlock = big.NewInt(200)
+	sessionID = pool.StartSession(params)
+	tm.lastSeenBlock = big.NewInt(200)
+	pool.SelectSender()
+	_, err = pool.SessionAccount(sessionID)
+	assert.Contains(err.Error(), "error loading session")
+	assert.Equal(ErrTicketParamsExpired, pool.ValidateTicketParams(&params))
+
+	// Ticket params that are bound but never used to start a session are removed
+	ttl := unstartedSessionTTL
+	defer func() { unstartedSessionTTL = ttl }()
+	unstartedSessionTTL = 0
+	pool.BindTicketParams(accts[1], RandHash())
+	pool.SelectSender()
+	require.Len(pool.sessions, 0)
+}
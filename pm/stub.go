@@ -1,6 +1,7 @@
 package pm
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"sync"
@@ -23,16 +24,25 @@ type stubTicketStore struct {
 	tickets         map[string][]*Ticket
 	sigs            map[string][][]byte
 	recipientRands  map[string][]*big.Int
+	redemptions     []*stubRedemption
+	deferred        map[ethcommon.Hash]*SignedTicket
 	storeShouldFail bool
 	loadShouldFail  bool
 	lock            sync.RWMutex
 }
 
+type stubRedemption struct {
+	ticket    *Ticket
+	txHash    ethcommon.Hash
+	redeemErr error
+}
+
 func newStubTicketStore() *stubTicketStore {
 	return &stubTicketStore{
 		tickets:        make(map[string][]*Ticket),
 		sigs:           make(map[string][][]byte),
 		recipientRands: make(map[string][]*big.Int),
+		deferred:       make(map[ethcommon.Hash]*SignedTicket),
 	}
 }
 
@@ -81,6 +91,75 @@ func (ts *stubTicketStore) LoadWinningTickets(sessionIDs []string) ([]*Ticket, [
 	return allTix, allSigs, allRecipientRands, nil
 }
 
+func (ts *stubTicketStore) StoreRedemption(ticket *Ticket, txHash ethcommon.Hash, redeemErr error) error {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if ts.storeShouldFail {
+		return fmt.Errorf("stub ticket store store error")
+	}
+
+	ts.redemptions = append(ts.redemptions, &stubRedemption{ticket, txHash, redeemErr})
+
+	return nil
+}
+
+func (ts *stubTicketStore) StoreDeferredTicket(ticket *SignedTicket) error {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	if ts.storeShouldFail {
+		return fmt.Errorf("stub ticket store store error")
+	}
+
+	ts.deferred[ticket.Hash()] = ticket
+
+	return nil
+}
+
+func (ts *stubTicketStore) RemoveDeferredTicket(ticket *Ticket) error {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	delete(ts.deferred, ticket.Hash())
+
+	return nil
+}
+
+func (ts *stubTicketStore) LoadDeferredTickets() ([]*SignedTicket, error) {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	if ts.loadShouldFail {
+		return nil, fmt.Errorf("stub ticket store load error")
+	}
+
+	var tickets []*SignedTicket
+	for _, ticket := range ts.deferred {
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
+
+func (ts *stubTicketStore) getDeferred() map[ethcommon.Hash]*SignedTicket {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	deferred := make(map[ethcommon.Hash]*SignedTicket)
+	for hash, ticket := range ts.deferred {
+		deferred[hash] = ticket
+	}
+	return deferred
+}
+
+func (ts *stubTicketStore) getRedemptions() []*stubRedemption {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return append([]*stubRedemption{}, ts.redemptions...)
+}
+
 func (ts *stubBlockStore) LastSeenBlock() (*big.Int, error) {
 	return ts.lastBlock, ts.err
 }
@@ -109,6 +188,14 @@ type stubBroker struct {
 	claimableReserveShouldFail bool
 
 	checkTxErr error
+
+	fundings []stubFunding
+	fundErr  error
+}
+
+type stubFunding struct {
+	deposit *big.Int
+	reserve *big.Int
 }
 
 func newStubBroker() *stubBroker {
@@ -119,15 +206,34 @@ func newStubBroker() *stubBroker {
 }
 
 func (b *stubBroker) FundDepositAndReserve(depositAmount, reserveAmount *big.Int) (*types.Transaction, error) {
-	return nil, nil
+	return b.fund(depositAmount, reserveAmount)
 }
 
 func (b *stubBroker) FundDeposit(amount *big.Int) (*types.Transaction, error) {
-	return nil, nil
+	return b.fund(amount, big.NewInt(0))
 }
 
 func (b *stubBroker) FundReserve(amount *big.Int) (*types.Transaction, error) {
-	return nil, nil
+	return b.fund(big.NewInt(0), amount)
+}
+
+func (b *stubBroker) fund(depositAmount, reserveAmount *big.Int) (*types.Transaction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.fundErr != nil {
+		return nil, b.fundErr
+	}
+	b.fundings = append(b.fundings, stubFunding{deposit: depositAmount, reserve: reserveAmount})
+
+	return types.NewTransaction(uint64(len(b.fundings)), ethcommon.Address{}, big.NewInt(0), 0, big.NewInt(0), nil), nil
+}
+
+func (b *stubBroker) getFundings() []stubFunding {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]stubFunding(nil), b.fundings...)
 }
 
 func (b *stubBroker) Unlock() (*types.Transaction, error) {
@@ -310,6 +416,43 @@ func (s *stubSenderManager) Clear(addr ethcommon.Address) {
 	delete(s.claimedReserve, addr)
 }
 
+type stubBalanceReader struct {
+	balance *big.Int
+	err     error
+}
+
+func (s *stubBalanceReader) BalanceAt(ctx context.Context, account ethcommon.Address, blockNumber *big.Int) (*big.Int, error) {
+	return s.balance, s.err
+}
+
+type stubFundingStore struct {
+	funded  map[ethcommon.Address]*big.Int
+	loadErr error
+	saveErr error
+}
+
+func newStubFundingStore() *stubFundingStore {
+	return &stubFundingStore{funded: make(map[ethcommon.Address]*big.Int)}
+}
+
+func (s *stubFundingStore) FundedAmount(addr ethcommon.Address) (*big.Int, error) {
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+	if amount, ok := s.funded[addr]; ok {
+		return new(big.Int).Set(amount), nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (s *stubFundingStore) SetFundedAmount(addr ethcommon.Address, amount *big.Int) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.funded[addr] = new(big.Int).Set(amount)
+	return nil
+}
+
 type stubGasPriceMonitor struct {
 	gasPrice *big.Int
 }
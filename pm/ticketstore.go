@@ -2,6 +2,8 @@ package pm
 
 import (
 	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
 )
 
 // TicketStore is an interface which describes an object capable
@@ -14,4 +16,17 @@ type TicketStore interface {
 	// Load fetches all persisted tickets in the store with their signatures and recipientRands
 	// for a session ID
 	LoadWinningTickets(sessionIDs []string) (tickets []*Ticket, sigs [][]byte, recipientRands []*big.Int, err error)
+
+	// StoreRedemption records the outcome of redeeming a winning ticket. txHash is
+	// the zero hash if the redemption transaction could not be submitted
+	StoreRedemption(ticket *Ticket, txHash ethcommon.Hash, redeemErr error) error
+
+	// StoreDeferredTicket persists a winning ticket whose redemption is deferred
+	StoreDeferredTicket(ticket *SignedTicket) error
+
+	// RemoveDeferredTicket removes a ticket whose redemption is no longer deferred
+	RemoveDeferredTicket(ticket *Ticket) error
+
+	// LoadDeferredTickets fetches all persisted deferred tickets
+	LoadDeferredTickets() ([]*SignedTicket, error)
 }
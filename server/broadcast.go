@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/golang/glog"
 
 	"github.com/livepeer/go-livepeer/common"
@@ -63,6 +64,11 @@ type BroadcastSessionsManager struct {
 	finished   bool // set at stream end
 
 	createSessions func() ([]*BroadcastSession, error)
+	stats          *streamStats
+	// sessPool is the broadcaster wide session pool, if any
+	sessPool *core.SessionPool
+	// filter is the allowlist and denylist of orchestrators, if any
+	filter *common.OrchestratorFilter
 }
 
 func (bsm *BroadcastSessionsManager) selectSession() *BroadcastSession {
@@ -84,6 +90,12 @@ func (bsm *BroadcastSessionsManager) selectSession() *BroadcastSession {
 		}
 
 		if _, ok := bsm.sessMap[sess.OrchestratorInfo.Transcoder]; ok {
+			// Stop using orchestrators that were denied while the stream is running
+			if !infoAllowed(bsm.filter, sess.OrchestratorInfo) {
+				glog.Infof("Removing session of orchestrator that is not allowed manifestID=%s orch=%s", bsm.mid, sess.OrchestratorInfo.Transcoder)
+				delete(bsm.sessMap, sess.OrchestratorInfo.Transcoder)
+				continue
+			}
 			return sess
 		}
 		/*
@@ -103,6 +115,10 @@ func (bsm *BroadcastSessionsManager) removeSession(session *BroadcastSession) {
 	defer bsm.sessLock.Unlock()
 
 	delete(bsm.sessMap, session.OrchestratorInfo.Transcoder)
+	if bsm.sessPool != nil {
+		bsm.sessPool.Remove(session.OrchestratorInfo.Transcoder)
+	}
+	endPMSession(session)
 }
 
 func (bsm *BroadcastSessionsManager) completeSession(sess *BroadcastSession) {
@@ -162,6 +178,7 @@ func (bsm *BroadcastSessionsManager) refreshSessions() {
 		if _, ok := bsm.sessMap[sess.OrchestratorInfo.Transcoder]; ok {
 			continue
 		}
+		sess.stats = bsm.stats
 		uniqueSessions = append(uniqueSessions, sess)
 		bsm.sessMap[sess.OrchestratorInfo.Transcoder] = sess
 	}
@@ -174,23 +191,98 @@ func (bsm *BroadcastSessionsManager) cleanup() {
 	defer bsm.sessLock.Unlock()
 	bsm.finished = true
 	bsm.sel.Clear()
+	var sessions []*BroadcastSession
+	for _, sess := range bsm.sessMap {
+		endPMSession(sess)
+		sessions = append(sessions, sess)
+	}
 	bsm.sessMap = make(map[string]*BroadcastSession) // prevent segfaults
+
+	// Failed sessions were removed from sessMap, so the remaining sessions are healthy
+	if bsm.sessPool != nil && len(sessions) > 0 {
+		go returnSessions(bsm.sessPool, bsm.filter, sessions)
+	}
 }
 
-func NewSessionManager(node *core.LivepeerNode, params *streamParameters, pl core.PlaylistManager, sel BroadcastSessionsSelector) *BroadcastSessionsManager {
+// returnSessions returns the orchestrators of the sessions of a finished stream to the
+// session pool. The ticket params of the sessions were used by the stream, so the info of
+// each orchestrator is fetched again before it is returned
+func returnSessions(sessPool *core.SessionPool, filter *common.OrchestratorFilter, sessions []*BroadcastSession) {
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	defer cancel()
+
+	infos := make([]*net.OrchestratorInfo, len(sessions))
+	var wg sync.WaitGroup
+	for i, sess := range sessions {
+		// Orchestrators without ticket params are not paid, so their info can be reused
+		if sess.OrchestratorInfo.TicketParams == nil {
+			infos[i] = sess.OrchestratorInfo
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, sess *BroadcastSession) {
+			defer wg.Done()
+
+			uri, err := url.Parse(sess.OrchestratorInfo.Transcoder)
+			if err != nil {
+				return
+			}
+			info, err := getOrchestratorInfoRPC(ctx, sess.Broadcaster, uri)
+			if err != nil {
+				glog.V(common.DEBUG).Infof("Not returning orchestrator to session pool orch=%v err=%v", sess.OrchestratorInfo.Transcoder, err)
+				return
+			}
+			infos[i] = info
+		}(i, sess)
+	}
+	wg.Wait()
+
+	var fresh []*net.OrchestratorInfo
+	for _, info := range infos {
+		if info != nil && infoAllowed(filter, info) {
+			fresh = append(fresh, info)
+		}
+	}
+	sessPool.Put(fresh...)
+}
+
+// endPMSession ends the PM session of a session that is no longer used so that
+// the sender pool account paying for it no longer counts its recipient
+func endPMSession(sess *BroadcastSession) {
+	if pool, ok := sess.Sender.(*pm.SenderPool); ok {
+		pool.EndSession(sess.PMSessionID)
+	}
+}
+
+func NewSessionManager(node *core.LivepeerNode, params *streamParameters, pl core.PlaylistManager, sel BroadcastSessionsSelector, stats *streamStats) *BroadcastSessionsManager {
 	var poolSize float64
 	if node.OrchestratorPool != nil {
 		poolSize = float64(node.OrchestratorPool.Size())
 	}
 	maxInflight := common.HTTPTimeout.Seconds() / SegLen.Seconds()
 	numOrchs := int(math.Min(poolSize, maxInflight*2))
+	// The first sessions of the stream are seeded from the session pool, if possible
+	seeded := false
+	createSessions := func() ([]*BroadcastSession, error) {
+		if !seeded {
+			seeded = true
+			if sessions := seedOrchestrators(node, params, pl, numOrchs); len(sessions) > 0 {
+				return sessions, nil
+			}
+		}
+		return selectOrchestrator(node, params, pl, numOrchs)
+	}
 	bsm := &BroadcastSessionsManager{
 		mid:            params.mid,
 		sel:            sel,
 		sessMap:        make(map[string]*BroadcastSession),
-		createSessions: func() ([]*BroadcastSession, error) { return selectOrchestrator(node, params, pl, numOrchs) },
+		createSessions: createSessions,
 		sessLock:       &sync.Mutex{},
 		numOrchs:       numOrchs,
+		stats:          stats,
+		sessPool:       node.SessionPool,
+		filter:         node.OrchFilter,
 	}
 	bsm.refreshSessions()
 	return bsm
@@ -211,22 +303,75 @@ func selectOrchestrator(n *core.LivepeerNode, params *streamParameters, cpl core
 		return nil, err
 	}
 
+	return newBroadcastSessions(n, params, cpl, tinfos), nil
+}
+
+// seedOrchestrators creates sessions for a new stream with orchestrators from the session pool
+func seedOrchestrators(n *core.LivepeerNode, params *streamParameters, cpl core.PlaylistManager, count int) []*BroadcastSession {
+	if n.SessionPool == nil {
+		return nil
+	}
+
+	maxPrice := BroadcastCfg.MaxPrice()
+	var tinfos []*net.OrchestratorInfo
+	for _, tinfo := range n.SessionPool.Get(count) {
+		if !infoAllowed(n.OrchFilter, tinfo) {
+			continue
+		}
+		if maxPrice != nil {
+			price, err := common.RatPriceInfo(tinfo.PriceInfo)
+			if err != nil || (price != nil && price.Cmp(maxPrice) > 0) {
+				continue
+			}
+		}
+		tinfos = append(tinfos, tinfo)
+	}
+	if len(tinfos) > 0 {
+		glog.V(common.DEBUG).Infof("Seeding sessions from session pool manifestID=%s numOrch=%d", params.mid, len(tinfos))
+	}
+
+	return newBroadcastSessions(n, params, cpl, tinfos)
+}
+
+// infoAllowed returns whether an orchestrator is allowed by filter
+func infoAllowed(filter *common.OrchestratorFilter, info *net.OrchestratorInfo) bool {
+	if filter == nil {
+		return true
+	}
+	uri, err := url.Parse(info.Transcoder)
+	if err != nil {
+		return false
+	}
+	return filter.Allowed(uri, ethcommon.BytesToAddress(info.GetTicketParams().GetRecipient()))
+}
+
+// unpaidPool is implemented by orchestrator pools with orchestrators that are used without payments
+type unpaidPool interface {
+	Unpaid(transcoder string) bool
+}
+
+// newBroadcastSessions creates sessions for a stream with its own PM sessions and storage for orchestrators
+func newBroadcastSessions(n *core.LivepeerNode, params *streamParameters, cpl core.PlaylistManager, tinfos []*net.OrchestratorInfo) []*BroadcastSession {
 	var sessions []*BroadcastSession
 
+	unpaid, _ := n.OrchestratorPool.(unpaidPool)
+
 	for _, tinfo := range tinfos {
 		var (
+			sender       pm.Sender
 			sessionID    string
 			balance      Balance
 			ticketParams *pm.TicketParams
 		)
 
-		if n.Sender != nil {
+		if n.Sender != nil && (unpaid == nil || !unpaid.Unpaid(tinfo.Transcoder)) {
+			sender = n.Sender
 			ticketParams = pmTicketParams(tinfo.TicketParams)
-			sessionID = n.Sender.StartSession(*ticketParams)
-		}
+			sessionID = sender.StartSession(*ticketParams)
 
-		if n.Balances != nil {
-			balance = core.NewBalance(ticketParams.Recipient, params.mid, n.Balances)
+			if n.Balances != nil {
+				balance = core.NewBalance(ticketParams.Recipient, params.mid, n.Balances)
+			}
 		}
 
 		var orchOS drivers.OSSession
@@ -248,14 +393,15 @@ func selectOrchestrator(n *core.LivepeerNode, params *streamParameters, cpl core
 			OrchestratorInfo: tinfo,
 			OrchestratorOS:   orchOS,
 			BroadcasterOS:    bcastOS,
-			Sender:           n.Sender,
+			Sender:           sender,
 			PMSessionID:      sessionID,
 			Balance:          balance,
+			spending:         n.Spending,
 		}
 
 		sessions = append(sessions, session)
 	}
-	return sessions, nil
+	return sessions
 }
 
 func processSegment(cxn *rtmpConnection, seg *stream.HLSSegment) ([]string, error) {
@@ -270,6 +416,7 @@ func processSegment(cxn *rtmpConnection, seg *stream.HLSSegment) ([]string, erro
 	if monitor.Enabled {
 		monitor.SegmentEmerged(nonce, seg.SeqNo, len(BroadcastJobVideoProfiles))
 	}
+	cxn.stats.segmentEmerged(seg.SeqNo, len(seg.Data), seg.Duration)
 
 	seg.Name = "" // hijack seg.Name to convey the uploaded URI
 	name := fmt.Sprintf("%s/%d.ts", vProfile.Name, seg.SeqNo)
@@ -279,6 +426,7 @@ func processSegment(cxn *rtmpConnection, seg *stream.HLSSegment) ([]string, erro
 		if monitor.Enabled {
 			monitor.SegmentUploadFailed(nonce, seg.SeqNo, monitor.SegmentUploadErrorUnknown, err.Error(), true)
 		}
+		cxn.stats.segmentFailed(seg.SeqNo)
 		return nil, err
 	}
 	if cpl.GetOSSession().IsExternal() {
@@ -295,6 +443,12 @@ func processSegment(cxn *rtmpConnection, seg *stream.HLSSegment) ([]string, erro
 		}
 	}
 
+	// Only the source is kept once the stream is over budget
+	if cxn.spending != nil && cxn.spending.BudgetExceeded(mid) {
+		glog.V(common.DEBUG).Infof("Budget reached, not transcoding segment nonce=%d manifestID=%s seqNo=%d", nonce, mid, seg.SeqNo)
+		return nil, errBudgetExceeded
+	}
+
 	var sv *verification.SegmentVerifier
 	if Policy != nil {
 		sv = verification.NewSegmentVerifier(Policy)
@@ -308,12 +462,14 @@ func processSegment(cxn *rtmpConnection, seg *stream.HLSSegment) ([]string, erro
 
 		if shouldStopStream(err) {
 			glog.Warningf("Stopping current stream due to: %v", err)
+			cxn.stats.segmentFailed(seg.SeqNo)
 			rtmpStrm.Close()
 			return nil, err
 		}
 
 		// recoverable error, retry
 	}
+	cxn.stats.segmentFailed(seg.SeqNo)
 	return nil, errors.New("Hit max transcode attempts")
 }
 
@@ -329,6 +485,7 @@ func transcodeSegment(cxn *rtmpConnection, seg *stream.HLSSegment, name string,
 		if monitor.Enabled {
 			monitor.SegmentTranscodeFailed(monitor.SegmentTranscodeErrorNoOrchestrators, nonce, seg.SeqNo, errNoOrchs, true)
 		}
+		cxn.stats.segmentFailed(seg.SeqNo)
 		glog.Infof("No sessions available for segment nonce=%d manifestID=%s seqNo=%d", nonce, cxn.mid, seg.SeqNo)
 		// We may want to introduce a "non-retryable" error type here
 		// would help error propagation for live ingest.
@@ -497,8 +654,11 @@ func transcodeSegment(cxn *rtmpConnection, seg *stream.HLSSegment, name string,
 			if monitor.Enabled {
 				monitor.SegmentTranscodeFailed(monitor.SegmentTranscodeErrorPlaylist, nonce, seg.SeqNo, err, false)
 			}
+			continue
 		}
+		cxn.stats.renditionAppeared(seg.SeqNo, sess.Profiles[i].Name)
 	}
+	cxn.stats.segmentTranscoded(seg.SeqNo)
 
 	if monitor.Enabled {
 		monitor.SegmentFullyTranscoded(nonce, seg.SeqNo, common.ProfilesNames(sess.Profiles), errCode)
@@ -606,7 +766,17 @@ func updateSession(sess *BroadcastSession, res *ReceivedTranscodeResult) *Broadc
 		// and the next time this BroadcastSession is used, the ticket params will be validated
 		// during ticket creation in genPayment(). If ticket params validation during ticket
 		// creation fails, then this BroadcastSession will be removed
+		if pool, ok := newSess.Sender.(*pm.SenderPool); ok {
+			// The ticket params were requested by the account that paid for the segment
+			if addr, err := pool.SessionAccount(sess.PMSessionID); err == nil {
+				pool.BindTicketParams(addr, ethcommon.BytesToHash(oInfo.TicketParams.RecipientRandHash))
+			}
+		}
 		newSess.PMSessionID = newSess.Sender.StartSession(*pmTicketParams(oInfo.TicketParams))
+		if pool, ok := newSess.Sender.(*pm.SenderPool); ok && newSess.PMSessionID != sess.PMSessionID {
+			// The session with the previous ticket params was replaced
+			pool.EndSession(sess.PMSessionID)
+		}
 	}
 
 	return newSess
@@ -12,10 +12,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/golang/protobuf/proto"
 	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/core"
 	"github.com/livepeer/go-livepeer/drivers"
+	"github.com/livepeer/go-livepeer/eth"
 	"github.com/livepeer/go-livepeer/net"
 	"github.com/livepeer/go-livepeer/pm"
 	"github.com/livepeer/go-livepeer/verification"
@@ -203,7 +206,7 @@ func TestNewSessionManager(t *testing.T) {
 	pl := core.NewBasicPlaylistManager(mid, storage)
 
 	// Check empty pool produces expected numOrchs
-	sess := NewSessionManager(n, params, pl, &LIFOSelector{})
+	sess := NewSessionManager(n, params, pl, &LIFOSelector{}, nil)
 	assert.Equal(0, sess.numOrchs)
 
 	// Check numOrchs up to maximum and a bit beyond
@@ -211,7 +214,7 @@ func TestNewSessionManager(t *testing.T) {
 	n.OrchestratorPool = sd
 	max := int(common.HTTPTimeout.Seconds()/SegLen.Seconds()) * 2
 	for i := 0; i < 10; i++ {
-		sess = NewSessionManager(n, params, pl, &LIFOSelector{})
+		sess = NewSessionManager(n, params, pl, &LIFOSelector{}, nil)
 		if i < max {
 			assert.Equal(i, sess.numOrchs)
 		} else {
@@ -224,6 +227,157 @@ func TestNewSessionManager(t *testing.T) {
 	assert.True(sd.Size() > max, "pool should be greater than max numOrchs")
 }
 
+func TestNewSessionManager_SessionPool(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	n, _ := core.NewLivepeerNode(nil, "", nil)
+	sd := &stubDiscovery{lock: &sync.Mutex{}}
+	for i := 0; i < 4; i++ {
+		sd.infos = append(sd.infos, &net.OrchestratorInfo{
+			Transcoder:   fmt.Sprintf("transcoder%d", i),
+			PriceInfo:    &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+			TicketParams: &net.TicketParams{RecipientRandHash: []byte{byte(i)}},
+		})
+	}
+	n.OrchestratorPool = sd
+	// The session pool uses its own discovery and is only refilled when unblocked
+	poolSD := &stubDiscovery{lock: &sync.Mutex{}, infos: sd.infos}
+	n.SessionPool = core.NewSessionPool(poolSD, nil, 4, time.Hour)
+	n.SessionPool.Fill()
+	poolSD.waitGetOrch = make(chan struct{})
+
+	newStream := func() (*BroadcastSessionsManager, core.PlaylistManager) {
+		mid := core.RandomManifestID()
+		storage := drivers.NewMemoryDriver(nil).NewSession(string(mid))
+		pl := core.NewBasicPlaylistManager(mid, storage)
+		return NewSessionManager(n, &streamParameters{mid: mid}, pl, &LIFOSelector{}, nil), pl
+	}
+
+	// New streams are seeded from the session pool without discovery
+	bsm1, _ := newStream()
+	require.Len(bsm1.sessMap, 4)
+	assert.Equal(0, sd.getOrchCalls)
+
+	// The info in the session pool is only used by one stream
+	bsm2, _ := newStream()
+	assert.Len(bsm2.sessMap, 4)
+	assert.Equal(1, sd.getOrchCalls)
+	for transcoder, sess := range bsm1.sessMap {
+		assert.NotEqual(sess, bsm2.sessMap[transcoder])
+		assert.NotEqual(sess.ManifestID, bsm2.sessMap[transcoder].ManifestID)
+	}
+
+	// Orchestrators with a price above the max price are not used to seed streams
+	n.SessionPool.Put(poolSD.infos...)
+	BroadcastCfg.SetMaxPrice(big.NewRat(1, 2))
+	bsm3, _ := newStream()
+	BroadcastCfg.SetMaxPrice(nil)
+	assert.Equal(2, sd.getOrchCalls)
+	assert.Len(bsm3.sessMap, 4)
+
+	// Streams are not seeded without a session pool
+	n.SessionPool.Put(poolSD.infos...)
+	sessPool := n.SessionPool
+	n.SessionPool = nil
+	newStream()
+	assert.Equal(3, sd.getOrchCalls)
+	n.SessionPool = sessPool
+
+	// Failed sessions are removed from the session pool
+	bsm1.removeSession(bsm1.sessMap["transcoder0"])
+	assert.Len(n.SessionPool.Get(4), 3)
+
+	// The sessions of a finished stream are returned to the session pool with fresh ticket params
+	oldGetOrchestratorInfoRPC := getOrchestratorInfoRPC
+	defer func() { getOrchestratorInfoRPC = oldGetOrchestratorInfoRPC }()
+	var rpcLock sync.Mutex
+	var refreshed []string
+	getOrchestratorInfoRPC = func(ctx context.Context, bcast common.Broadcaster, uri *url.URL) (*net.OrchestratorInfo, error) {
+		rpcLock.Lock()
+		refreshed = append(refreshed, uri.String())
+		rpcLock.Unlock()
+		if uri.String() == "transcoder3" {
+			return nil, errors.New("GetOrchestrator error")
+		}
+		return &net.OrchestratorInfo{Transcoder: uri.String(), TicketParams: &net.TicketParams{RecipientRandHash: []byte("fresh")}}, nil
+	}
+	bsm1.cleanup()
+	var returned []*net.OrchestratorInfo
+	assert.Eventually(func() bool {
+		returned = append(returned, n.SessionPool.Get(4)...)
+		return len(returned) == 2
+	}, time.Second, 5*time.Millisecond)
+	for _, info := range returned {
+		assert.Contains([]string{"transcoder1", "transcoder2"}, info.Transcoder)
+		assert.Equal([]byte("fresh"), info.TicketParams.RecipientRandHash)
+	}
+	// The failed session is not refreshed and the orchestrator that failed to refresh is not returned
+	rpcLock.Lock()
+	assert.ElementsMatch([]string{"transcoder1", "transcoder2", "transcoder3"}, refreshed)
+	rpcLock.Unlock()
+
+	// The pool is refilled from discovery
+	close(poolSD.waitGetOrch)
+	assert.Eventually(func() bool { return len(n.SessionPool.Get(4)) == 4 }, time.Second, 5*time.Millisecond)
+}
+
+type stubUnpaidPool struct {
+	*stubDiscovery
+	unpaid map[string]bool
+}
+
+func (p *stubUnpaidPool) Unpaid(transcoder string) bool {
+	return p.unpaid[transcoder]
+}
+
+func TestNewBroadcastSessions_Unpaid(t *testing.T) {
+	assert := assert.New(t)
+
+	n, _ := core.NewLivepeerNode(nil, "", nil)
+	sender := &pm.MockSender{}
+	sender.On("StartSession", mock.Anything).Return("foo")
+	n.Sender = sender
+
+	infos := []*net.OrchestratorInfo{
+		{Transcoder: "transcoder1", TicketParams: &net.TicketParams{}},
+		{Transcoder: "transcoder2"},
+	}
+	mid := core.RandomManifestID()
+	pl := core.NewBasicPlaylistManager(mid, drivers.NewMemoryDriver(nil).NewSession(string(mid)))
+
+	// Orchestrators are paid unless the pool uses them without payments
+	n.OrchestratorPool = &stubUnpaidPool{
+		stubDiscovery: &stubDiscovery{infos: infos},
+		unpaid:        map[string]bool{"transcoder2": true},
+	}
+	sessions := newBroadcastSessions(n, &streamParameters{mid: mid}, pl, infos)
+	assert.Len(sessions, 2)
+	assert.Equal(sender, sessions[0].Sender)
+	assert.Equal("foo", sessions[0].PMSessionID)
+	assert.Nil(sessions[1].Sender)
+	assert.Empty(sessions[1].PMSessionID)
+	assert.Nil(sessions[1].Balance)
+	sender.AssertNumberOfCalls(t, "StartSession", 1)
+}
+
+func TestSelectSession_OrchestratorFilter(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	bsm := bsmWithSessList([]*BroadcastSession{StubBroadcastSession("https://127.0.0.1:1"), StubBroadcastSession("https://127.0.0.1:2")})
+	filter, err := common.NewOrchestratorFilter(nil)
+	require.Nil(err)
+	bsm.filter = filter
+
+	// Sessions of orchestrators that are denied while the stream is running are removed
+	require.Nil(filter.Add(common.OrchDenyList, "https://127.0.0.1:2"))
+	sess := bsm.selectSession()
+	require.NotNil(sess)
+	assert.Equal("https://127.0.0.1:1", sess.OrchestratorInfo.Transcoder)
+	assert.Len(bsm.sessMap, 1)
+}
+
 func wgWait(wg *sync.WaitGroup) bool {
 	c := make(chan struct{})
 	go func() { defer close(c); wg.Wait() }()
@@ -496,6 +650,9 @@ func TestTranscodeSegment_ExpiredParams_GetOrchestratorInfoAndRetry(t *testing.T
 			},
 		},
 	}
+	orch := newStubOrchestrator()
+	tr.Info.TicketParams.Recipient = orch.Address().Bytes()
+	require.Nil(signOrchestratorInfo(orch, tr.Info, certHash(ts.Certificate().Raw)))
 	buf, err := proto.Marshal(tr)
 	require.Nil(err)
 
@@ -724,6 +881,49 @@ func TestProcessSegment_MaxAttempts(t *testing.T) {
 	assert.Len(bsm.sessMap, 0)
 }
 
+func TestProcessSegment_BudgetExceeded(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	spending, err := core.NewSpendTracker(dbh, nil)
+	require.Nil(err)
+	spending.SetBudgets(big.NewRat(10, 1), nil)
+
+	transcodeCalls := 0
+	ts, mux := stubTLSServer()
+	defer ts.Close()
+	mux.HandleFunc("/segment", func(w http.ResponseWriter, r *http.Request) {
+		transcodeCalls++
+	})
+	bsm := bsmWithSessList([]*BroadcastSession{StubBroadcastSession(ts.URL)})
+	pl := &stubPlaylistManager{os: &stubOSSession{}}
+	cxn := &rtmpConnection{
+		mid:         "foo",
+		profile:     &ffmpeg.VideoProfile{Name: "source"},
+		sessManager: bsm,
+		pl:          pl,
+		spending:    spending,
+	}
+
+	// Under budget the segment is transcoded
+	_, err = processSegment(cxn, &stream.HLSSegment{SeqNo: 1})
+	assert.Nil(err)
+	assert.Equal(1, transcodeCalls)
+
+	// Over budget only the source is kept
+	require.Nil(spending.Record(&common.DBSpending{ManifestID: "foo", EV: big.NewRat(10, 1)}))
+	urls, err := processSegment(cxn, &stream.HLSSegment{SeqNo: 2})
+	assert.Equal(errBudgetExceeded, err)
+	assert.Empty(urls)
+	assert.Equal(1, transcodeCalls)
+	assert.Equal(uint64(2), pl.seq)
+	assert.Equal("source", pl.profile.Name)
+}
+
 func TestTranscodeSegment_VerifyPixels(t *testing.T) {
 	require := require.New(t)
 	assert := assert.New(t)
@@ -858,6 +1058,79 @@ func TestUpdateSession(t *testing.T) {
 	assert.Equal("foo", sess.PMSessionID)
 }
 
+func TestUpdateSession_SenderPool(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var signers []pm.Signer
+	var addrs []ethcommon.Address
+	for _, b := range []string{"foo", "bar"} {
+		addr := ethcommon.BytesToAddress([]byte(b))
+		signer := &eth.MockClient{}
+		signer.On("Account").Return(accounts.Account{Address: addr})
+		signers = append(signers, signer)
+		addrs = append(addrs, addr)
+	}
+	pool := pm.NewSenderPool(signers, &stubPaymentChain{}, nil, big.NewRat(1, 1), 1)
+
+	params := pm.TicketParams{RecipientRandHash: ethcommon.Hash{1}}
+	pool.BindTicketParams(addrs[1], params.RecipientRandHash)
+	sess := &BroadcastSession{Sender: pool, PMSessionID: pool.StartSession(params)}
+
+	// Updated ticket params are paid by the account that paid for the session
+	res := &ReceivedTranscodeResult{
+		Info: &net.OrchestratorInfo{
+			TicketParams: &net.TicketParams{RecipientRandHash: []byte{2}},
+		},
+	}
+	newSess := updateSession(sess, res)
+	assert.NotEqual(sess.PMSessionID, newSess.PMSessionID)
+	addr, err := pool.SessionAccount(newSess.PMSessionID)
+	require.Nil(err)
+	assert.Equal(addrs[1], addr)
+
+	// The replaced session is removed from the pool
+	_, err = pool.SessionAccount(sess.PMSessionID)
+	assert.Contains(err.Error(), "error loading session")
+}
+
+func TestBroadcastSessionsManager_EndsSenderPoolSessions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	addr := ethcommon.BytesToAddress([]byte("foo"))
+	signer := &eth.MockClient{}
+	signer.On("Account").Return(accounts.Account{Address: addr})
+	pool := pm.NewSenderPool([]pm.Signer{signer}, &stubPaymentChain{}, nil, big.NewRat(1, 1), 1)
+
+	var sessList []*BroadcastSession
+	for i := 1; i <= 2; i++ {
+		params := pm.TicketParams{Recipient: pm.RandAddress(), RecipientRandHash: ethcommon.Hash{byte(i)}}
+		pool.BindTicketParams(addr, params.RecipientRandHash)
+		sess := StubBroadcastSession(fmt.Sprintf("transcoder%d", i))
+		sess.Sender = pool
+		sess.PMSessionID = pool.StartSession(params)
+		sessList = append(sessList, sess)
+	}
+	bsm := bsmWithSessList(sessList)
+	for _, sess := range sessList {
+		_, err := pool.SessionAccount(sess.PMSessionID)
+		require.Nil(err)
+	}
+
+	// Failed sessions no longer count against the account
+	bsm.removeSession(sessList[0])
+	_, err := pool.SessionAccount(sessList[0].PMSessionID)
+	assert.Contains(err.Error(), "error loading session")
+	_, err = pool.SessionAccount(sessList[1].PMSessionID)
+	assert.Nil(err)
+
+	// Neither do the sessions of a finished stream
+	bsm.cleanup()
+	_, err = pool.SessionAccount(sessList[1].PMSessionID)
+	assert.Contains(err.Error(), "error loading session")
+}
+
 func TestHLSInsertion(t *testing.T) {
 	assert := assert.New(t)
 
@@ -4,8 +4,12 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
+	"io/ioutil"
 	"math/big"
 	"net/url"
 	"os"
@@ -107,3 +111,31 @@ func getCert(uri *url.URL, workDir string) (string, string, error) {
 	}
 	return certFile, keyFile, nil
 }
+
+// certHash returns the hash of a DER encoded certificate that an orchestrator
+// includes in its signed OrchestratorInfo to pin its self-signed certificate
+func certHash(der []byte) []byte {
+	h := sha256.Sum256(der)
+	return h[:]
+}
+
+// certFileHash returns the hash of the PEM encoded certificate in certFile
+func certFileHash(certFile string) ([]byte, error) {
+	data, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate found in " + certFile)
+	}
+	return certHash(block.Bytes), nil
+}
+
+// peerCertHash returns the hash of the certificate presented by the TLS peer of a connection
+func peerCertHash(state *tls.ConnectionState) []byte {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return certHash(state.PeerCertificates[0].Raw)
+}
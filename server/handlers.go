@@ -1,13 +1,20 @@
 package server
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/golang/glog"
 	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/core"
 	"github.com/livepeer/go-livepeer/eth"
 	"github.com/livepeer/go-livepeer/pm"
 )
@@ -224,31 +231,56 @@ func withdrawHandler(client eth.LivepeerEthClient) http.Handler {
 	})
 }
 
-func senderInfoHandler(client eth.LivepeerEthClient) http.Handler {
+// accountSenderInfo is the sender info of one of the accounts of a sender pool
+type accountSenderInfo struct {
+	Address ethcommon.Address
+	*pm.SenderInfo
+}
+
+// senderPoolInfo is the aggregated sender info of the accounts of a sender pool
+type senderPoolInfo struct {
+	*pm.SenderInfo
+	Senders []accountSenderInfo
+}
+
+func senderInfoHandler(client eth.LivepeerEthClient, sender pm.Sender) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if client == nil {
 			respondWith500(w, "missing ETH client")
 			return
 		}
 
-		info, err := client.GetSenderInfo(client.Account().Address)
-		if err != nil {
-			if err.Error() == "ErrNoResult" {
-				info = &pm.SenderInfo{
-					Deposit:       big.NewInt(0),
-					WithdrawRound: big.NewInt(0),
-					Reserve: &pm.ReserveInfo{
-						FundsRemaining:        big.NewInt(0),
-						ClaimedInCurrentRound: big.NewInt(0),
-					},
+		var res interface{}
+		if pool, ok := sender.(*pm.SenderPool); ok {
+			total := zeroSenderInfo()
+			var senders []accountSenderInfo
+			for _, addr := range pool.Accounts() {
+				info, err := getSenderInfo(client, addr)
+				if err != nil {
+					respondWith500(w, fmt.Sprintf("could not query sender info: %v", err))
+					return
+				}
+				senders = append(senders, accountSenderInfo{Address: addr, SenderInfo: info})
+
+				total.Deposit.Add(total.Deposit, info.Deposit)
+				total.Reserve.FundsRemaining.Add(total.Reserve.FundsRemaining, info.Reserve.FundsRemaining)
+				total.Reserve.ClaimedInCurrentRound.Add(total.Reserve.ClaimedInCurrentRound, info.Reserve.ClaimedInCurrentRound)
+				// Report the earliest round that any of the accounts can withdraw in
+				if info.WithdrawRound.Sign() > 0 && (total.WithdrawRound.Sign() == 0 || info.WithdrawRound.Cmp(total.WithdrawRound) < 0) {
+					total.WithdrawRound.Set(info.WithdrawRound)
 				}
-			} else {
+			}
+			res = senderPoolInfo{SenderInfo: total, Senders: senders}
+		} else {
+			info, err := getSenderInfo(client, client.Account().Address)
+			if err != nil {
 				respondWith500(w, fmt.Sprintf("could not query sender info: %v", err))
 				return
 			}
+			res = info
 		}
 
-		data, err := json.Marshal(info)
+		data, err := json.Marshal(res)
 		if err != nil {
 			respondWith500(w, fmt.Sprintf("could not parse sender info: %v", err))
 			return
@@ -260,6 +292,29 @@ func senderInfoHandler(client eth.LivepeerEthClient) http.Handler {
 	})
 }
 
+// getSenderInfo returns the sender info of addr or zero values if addr has never funded a deposit or reserve
+func getSenderInfo(client eth.LivepeerEthClient, addr ethcommon.Address) (*pm.SenderInfo, error) {
+	info, err := client.GetSenderInfo(addr)
+	if err != nil {
+		if err.Error() == "ErrNoResult" {
+			return zeroSenderInfo(), nil
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+func zeroSenderInfo() *pm.SenderInfo {
+	return &pm.SenderInfo{
+		Deposit:       big.NewInt(0),
+		WithdrawRound: big.NewInt(0),
+		Reserve: &pm.ReserveInfo{
+			FundsRemaining:        big.NewInt(0),
+			ClaimedInCurrentRound: big.NewInt(0),
+		},
+	}
+}
+
 func ticketBrokerParamsHandler(client eth.LivepeerEthClient) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if client == nil {
@@ -309,3 +364,513 @@ func signMessageHandler(client eth.LivepeerEthClient) http.Handler {
 		w.Write(signed)
 	})
 }
+
+func respondJSON(w http.ResponseWriter, v interface{}) {
+	respondJSONWithStatus(w, v, http.StatusOK)
+}
+
+func respondJSONWithStatus(w http.ResponseWriter, v interface{}, code int) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		respondWith500(w, fmt.Sprintf("could not marshal response: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+func streamsHandler(s *LivepeerServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondJSON(w, s.streamSummaries())
+	})
+}
+
+// streamHealthHandler serves /streams/{manifestID}/health
+func streamHealthHandler(s *LivepeerServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/streams/"), "/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] != "health" {
+			respondWithError(w, fmt.Sprintf("unknown path: %s", r.URL.Path), http.StatusNotFound)
+			return
+		}
+
+		health := s.streamHealth(core.ManifestID(parts[0]))
+		if health == nil {
+			respondWithError(w, fmt.Sprintf("unknown stream: %s", parts[0]), http.StatusNotFound)
+			return
+		}
+
+		respondJSON(w, health)
+	})
+}
+
+// healthHandler runs the given checks and responds with 200 if all of them
+// pass or 503 otherwise. Used for /healthz and /readyz
+func healthHandler(checks func() []healthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := runHealthChecks(r.Context(), checks())
+		if !report.ok() {
+			glog.Errorf("Health check failed path=%s checks=%+v", r.URL.Path, failedChecks(report))
+			respondJSONWithStatus(w, report, http.StatusServiceUnavailable)
+			return
+		}
+		respondJSON(w, report)
+	})
+}
+
+type drainStatus struct {
+	Draining bool `json:"draining"`
+	InFlight int  `json:"inFlight"`
+}
+
+// drainHandler reports whether the node is draining. A POST request makes
+// the node stop accepting new work and exit once its in-flight work is done
+func drainHandler(n *core.LivepeerNode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && n.StartDrain() {
+			glog.Infof("Drain requested from addr=%s", r.RemoteAddr)
+		}
+		respondJSON(w, drainStatus{Draining: n.IsDraining(), InFlight: n.ActiveWork()})
+	})
+}
+
+type senderBalance struct {
+	Sender     string    `json:"sender"`
+	ManifestID string    `json:"manifestID"`
+	Balance    string    `json:"balance"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// balancesHandler lists the credit balances of broadcasters with the node,
+// optionally filtered by the sender and manifestID query params
+func balancesHandler(n *core.LivepeerNode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Balances == nil {
+			respondWith500(w, "missing balances")
+			return
+		}
+
+		sender := r.FormValue("sender")
+		if sender != "" && !ethcommon.IsHexAddress(sender) {
+			respondWith400(w, fmt.Sprintf("invalid sender: %v", sender))
+			return
+		}
+		manifestID := r.FormValue("manifestID")
+
+		balances := []senderBalance{}
+		for _, b := range n.Balances.Snapshot() {
+			if sender != "" && b.Sender != ethcommon.HexToAddress(sender) {
+				continue
+			}
+			if manifestID != "" && b.ManifestID != manifestID {
+				continue
+			}
+			balances = append(balances, senderBalance{
+				Sender:     b.Sender.Hex(),
+				ManifestID: b.ManifestID,
+				Balance:    b.Amount.FloatString(0),
+				UpdatedAt:  b.UpdatedAt,
+			})
+		}
+		respondJSON(w, balances)
+	})
+}
+
+type ledgerEntry struct {
+	ID         int64     `json:"id"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Type       string    `json:"type"`
+	Sender     string    `json:"sender"`
+	ManifestID string    `json:"manifestID"`
+	Amount     string    `json:"amount"`
+	FaceValue  string    `json:"faceValue,omitempty"`
+	Won        bool      `json:"won"`
+	Pixels     int64     `json:"pixels"`
+	TicketHash string    `json:"ticketHash,omitempty"`
+	TxHash     string    `json:"txHash,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+var ledgerCSVHeader = []string{"id", "createdAt", "type", "sender", "manifestID", "amount", "faceValue", "won", "pixels", "ticketHash", "txHash", "error"}
+
+func (e *ledgerEntry) csvRecord() []string {
+	return []string{
+		strconv.FormatInt(e.ID, 10),
+		e.CreatedAt.Format(time.RFC3339),
+		e.Type,
+		e.Sender,
+		e.ManifestID,
+		e.Amount,
+		e.FaceValue,
+		strconv.FormatBool(e.Won),
+		strconv.FormatInt(e.Pixels, 10),
+		e.TicketHash,
+		e.TxHash,
+		e.Error,
+	}
+}
+
+func newLedgerEntry(e *common.DBLedgerEntry) *ledgerEntry {
+	entry := &ledgerEntry{
+		ID:         e.ID,
+		CreatedAt:  e.CreatedAt,
+		Type:       e.Type,
+		Sender:     e.Sender.Hex(),
+		ManifestID: e.ManifestID,
+		Won:        e.Won,
+		Pixels:     e.Pixels,
+		Error:      e.Error,
+	}
+	if e.Amount != nil {
+		entry.Amount = e.Amount.RatString()
+	}
+	if e.FaceValue != nil {
+		entry.FaceValue = e.FaceValue.String()
+	}
+	if (e.TicketHash != ethcommon.Hash{}) {
+		entry.TicketHash = e.TicketHash.Hex()
+	}
+	if (e.TxHash != ethcommon.Hash{}) {
+		entry.TxHash = e.TxHash.Hex()
+	}
+	return entry
+}
+
+// parseTimeParam parses an optional RFC3339 time from a form param
+func parseTimeParam(r *http.Request, param string) (time.Time, error) {
+	v := r.FormValue(param)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %v: %v", param, err)
+	}
+	return t, nil
+}
+
+// ledgerHandler lists the entries of the node's payment ledger as JSON or,
+// with format=csv, as CSV. Entries can be filtered with the sender, manifestID
+// and type query params and limited to the [from, to) RFC3339 time range
+func ledgerHandler(db *common.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if db == nil {
+			respondWith500(w, "missing database")
+			return
+		}
+
+		filter := &common.DBLedgerFilter{
+			ManifestID: r.FormValue("manifestID"),
+			Type:       r.FormValue("type"),
+		}
+		if sender := r.FormValue("sender"); sender != "" {
+			if !ethcommon.IsHexAddress(sender) {
+				respondWith400(w, fmt.Sprintf("invalid sender: %v", sender))
+				return
+			}
+			addr := ethcommon.HexToAddress(sender)
+			filter.Sender = &addr
+		}
+		switch filter.Type {
+		case "", common.LedgerTicket, common.LedgerDebit, common.LedgerRedemption:
+		default:
+			respondWith400(w, fmt.Sprintf("invalid type: %v", filter.Type))
+			return
+		}
+		var err error
+		if filter.From, err = parseTimeParam(r, "from"); err != nil {
+			respondWith400(w, err.Error())
+			return
+		}
+		if filter.To, err = parseTimeParam(r, "to"); err != nil {
+			respondWith400(w, err.Error())
+			return
+		}
+		format := r.FormValue("format")
+		if format != "" && format != "json" && format != "csv" {
+			respondWith400(w, fmt.Sprintf("invalid format: %v", format))
+			return
+		}
+
+		dbEntries, err := db.LedgerEntries(filter)
+		if err != nil {
+			respondWith500(w, fmt.Sprintf("could not query ledger: %v", err))
+			return
+		}
+		entries := make([]*ledgerEntry, 0, len(dbEntries))
+		for _, e := range dbEntries {
+			entries = append(entries, newLedgerEntry(e))
+		}
+
+		if format != "csv" {
+			respondJSON(w, entries)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="ledger.csv"`)
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		cw.Write(ledgerCSVHeader)
+		for _, e := range entries {
+			cw.Write(e.csvRecord())
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			glog.Errorf("Error writing ledger CSV err=%v", err)
+		}
+	})
+}
+
+type spendingTotals struct {
+	Tickets int    `json:"tickets"`
+	EV      string `json:"ev"`
+	Pixels  int64  `json:"pixels"`
+	Fees    string `json:"fees"`
+
+	ev, fees *big.Rat
+}
+
+func newSpendingTotals() *spendingTotals {
+	return &spendingTotals{EV: "0", Fees: "0", ev: big.NewRat(0, 1), fees: big.NewRat(0, 1)}
+}
+
+func (t *spendingTotals) add(s *common.DBSpending) {
+	t.Tickets += s.Tickets
+	t.Pixels += s.Pixels
+	t.ev.Add(t.ev, s.EV)
+	t.fees.Add(t.fees, s.Fees)
+	t.EV = t.ev.RatString()
+	t.Fees = t.fees.RatString()
+}
+
+type spendingSummary struct {
+	spendingTotals
+	Streams       map[string]*spendingTotals `json:"streams"`
+	Orchestrators map[string]*spendingTotals `json:"orchestrators"`
+}
+
+// spendingHandler summarizes the broadcaster's spending ledger in total, per
+// stream and per orchestrator. Entries can be filtered with the manifestID and
+// orchestrator query params and limited to the [from, to) RFC3339 time range
+func spendingHandler(db *common.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if db == nil {
+			respondWith500(w, "missing database")
+			return
+		}
+
+		filter := &common.DBSpendingFilter{
+			ManifestID:   r.FormValue("manifestID"),
+			Orchestrator: r.FormValue("orchestrator"),
+		}
+		var err error
+		if filter.From, err = parseTimeParam(r, "from"); err != nil {
+			respondWith400(w, err.Error())
+			return
+		}
+		if filter.To, err = parseTimeParam(r, "to"); err != nil {
+			respondWith400(w, err.Error())
+			return
+		}
+
+		entries, err := db.SpendingEntries(filter)
+		if err != nil {
+			respondWith500(w, fmt.Sprintf("could not query spending: %v", err))
+			return
+		}
+
+		summary := &spendingSummary{
+			spendingTotals: *newSpendingTotals(),
+			Streams:        make(map[string]*spendingTotals),
+			Orchestrators:  make(map[string]*spendingTotals),
+		}
+		for _, e := range entries {
+			summary.add(e)
+			if _, ok := summary.Streams[e.ManifestID]; !ok {
+				summary.Streams[e.ManifestID] = newSpendingTotals()
+			}
+			summary.Streams[e.ManifestID].add(e)
+			if _, ok := summary.Orchestrators[e.Orchestrator]; !ok {
+				summary.Orchestrators[e.Orchestrator] = newSpendingTotals()
+			}
+			summary.Orchestrators[e.Orchestrator].add(e)
+		}
+
+		respondJSON(w, summary)
+	})
+}
+
+type budgetStatus struct {
+	StreamBudget string `json:"streamBudget"`
+	DailyBudget  string `json:"dailyBudget"`
+	DailySpent   string `json:"dailySpent"`
+}
+
+// budgetsHandler reports the broadcaster's spending budgets and the spending
+// of the current day. A POST sets the budgets given by the streamBudget and
+// dailyBudget params in wei, where 0 is unlimited
+func budgetsHandler(n *core.LivepeerNode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Spending == nil {
+			respondWith500(w, "missing spending tracker")
+			return
+		}
+
+		stream, daily := n.Spending.Budgets()
+		if r.Method == http.MethodPost {
+			var err error
+			if stream, err = budgetParam(r, "streamBudget", stream); err != nil {
+				respondWith400(w, err.Error())
+				return
+			}
+			if daily, err = budgetParam(r, "dailyBudget", daily); err != nil {
+				respondWith400(w, err.Error())
+				return
+			}
+			n.Spending.SetBudgets(stream, daily)
+			glog.Infof("Budgets set streamBudget=%v dailyBudget=%v", formatBudget(stream), formatBudget(daily))
+		}
+
+		respondJSON(w, budgetStatus{
+			StreamBudget: formatBudget(stream),
+			DailyBudget:  formatBudget(daily),
+			DailySpent:   n.Spending.DailySpent().FloatString(0),
+		})
+	})
+}
+
+// budgetParam parses a budget in wei from a request param, returning
+// current if the param is not set
+func budgetParam(r *http.Request, param string, current *big.Rat) (*big.Rat, error) {
+	val := r.FormValue(param)
+	if val == "" {
+		return current, nil
+	}
+	b, err := common.ParseBigInt(val)
+	if err != nil || b.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %v: %v", param, val)
+	}
+	return new(big.Rat).SetInt(b), nil
+}
+
+func formatBudget(b *big.Rat) string {
+	if b == nil {
+		return "0"
+	}
+	return b.FloatString(0)
+}
+
+type txStatus struct {
+	Hash        string    `json:"hash"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Sender      string    `json:"sender"`
+	Nonce       uint64    `json:"nonce"`
+	Method      string    `json:"method"`
+	CurrentHash string    `json:"currentHash"`
+	GasPrice    string    `json:"gasPrice"`
+	Attempts    int       `json:"attempts"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// transactionsHandler returns the status of the transactions tracked by the
+// transaction manager. Transactions can be filtered with the status and
+// sender query params
+func transactionsHandler(db *common.DB) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if db == nil {
+			respondWith500(w, "missing database")
+			return
+		}
+
+		filter := &common.DBTxFilter{Status: r.FormValue("status")}
+		switch filter.Status {
+		case "", common.TxPending, common.TxMined, common.TxFailed, common.TxDropped:
+		default:
+			respondWith400(w, fmt.Sprintf("invalid status: %v", filter.Status))
+			return
+		}
+		if sender := r.FormValue("sender"); sender != "" {
+			if !ethcommon.IsHexAddress(sender) {
+				respondWith400(w, fmt.Sprintf("invalid sender: %v", sender))
+				return
+			}
+			addr := ethcommon.HexToAddress(sender)
+			filter.Sender = &addr
+		}
+
+		dbTxs, err := db.Txs(filter)
+		if err != nil {
+			respondWith500(w, fmt.Sprintf("could not query transactions: %v", err))
+			return
+		}
+		txs := make([]*txStatus, 0, len(dbTxs))
+		for _, t := range dbTxs {
+			tx := &txStatus{
+				Hash:        t.Hash.Hex(),
+				CreatedAt:   t.CreatedAt,
+				UpdatedAt:   t.UpdatedAt,
+				Sender:      t.Sender.Hex(),
+				Nonce:       t.Nonce,
+				Method:      t.Method,
+				CurrentHash: t.CurrentHash.Hex(),
+				Attempts:    t.Attempts,
+				Status:      t.Status,
+				Error:       t.Error,
+			}
+			if t.GasPrice != nil {
+				tx.GasPrice = t.GasPrice.String()
+			}
+			txs = append(txs, tx)
+		}
+
+		respondJSON(w, txs)
+	})
+}
+
+type orchLists struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// orchestratorListsHandler reports the allowlist and denylist of orchestrators. A POST adds
+// the ethereum address or service URI given by the address param to the list given by the
+// list param, or removes it from the list if the action param is remove
+func orchestratorListsHandler(n *core.LivepeerNode) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.OrchFilter == nil {
+			respondWith500(w, "missing orchestrator lists")
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			list, addr := r.FormValue("list"), r.FormValue("address")
+			var err error
+			switch action := r.FormValue("action"); action {
+			case "", "add":
+				err = n.OrchFilter.Add(list, addr)
+			case "remove":
+				err = n.OrchFilter.Remove(list, addr)
+			default:
+				err = fmt.Errorf("invalid action: %v", action)
+			}
+			if err != nil {
+				respondWith400(w, err.Error())
+				return
+			}
+			glog.Infof("Orchestrator list updated list=%v address=%v action=%v", list, addr, r.FormValue("action"))
+		}
+
+		lists := orchLists{
+			Allow: n.OrchFilter.List(common.OrchAllowList),
+			Deny:  n.OrchFilter.List(common.OrchDenyList),
+		}
+		sort.Strings(lists.Allow)
+		sort.Strings(lists.Deny)
+		respondJSON(w, lists)
+	})
+}
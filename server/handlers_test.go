@@ -12,9 +12,12 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/core"
 	"github.com/livepeer/go-livepeer/eth"
 	"github.com/livepeer/go-livepeer/pm"
 	"github.com/stretchr/testify/assert"
@@ -526,7 +529,7 @@ func TestWithdrawHandler_Success(t *testing.T) {
 }
 
 func TestSenderInfoHandler_MissingClient(t *testing.T) {
-	handler := senderInfoHandler(nil)
+	handler := senderInfoHandler(nil, nil)
 
 	resp := httpGetResp(handler)
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -538,7 +541,7 @@ func TestSenderInfoHandler_MissingClient(t *testing.T) {
 
 func TestSenderInfoHandler_GetSenderInfoErrNoResult(t *testing.T) {
 	client := &eth.MockClient{}
-	handler := senderInfoHandler(client)
+	handler := senderInfoHandler(client, nil)
 	addr := ethcommon.Address{}
 
 	client.On("Account").Return(accounts.Account{Address: addr})
@@ -554,7 +557,7 @@ func TestSenderInfoHandler_GetSenderInfoErrNoResult(t *testing.T) {
 
 func TestSenderInfoHandler_GetSenderInfoOtherError(t *testing.T) {
 	client := &eth.MockClient{}
-	handler := senderInfoHandler(client)
+	handler := senderInfoHandler(client, nil)
 	addr := ethcommon.Address{}
 
 	client.On("Account").Return(accounts.Account{Address: addr})
@@ -570,7 +573,7 @@ func TestSenderInfoHandler_GetSenderInfoOtherError(t *testing.T) {
 
 func TestSenderInfoHandler_Success(t *testing.T) {
 	client := &eth.MockClient{}
-	handler := senderInfoHandler(client)
+	handler := senderInfoHandler(client, nil)
 	addr := ethcommon.Address{}
 
 	mockInfo := &pm.SenderInfo{
@@ -693,6 +696,312 @@ func httpPostFormResp(handler http.Handler, body io.Reader) *http.Response {
 	return httpPostResp(handler, body, headers)
 }
 
+func TestDrainHandler(t *testing.T) {
+	assert := assert.New(t)
+	n := &core.LivepeerNode{}
+	require.Nil(t, n.BeginWork())
+	handler := drainHandler(n)
+
+	var status drainStatus
+	decode := func(resp *http.Response) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(t, json.Unmarshal(body, &status))
+	}
+
+	// GET only reports the status
+	resp := httpGetResp(handler)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	decode(resp)
+	assert.Equal(drainStatus{Draining: false, InFlight: 1}, status)
+	assert.False(n.IsDraining())
+
+	// POST starts draining
+	resp = httpPostResp(handler, nil, nil)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	decode(resp)
+	assert.Equal(drainStatus{Draining: true, InFlight: 1}, status)
+	assert.True(n.IsDraining())
+
+	n.EndWork()
+	resp = httpPostResp(handler, nil, nil)
+	decode(resp)
+	assert.Equal(drainStatus{Draining: true, InFlight: 0}, status)
+}
+
+func TestBalancesHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	n := &core.LivepeerNode{}
+	handler := balancesHandler(n)
+
+	get := func(query string) (*http.Response, []senderBalance) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/balances?"+query, nil))
+		resp := w.Result()
+		if resp.StatusCode != http.StatusOK {
+			return resp, nil
+		}
+		var balances []senderBalance
+		body, _ := ioutil.ReadAll(resp.Body)
+		require.Nil(json.Unmarshal(body, &balances))
+		return resp, balances
+	}
+
+	// No balances on the node
+	resp, _ := get("")
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	n.Balances = core.NewAddressBalances(time.Minute)
+	defer n.Balances.StopCleanup()
+
+	resp, balances := get("")
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Empty(balances)
+
+	sender1 := pm.RandAddress()
+	sender2 := pm.RandAddress()
+	n.Balances.Credit(sender1, core.ManifestID("foo"), big.NewRat(100, 1))
+	n.Balances.Credit(sender1, core.ManifestID("bar"), big.NewRat(50, 1))
+	n.Balances.Credit(sender2, core.ManifestID("foo"), big.NewRat(25, 1))
+
+	_, balances = get("")
+	assert.Len(balances, 3)
+
+	_, balances = get("sender=" + sender1.Hex())
+	require.Len(balances, 2)
+	assert.Equal(sender1.Hex(), balances[0].Sender)
+	assert.Equal("bar", balances[0].ManifestID)
+	assert.Equal("50", balances[0].Balance)
+	assert.Equal("foo", balances[1].ManifestID)
+	assert.Equal("100", balances[1].Balance)
+
+	_, balances = get("manifestID=foo")
+	assert.Len(balances, 2)
+
+	_, balances = get("manifestID=foo&sender=" + sender2.Hex())
+	require.Len(balances, 1)
+	assert.Equal("25", balances[0].Balance)
+
+	resp, _ = get("sender=foo")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestLedgerHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	get := func(handler http.Handler, query string) *http.Response {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/ledger?"+query, nil))
+		return w.Result()
+	}
+
+	resp := get(ledgerHandler(nil), "")
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	handler := ledgerHandler(dbh)
+
+	sender := pm.RandAddress()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ticketHash := pm.RandHash()
+	require.Nil(dbh.InsertLedgerEntry(&common.DBLedgerEntry{
+		CreatedAt:  start,
+		Type:       common.LedgerTicket,
+		Sender:     sender,
+		ManifestID: "foo",
+		Amount:     big.NewRat(10, 1),
+		FaceValue:  big.NewInt(1000),
+		Won:        true,
+		TicketHash: ticketHash,
+	}))
+	require.Nil(dbh.InsertLedgerEntry(&common.DBLedgerEntry{
+		CreatedAt:  start.Add(time.Hour),
+		Type:       common.LedgerDebit,
+		Sender:     sender,
+		ManifestID: "foo",
+		Amount:     big.NewRat(5, 1),
+		Pixels:     100,
+	}))
+
+	decode := func(resp *http.Response) []ledgerEntry {
+		require.Equal(http.StatusOK, resp.StatusCode)
+		body, _ := ioutil.ReadAll(resp.Body)
+		var entries []ledgerEntry
+		require.Nil(json.Unmarshal(body, &entries))
+		return entries
+	}
+
+	entries := decode(get(handler, ""))
+	require.Len(entries, 2)
+	assert.Equal(ledgerEntry{
+		ID:         1,
+		CreatedAt:  start,
+		Type:       common.LedgerTicket,
+		Sender:     sender.Hex(),
+		ManifestID: "foo",
+		Amount:     "10",
+		FaceValue:  "1000",
+		Won:        true,
+		TicketHash: ticketHash.Hex(),
+	}, entries[0])
+
+	entries = decode(get(handler, "type=debit&sender="+sender.Hex()))
+	require.Len(entries, 1)
+	assert.Equal(int64(100), entries[0].Pixels)
+
+	entries = decode(get(handler, "from=2020-01-01T00:30:00Z&to=2020-01-02T00:00:00Z"))
+	require.Len(entries, 1)
+	assert.Equal(common.LedgerDebit, entries[0].Type)
+
+	entries = decode(get(handler, "manifestID=bar"))
+	assert.Empty(entries)
+
+	// CSV export
+	resp = get(handler, "format=csv&type=ticket")
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("text/csv", resp.Header.Get("Content-Type"))
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(
+		"id,createdAt,type,sender,manifestID,amount,faceValue,won,pixels,ticketHash,txHash,error\n"+
+			"1,2020-01-01T00:00:00Z,ticket,"+sender.Hex()+",foo,10,1000,true,0,"+ticketHash.Hex()+",,\n",
+		string(body),
+	)
+
+	// Invalid params
+	for _, query := range []string{"sender=foo", "type=foo", "from=yesterday", "to=1", "format=xml"} {
+		assert.Equal(http.StatusBadRequest, get(handler, query).StatusCode, query)
+	}
+}
+
+func TestSpendingHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	get := func(handler http.Handler, query string) *http.Response {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/spending?"+query, nil))
+		return w.Result()
+	}
+
+	resp := get(spendingHandler(nil), "")
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	handler := spendingHandler(dbh)
+
+	decode := func(resp *http.Response) *spendingSummary {
+		require.Equal(http.StatusOK, resp.StatusCode)
+		var summary spendingSummary
+		require.Nil(json.NewDecoder(resp.Body).Decode(&summary))
+		return &summary
+	}
+
+	// Empty ledger
+	summary := decode(get(handler, ""))
+	assert.Equal("0", summary.EV)
+	assert.Equal("0", summary.Fees)
+	assert.Empty(summary.Streams)
+	assert.Empty(summary.Orchestrators)
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Nil(dbh.InsertSpending(&common.DBSpending{CreatedAt: start, ManifestID: "foo", Orchestrator: "o1", Tickets: 2, EV: big.NewRat(20, 1), Pixels: 100, Fees: big.NewRat(15, 1)}))
+	require.Nil(dbh.InsertSpending(&common.DBSpending{CreatedAt: start.Add(time.Hour), ManifestID: "foo", Orchestrator: "o2", Tickets: 1, EV: big.NewRat(10, 1), Pixels: 50, Fees: big.NewRat(8, 1)}))
+	require.Nil(dbh.InsertSpending(&common.DBSpending{CreatedAt: start.Add(2 * time.Hour), ManifestID: "bar", Orchestrator: "o1", Pixels: 10, Fees: big.NewRat(1, 3)}))
+
+	summary = decode(get(handler, ""))
+	assert.Equal(3, summary.Tickets)
+	assert.Equal("30", summary.EV)
+	assert.Equal(int64(160), summary.Pixels)
+	// Fractional wei amounts are exported exactly
+	assert.Equal("70/3", summary.Fees)
+	require.Len(summary.Streams, 2)
+	assert.Equal(3, summary.Streams["foo"].Tickets)
+	assert.Equal("30", summary.Streams["foo"].EV)
+	assert.Equal("0", summary.Streams["bar"].EV)
+	assert.Equal("1/3", summary.Streams["bar"].Fees)
+	require.Len(summary.Orchestrators, 2)
+	assert.Equal(int64(110), summary.Orchestrators["o1"].Pixels)
+	assert.Equal("10", summary.Orchestrators["o2"].EV)
+
+	// Filters
+	summary = decode(get(handler, "manifestID=foo&orchestrator=o1"))
+	assert.Equal("20", summary.EV)
+	assert.Len(summary.Streams, 1)
+	assert.Len(summary.Orchestrators, 1)
+
+	q := url.Values{}
+	q.Set("from", start.Add(time.Hour).Format(time.RFC3339))
+	q.Set("to", start.Add(2*time.Hour).Format(time.RFC3339))
+	summary = decode(get(handler, q.Encode()))
+	assert.Equal(1, summary.Tickets)
+	assert.Equal(int64(50), summary.Pixels)
+
+	resp = get(handler, "from=yesterday")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+	resp = get(handler, "to=tomorrow")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestBudgetsHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	do := func(handler http.Handler, method, query string) *http.Response {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "http://example.com/budgets?"+query, nil))
+		return w.Result()
+	}
+	decode := func(resp *http.Response) budgetStatus {
+		require.Equal(http.StatusOK, resp.StatusCode)
+		var status budgetStatus
+		require.Nil(json.NewDecoder(resp.Body).Decode(&status))
+		return status
+	}
+
+	n, _ := core.NewLivepeerNode(nil, "", nil)
+	resp := do(budgetsHandler(n), "GET", "")
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	n.Spending, err = core.NewSpendTracker(dbh, nil)
+	require.Nil(err)
+	require.Nil(n.Spending.Record(&common.DBSpending{ManifestID: "foo", EV: big.NewRat(5, 1)}))
+	handler := budgetsHandler(n)
+
+	// Unlimited by default
+	assert.Equal(budgetStatus{StreamBudget: "0", DailyBudget: "0", DailySpent: "5"}, decode(do(handler, "GET", "")))
+
+	// GET does not update budgets
+	assert.Equal(budgetStatus{StreamBudget: "0", DailyBudget: "0", DailySpent: "5"}, decode(do(handler, "GET", "streamBudget=10")))
+
+	assert.Equal(budgetStatus{StreamBudget: "10", DailyBudget: "100", DailySpent: "5"}, decode(do(handler, "POST", "streamBudget=10&dailyBudget=100")))
+	stream, daily := n.Spending.Budgets()
+	assert.Zero(stream.Cmp(big.NewRat(10, 1)))
+	assert.Zero(daily.Cmp(big.NewRat(100, 1)))
+
+	// Budgets that are not set are unchanged
+	assert.Equal(budgetStatus{StreamBudget: "10", DailyBudget: "0", DailySpent: "5"}, decode(do(handler, "POST", "dailyBudget=0")))
+
+	resp = do(handler, "POST", "streamBudget=foo")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+	resp = do(handler, "POST", "dailyBudget=-1")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+	stream, daily = n.Spending.Budgets()
+	assert.Zero(stream.Cmp(big.NewRat(10, 1)))
+	assert.Nil(daily)
+}
+
 func httpPostResp(handler http.Handler, body io.Reader, headers map[string]string) *http.Response {
 	return httpResp(handler, "POST", body, headers)
 }
@@ -715,3 +1024,200 @@ func httpResp(handler http.Handler, method string, body io.Reader, headers map[s
 
 	return w.Result()
 }
+
+func TestTransactionsHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	get := func(handler http.Handler, query string) *http.Response {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com/transactions?"+query, nil))
+		return w.Result()
+	}
+
+	resp := get(transactionsHandler(nil), "")
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	handler := transactionsHandler(dbh)
+
+	sender := pm.RandAddress()
+	pending := &common.DBTx{
+		Hash:        pm.RandHash(),
+		Sender:      sender,
+		Nonce:       1,
+		Method:      "reward",
+		CurrentHash: pm.RandHash(),
+		GasPrice:    big.NewInt(120),
+		Attempts:    2,
+		Status:      common.TxPending,
+	}
+	require.Nil(dbh.StoreTx(pending))
+	require.Nil(dbh.StoreTx(&common.DBTx{
+		Hash:     pm.RandHash(),
+		Sender:   pm.RandAddress(),
+		Nonce:    1,
+		Method:   "bond",
+		Attempts: 1,
+		Status:   common.TxMined,
+	}))
+
+	decode := func(resp *http.Response) []txStatus {
+		require.Equal(http.StatusOK, resp.StatusCode)
+		body, _ := ioutil.ReadAll(resp.Body)
+		var txs []txStatus
+		require.Nil(json.Unmarshal(body, &txs))
+		return txs
+	}
+
+	assert.Len(decode(get(handler, "")), 2)
+
+	txs := decode(get(handler, "status=pending"))
+	require.Len(txs, 1)
+	assert.Equal(pending.Hash.Hex(), txs[0].Hash)
+	assert.Equal(pending.CurrentHash.Hex(), txs[0].CurrentHash)
+	assert.Equal(sender.Hex(), txs[0].Sender)
+	assert.Equal(uint64(1), txs[0].Nonce)
+	assert.Equal("reward", txs[0].Method)
+	assert.Equal("120", txs[0].GasPrice)
+	assert.Equal(2, txs[0].Attempts)
+	assert.Equal(common.TxPending, txs[0].Status)
+
+	txs = decode(get(handler, "sender="+sender.Hex()))
+	require.Len(txs, 1)
+	assert.Equal(pending.Hash.Hex(), txs[0].Hash)
+
+	assert.Empty(decode(get(handler, "status=dropped")))
+
+	resp = get(handler, "status=foo")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+	resp = get(handler, "sender=foo")
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSenderInfoHandler_SenderPool(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	client := &eth.MockClient{}
+	addr1 := ethcommon.BytesToAddress([]byte("foo"))
+	addr2 := ethcommon.BytesToAddress([]byte("bar"))
+	var signers []pm.Signer
+	for _, addr := range []ethcommon.Address{addr1, addr2} {
+		signer := &eth.MockClient{}
+		signer.On("Account").Return(accounts.Account{Address: addr})
+		signers = append(signers, signer)
+	}
+	pool := pm.NewSenderPool(signers, nil, nil, big.NewRat(1, 1), 1)
+	handler := senderInfoHandler(client, pool)
+
+	client.On("GetSenderInfo", addr1).Return(&pm.SenderInfo{
+		Deposit:       big.NewInt(100),
+		WithdrawRound: big.NewInt(0),
+		Reserve: &pm.ReserveInfo{
+			FundsRemaining:        big.NewInt(200),
+			ClaimedInCurrentRound: big.NewInt(10),
+		}}, nil).Once()
+	client.On("GetSenderInfo", addr2).Return(nil, errors.New("ErrNoResult")).Once()
+
+	resp := httpGetResp(handler)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	var info struct {
+		pm.SenderInfo
+		Senders []struct {
+			Address ethcommon.Address
+			pm.SenderInfo
+		}
+	}
+	require.Nil(json.Unmarshal(body, &info))
+	assert.Equal(big.NewInt(100), info.Deposit)
+	assert.Equal(big.NewInt(0), info.WithdrawRound)
+	assert.Equal(big.NewInt(200), info.Reserve.FundsRemaining)
+	assert.Equal(big.NewInt(10), info.Reserve.ClaimedInCurrentRound)
+	require.Len(info.Senders, 2)
+	assert.Equal(addr1, info.Senders[0].Address)
+	assert.Equal(big.NewInt(100), info.Senders[0].Deposit)
+	assert.Equal(addr2, info.Senders[1].Address)
+	assert.Equal(big.NewInt(0), info.Senders[1].Deposit)
+
+	// The earliest withdraw round of the accounts is reported
+	client.On("GetSenderInfo", addr1).Return(&pm.SenderInfo{
+		Deposit:       big.NewInt(100),
+		WithdrawRound: big.NewInt(9),
+		Reserve:       &pm.ReserveInfo{FundsRemaining: big.NewInt(0), ClaimedInCurrentRound: big.NewInt(0)},
+	}, nil).Once()
+	client.On("GetSenderInfo", addr2).Return(&pm.SenderInfo{
+		Deposit:       big.NewInt(100),
+		WithdrawRound: big.NewInt(7),
+		Reserve:       &pm.ReserveInfo{FundsRemaining: big.NewInt(0), ClaimedInCurrentRound: big.NewInt(0)},
+	}, nil).Once()
+
+	resp = httpGetResp(handler)
+	body, _ = ioutil.ReadAll(resp.Body)
+	require.Nil(json.Unmarshal(body, &info))
+	assert.Equal(big.NewInt(200), info.Deposit)
+	assert.Equal(big.NewInt(7), info.WithdrawRound)
+
+	// Errors for any account fail the request
+	client.On("GetSenderInfo", addr1).Return(nil, errors.New("foo")).Once()
+
+	resp = httpGetResp(handler)
+	body, _ = ioutil.ReadAll(resp.Body)
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal("could not query sender info: foo", strings.TrimSpace(string(body)))
+}
+
+func TestOrchestratorListsHandler(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	do := func(handler http.Handler, method, query string) *http.Response {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "http://example.com/orchestratorLists?"+query, nil))
+		return w.Result()
+	}
+	decode := func(resp *http.Response) orchLists {
+		require.Equal(http.StatusOK, resp.StatusCode)
+		var lists orchLists
+		require.Nil(json.NewDecoder(resp.Body).Decode(&lists))
+		return lists
+	}
+
+	n, _ := core.NewLivepeerNode(nil, "", nil)
+	resp := do(orchestratorListsHandler(n), "GET", "")
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	n.OrchFilter, err = common.NewOrchestratorFilter(dbh)
+	require.Nil(err)
+	handler := orchestratorListsHandler(n)
+
+	assert.Equal(orchLists{Allow: []string{}, Deny: []string{}}, decode(do(handler, "GET", "")))
+
+	addr := pm.RandAddress()
+	lists := decode(do(handler, "POST", "list=deny&address="+addr.Hex()))
+	assert.Equal([]string{strings.ToLower(addr.Hex())}, lists.Deny)
+	lists = decode(do(handler, "POST", "list=allow&action=add&address=https://127.0.0.1:8935"))
+	assert.Equal([]string{"https://127.0.0.1:8935"}, lists.Allow)
+
+	// Lists are persisted
+	filter, err := common.NewOrchestratorFilter(dbh)
+	require.Nil(err)
+	assert.Equal([]string{strings.ToLower(addr.Hex())}, filter.List(common.OrchDenyList))
+
+	lists = decode(do(handler, "POST", "list=deny&action=remove&address="+addr.Hex()))
+	assert.Equal(orchLists{Allow: []string{"https://127.0.0.1:8935"}, Deny: []string{}}, lists)
+
+	// Invalid params
+	assert.Equal(http.StatusBadRequest, do(handler, "POST", "list=foo&address="+addr.Hex()).StatusCode)
+	assert.Equal(http.StatusBadRequest, do(handler, "POST", "list=deny&address=foo").StatusCode)
+	assert.Equal(http.StatusBadRequest, do(handler, "POST", "list=deny&action=foo&address="+addr.Hex()).StatusCode)
+}
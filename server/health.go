@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/drivers"
+)
+
+const (
+	healthOK     = "ok"
+	healthFailed = "failed"
+
+	// healthCheckTimeout bounds the time taken by all checks of a single request
+	healthCheckTimeout = 5 * time.Second
+
+	// healthStoragePath is the object storage session used for round-trip checks
+	healthStoragePath = "healthcheck"
+)
+
+// HealthMaxBlockLag is the number of blocks the block watcher may fall
+// behind the chain head before the node is reported as unhealthy
+var HealthMaxBlockLag = int64(20)
+
+// HealthGasPriceMaxPolls is the number of gas price polling intervals that
+// may pass without an update before the gas price is considered stale
+var HealthGasPriceMaxPolls = int64(3)
+
+type headerReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) (string, error)
+}
+
+type healthCheckResult struct {
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+type healthReport struct {
+	Status string                        `json:"status"`
+	Checks map[string]*healthCheckResult `json:"checks"`
+}
+
+func (r *healthReport) ok() bool {
+	return r.Status == healthOK
+}
+
+// livenessChecks returns the checks that indicate whether the node
+// is stuck and should be restarted
+func (s *LivepeerServer) livenessChecks() []healthCheck {
+	n := s.LivepeerNode
+	checks := []healthCheck{}
+	if n.Database != nil {
+		checks = append(checks, healthCheck{"database", func(ctx context.Context) (string, error) {
+			return checkDatabase(n.Database)
+		}})
+	}
+	if n.Eth != nil && n.Database != nil {
+		checks = append(checks, healthCheck{"blockWatcher", func(ctx context.Context) (string, error) {
+			backend, err := n.Eth.Backend()
+			if err != nil {
+				return "", err
+			}
+			return checkBlockLag(ctx, backend, n.Database)
+		}})
+	}
+	return checks
+}
+
+// readinessChecks returns the checks that indicate whether the node
+// is able to serve traffic
+func (s *LivepeerServer) readinessChecks() []healthCheck {
+	n := s.LivepeerNode
+	checks := s.livenessChecks()
+	checks = append(checks, healthCheck{"drain", func(ctx context.Context) (string, error) {
+		return checkDrain(n)
+	}})
+	if n.Eth != nil {
+		checks = append(checks, healthCheck{"ethereum", func(ctx context.Context) (string, error) {
+			backend, err := n.Eth.Backend()
+			if err != nil {
+				return "", err
+			}
+			return checkEthereum(ctx, backend)
+		}})
+	}
+	if drivers.NodeStorage != nil {
+		checks = append(checks, healthCheck{"objectStorage", func(ctx context.Context) (string, error) {
+			return checkObjectStorage(drivers.NodeStorage)
+		}})
+	}
+	if n.NodeType == core.BroadcasterNode {
+		checks = append(checks, healthCheck{"orchestratorPool", func(ctx context.Context) (string, error) {
+			return checkOrchestratorPool(n.OrchestratorPool)
+		}})
+	}
+	if n.NodeType == core.OrchestratorNode && n.TranscoderManager != nil {
+		checks = append(checks, healthCheck{"transcoders", func(ctx context.Context) (string, error) {
+			return checkTranscoders(n.TranscoderManager)
+		}})
+	}
+	if n.GasPriceMonitor != nil {
+		checks = append(checks, healthCheck{"gasPrice", func(ctx context.Context) (string, error) {
+			return checkGasPrice(n.GasPriceMonitor.LastUpdated(), n.GasPriceMonitor.PollingInterval())
+		}})
+	}
+	return checks
+}
+
+// runHealthChecks runs all checks concurrently and collects their results.
+// The report is only healthy if every check passes
+func runHealthChecks(ctx context.Context, checks []healthCheck) *healthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := &healthReport{
+		Status: healthOK,
+		Checks: make(map[string]*healthCheckResult),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c healthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			detail, err := c.check(ctx)
+			res := &healthCheckResult{
+				Status:    healthOK,
+				Detail:    detail,
+				LatencyMs: int64(time.Since(start) / time.Millisecond),
+			}
+			if err != nil {
+				res.Status = healthFailed
+				res.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = res
+			if err != nil {
+				report.Status = healthFailed
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// failedChecks returns the errors of the checks in the report that failed
+func failedChecks(report *healthReport) map[string]string {
+	failed := make(map[string]string)
+	for name, res := range report.Checks {
+		if res.Status != healthOK {
+			failed[name] = res.Error
+		}
+	}
+	return failed
+}
+
+func checkDatabase(db *common.DB) (string, error) {
+	if err := db.CheckWritable(); err != nil {
+		return "", err
+	}
+	return "writable", nil
+}
+
+func checkDrain(n *core.LivepeerNode) (string, error) {
+	detail := fmt.Sprintf("inFlight=%v", n.ActiveWork())
+	if n.IsDraining() {
+		return detail, fmt.Errorf("node is draining")
+	}
+	return detail, nil
+}
+
+func checkEthereum(ctx context.Context, hr headerReader) (string, error) {
+	head, err := hr.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("head=%v", head.Number), nil
+}
+
+func checkBlockLag(ctx context.Context, hr headerReader, db *common.DB) (string, error) {
+	lastSeen, err := db.LastSeenBlock()
+	if err != nil {
+		return "", err
+	}
+	if lastSeen == nil {
+		return "", fmt.Errorf("no blocks processed yet")
+	}
+	head, err := hr.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	lag := new(big.Int).Sub(head.Number, lastSeen)
+	detail := fmt.Sprintf("lastSeen=%v head=%v lag=%v", lastSeen, head.Number, lag)
+	if lag.Cmp(big.NewInt(HealthMaxBlockLag)) > 0 {
+		return detail, fmt.Errorf("block watcher is %v blocks behind, max allowed is %v", lag, HealthMaxBlockLag)
+	}
+	return detail, nil
+}
+
+// checkObjectStorage writes a probe to the node's storage and reads it back
+func checkObjectStorage(driver drivers.OSDriver) (string, error) {
+	sess := driver.NewSession(healthStoragePath)
+	defer sess.EndSession()
+
+	data := []byte(fmt.Sprintf("%d", time.Now().UnixNano()))
+	uri, err := sess.SaveData("probe", data)
+	if err != nil {
+		return "", fmt.Errorf("could not save probe: %v", err)
+	}
+
+	var readBack []byte
+	if ms, ok := sess.(*drivers.MemorySession); ok {
+		readBack = ms.GetData(uri)
+	} else if sess.IsExternal() {
+		if readBack, err = drivers.GetSegmentData(uri); err != nil {
+			return "", fmt.Errorf("could not read probe: %v", err)
+		}
+	} else {
+		return "saved " + uri, nil
+	}
+	if !bytes.Equal(data, readBack) {
+		return "", fmt.Errorf("probe read back from %v does not match", uri)
+	}
+	return "round trip " + uri, nil
+}
+
+func checkOrchestratorPool(pool common.OrchestratorPool) (string, error) {
+	if pool == nil {
+		return "", fmt.Errorf("no orchestrator pool configured")
+	}
+	size := pool.Size()
+	if size == 0 {
+		return "size=0", fmt.Errorf("no orchestrators available")
+	}
+	return fmt.Sprintf("size=%v", size), nil
+}
+
+func checkTranscoders(rtm *core.RemoteTranscoderManager) (string, error) {
+	count := rtm.RegisteredTranscodersCount()
+	if count == 0 {
+		return "registered=0", fmt.Errorf("no transcoders registered")
+	}
+	return fmt.Sprintf("registered=%v", count), nil
+}
+
+func checkGasPrice(lastUpdated time.Time, pollingInterval time.Duration) (string, error) {
+	if lastUpdated.IsZero() {
+		return "", fmt.Errorf("gas price has not been fetched yet")
+	}
+	age := time.Since(lastUpdated)
+	detail := fmt.Sprintf("lastUpdated=%v age=%v", lastUpdated.UTC().Format(time.RFC3339), age.Round(time.Second))
+	if maxAge := time.Duration(HealthGasPriceMaxPolls) * pollingInterval; age > maxAge {
+		return detail, fmt.Errorf("gas price is stale, not updated for %v", age.Round(time.Second))
+	}
+	return detail, nil
+}
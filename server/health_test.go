@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/livepeer/go-livepeer/common"
+	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/drivers"
+	"github.com/livepeer/go-livepeer/eth/blockwatch"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/go-livepeer/pm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubHeaderReader struct {
+	head *big.Int
+	err  error
+}
+
+func (r *stubHeaderReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &types.Header{Number: r.head}, nil
+}
+
+func TestHealth_CheckEthereum(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := checkEthereum(context.Background(), &stubHeaderReader{err: errors.New("dial error")})
+	assert.EqualError(err, "dial error")
+
+	detail, err := checkEthereum(context.Background(), &stubHeaderReader{head: big.NewInt(5)})
+	assert.Nil(err)
+	assert.Equal("head=5", detail)
+}
+
+func TestHealth_CheckBlockLag(t *testing.T) {
+	assert := assert.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(t, err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	hr := &stubHeaderReader{head: big.NewInt(100)}
+
+	// No blocks seen by the block watcher yet
+	_, err = checkBlockLag(context.Background(), hr, dbh)
+	assert.EqualError(err, "no blocks processed yet")
+
+	require.Nil(t, dbh.InsertMiniHeader(&blockwatch.MiniHeader{Number: big.NewInt(90), Hash: pm.RandHash(), Parent: pm.RandHash()}))
+
+	// Within the allowed lag
+	detail, err := checkBlockLag(context.Background(), hr, dbh)
+	assert.Nil(err)
+	assert.Equal("lastSeen=90 head=100 lag=10", detail)
+
+	// Too far behind
+	hr.head = big.NewInt(90 + HealthMaxBlockLag + 1)
+	detail, err = checkBlockLag(context.Background(), hr, dbh)
+	assert.EqualError(err, "block watcher is 21 blocks behind, max allowed is 20")
+	assert.Equal("lastSeen=90 head=111 lag=21", detail)
+
+	// Backend error
+	hr.err = errors.New("dial error")
+	_, err = checkBlockLag(context.Background(), hr, dbh)
+	assert.EqualError(err, "dial error")
+}
+
+func TestHealth_CheckObjectStorage(t *testing.T) {
+	assert := assert.New(t)
+
+	detail, err := checkObjectStorage(drivers.NewMemoryDriver(nil))
+	assert.Nil(err)
+	assert.Equal("round trip /stream/healthcheck/probe", detail)
+}
+
+func TestHealth_CheckOrchestratorPool(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := checkOrchestratorPool(nil)
+	assert.EqualError(err, "no orchestrator pool configured")
+
+	sd := &stubDiscovery{}
+	detail, err := checkOrchestratorPool(sd)
+	assert.EqualError(err, "no orchestrators available")
+	assert.Equal("size=0", detail)
+
+	sd.infos = []*net.OrchestratorInfo{{Transcoder: "transcoder1"}, {Transcoder: "transcoder2"}}
+	detail, err = checkOrchestratorPool(sd)
+	assert.Nil(err)
+	assert.Equal("size=2", detail)
+}
+
+func TestHealth_CheckTranscoders(t *testing.T) {
+	detail, err := checkTranscoders(core.NewRemoteTranscoderManager())
+	assert.EqualError(t, err, "no transcoders registered")
+	assert.Equal(t, "registered=0", detail)
+}
+
+func TestHealth_CheckGasPrice(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := checkGasPrice(time.Time{}, time.Second)
+	assert.EqualError(err, "gas price has not been fetched yet")
+
+	_, err = checkGasPrice(time.Now(), time.Second)
+	assert.Nil(err)
+
+	_, err = checkGasPrice(time.Now().Add(-time.Minute), time.Second)
+	assert.EqualError(err, "gas price is stale, not updated for 1m0s")
+}
+
+func TestHealth_RunHealthChecks(t *testing.T) {
+	assert := assert.New(t)
+
+	// No checks
+	report := runHealthChecks(context.Background(), nil)
+	assert.True(report.ok())
+	assert.Empty(report.Checks)
+
+	pass := healthCheck{"pass", func(ctx context.Context) (string, error) { return "fine", nil }}
+	fail := healthCheck{"fail", func(ctx context.Context) (string, error) { return "bad", errors.New("oops") }}
+
+	report = runHealthChecks(context.Background(), []healthCheck{pass})
+	assert.True(report.ok())
+	assert.Equal(&healthCheckResult{Status: healthOK, Detail: "fine"}, report.Checks["pass"])
+
+	// A single failing check fails the report
+	report = runHealthChecks(context.Background(), []healthCheck{pass, fail})
+	assert.False(report.ok())
+	assert.Equal(healthFailed, report.Status)
+	assert.Equal(healthOK, report.Checks["pass"].Status)
+	assert.Equal(&healthCheckResult{Status: healthFailed, Detail: "bad", Error: "oops"}, report.Checks["fail"])
+	assert.Equal(map[string]string{"fail": "oops"}, failedChecks(report))
+}
+
+func TestHealth_Handlers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	oldStorage := drivers.NodeStorage
+	drivers.NodeStorage = drivers.NewMemoryDriver(nil)
+	defer func() { drivers.NodeStorage = oldStorage }()
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	n, _ := core.NewLivepeerNode(nil, "./tmp", dbh)
+	s := NewLivepeerServer("127.0.0.1:1938", n)
+
+	get := func(path string) (int, *healthReport) {
+		w := httptest.NewRecorder()
+		s.HTTPMux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		resp := w.Result()
+		body, _ := ioutil.ReadAll(resp.Body)
+		var report healthReport
+		require.Nil(json.Unmarshal(body, &report))
+		return resp.StatusCode, &report
+	}
+
+	// Liveness only checks the database when offchain
+	code, report := get("/healthz")
+	assert.Equal(http.StatusOK, code)
+	assert.Equal(healthOK, report.Status)
+	require.Len(report.Checks, 1)
+	assert.Equal("writable", report.Checks["database"].Detail)
+
+	// Broadcaster is not ready without orchestrators
+	code, report = get("/readyz")
+	assert.Equal(http.StatusServiceUnavailable, code)
+	assert.Equal(healthFailed, report.Status)
+	assert.Len(report.Checks, 4)
+	assert.Equal(healthOK, report.Checks["database"].Status)
+	assert.Equal("inFlight=0", report.Checks["drain"].Detail)
+	assert.Equal(healthOK, report.Checks["objectStorage"].Status)
+	assert.Equal("no orchestrator pool configured", report.Checks["orchestratorPool"].Error)
+
+	n.OrchestratorPool = &stubDiscovery{infos: []*net.OrchestratorInfo{{Transcoder: "transcoder1"}}}
+	code, report = get("/readyz")
+	assert.Equal(http.StatusOK, code)
+	assert.Equal(healthOK, report.Status)
+
+	// Orchestrators check remote transcoders instead of the pool
+	n.NodeType = core.OrchestratorNode
+	n.TranscoderManager = core.NewRemoteTranscoderManager()
+	code, report = get("/readyz")
+	assert.Equal(http.StatusServiceUnavailable, code)
+	assert.NotContains(report.Checks, "orchestratorPool")
+	assert.Equal("no transcoders registered", report.Checks["transcoders"].Error)
+
+	// Draining nodes are not ready but still alive
+	n.TranscoderManager = nil
+	n.StartDrain()
+	code, report = get("/readyz")
+	assert.Equal(http.StatusServiceUnavailable, code)
+	assert.Equal("node is draining", report.Checks["drain"].Error)
+	code, _ = get("/healthz")
+	assert.Equal(http.StatusOK, code)
+}
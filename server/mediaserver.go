@@ -48,6 +48,7 @@ var errDiscovery = errors.New("ErrDiscovery")
 var errNoOrchs = errors.New("ErrNoOrchs")
 var errUnknownStream = errors.New("ErrUnknownStream")
 var errMismatchedParams = errors.New("Mismatched type for stream params")
+var errBudgetExceeded = errors.New("BudgetExceeded")
 
 const HLSWaitInterval = time.Second
 const HLSBufferCap = uint(43200) //12 hrs assuming 1s segment
@@ -61,6 +62,12 @@ var BroadcastJobVideoProfiles = []ffmpeg.VideoProfile{ffmpeg.P240p30fps4x3, ffmp
 
 var AuthWebhookURL string
 
+// BudgetWebhookURL is notified when a stream reaches a spending budget
+var BudgetWebhookURL string
+
+// FundingWebhookURL is notified when the deposit and reserve cannot be topped up
+var FundingWebhookURL string
+
 var refreshIntervalHttpPush = 1 * time.Minute
 
 type streamParameters struct {
@@ -82,6 +89,8 @@ type rtmpConnection struct {
 	profile     *ffmpeg.VideoProfile
 	params      *streamParameters
 	sessManager *BroadcastSessionsManager
+	stats       *streamStats
+	spending    *core.SpendTracker
 	lastUsed    time.Time
 }
 
@@ -131,6 +140,8 @@ func NewLivepeerServer(rtmpAddr string, lpNode *core.LivepeerNode) *LivepeerServ
 	if lpNode.NodeType == core.BroadcasterNode {
 		opts.HttpMux.HandleFunc("/live/", ls.HandlePush)
 	}
+	opts.HttpMux.Handle("/healthz", healthHandler(ls.livenessChecks))
+	opts.HttpMux.Handle("/readyz", healthHandler(ls.readinessChecks))
 	return ls
 }
 
@@ -296,6 +307,67 @@ func authenticateStream(url string) (*authWebhookResponse, error) {
 	return &authResp, nil
 }
 
+type budgetWebhookRequest struct {
+	ManifestID string `json:"manifestID"`
+	Budget     string `json:"budget"`
+	Spent      string `json:"spent"`
+	Limit      string `json:"limit"`
+}
+
+// NotifyBudgetExceeded posts a budget event to the budget webhook, if any
+func NotifyBudgetExceeded(e core.BudgetEvent) {
+	postWebhook(BudgetWebhookURL, budgetWebhookRequest{
+		ManifestID: string(e.ManifestID),
+		Budget:     e.Budget,
+		Spent:      e.Spent.FloatString(0),
+		Limit:      e.Limit.FloatString(0),
+	})
+}
+
+type fundingWebhookRequest struct {
+	Reason    string `json:"reason"`
+	Deposit   string `json:"deposit"`
+	Reserve   string `json:"reserve"`
+	Required  string `json:"required"`
+	Available string `json:"available"`
+}
+
+// NotifyFundingWarning posts a warning that the deposit and reserve could
+// not be topped up to the funding webhook, if any
+func NotifyFundingWarning(w pm.FundingWarning) {
+	postWebhook(FundingWebhookURL, fundingWebhookRequest{
+		Reason:    w.Reason,
+		Deposit:   w.Deposit.String(),
+		Reserve:   w.Reserve.String(),
+		Required:  w.Required.String(),
+		Available: w.Available.String(),
+	})
+}
+
+// postWebhook posts a JSON notification to a webhook URL, if set. The request
+// is sent in the background so that it does not hold up the caller
+func postWebhook(url string, body interface{}) {
+	if url == "" {
+		return
+	}
+	jsonValue, err := json.Marshal(body)
+	if err != nil {
+		glog.Error("Error encoding webhook notification err=", err)
+		return
+	}
+	go func() {
+		resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonValue))
+		if err != nil {
+			glog.Errorf("Error notifying webhook url=%s err=%v", url, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 200 {
+			glog.Errorf("Error notifying webhook url=%s status=%s", url, resp.Status)
+		}
+	}()
+}
+
 func streamParams(rtmpStrm stream.RTMPVideoStream) *streamParameters {
 	d := rtmpStrm.AppData()
 	p, ok := d.(*streamParameters)
@@ -334,7 +406,15 @@ func gotRTMPStreamHandler(s *LivepeerServer) func(url *url.URL, rtmpStrm stream.
 						monitor.StreamStarted(nonce)
 					}
 				}
-				go processSegment(cxn, seg)
+				// Segments that emerge while draining are dropped
+				if err := s.LivepeerNode.BeginWork(); err != nil {
+					glog.Errorf("Dropping segment nonce=%d seqNo=%d: %v", nonce, seg.SeqNo, err)
+					return
+				}
+				go func() {
+					defer s.LivepeerNode.EndWork()
+					processSegment(cxn, seg)
+				}()
 			})
 
 			segOptions := segmenter.SegmenterOptions{
@@ -377,6 +457,11 @@ func endRTMPStreamHandler(s *LivepeerServer) func(url *url.URL, rtmpStrm stream.
 }
 
 func (s *LivepeerServer) registerConnection(rtmpStrm stream.RTMPVideoStream) (*rtmpConnection, error) {
+	// Refuse new streams while shutting down
+	if s.LivepeerNode.IsDraining() {
+		return nil, core.ErrNodeDraining
+	}
+
 	nonce := rand.Uint64()
 
 	// Set up the connection tracking
@@ -414,6 +499,7 @@ func (s *LivepeerServer) registerConnection(rtmpStrm stream.RTMPVideoStream) (*r
 	if s.LivepeerNode.Eth != nil {
 		stakeRdr = &storeStakeReader{store: s.LivepeerNode.Database}
 	}
+	stats := newStreamStats()
 	cxn := &rtmpConnection{
 		mid:         mid,
 		nonce:       nonce,
@@ -421,7 +507,9 @@ func (s *LivepeerServer) registerConnection(rtmpStrm stream.RTMPVideoStream) (*r
 		pl:          playlist,
 		profile:     &vProfile,
 		params:      params,
-		sessManager: NewSessionManager(s.LivepeerNode, params, playlist, NewMinLSSelector(stakeRdr, 1.0)),
+		sessManager: NewSessionManager(s.LivepeerNode, params, playlist, NewMinLSSelector(stakeRdr, 1.0), stats),
+		stats:       stats,
+		spending:    s.LivepeerNode.Spending,
 		lastUsed:    time.Now(),
 	}
 
@@ -458,6 +546,9 @@ func removeRTMPStream(s *LivepeerServer, mid core.ManifestID) error {
 	cxn.stream.Close()
 	cxn.sessManager.cleanup()
 	cxn.pl.Cleanup()
+	if cxn.spending != nil {
+		cxn.spending.Forget(mid)
+	}
 	glog.Infof("Ended stream with id=%s", mid)
 	delete(s.rtmpConnections, mid)
 
@@ -611,6 +702,10 @@ func (s *LivepeerServer) HandlePush(w http.ResponseWriter, r *http.Request) {
 
 	// Check for presence and register if a fresh cxn
 	if !exists {
+		if s.LivepeerNode.IsDraining() {
+			http.Error(w, core.ErrNodeDraining.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		appData := (createRTMPStreamIDHandler(s))(r.URL)
 		if appData == nil {
 			http.Error(w, "Could not create stream ID: ", http.StatusInternalServerError)
@@ -664,8 +759,18 @@ func (s *LivepeerServer) HandlePush(w http.ResponseWriter, r *http.Request) {
 		Duration: float64(duration) / 1000.0,
 	}
 
+	if err := s.LivepeerNode.BeginWork(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.LivepeerNode.EndWork()
+
 	// Do the transcoding!
 	urls, err := processSegment(cxn, seg)
+	if err == errBudgetExceeded {
+		http.Error(w, err.Error(), http.StatusPaymentRequired)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
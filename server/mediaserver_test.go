@@ -954,3 +954,54 @@ func TestParsePresets(t *testing.T) {
 	assert.Equal([]ffmpeg.VideoProfile{ffmpeg.P240p30fps16x9, ffmpeg.P720p30fps16x9}, p)
 
 }
+
+func TestNotifyBudgetExceeded(t *testing.T) {
+	assert := assert.New(t)
+
+	// No webhook set
+	NotifyBudgetExceeded(core.BudgetEvent{ManifestID: "foo", Budget: core.StreamBudget, Spent: big.NewRat(1, 1), Limit: big.NewRat(1, 1)})
+
+	reqs := make(chan budgetWebhookRequest, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req budgetWebhookRequest
+		assert.Equal("application/json", r.Header.Get("Content-Type"))
+		assert.Nil(json.NewDecoder(r.Body).Decode(&req))
+		reqs <- req
+	}))
+	defer ts.Close()
+	BudgetWebhookURL = ts.URL
+	defer func() { BudgetWebhookURL = "" }()
+
+	NotifyBudgetExceeded(core.BudgetEvent{ManifestID: "foo", Budget: core.DailyBudget, Spent: big.NewRat(101, 1), Limit: big.NewRat(100, 1)})
+	select {
+	case req := <-reqs:
+		assert.Equal(budgetWebhookRequest{ManifestID: "foo", Budget: "daily", Spent: "101", Limit: "100"}, req)
+	case <-time.After(5 * time.Second):
+		t.Fatal("budget webhook not called")
+	}
+}
+
+func TestNotifyFundingWarning(t *testing.T) {
+	assert := assert.New(t)
+
+	// No webhook set
+	NotifyFundingWarning(pm.FundingWarning{Reason: pm.FundingLowBalance, Deposit: big.NewInt(1), Reserve: big.NewInt(2), Required: big.NewInt(3), Available: big.NewInt(0)})
+
+	reqs := make(chan fundingWebhookRequest, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req fundingWebhookRequest
+		assert.Nil(json.NewDecoder(r.Body).Decode(&req))
+		reqs <- req
+	}))
+	defer ts.Close()
+	FundingWebhookURL = ts.URL
+	defer func() { FundingWebhookURL = "" }()
+
+	NotifyFundingWarning(pm.FundingWarning{Reason: pm.FundingSpendCap, Deposit: big.NewInt(1), Reserve: big.NewInt(2), Required: big.NewInt(300), Available: big.NewInt(20)})
+	select {
+	case req := <-reqs:
+		assert.Equal(fundingWebhookRequest{Reason: "spendCap", Deposit: "1", Reserve: "2", Required: "300", Available: "20"}, req)
+	case <-time.After(5 * time.Second):
+		t.Fatal("funding webhook not called")
+	}
+}
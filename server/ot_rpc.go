@@ -13,11 +13,8 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
-	"os"
-	"os/signal"
 	"strconv"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/cenkalti/backoff"
@@ -99,20 +96,6 @@ func runTranscoder(n *core.LivepeerNode, orchAddr string, capacity int) error {
 		return err
 	}
 
-	// Catch interrupt signal to shut down transcoder
-	exitc := make(chan os.Signal)
-	signal.Notify(exitc, os.Interrupt, syscall.SIGTERM)
-	defer signal.Stop(exitc)
-	go func() {
-		select {
-		case sig := <-exitc:
-			glog.Infof("Exiting Livepeer Transcoder: %v", sig)
-			// Cancelling context will close connection to orchestrator
-			cancel()
-			return
-		}
-	}()
-
 	httpc := &http.Client{Transport: &http2.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
 	var wg sync.WaitGroup
 	for {
@@ -146,7 +129,14 @@ func runTranscode(n *core.LivepeerNode, orchAddr string, httpc *http.Client, not
 	var contentType string
 	var body bytes.Buffer
 
-	tData, err := n.Transcoder.Transcode(notify.Job, notify.Url, profiles)
+	// Tasks assigned while draining are refused so the orchestrator can retry
+	// them elsewhere; accepted tasks are tracked until their results are sent
+	var tData *core.TranscodeData
+	err := n.BeginWork()
+	if err == nil {
+		defer n.EndWork()
+		tData, err = n.Transcoder.Transcode(notify.Job, notify.Url, profiles)
+	}
 	glog.V(common.VERBOSE).Infof("Transcoding done for taskId=%d url=%s err=%v", notify.TaskId, notify.Url, err)
 	if err != nil {
 		glog.Error("Unable to transcode ", err)
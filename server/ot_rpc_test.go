@@ -194,3 +194,36 @@ func TestRemoteTranscoderError(t *testing.T) {
 	assert.Equal(protoVerLPT, headers.Get("Authorization"))
 	assert.Equal(errText, string(body))
 }
+
+func TestRemoteTranscoder_Draining(t *testing.T) {
+	httpc := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	assert := assert.New(t)
+	notify := &net.NotifySegment{
+		TaskId: 743,
+		Url:    "linktomanifest",
+	}
+	tr := &stubTranscoder{}
+	node, _ := core.NewLivepeerNode(nil, "/tmp/thisdirisnotactuallyusedinthistest", nil)
+	node.OrchSecret = "verbigsecret"
+	node.Transcoder = tr
+	node.StartDrain()
+
+	var headers http.Header
+	var body []byte
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out, err := ioutil.ReadAll(r.Body)
+		assert.NoError(err)
+		headers = r.Header
+		body = out
+		w.Write(nil)
+	}))
+	defer ts.Close()
+	parsedURL, _ := url.Parse(ts.URL)
+
+	// Tasks assigned while draining are refused without transcoding
+	runTranscode(node, parsedURL.Host, httpc, notify)
+	assert.Equal(0, tr.called)
+	assert.Equal("743", headers.Get("TaskId"))
+	assert.Equal(transcodingErrorMimeType, headers.Get("Content-Type"))
+	assert.Equal(core.ErrNodeDraining.Error(), string(body))
+}
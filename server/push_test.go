@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"mime"
 	"mime/multipart"
 	"net/http"
@@ -20,10 +21,12 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
+	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/core"
 	"github.com/livepeer/go-livepeer/drivers"
 	"github.com/livepeer/go-livepeer/net"
 	"github.com/livepeer/lpms/ffmpeg"
+	"github.com/livepeer/lpms/stream"
 )
 
 func requestSetup(s *LivepeerServer) (http.Handler, *strings.Reader, *httptest.ResponseRecorder) {
@@ -453,3 +456,68 @@ func TestWebhookRequestURL(t *testing.T) {
 	// Server has empty sessions list, so it will return 503
 	assert.Equal(503, resp.StatusCode)
 }
+
+func TestPush_Draining(t *testing.T) {
+	assert := assert.New(t)
+	drivers.NodeStorage = drivers.NewMemoryDriver(nil)
+	n, _ := core.NewLivepeerNode(nil, "./tmp", nil)
+	s := NewLivepeerServer("127.0.0.1:1938", n)
+
+	// Register a stream before draining starts
+	mid := core.ManifestID("draining")
+	cxn, err := s.registerConnection(stream.NewBasicRTMPVideoStream(&streamParameters{mid: mid}))
+	require.Nil(t, err)
+	defer cxn.stream.Close()
+
+	n.StartDrain()
+
+	// New streams are refused
+	_, err = s.registerConnection(stream.NewBasicRTMPVideoStream(&streamParameters{mid: "fresh"}))
+	assert.Equal(core.ErrNodeDraining, err)
+
+	w := httptest.NewRecorder()
+	s.HandlePush(w, httptest.NewRequest("POST", "/live/fresh/0.ts", strings.NewReader("")))
+	assert.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+
+	// Segments for existing streams are refused
+	w = httptest.NewRecorder()
+	s.HandlePush(w, httptest.NewRequest("POST", "/live/draining/0.ts", strings.NewReader("")))
+	assert.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+	assert.Zero(n.ActiveWork())
+}
+
+func TestPush_BudgetExceeded(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+
+	oldURL := AuthWebhookURL
+	defer func() { AuthWebhookURL = oldURL }()
+	AuthWebhookURL = ""
+
+	drivers.NodeStorage = drivers.NewMemoryDriver(nil)
+	n, _ := core.NewLivepeerNode(nil, "./tmp", dbh)
+	n.Spending, err = core.NewSpendTracker(dbh, nil)
+	require.Nil(err)
+	n.Spending.SetBudgets(nil, big.NewRat(1, 1))
+	require.Nil(n.Spending.Record(&common.DBSpending{ManifestID: "other", EV: big.NewRat(1, 1)}))
+	s := NewLivepeerServer("127.0.0.1:1938", n)
+
+	w := httptest.NewRecorder()
+	s.HandlePush(w, httptest.NewRequest("POST", "/live/budget/0.ts", strings.NewReader("")))
+	resp := w.Result()
+	defer resp.Body.Close()
+	assert.Equal(http.StatusPaymentRequired, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(errBudgetExceeded.Error(), strings.TrimSpace(string(body)))
+
+	// The source segment is still available
+	cxn, ok := s.rtmpConnections["budget"]
+	require.True(ok)
+	defer removeRTMPStream(s, "budget")
+	assert.NotNil(cxn.pl.GetHLSMediaPlaylist("source"))
+}
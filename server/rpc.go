@@ -1,19 +1,26 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"math/big"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 
 	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/core"
+	lpcrypto "github.com/livepeer/go-livepeer/crypto"
 	"github.com/livepeer/go-livepeer/drivers"
 	"github.com/livepeer/go-livepeer/net"
 	"github.com/livepeer/go-livepeer/pm"
@@ -24,17 +31,54 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 )
 
 const GRPCConnectTimeout = 3 * time.Second
 const GRPCTimeout = 8 * time.Second
 
+// OrchestratorReqMaxAge is how far the timestamp of an orchestrator request can be
+// from the time of the orchestrator for the request to be accepted
+var OrchestratorReqMaxAge = time.Minute
+
+// AcceptLegacyOrchestratorReqs is whether orchestrator requests from older broadcasters,
+// which only sign their address and can be replayed, are accepted
+var AcceptLegacyOrchestratorReqs = true
+
+// RequireSignedOrchestratorInfo is whether broadcasters reject OrchestratorInfo from on-chain orchestrators
+// that neither pin a TLS certificate nor sign the info, i.e. orchestrators running older versions.
+// It is off by default while orchestrators upgrade, so until it is set an attacker on the path can
+// strip the signature from the info of orchestrators that have not been seen signing yet
+var RequireSignedOrchestratorInfo = false
+
+// SendLegacyOrchestratorReqs is whether broadcasters retry orchestrator requests without a timestamp
+// for orchestrators that are not known to support timestamps, i.e. orchestrators running older versions
+var SendLegacyOrchestratorReqs = true
+
+var errOrchReqSig = errors.New("orchestrator req sig check failed")
+var errOrchReqLegacy = errors.New("orchestrator req is missing a timestamp")
+var errOrchReqExpired = errors.New("orchestrator req timestamp is outside of the accepted window")
+var errOrchReqReplayed = errors.New("orchestrator req has already been used")
+
+var orchReqCache = newReplayCache()
+
+// orchReqTimestamps contains the URIs of the orchestrators that are known to support
+// timestamped requests. These orchestrators are never sent requests without a timestamp
+var orchReqTimestamps sync.Map
+
+// orchInfoSigners contains the URIs of the orchestrators that have sent signed OrchestratorInfo.
+// Unsigned info from these orchestrators is always rejected
+var orchInfoSigners sync.Map
+
+var errPinnedCert = errors.New("TLS certificate does not match the pinned certificate")
+
 type Orchestrator interface {
 	ServiceURI() *url.URL
 	Address() ethcommon.Address
 	TranscoderSecret() string
 	Sign([]byte) ([]byte, error)
+	SegmentSigner() *core.SegmentSigner
 	VerifySig(ethcommon.Address, string, []byte) bool
 	CurrentBlock() *big.Int
 	CheckCapacity(core.ManifestID) error
@@ -99,6 +143,11 @@ type BroadcastSession struct {
 	PMSessionID      string
 	Balance          Balance
 	LatencyScore     float64
+
+	// stats of the stream this session belongs to, if any
+	stats *streamStats
+	// spending ledger of the broadcaster, if any
+	spending *core.SpendTracker
 }
 
 // ReceivedTranscodeResult contains received transcode result data and related metadata
@@ -112,6 +161,8 @@ type lphttp struct {
 	orchestrator Orchestrator
 	orchRPC      *grpc.Server
 	transRPC     *http.ServeMux
+	// hash of the TLS certificate that is pinned in signed OrchestratorInfo
+	tlsCertHash []byte
 }
 
 // grpc methods
@@ -125,7 +176,14 @@ func (h *lphttp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *lphttp) GetOrchestrator(context context.Context, req *net.OrchestratorRequest) (*net.OrchestratorInfo, error) {
-	return getOrchestrator(h.orchestrator, req)
+	info, err := getOrchestrator(h.orchestrator, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := signOrchestratorInfo(h.orchestrator, info, h.tlsCertHash); err != nil {
+		return nil, err
+	}
+	return info, nil
 }
 
 func (h *lphttp) Ping(context context.Context, req *net.PingPong) (*net.PingPong, error) {
@@ -151,6 +209,11 @@ func StartTranscodeServer(orch Orchestrator, bind string, mux *http.ServeMux, wo
 	if err != nil {
 		return // XXX return error
 	}
+	lp.tlsCertHash, err = certFileHash(cert)
+	if err != nil {
+		glog.Error("Could not read TLS certificate ", err)
+		return
+	}
 
 	glog.Info("Listening for RPC on ", bind)
 	srv := http.Server{
@@ -191,8 +254,34 @@ func CheckOrchestratorAvailability(orch Orchestrator) bool {
 	return orch.VerifySig(orch.Address(), string(ping), pong.Value)
 }
 
+// PingOrchestrator - the broadcaster calls PingOrchestrator which invokes Ping on the orchestrator
+// and returns the round trip time of connecting to the orchestrator and receiving its pong.
+// The ping is empty so that the orchestrator does not sign it, and the pong is not checked
+func PingOrchestrator(ctx context.Context, orchestratorServer *url.URL) (time.Duration, error) {
+	start := time.Now()
+
+	c, conn, err := startOrchestratorClient(orchestratorServer)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, GRPCTimeout)
+	defer cancel()
+
+	if _, err := c.Ping(ctx, &net.PingPong{}); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
+
 func ping(context context.Context, req *net.PingPong, orch Orchestrator) (*net.PingPong, error) {
-	glog.Info("Received Ping request")
+	glog.V(common.DEBUG).Info("Received Ping request")
+	// Empty pings only measure latency, so they are answered without a signature
+	if len(req.Value) == 0 {
+		return &net.PingPong{}, nil
+	}
 	value, err := orch.Sign(req.Value)
 	if err != nil {
 		glog.Error("Unable to sign Ping request")
@@ -209,16 +298,80 @@ func GetOrchestratorInfo(ctx context.Context, bcast common.Broadcaster, orchestr
 	}
 	defer conn.Close()
 
+	// Ticket params are only valid for the sender that requests them, so with
+	// multiple sender accounts the request is made as the account that will pay
+	pool := senderPool(bcast)
+	if pool != nil {
+		bcast = core.NewSignerBroadcaster(pool.SelectSender())
+	}
+
 	req, err := genOrchestratorReq(bcast)
-	r, err := c.GetOrchestrator(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var p peer.Peer
+	r, err := c.GetOrchestrator(ctx, req, grpc.Peer(&p))
+	if err != nil && isOrchReqSigErr(err) && SendLegacyOrchestratorReqs && !supportsOrchestratorReqTimestamps(orchestratorServer) {
+		// Orchestrators running older versions reject the request because they only accept signatures over the address
+		glog.V(common.DEBUG).Infof("Retrying orchestrator request without timestamp orch=%v", orchestratorServer)
+		if req, err = genLegacyOrchestratorReq(bcast); err != nil {
+			return nil, err
+		}
+		r, err = c.GetOrchestrator(ctx, req, grpc.Peer(&p))
+	}
 	if err != nil {
 		glog.Errorf("Could not get orchestrator orch=%v err=%v", orchestratorServer, err)
 		return nil, errors.New("Could not get orchestrator err=" + err.Error())
 	}
 
+	var tlsState *tls.ConnectionState
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		tlsState = &tlsInfo.State
+	}
+	if err := verifyOrchestratorInfo(orchestratorServer.String(), r, tlsState); err != nil {
+		glog.Errorf("Invalid orchestrator info orch=%v err=%v", orchestratorServer, err)
+		return nil, errors.Wrap(err, "Invalid orchestrator info")
+	}
+
+	// Orchestrators that support timestamps also sign their info
+	if req.Timestamp != 0 || len(r.Sig) > 0 {
+		orchReqTimestamps.Store(orchestratorServer.String(), true)
+	}
+
+	if pool != nil && r.TicketParams != nil {
+		pool.BindTicketParams(bcast.Address(), ethcommon.BytesToHash(r.TicketParams.RecipientRandHash))
+	}
+
 	return r, nil
 }
 
+// isOrchReqSigErr returns whether err is from an orchestrator that rejected the signature of a request.
+// Orchestrators running older versions return the error without a status code
+func isOrchReqSigErr(err error) bool {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return true
+	case codes.Unknown:
+		return strings.Contains(status.Convert(err).Message(), errOrchReqSig.Error())
+	}
+	return false
+}
+
+// supportsOrchestratorReqTimestamps returns whether the orchestrator at uri is known to support timestamped requests
+func supportsOrchestratorReqTimestamps(uri *url.URL) bool {
+	_, ok := orchReqTimestamps.Load(uri.String())
+	return ok
+}
+
+// senderPool returns the sender pool of bcast if it pays from multiple accounts
+func senderPool(bcast common.Broadcaster) *pm.SenderPool {
+	b, ok := bcast.(interface{ SenderPool() *pm.SenderPool })
+	if !ok {
+		return nil
+	}
+	return b.SenderPool()
+}
+
 func startOrchestratorClient(uri *url.URL) (net.OrchestratorClient, *grpc.ClientConn, error) {
 	glog.Infof("Connecting RPC to %v", uri)
 	conn, err := grpc.Dial(uri.Host,
@@ -235,16 +388,39 @@ func startOrchestratorClient(uri *url.URL) (net.OrchestratorClient, *grpc.Client
 }
 
 func genOrchestratorReq(b common.Broadcaster) (*net.OrchestratorRequest, error) {
-	sig, err := b.Sign([]byte(fmt.Sprintf("%v", b.Address().Hex())))
+	ts := time.Now().UnixNano()
+	sig, err := b.Sign([]byte(orchestratorReqMsg(b.Address(), ts)))
+	if err != nil {
+		return nil, err
+	}
+	return &net.OrchestratorRequest{Address: b.Address().Bytes(), Sig: sig, Timestamp: ts}, nil
+}
+
+// genLegacyOrchestratorReq generates a request for orchestrators that do not support timestamps
+func genLegacyOrchestratorReq(b common.Broadcaster) (*net.OrchestratorRequest, error) {
+	sig, err := b.Sign([]byte(orchestratorReqMsg(b.Address(), 0)))
 	if err != nil {
 		return nil, err
 	}
 	return &net.OrchestratorRequest{Address: b.Address().Bytes(), Sig: sig}, nil
 }
 
+// orchestratorReqMsg returns the message that a broadcaster signs for an orchestrator
+// request. Requests without a timestamp only sign the address of the broadcaster
+func orchestratorReqMsg(addr ethcommon.Address, ts int64) string {
+	if ts == 0 {
+		return addr.Hex()
+	}
+	return fmt.Sprintf("%v:%v", addr.Hex(), ts)
+}
+
 func getOrchestrator(orch Orchestrator, req *net.OrchestratorRequest) (*net.OrchestratorInfo, error) {
 	addr := ethcommon.BytesToAddress(req.Address)
-	if err := verifyOrchestratorReq(orch, addr, req.Sig); err != nil {
+	if err := verifyOrchestratorReq(orch, req); err != nil {
+		// Signature failures have their own status code so that broadcasters can retry with a legacy request
+		if err == errOrchReqSig {
+			return nil, status.Errorf(codes.Unauthenticated, "Invalid orchestrator request (%v)", err)
+		}
 		return nil, fmt.Errorf("Invalid orchestrator request (%v)", err)
 	}
 
@@ -269,6 +445,11 @@ func orchestratorInfo(orch Orchestrator, addr ethcommon.Address, serviceURI stri
 		PriceInfo:    priceInfo,
 	}
 
+	if signer := orch.SegmentSigner(); signer != nil {
+		tr.SegmentSigner = signer.Address().Bytes()
+		tr.SegmentSignerSig = signer.Sig()
+	}
+
 	os := drivers.NodeStorage.NewSession(string(core.RandomManifestID()))
 
 	if os != nil && os.IsExternal() {
@@ -278,14 +459,147 @@ func orchestratorInfo(orch Orchestrator, addr ethcommon.Address, serviceURI stri
 	return &tr, nil
 }
 
-func verifyOrchestratorReq(orch Orchestrator, addr ethcommon.Address, sig []byte) error {
-	if !orch.VerifySig(addr, addr.Hex(), sig) {
+// signOrchestratorInfo pins the TLS certificate with certHash in info and signs info
+// so that broadcasters can check that the TLS peer is the ticket recipient. Info is
+// signed by the segment signer if there is one so that the orchestrator account key
+// is not used for every request
+func signOrchestratorInfo(orch Orchestrator, info *net.OrchestratorInfo, certHash []byte) error {
+	// Without a pinned certificate broadcasters reject info regardless of its signature
+	if len(certHash) == 0 {
+		return nil
+	}
+
+	info.TlsCertHash = certHash
+	msg, err := orchestratorInfoMsg(info)
+	if err != nil {
+		return err
+	}
+	if signer := orch.SegmentSigner(); signer != nil {
+		info.Sig, err = signer.Sign(crypto.Keccak256(msg))
+		return err
+	}
+	info.Sig, err = orch.Sign(msg)
+	return err
+}
+
+// orchestratorInfoMsg returns the message that the ticket recipient signs for info,
+// which is the serialized info without its signature
+func orchestratorInfoMsg(info *net.OrchestratorInfo) ([]byte, error) {
+	unsigned := proto.Clone(info).(*net.OrchestratorInfo)
+	unsigned.Sig = nil
+	return proto.Marshal(unsigned)
+}
+
+// verifyOrchestratorInfo checks that info from the orchestrator at uri is signed by its ticket
+// recipient, or a segment signer authorized by it, and that the certificate of the TLS peer
+// that sent info is the one pinned in info
+func verifyOrchestratorInfo(uri string, info *net.OrchestratorInfo, tlsState *tls.ConnectionState) error {
+	// Off-chain orchestrators do not have an address to sign with
+	if info.TicketParams == nil {
+		return nil
+	}
+
+	// Orchestrators running older versions do not sign their info
+	if len(info.TlsCertHash) == 0 && len(info.Sig) == 0 {
+		if _, ok := orchInfoSigners.Load(uri); ok {
+			return fmt.Errorf("orchestrator info is not signed by orch=%v which signed it before", uri)
+		}
+		if !RequireSignedOrchestratorInfo {
+			glog.V(common.DEBUG).Infof("Accepting unsigned orchestrator info orch=%v", uri)
+			return nil
+		}
+	}
+
+	if len(info.TlsCertHash) == 0 || !bytes.Equal(info.TlsCertHash, peerCertHash(tlsState)) {
+		return errPinnedCert
+	}
+
+	msg, err := orchestratorInfoMsg(info)
+	if err != nil {
+		return err
+	}
+	recipient := ethcommon.BytesToAddress(info.TicketParams.Recipient)
+	signer := recipient
+	if len(info.SegmentSigner) > 0 {
+		signer = ethcommon.BytesToAddress(info.SegmentSigner)
+		if !lpcrypto.VerifySig(recipient, crypto.Keccak256(core.SegmentSignerMsg(signer)), info.SegmentSignerSig) {
+			return fmt.Errorf("segment signer=%v is not authorized by recipient=%v", signer.Hex(), recipient.Hex())
+		}
+	}
+	if !lpcrypto.VerifySig(signer, crypto.Keccak256(msg), info.Sig) {
+		return fmt.Errorf("orchestrator info is not signed by recipient=%v", recipient.Hex())
+	}
+
+	orchInfoSigners.Store(uri, true)
+	return nil
+}
+
+func verifyOrchestratorReq(orch Orchestrator, req *net.OrchestratorRequest) error {
+	addr := ethcommon.BytesToAddress(req.Address)
+	if req.Timestamp == 0 && !AcceptLegacyOrchestratorReqs {
+		return errOrchReqLegacy
+	}
+	if req.Timestamp != 0 {
+		age := time.Since(time.Unix(0, req.Timestamp))
+		if age > OrchestratorReqMaxAge || age < -OrchestratorReqMaxAge {
+			return errOrchReqExpired
+		}
+	}
+
+	if !orch.VerifySig(addr, orchestratorReqMsg(addr, req.Timestamp), req.Sig) {
 		glog.Error("orchestrator req sig check failed")
-		return fmt.Errorf("orchestrator req sig check failed")
+		return errOrchReqSig
+	}
+
+	// A signed timestamp can only be used once within the accepted window. Orchestrators that do not
+	// verify signatures cannot tell requests apart, e.g. off-chain broadcasters do not sign requests
+	if req.Timestamp != 0 && verifiesSigs(orch) &&
+		!orchReqCache.add(orchestratorReqMsg(addr, req.Timestamp), time.Unix(0, req.Timestamp).Add(OrchestratorReqMaxAge)) {
+		return errOrchReqReplayed
 	}
+
 	return orch.CheckCapacity("")
 }
 
+// verifiesSigs returns whether orch verifies the signatures of requests, which requires an Eth client
+func verifiesSigs(orch Orchestrator) bool {
+	o, ok := orch.(interface{ VerifiesSigs() bool })
+	return !ok || o.VerifiesSigs()
+}
+
+// replayCache records keys until they expire to detect reuse
+type replayCache struct {
+	mu        sync.Mutex
+	keys      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newReplayCache() *replayCache {
+	return &replayCache{keys: make(map[string]time.Time)}
+}
+
+// add records key until expiry and returns false if key is already recorded
+func (c *replayCache) add(key string, expiry time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.lastPrune) > OrchestratorReqMaxAge {
+		for k, exp := range c.keys {
+			if now.After(exp) {
+				delete(c.keys, k)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	if exp, ok := c.keys[key]; ok && !now.After(exp) {
+		return false
+	}
+	c.keys[key] = expiry
+	return true
+}
+
 func pmTicketParams(params *net.TicketParams) *pm.TicketParams {
 	if params == nil {
 		return nil
@@ -1,12 +1,18 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -17,14 +23,21 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
 
 	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/livepeer/go-livepeer/common"
 	"github.com/livepeer/go-livepeer/core"
 	"github.com/livepeer/go-livepeer/crypto"
 	"github.com/livepeer/go-livepeer/drivers"
+	"github.com/livepeer/go-livepeer/eth"
 	"github.com/livepeer/go-livepeer/net"
 	"github.com/livepeer/go-livepeer/pm"
 	"github.com/livepeer/lpms/ffmpeg"
@@ -60,13 +73,14 @@ func (m *mockBalance) Clear() {
 }
 
 type stubOrchestrator struct {
-	priv         *ecdsa.PrivateKey
-	block        *big.Int
-	signErr      error
-	sessCapErr   error
-	ticketParams *net.TicketParams
-	priceInfo    *net.PriceInfo
-	serviceURI   string
+	priv          *ecdsa.PrivateKey
+	block         *big.Int
+	signErr       error
+	sessCapErr    error
+	ticketParams  *net.TicketParams
+	priceInfo     *net.PriceInfo
+	serviceURI    string
+	segmentSigner *core.SegmentSigner
 }
 
 func (r *stubOrchestrator) ServiceURI() *url.URL {
@@ -102,6 +116,10 @@ func (r *stubOrchestrator) Sign(msg []byte) ([]byte, error) {
 	return append(sig[:64], v), nil
 }
 
+func (r *stubOrchestrator) SegmentSigner() *core.SegmentSigner {
+	return r.segmentSigner
+}
+
 func (r *stubOrchestrator) VerifySig(addr ethcommon.Address, msg string, sig []byte) bool {
 	return crypto.VerifySig(addr, ethcrypto.Keccak256([]byte(msg)), sig)
 }
@@ -167,27 +185,26 @@ func TestRPCTranscoderReq(t *testing.T) {
 		t.Error("Unable to create orchestrator req ", req)
 	}
 
-	addr := ethcommon.BytesToAddress(req.Address)
-	if verifyOrchestratorReq(o, addr, req.Sig) != nil { // normal case
+	if verifyOrchestratorReq(o, req) != nil { // normal case
 		t.Error("Unable to verify orchestrator request")
 	}
 
 	// wrong broadcaster
-	addr = ethcrypto.PubkeyToAddress(stubBroadcaster2().priv.PublicKey)
-	if verifyOrchestratorReq(o, addr, req.Sig) == nil {
+	addr := ethcrypto.PubkeyToAddress(stubBroadcaster2().priv.PublicKey)
+	if verifyOrchestratorReq(o, &net.OrchestratorRequest{Address: addr.Bytes(), Sig: req.Sig, Timestamp: req.Timestamp}) == nil {
 		t.Error("Did not expect verification to pass; should mismatch broadcaster")
 	}
 
 	// invalid address
 	addr = ethcommon.BytesToAddress([]byte("#non-hex address!"))
-	if verifyOrchestratorReq(o, addr, req.Sig) == nil {
+	if verifyOrchestratorReq(o, &net.OrchestratorRequest{Address: addr.Bytes(), Sig: req.Sig, Timestamp: req.Timestamp}) == nil {
 		t.Error("Did not expect verification to pass; should mismatch broadcaster")
 	}
-	addr = ethcommon.BytesToAddress(req.Address)
 
 	// at capacity
+	req, _ = genOrchestratorReq(b)
 	o.sessCapErr = fmt.Errorf("At capacity")
-	if err := verifyOrchestratorReq(o, addr, req.Sig); err != o.sessCapErr {
+	if err := verifyOrchestratorReq(o, req); err != o.sessCapErr {
 		t.Errorf("Expected %v; got %v", o.sessCapErr, err)
 	}
 	o.sessCapErr = nil
@@ -537,6 +554,192 @@ func TestGenPayment(t *testing.T) {
 	sender.AssertNotCalled(t, "CreateTicketBatch", s.PMSessionID, 0)
 }
 
+// keySigner is a pm.Signer for an account with a private key
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func newKeySigner(t *testing.T) *keySigner {
+	key, err := ethcrypto.GenerateKey()
+	require.Nil(t, err)
+	return &keySigner{key: key}
+}
+
+func (s *keySigner) Account() accounts.Account {
+	return accounts.Account{Address: ethcrypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *keySigner) Sign(msg []byte) ([]byte, error) {
+	sig, err := ethcrypto.Sign(accounts.TextHash(msg), s.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// stubPaymentChain provides the on-chain state that the sender and the recipient of tickets need
+type stubPaymentChain struct {
+	senders map[ethcommon.Address]*pm.SenderInfo
+}
+
+func (c *stubPaymentChain) LastInitializedRound() *big.Int                           { return big.NewInt(10) }
+func (c *stubPaymentChain) LastInitializedBlockHash() [32]byte                       { return [32]byte{10} }
+func (c *stubPaymentChain) GetTranscoderPoolSize() *big.Int                          { return big.NewInt(1) }
+func (c *stubPaymentChain) LastSeenBlock() *big.Int                                  { return big.NewInt(100) }
+func (c *stubPaymentChain) GasPrice() *big.Int                                       { return big.NewInt(1) }
+func (c *stubPaymentChain) Clear(addr ethcommon.Address)                             {}
+func (c *stubPaymentChain) Redeemable() chan *pm.SignedTicket                        { return nil }
+func (c *stubPaymentChain) Start()                                                   {}
+func (c *stubPaymentChain) Stop()                                                    {}
+func (c *stubPaymentChain) AddFloat(ethcommon.Address, *big.Int) error               { return nil }
+func (c *stubPaymentChain) SubFloat(ethcommon.Address, *big.Int)                     {}
+func (c *stubPaymentChain) ValidateSender(ethcommon.Address) error                   { return nil }
+func (c *stubPaymentChain) QueueTicket(ethcommon.Address, *pm.SignedTicket)          {}
+func (c *stubPaymentChain) SubscribeRounds(sink chan<- types.Log) event.Subscription { return nil }
+func (c *stubPaymentChain) SubscribeBlocks(sink chan<- *big.Int) event.Subscription  { return nil }
+
+func (c *stubPaymentChain) MaxFloat(ethcommon.Address) (*big.Int, error) {
+	return big.NewInt(1000000000), nil
+}
+
+func (c *stubPaymentChain) GetSenderInfo(addr ethcommon.Address) (*pm.SenderInfo, error) {
+	return c.senders[addr], nil
+}
+
+func (c *stubPaymentChain) ClaimedReserve(reserveHolder ethcommon.Address, claimant ethcommon.Address) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (c *stubPaymentChain) StoreWinningTicket(string, *pm.Ticket, []byte, *big.Int) error {
+	return nil
+}
+
+func (c *stubPaymentChain) LoadWinningTickets([]string) ([]*pm.Ticket, [][]byte, []*big.Int, error) {
+	return nil, nil, nil, nil
+}
+
+func (c *stubPaymentChain) StoreRedemption(*pm.Ticket, ethcommon.Hash, error) error {
+	return nil
+}
+
+func (c *stubPaymentChain) StoreDeferredTicket(*pm.SignedTicket) error {
+	return nil
+}
+
+func (c *stubPaymentChain) RemoveDeferredTicket(*pm.Ticket) error {
+	return nil
+}
+
+func (c *stubPaymentChain) LoadDeferredTickets() ([]*pm.SignedTicket, error) {
+	return nil, nil
+}
+
+func TestGenPayment_SenderPool_ProcessPayment(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The broadcaster pays from two accounts
+	signers := []pm.Signer{newKeySigner(t), newKeySigner(t)}
+	chain := &stubPaymentChain{senders: make(map[ethcommon.Address]*pm.SenderInfo)}
+	for _, signer := range signers {
+		chain.senders[signer.Account().Address] = &pm.SenderInfo{
+			Deposit:       big.NewInt(1000000000),
+			WithdrawRound: big.NewInt(0),
+			Reserve:       &pm.ReserveInfo{FundsRemaining: big.NewInt(1000000000), ClaimedInCurrentRound: big.NewInt(0)},
+		}
+	}
+	pool := pm.NewSenderPool(signers, chain, chain, big.NewRat(1000000, 1), 1)
+	account := signers[1].Account().Address
+
+	// The orchestrator validates tickets with its recipient
+	orchAddr := pm.RandAddress()
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	require.Nil(dbh.UpdateOrch(&common.DBOrch{EthereumAddr: orchAddr.Hex(), ActivationRound: 1, DeactivationRound: 999}))
+
+	n, _ := core.NewLivepeerNode(&eth.StubClient{TranscoderAddress: orchAddr}, "", dbh)
+	n.Balances = core.NewAddressBalances(time.Minute)
+	n.SetBasePrice(big.NewRat(1, 1))
+	validator := pm.NewValidator(&pm.DefaultSigVerifier{}, chain)
+	n.Recipient = pm.NewRecipientWithSecret(orchAddr, nil, validator, chain, chain, chain, chain, [32]byte{1}, pm.TicketParamsConfig{
+		EV:               big.NewInt(1000),
+		RedeemGas:        100000,
+		TxCostMultiplier: 100,
+	})
+	orch := core.NewOrchestrator(n, chain)
+
+	// The ticket params are requested by the account that is not the broadcaster's main account
+	ticketParams, err := orch.TicketParams(account)
+	require.Nil(err)
+	priceInfo, err := orch.PriceInfo(account)
+	require.Nil(err)
+	pool.BindTicketParams(account, ethcommon.BytesToHash(ticketParams.RecipientRandHash))
+
+	bnode, _ := core.NewLivepeerNode(&eth.StubClient{TranscoderAddress: signers[0].Account().Address}, "", nil)
+	bnode.Sender = pool
+	sess := &BroadcastSession{
+		Broadcaster:      core.NewBroadcaster(bnode),
+		ManifestID:       core.RandomManifestID(),
+		OrchestratorInfo: &net.OrchestratorInfo{PriceInfo: priceInfo, TicketParams: ticketParams},
+		Sender:           pool,
+		PMSessionID:      pool.StartSession(*pmTicketParams(ticketParams)),
+	}
+
+	// Tickets from the account that pays for the session are accepted by the orchestrator
+	data, err := genPayment(sess, 2)
+	require.Nil(err)
+	payment, err := getPayment(data)
+	require.Nil(err)
+	assert.Equal(account, ethcommon.BytesToAddress(payment.Sender))
+	require.Nil(orch.ProcessPayment(payment, sess.ManifestID))
+	assert.True(n.Balances.Balance(account, sess.ManifestID).Sign() > 0)
+	assert.Nil(n.Balances.Balance(signers[0].Account().Address, sess.ManifestID))
+
+	// Payments without tickets are from the same account
+	data, err = genPayment(sess, 0)
+	require.Nil(err)
+	payment, err = getPayment(data)
+	require.Nil(err)
+	assert.Equal(account, ethcommon.BytesToAddress(payment.Sender))
+
+	// Segments are signed by the account that pays for them, so ServeSegment accepts them
+	n.SetServiceURI(&url.URL{Scheme: "https", Host: "orch.example.com:8935"})
+	drivers.NodeStorage = drivers.NewMemoryDriver(nil)
+	oldMaxSessions := core.MaxSessions
+	core.MaxSessions = 10
+	defer func() { core.MaxSessions = oldMaxSessions }()
+	handler := serveSegmentHandler(&noTranscodeOrchestrator{Orchestrator: orch})
+	seg := &stream.HLSSegment{SeqNo: 1, Data: []byte("foo")}
+	segCreds, err := genSegCreds(sess, seg)
+	require.Nil(err)
+	payment64, err := genPayment(sess, 2)
+	require.Nil(err)
+	resp := httpPostResp(handler, bytes.NewReader(seg.Data), map[string]string{
+		paymentHeader: payment64,
+		segmentHeader: segCreds,
+	})
+	defer resp.Body.Close()
+	require.Equal(http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.Nil(err)
+	var tr net.TranscodeResult
+	require.Nil(proto.Unmarshal(body, &tr))
+	assert.Equal("transcode skipped", tr.GetError())
+	assert.Equal(int64(1), tr.Seq)
+}
+
+// noTranscodeOrchestrator serves segments without transcoding them
+type noTranscodeOrchestrator struct {
+	Orchestrator
+}
+
+func (o *noTranscodeOrchestrator) TranscodeSeg(*core.SegTranscodingMetadata, *stream.HLSSegment) (*core.TranscodeResult, error) {
+	return nil, errors.New("transcode skipped")
+}
+
 func TestPing(t *testing.T) {
 	o := newStubOrchestrator()
 
@@ -784,6 +987,37 @@ func TestGetOrchestrator_PriceInfoError(t *testing.T) {
 	assert.EqualError(t, err, expErr.Error())
 }
 
+func TestGetOrchestrator_SegmentSigner(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	orch := &mockOrchestrator{}
+	drivers.NodeStorage = drivers.NewMemoryDriver(nil)
+	orch.On("VerifySig", mock.Anything, mock.Anything, mock.Anything).Return(true)
+	orch.On("ServiceURI").Return(url.Parse("http://someuri.com"))
+	orch.On("TicketParams", mock.Anything).Return(nil, nil)
+	orch.On("PriceInfo", mock.Anything).Return(nil, nil)
+
+	oInfo, err := getOrchestrator(orch, &net.OrchestratorRequest{})
+	require.Nil(err)
+	assert.Nil(oInfo.SegmentSigner)
+	assert.Nil(oInfo.SegmentSignerSig)
+
+	ms, err := eth.NewMockSigner(types.HomesteadSigner{})
+	require.Nil(err)
+	defer ms.Close()
+	am, err := eth.NewRemoteAccountManager(ms.URL, ethcommon.Address{}, types.HomesteadSigner{})
+	require.Nil(err)
+	require.Nil(am.Unlock(""))
+	orch.segmentSigner, err = core.NewSegmentSigner(am, &eth.StubClient{})
+	require.Nil(err)
+
+	oInfo, err = getOrchestrator(orch, &net.OrchestratorRequest{})
+	require.Nil(err)
+	assert.Equal(ms.Address().Bytes(), oInfo.SegmentSigner)
+	assert.Equal(orch.segmentSigner.Sig(), oInfo.SegmentSignerSig)
+}
+
 type mockOSSession struct {
 	mock.Mock
 }
@@ -812,6 +1046,7 @@ func (s *mockOSSession) IsExternal() bool {
 
 type mockOrchestrator struct {
 	mock.Mock
+	segmentSigner *core.SegmentSigner
 }
 
 func (o *mockOrchestrator) ServiceURI() *url.URL {
@@ -833,6 +1068,9 @@ func (o *mockOrchestrator) Sign(msg []byte) ([]byte, error) {
 	o.Called(msg)
 	return nil, nil
 }
+func (o *mockOrchestrator) SegmentSigner() *core.SegmentSigner {
+	return o.segmentSigner
+}
 func (o *mockOrchestrator) VerifySig(addr ethcommon.Address, msg string, sig []byte) bool {
 	args := o.Called(addr, msg, sig)
 	return args.Bool(0)
@@ -926,3 +1164,429 @@ func defaultTicketSenderParams(t *testing.T) *net.TicketSenderParams {
 		Sig:         pm.RandBytes(123),
 	}
 }
+
+func TestSignOrchestratorInfo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	orch := newStubOrchestrator()
+	info := &net.OrchestratorInfo{
+		Transcoder:   "https://127.0.0.1:8935",
+		TicketParams: &net.TicketParams{Recipient: orch.Address().Bytes()},
+		PriceInfo:    &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+	}
+	uri := info.Transcoder
+	defer orchInfoSigners.Delete(uri)
+
+	// Info is not signed without a pinned certificate
+	require.Nil(signOrchestratorInfo(orch, info, nil))
+	assert.Nil(info.Sig)
+	assert.Nil(info.TlsCertHash)
+
+	cert := []byte("cert")
+	require.Nil(signOrchestratorInfo(orch, info, certHash(cert)))
+	assert.Equal(certHash(cert), info.TlsCertHash)
+	assert.Nil(verifyOrchestratorInfo(uri, info, stubTLSState(cert)))
+
+	orch.signErr = errors.New("Sign error")
+	assert.EqualError(signOrchestratorInfo(orch, info, certHash(cert)), "Sign error")
+
+	// Info is signed by the segment signer instead of the orchestrator account
+	orch.segmentSigner = newStubSegmentSigner(t, orch)
+	info.SegmentSigner = orch.segmentSigner.Address().Bytes()
+	info.SegmentSignerSig = orch.segmentSigner.Sig()
+	require.Nil(signOrchestratorInfo(orch, info, certHash(cert)))
+	assert.Nil(verifyOrchestratorInfo(uri, info, stubTLSState(cert)))
+	msg, err := orchestratorInfoMsg(info)
+	require.Nil(err)
+	assert.True(crypto.VerifySig(orch.segmentSigner.Address(), ethcrypto.Keccak256(msg), info.Sig))
+}
+
+// newStubSegmentSigner returns a segment signer that is authorized by orch
+func newStubSegmentSigner(t *testing.T, orch *stubOrchestrator) *core.SegmentSigner {
+	client := &recipientClient{StubClient: &eth.StubClient{TranscoderAddress: orch.Address()}, key: orch.priv}
+	signer, err := core.NewSegmentSigner(&keyAccountManager{keySigner: newKeySigner(t)}, client)
+	require.Nil(t, err)
+	return signer
+}
+
+// recipientClient is an Eth client that signs with the key of the ticket recipient
+type recipientClient struct {
+	*eth.StubClient
+	key *ecdsa.PrivateKey
+}
+
+func (c *recipientClient) Sign(msg []byte) ([]byte, error) {
+	return (&keySigner{key: c.key}).Sign(msg)
+}
+
+// keyAccountManager is an eth.AccountManager that can only sign messages
+type keyAccountManager struct {
+	eth.AccountManager
+	*keySigner
+}
+
+func (am *keyAccountManager) Account() accounts.Account { return am.keySigner.Account() }
+func (am *keyAccountManager) Sign(msg []byte) ([]byte, error) {
+	return am.keySigner.Sign(msg)
+}
+
+func TestVerifyOrchestratorInfo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	orch := newStubOrchestrator()
+	cert := []byte("cert")
+	uri := "https://127.0.0.1:8935"
+	defer orchInfoSigners.Delete(uri)
+	signedInfo := func() *net.OrchestratorInfo {
+		info := &net.OrchestratorInfo{
+			Transcoder:   uri,
+			TicketParams: &net.TicketParams{Recipient: orch.Address().Bytes()},
+			PriceInfo:    &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+		}
+		require.Nil(signOrchestratorInfo(orch, info, certHash(cert)))
+		return info
+	}
+
+	// Info from orchestrators that neither pin a certificate nor sign is accepted
+	// unless signed info is required
+	unsignedInfo := signedInfo()
+	unsignedInfo.Sig = nil
+	unsignedInfo.TlsCertHash = nil
+	assert.Nil(verifyOrchestratorInfo(uri, unsignedInfo, stubTLSState(cert)))
+
+	RequireSignedOrchestratorInfo = true
+	err := verifyOrchestratorInfo(uri, unsignedInfo, stubTLSState(cert))
+	RequireSignedOrchestratorInfo = false
+	assert.EqualError(err, "TLS certificate does not match the pinned certificate")
+
+	assert.Nil(verifyOrchestratorInfo(uri, signedInfo(), stubTLSState(cert)))
+
+	// Unsigned info is rejected from orchestrators that signed their info before
+	err = verifyOrchestratorInfo(uri, unsignedInfo, stubTLSState(cert))
+	assert.EqualError(err, "orchestrator info is not signed by orch="+uri+" which signed it before")
+
+	// Info from off-chain orchestrators is not signed
+	assert.Nil(verifyOrchestratorInfo(uri, &net.OrchestratorInfo{}, nil))
+
+	// The TLS peer must present the pinned certificate
+	err = verifyOrchestratorInfo(uri, signedInfo(), stubTLSState([]byte("other")))
+	assert.EqualError(err, "TLS certificate does not match the pinned certificate")
+	err = verifyOrchestratorInfo(uri, signedInfo(), nil)
+	assert.EqualError(err, "TLS certificate does not match the pinned certificate")
+
+	// Info must be signed by the ticket recipient
+	info := signedInfo()
+	info.Transcoder = "https://127.0.0.1:9999"
+	err = verifyOrchestratorInfo(uri, info, stubTLSState(cert))
+	assert.Contains(err.Error(), "orchestrator info is not signed by recipient")
+
+	info = signedInfo()
+	info.TicketParams.Recipient = pm.RandAddress().Bytes()
+	err = verifyOrchestratorInfo(uri, info, stubTLSState(cert))
+	assert.Contains(err.Error(), "orchestrator info is not signed by recipient")
+
+	// The pinned certificate cannot be replaced
+	info = signedInfo()
+	info.TlsCertHash = certHash([]byte("other"))
+	err = verifyOrchestratorInfo(uri, info, stubTLSState([]byte("other")))
+	assert.Contains(err.Error(), "orchestrator info is not signed by recipient")
+
+	// Info without a signature is rejected
+	info = signedInfo()
+	info.Sig = nil
+	err = verifyOrchestratorInfo(uri, info, stubTLSState(cert))
+	assert.Contains(err.Error(), "orchestrator info is not signed by recipient")
+
+	// Info can be signed by a segment signer that is authorized by the recipient
+	orch.segmentSigner = newStubSegmentSigner(t, orch)
+	signedBySegmentSigner := func() *net.OrchestratorInfo {
+		info := signedInfo()
+		info.SegmentSigner = orch.segmentSigner.Address().Bytes()
+		info.SegmentSignerSig = orch.segmentSigner.Sig()
+		require.Nil(signOrchestratorInfo(orch, info, certHash(cert)))
+		return info
+	}
+	assert.Nil(verifyOrchestratorInfo(uri, signedBySegmentSigner(), stubTLSState(cert)))
+
+	other := newStubSegmentSigner(t, newStubOrchestrator())
+	info = signedBySegmentSigner()
+	info.SegmentSignerSig = other.Sig()
+	require.Nil(signOrchestratorInfo(orch, info, certHash(cert)))
+	err = verifyOrchestratorInfo(uri, info, stubTLSState(cert))
+	assert.Contains(err.Error(), "is not authorized by recipient")
+
+	info = signedBySegmentSigner()
+	info.SegmentSigner = other.Address().Bytes()
+	info.SegmentSignerSig = other.Sig()
+	err = verifyOrchestratorInfo(uri, info, stubTLSState(cert))
+	assert.Contains(err.Error(), "is not authorized by recipient")
+}
+
+func stubTLSState(cert []byte) *tls.ConnectionState {
+	return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Raw: cert}}}
+}
+
+func TestGetOrchestratorInfo_PinnedCert(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	drivers.NodeStorage = drivers.NewMemoryDriver(nil)
+	orch := newStubOrchestrator()
+	orch.ticketParams = &net.TicketParams{Recipient: orch.Address().Bytes()}
+	orch.priceInfo = &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1}
+
+	s := grpc.NewServer()
+	lp := &lphttp{orchestrator: orch, orchRPC: s, transRPC: http.NewServeMux()}
+	net.RegisterOrchestratorServer(s, lp)
+	ts := httptest.NewUnstartedServer(lp)
+	ts.TLS = &tls.Config{NextProtos: []string{http2.NextProtoTLS}}
+	ts.StartTLS()
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	require.Nil(err)
+
+	defer orchReqTimestamps.Delete(uri.String())
+	defer orchInfoSigners.Delete(uri.String())
+
+	lp.tlsCertHash = certHash(ts.Certificate().Raw)
+	info, err := GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	require.Nil(err)
+	assert.Equal(lp.tlsCertHash, info.TlsCertHash)
+	assert.NotEmpty(info.Sig)
+	assert.True(supportsOrchestratorReqTimestamps(uri))
+
+	// Info that is no longer signed is rejected
+	lp.tlsCertHash = nil
+	_, err = GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	assert.EqualError(err, "Invalid orchestrator info: orchestrator info is not signed by orch="+uri.String()+" which signed it before")
+
+	// Info that pins another certificate is rejected
+	lp.tlsCertHash = certHash([]byte("other"))
+	_, err = GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	assert.EqualError(err, "Invalid orchestrator info: TLS certificate does not match the pinned certificate")
+
+	// Info must be signed by the ticket recipient
+	lp.tlsCertHash = certHash(ts.Certificate().Raw)
+	orch.ticketParams = &net.TicketParams{Recipient: pm.RandAddress().Bytes()}
+	_, err = GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	assert.Contains(err.Error(), "Invalid orchestrator info: orchestrator info is not signed by recipient")
+}
+
+// noSignOrchestrator is an orchestrator that fails to sign
+type noSignOrchestrator struct {
+	*stubOrchestrator
+}
+
+func (o *noSignOrchestrator) Sign([]byte) ([]byte, error) {
+	return nil, errors.New("Sign error")
+}
+
+func TestPingOrchestrator(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	orch := newStubOrchestrator()
+	s := grpc.NewServer()
+	lp := &lphttp{orchestrator: orch, orchRPC: s, transRPC: http.NewServeMux()}
+	net.RegisterOrchestratorServer(s, lp)
+	ts := httptest.NewUnstartedServer(lp)
+	ts.TLS = &tls.Config{NextProtos: []string{http2.NextProtoTLS}}
+	ts.StartTLS()
+	uri, err := url.Parse(ts.URL)
+	require.Nil(err)
+
+	rtt, err := PingOrchestrator(context.Background(), uri)
+	require.Nil(err)
+	assert.True(rtt > 0)
+
+	// Latency probes are answered without a signature
+	lp.orchestrator = &noSignOrchestrator{orch}
+	_, err = PingOrchestrator(context.Background(), uri)
+	assert.Nil(err)
+	_, err = ping(context.Background(), &net.PingPong{Value: []byte("foo")}, lp.orchestrator)
+	assert.EqualError(err, "Sign error")
+
+	// Unreachable orchestrators return an error
+	ts.Close()
+	_, err = PingOrchestrator(context.Background(), uri)
+	assert.NotNil(err)
+}
+
+func TestVerifyOrchestratorReq_Timestamp(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	o := newStubOrchestrator()
+	b := stubBroadcaster2()
+
+	// Requests cannot be replayed
+	req, err := genOrchestratorReq(b)
+	require.Nil(err)
+	assert.NotZero(req.Timestamp)
+	assert.Nil(verifyOrchestratorReq(o, req))
+	assert.Equal(errOrchReqReplayed, verifyOrchestratorReq(o, req))
+
+	// The timestamp is signed
+	req, err = genOrchestratorReq(b)
+	require.Nil(err)
+	req.Timestamp++
+	assert.Equal(errOrchReqSig, verifyOrchestratorReq(o, req))
+
+	// Requests must be signed within the accepted window
+	for _, ts := range []time.Time{time.Now().Add(-2 * OrchestratorReqMaxAge), time.Now().Add(2 * OrchestratorReqMaxAge)} {
+		sig, err := b.Sign([]byte(orchestratorReqMsg(b.Address(), ts.UnixNano())))
+		require.Nil(err)
+		req = &net.OrchestratorRequest{Address: b.Address().Bytes(), Sig: sig, Timestamp: ts.UnixNano()}
+		assert.Equal(errOrchReqExpired, verifyOrchestratorReq(o, req))
+	}
+
+	// Legacy requests are accepted unless disabled
+	req, err = genLegacyOrchestratorReq(b)
+	require.Nil(err)
+	assert.Zero(req.Timestamp)
+	assert.Nil(verifyOrchestratorReq(o, req))
+	assert.Nil(verifyOrchestratorReq(o, req))
+
+	AcceptLegacyOrchestratorReqs = false
+	defer func() { AcceptLegacyOrchestratorReqs = true }()
+	assert.Equal(errOrchReqLegacy, verifyOrchestratorReq(o, req))
+}
+
+// offchainOrchestrator does not verify signatures like an orchestrator without an Eth client
+type offchainOrchestrator struct {
+	*stubOrchestrator
+}
+
+func (o *offchainOrchestrator) VerifySig(addr ethcommon.Address, msg string, sig []byte) bool {
+	return true
+}
+
+func (o *offchainOrchestrator) VerifiesSigs() bool {
+	return false
+}
+
+func TestVerifyOrchestratorReq_Offchain(t *testing.T) {
+	assert := assert.New(t)
+
+	o := &offchainOrchestrator{newStubOrchestrator()}
+
+	// Off-chain broadcasters do not sign requests, so requests are not checked for replays
+	ts := time.Now().UnixNano()
+	for i := 0; i < 2; i++ {
+		req := &net.OrchestratorRequest{Address: pm.RandAddress().Bytes(), Sig: []byte{}, Timestamp: ts}
+		assert.Nil(verifyOrchestratorReq(o, req))
+		assert.Nil(verifyOrchestratorReq(o, req))
+	}
+}
+
+func TestReplayCache(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newReplayCache()
+	assert.True(c.add("foo", time.Now().Add(time.Hour)))
+	assert.False(c.add("foo", time.Now().Add(time.Hour)))
+	assert.True(c.add("bar", time.Now().Add(-time.Second)))
+
+	// Expired keys can be added again and are pruned
+	assert.True(c.add("bar", time.Now().Add(time.Hour)))
+	c.lastPrune = time.Time{}
+	c.keys["baz"] = time.Now().Add(-time.Second)
+	assert.True(c.add("qux", time.Now().Add(time.Hour)))
+	assert.NotContains(c.keys, "baz")
+	assert.Len(c.keys, 3)
+}
+
+// legacyOrchestratorServer verifies requests the way older orchestrators do
+type legacyOrchestratorServer struct {
+	orch     *stubOrchestrator
+	requests []*net.OrchestratorRequest
+	err      error
+}
+
+func (l *legacyOrchestratorServer) GetOrchestrator(ctx context.Context, req *net.OrchestratorRequest) (*net.OrchestratorInfo, error) {
+	l.requests = append(l.requests, req)
+	if l.err != nil {
+		return nil, l.err
+	}
+	addr := ethcommon.BytesToAddress(req.Address)
+	if !l.orch.VerifySig(addr, addr.Hex(), req.Sig) {
+		return nil, fmt.Errorf("Invalid orchestrator request (orchestrator req sig check failed)")
+	}
+	return &net.OrchestratorInfo{Transcoder: "legacy"}, nil
+}
+
+func (l *legacyOrchestratorServer) Ping(ctx context.Context, req *net.PingPong) (*net.PingPong, error) {
+	return req, nil
+}
+
+func TestGetOrchestratorInfo_LegacyOrchestrator(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	s := grpc.NewServer()
+	legacy := &legacyOrchestratorServer{orch: newStubOrchestrator()}
+	net.RegisterOrchestratorServer(s, legacy)
+	ts := httptest.NewUnstartedServer(s)
+	ts.TLS = &tls.Config{NextProtos: []string{http2.NextProtoTLS}}
+	ts.StartTLS()
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	require.Nil(err)
+
+	// Fall back to a request without a timestamp
+	info, err := GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	require.Nil(err)
+	assert.Equal("legacy", info.Transcoder)
+	require.Len(legacy.requests, 2)
+	assert.NotZero(legacy.requests[0].Timestamp)
+	assert.Zero(legacy.requests[1].Timestamp)
+	assert.False(supportsOrchestratorReqTimestamps(uri))
+
+	// Requests without a timestamp are not sent for errors other than signature failures
+	legacy.requests = nil
+	legacy.err = errors.New("Invalid orchestrator request (OrchestratorCapped)")
+	_, err = GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	assert.Contains(err.Error(), "OrchestratorCapped")
+	assert.Len(legacy.requests, 1)
+
+	legacy.requests = nil
+	legacy.err = status.Error(codes.PermissionDenied, "Invalid orchestrator request (orchestrator req sig check failed)")
+	_, err = GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	assert.Contains(err.Error(), "sig check failed")
+	assert.Len(legacy.requests, 1)
+	legacy.err = nil
+
+	// Requests without a timestamp are not sent if disabled
+	legacy.requests = nil
+	SendLegacyOrchestratorReqs = false
+	_, err = GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	assert.Contains(err.Error(), "sig check failed")
+	assert.Len(legacy.requests, 1)
+	SendLegacyOrchestratorReqs = true
+
+	// Requests without a timestamp are not sent to orchestrators that are known to support timestamps
+	legacy.requests = nil
+	orchReqTimestamps.Store(uri.String(), true)
+	defer orchReqTimestamps.Delete(uri.String())
+	_, err = GetOrchestratorInfo(context.Background(), stubBroadcaster2(), uri)
+	assert.Contains(err.Error(), "sig check failed")
+	assert.Len(legacy.requests, 1)
+}
+
+func TestIsOrchReqSigErr(t *testing.T) {
+	assert := assert.New(t)
+
+	orch := &mockOrchestrator{}
+	orch.On("VerifySig", mock.Anything, mock.Anything, mock.Anything).Return(false)
+	_, err := getOrchestrator(orch, &net.OrchestratorRequest{})
+	assert.Equal(codes.Unauthenticated, status.Code(err))
+	assert.True(isOrchReqSigErr(err))
+
+	// Orchestrators running older versions return signature failures without a status code
+	assert.True(isOrchReqSigErr(status.Error(codes.Unknown, "Invalid orchestrator request (orchestrator req sig check failed)")))
+	assert.False(isOrchReqSigErr(status.Error(codes.Unknown, "Invalid orchestrator request (OrchestratorCapped)")))
+	assert.False(isOrchReqSigErr(status.Error(codes.Unavailable, "orchestrator req sig check failed")))
+	assert.False(isOrchReqSigErr(nil))
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"math/big"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/livepeer/go-livepeer/common"
@@ -18,6 +20,7 @@ import (
 	"github.com/livepeer/go-livepeer/eth"
 	"github.com/livepeer/go-livepeer/monitor"
 	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/go-livepeer/pm"
 	"github.com/livepeer/lpms/ffmpeg"
 	"github.com/livepeer/lpms/stream"
 	"golang.org/x/net/http2"
@@ -44,6 +47,34 @@ var httpClient = &http.Client{
 	Timeout:   common.HTTPTimeout,
 }
 
+// pinnedHTTPClients contains the clients that only connect to TLS peers presenting
+// a pinned certificate, by certificate hash
+var pinnedHTTPClients sync.Map
+
+// pinnedHTTPClient returns a client that fails the TLS handshake unless the peer presents
+// the certificate with hash. Clients are shared so that connections are reused across segments
+func pinnedHTTPClient(hash []byte) *http.Client {
+	if c, ok := pinnedHTTPClients.Load(string(hash)); ok {
+		return c.(*http.Client)
+	}
+
+	pinned := append([]byte(nil), hash...)
+	cfg := &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || !bytes.Equal(certHash(rawCerts[0]), pinned) {
+				return errPinnedCert
+			}
+			return nil
+		},
+	}
+	c, _ := pinnedHTTPClients.LoadOrStore(string(pinned), &http.Client{
+		Transport: &http2.Transport{TLSClientConfig: cfg},
+		Timeout:   common.HTTPTimeout,
+	})
+	return c.(*http.Client)
+}
+
 func (h *lphttp) ServeSegment(w http.ResponseWriter, r *http.Request) {
 	orch := h.orchestrator
 
@@ -78,6 +109,7 @@ func (h *lphttp) ServeSegment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The info is not signed because the broadcaster only sends segments to the pinned TLS peer
 	oInfo, err := orchestratorInfo(orch, sender, orch.ServiceURI().String())
 	if err != nil {
 		glog.Errorf("Error updating orchestrator info - err=%v", err)
@@ -309,6 +341,10 @@ func SubmitSegment(sess *BroadcastSession, seg *stream.HLSSegment, nonce uint64)
 	// at the time of completion
 	defer completeBalanceUpdate(sess, balUpdate)
 
+	// Spending is recorded once the segment has been submitted
+	var pixelCount int64
+	defer func() { recordSpending(sess, balUpdate, pixelCount) }()
+
 	payment, err := genPayment(sess, balUpdate.NumTickets)
 	if err != nil {
 		glog.Errorf("Could not create payment nonce=%d manifestID=%s seqNo=%d bytes=%v err=%v", nonce, sess.ManifestID, seg.SeqNo, len(data), err)
@@ -340,8 +376,13 @@ func SubmitSegment(sess *BroadcastSession, seg *stream.HLSSegment, nonce uint64)
 	}
 
 	glog.Infof("Submitting segment nonce=%d manifestID=%s seqNo=%d bytes=%v orch=%s", nonce, sess.ManifestID, seg.SeqNo, len(data), ti.Transcoder)
+	client := httpClient
+	if pinned := ti.GetTlsCertHash(); len(pinned) > 0 {
+		// Segments are only sent to the TLS peer pinned by the signed orchestrator info
+		client = pinnedHTTPClient(pinned)
+	}
 	start := time.Now()
-	resp, err := httpClient.Do(req)
+	resp, err := client.Do(req)
 	uploadDur := time.Since(start)
 	if err != nil {
 		glog.Errorf("Unable to submit segment orch=%v nonce=%d manifestID=%s seqNo=%d orch=%s err=%v", ti.Transcoder, nonce, sess.ManifestID, seg.SeqNo, ti.Transcoder, err)
@@ -362,6 +403,7 @@ func SubmitSegment(sess *BroadcastSession, seg *stream.HLSSegment, nonce uint64)
 		monitor.TicketValueSent(recipient, mid, balUpdate.NewCredit)
 		monitor.TicketsSent(recipient, mid, balUpdate.NumTickets)
 	}
+	sess.stats.paymentSent(balUpdate.NumTickets, balUpdate.NewCredit)
 
 	if resp.StatusCode != 200 {
 		data, _ := ioutil.ReadAll(resp.Body)
@@ -434,14 +476,12 @@ func SubmitSegment(sess *BroadcastSession, seg *stream.HLSSegment, nonce uint64)
 
 	// We treat a response as "receiving change" where the change is the difference between the credit and debit for the update
 	balUpdate.Status = ReceivedChange
+	for _, res := range tdata.Segments {
+		pixelCount += res.Pixels
+	}
 	if priceInfo != nil {
 		// The update's debit is the transcoding fee which is computed as the total number of pixels processed
 		// for all results returned multiplied by the orchestrator's price
-		var pixelCount int64
-		for _, res := range tdata.Segments {
-			pixelCount += res.Pixels
-		}
-
 		balUpdate.Debit.Mul(new(big.Rat).SetInt64(pixelCount), priceInfo)
 	}
 
@@ -453,13 +493,36 @@ func SubmitSegment(sess *BroadcastSession, seg *stream.HLSSegment, nonce uint64)
 	glog.Infof("Successfully transcoded segment nonce=%d manifestID=%s segName=%s seqNo=%d orch=%s dur=%s", nonce,
 		string(sess.ManifestID), seg.Name, seg.SeqNo, ti.Transcoder, transcodeDur)
 
+	info := tr.Info
+	if info != nil {
+		if err := verifyUpdatedOrchestratorInfo(ti, info, resp.TLS); err != nil {
+			glog.Errorf("Ignoring invalid orchestrator info nonce=%d manifestID=%s seqNo=%d orch=%s err=%v", nonce, sess.ManifestID, seg.SeqNo, ti.Transcoder, err)
+			info = nil
+		}
+	}
+
 	return &ReceivedTranscodeResult{
 		TranscodeData: tdata,
-		Info:          tr.Info,
+		Info:          info,
 		LatencyScore:  tookAllDur.Seconds() / seg.Duration,
 	}, nil
 }
 
+// verifyUpdatedOrchestratorInfo checks info that is returned with the results for a session with sessInfo.
+// Orchestrators do not sign this info because segments are only sent to the TLS peer pinned by sessInfo,
+// so the info is accepted if it has the same recipient and it keeps the pinned certificate
+func verifyUpdatedOrchestratorInfo(sessInfo, info *net.OrchestratorInfo, tlsState *tls.ConnectionState) error {
+	pinned := sessInfo.GetTlsCertHash()
+	if len(pinned) == 0 || info.TicketParams == nil {
+		return verifyOrchestratorInfo(sessInfo.Transcoder, info, tlsState)
+	}
+	if !bytes.Equal(info.TicketParams.Recipient, sessInfo.GetTicketParams().GetRecipient()) {
+		return errors.New("orchestrator info has a different recipient")
+	}
+	info.TlsCertHash = pinned
+	return nil
+}
+
 func genSegCreds(sess *BroadcastSession, seg *stream.HLSSegment) (string, error) {
 
 	// Generate signature for relevant parts of segment
@@ -470,7 +533,14 @@ func genSegCreds(sess *BroadcastSession, seg *stream.HLSSegment) (string, error)
 		Hash:       ethcommon.BytesToHash(hash),
 		Profiles:   sess.Profiles,
 	}
-	sig, err := sess.Broadcaster.Sign(md.Flatten())
+	bcast := sess.Broadcaster
+	if pool, ok := sess.Sender.(*pm.SenderPool); ok {
+		// The orchestrator checks the signature against the account that pays for the segment
+		if signer, err := pool.SessionSigner(sess.PMSessionID); err == nil {
+			bcast = core.NewSignerBroadcaster(signer)
+		}
+	}
+	sig, err := bcast.Sign(md.Flatten())
 	if err != nil {
 		return "", err
 	}
@@ -585,6 +655,27 @@ func completeBalanceUpdate(sess *BroadcastSession, update *BalanceUpdate) {
 	sess.Balance.Credit(change)
 }
 
+// recordSpending adds the tickets sent and pixels billed for a segment to the
+// broadcaster's spending ledger
+func recordSpending(sess *BroadcastSession, update *BalanceUpdate, pixels int64) {
+	if sess.spending == nil || update.Status == Staged {
+		return
+	}
+
+	s := &common.DBSpending{
+		ManifestID:   string(sess.ManifestID),
+		Orchestrator: sess.OrchestratorInfo.GetTranscoder(),
+		Tickets:      update.NumTickets,
+		EV:           update.NewCredit,
+		Pixels:       pixels,
+		Fees:         update.Debit,
+	}
+	if params := sess.OrchestratorInfo.GetTicketParams(); params != nil {
+		s.Recipient = ethcommon.BytesToAddress(params.Recipient)
+	}
+	sess.spending.Record(s)
+}
+
 func genPayment(sess *BroadcastSession, numTickets int) (string, error) {
 	if sess.Sender == nil {
 		return "", nil
@@ -595,8 +686,16 @@ func genPayment(sess *BroadcastSession, numTickets int) (string, error) {
 		return "", err
 	}
 
+	sender := sess.Broadcaster.Address()
+	if pool, ok := sess.Sender.(*pm.SenderPool); ok {
+		// Tickets and balances are tied to the account that pays for the session
+		if addr, err := pool.SessionAccount(sess.PMSessionID); err == nil {
+			sender = addr
+		}
+	}
+
 	protoPayment := &net.Payment{
-		Sender:        sess.Broadcaster.Address().Bytes(),
+		Sender:        sender.Bytes(),
 		ExpectedPrice: sess.OrchestratorInfo.PriceInfo,
 	}
 
@@ -1303,6 +1303,11 @@ func TestSubmitSegment_Success(t *testing.T) {
 
 	ts, mux := stubTLSServer()
 	defer ts.Close()
+	orch := newStubOrchestrator()
+	info.TicketParams.Recipient = orch.Address().Bytes()
+	require.Nil(signOrchestratorInfo(orch, info, certHash(ts.Certificate().Raw)))
+	buf, err = proto.Marshal(tr)
+	require.Nil(err)
 	mux.HandleFunc("/segment", func(w http.ResponseWriter, r *http.Request) {
 		if runChecks != nil {
 			runChecks(r)
@@ -1492,3 +1497,157 @@ func stubTLSServer() (*httptest.Server, *http.ServeMux) {
 
 	return ts, mux
 }
+
+func TestSubmitSegment_PinnedCert(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	ts, mux := stubTLSServer()
+	defer ts.Close()
+
+	orch := newStubOrchestrator()
+	info := &net.OrchestratorInfo{
+		Transcoder:   ts.URL,
+		PriceInfo:    &net.PriceInfo{PricePerUnit: 1, PixelsPerUnit: 1},
+		TicketParams: &net.TicketParams{Recipient: orch.Address().Bytes()},
+	}
+	require.Nil(signOrchestratorInfo(orch, info, certHash(ts.Certificate().Raw)))
+	tr := &net.TranscodeResult{
+		Info: info,
+		Result: &net.TranscodeResult_Data{
+			Data: &net.TranscodeData{Segments: []*net.TranscodedSegmentData{{Url: "foo"}}},
+		},
+	}
+	buf, err := proto.Marshal(tr)
+	require.Nil(err)
+	mux.HandleFunc("/segment", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf)
+	})
+
+	s := &BroadcastSession{
+		Broadcaster:      stubBroadcaster2(),
+		ManifestID:       core.RandomManifestID(),
+		OrchestratorInfo: proto.Clone(info).(*net.OrchestratorInfo),
+	}
+
+	res, err := SubmitSegment(s, &stream.HLSSegment{}, 0)
+	require.Nil(err)
+	assert.Equal(info.Sig, res.Info.Sig)
+
+	// Updated info is not signed because it is sent by the pinned TLS peer, and it keeps the pin
+	tr.Info = proto.Clone(info).(*net.OrchestratorInfo)
+	tr.Info.PriceInfo.PricePerUnit = 2
+	tr.Info.Sig = nil
+	tr.Info.TlsCertHash = nil
+	buf, err = proto.Marshal(tr)
+	require.Nil(err)
+	res, err = SubmitSegment(s, &stream.HLSSegment{}, 0)
+	require.Nil(err)
+	require.NotNil(res.Info)
+	assert.Equal(int64(2), res.Info.PriceInfo.PricePerUnit)
+	assert.Equal(info.TlsCertHash, res.Info.TlsCertHash)
+
+	// Updated info with another recipient is ignored
+	tr.Info.TicketParams.Recipient = pm.RandAddress().Bytes()
+	buf, err = proto.Marshal(tr)
+	require.Nil(err)
+	res, err = SubmitSegment(s, &stream.HLSSegment{}, 0)
+	require.Nil(err)
+	assert.Nil(res.Info)
+
+	// Segments are not sent if the server does not present the pinned certificate
+	received := false
+	mux.HandleFunc("/pinned/segment", func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	})
+	s.OrchestratorInfo.Transcoder = ts.URL + "/pinned"
+	s.OrchestratorInfo.TlsCertHash = certHash([]byte("other"))
+	_, err = SubmitSegment(s, &stream.HLSSegment{}, 0)
+	require.NotNil(err)
+	assert.Contains(err.Error(), "TLS certificate does not match the pinned certificate")
+	assert.False(received)
+}
+
+func TestSubmitSegment_RecordsSpending(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dbh, dbraw, err := common.TempDB(t)
+	require.Nil(err)
+	defer dbh.Close()
+	defer dbraw.Close()
+	spending, err := core.NewSpendTracker(dbh, nil)
+	require.Nil(err)
+
+	tr := &net.TranscodeResult{
+		Result: &net.TranscodeResult_Data{
+			Data: &net.TranscodeData{
+				Segments: []*net.TranscodedSegmentData{
+					&net.TranscodedSegmentData{Url: "foo", Pixels: 100},
+					&net.TranscodedSegmentData{Url: "bar", Pixels: 50},
+				},
+			},
+		},
+	}
+	buf, err := proto.Marshal(tr)
+	require.Nil(err)
+
+	ts, mux := stubTLSServer()
+	defer ts.Close()
+	mux.HandleFunc("/segment", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf)
+	})
+
+	balance := &mockBalance{}
+	balance.On("StageUpdate", mock.Anything, mock.Anything).Return(2, big.NewRat(7, 1), big.NewRat(0, 1))
+	balance.On("Credit", mock.Anything)
+	sender := &pm.MockSender{}
+	sender.On("EV", mock.Anything).Return(big.NewRat(1, 1), nil)
+	sender.On("CreateTicketBatch", mock.Anything, 2).Return(defaultTicketBatch(), nil)
+
+	recipient := pm.RandAddress()
+	s := &BroadcastSession{
+		Broadcaster: stubBroadcaster2(),
+		ManifestID:  core.RandomManifestID(),
+		OrchestratorInfo: &net.OrchestratorInfo{
+			Transcoder: ts.URL,
+			PriceInfo: &net.PriceInfo{
+				PricePerUnit:  2,
+				PixelsPerUnit: 1,
+			},
+			TicketParams: &net.TicketParams{Recipient: recipient.Bytes()},
+		},
+		Sender:   sender,
+		Balance:  balance,
+		spending: spending,
+	}
+
+	_, err = SubmitSegment(s, &stream.HLSSegment{Data: []byte("dummy")}, 0)
+	require.Nil(err)
+
+	entries, err := dbh.SpendingEntries(nil)
+	require.Nil(err)
+	require.Len(entries, 1)
+	e := entries[0]
+	assert.Equal(string(s.ManifestID), e.ManifestID)
+	assert.Equal(ts.URL, e.Orchestrator)
+	assert.Equal(recipient, e.Recipient)
+	assert.Equal(2, e.Tickets)
+	assert.Zero(e.EV.Cmp(big.NewRat(7, 1)))
+	assert.Equal(int64(150), e.Pixels)
+	assert.Zero(e.Fees.Cmp(big.NewRat(300, 1)))
+
+	spent, err := spending.Spent(s.ManifestID)
+	require.Nil(err)
+	assert.Zero(spent.Cmp(big.NewRat(7, 1)))
+
+	// Nothing is recorded if the segment is not submitted
+	ts.Close()
+	_, err = SubmitSegment(s, &stream.HLSSegment{Data: []byte("dummy")}, 0)
+	assert.NotNil(err)
+	entries, err = dbh.SpendingEntries(nil)
+	require.Nil(err)
+	assert.Len(entries, 1)
+}
@@ -0,0 +1,364 @@
+package server
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/core"
+)
+
+// Number of recent segments retained per stream for health reporting.
+// With 2 second segments this covers a bit more than the largest success rate window.
+const streamStatsSegments = 512
+
+// Number of recent segments used to estimate the ingest bitrate
+const ingestBitrateSegments = 10
+
+// Windows over which the segment success rate is reported
+var successRateWindows = []struct {
+	name string
+	dur  time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+}
+
+type segmentRecord struct {
+	seqNo      uint64
+	emerged    time.Time
+	bytes      int
+	duration   float64
+	transcoded bool
+	failed     bool
+}
+
+type renditionLag struct {
+	last  time.Duration
+	total time.Duration
+	count int
+}
+
+// streamStats tracks the health of a single broadcast stream. All methods
+// are safe to call on a nil receiver so callers do not need to check whether
+// stats are being collected.
+type streamStats struct {
+	mu sync.Mutex
+
+	started  time.Time
+	segments []*segmentRecord
+	lags     map[string]*renditionLag
+
+	emerged    int
+	transcoded int
+	failed     int
+
+	ticketsSent     int
+	ticketValueSent *big.Rat
+}
+
+func newStreamStats() *streamStats {
+	return &streamStats{
+		started:         time.Now(),
+		lags:            make(map[string]*renditionLag),
+		ticketValueSent: big.NewRat(0, 1),
+	}
+}
+
+func (s *streamStats) segmentEmerged(seqNo uint64, bytes int, duration float64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.emerged++
+	s.segments = append(s.segments, &segmentRecord{
+		seqNo:    seqNo,
+		emerged:  time.Now(),
+		bytes:    bytes,
+		duration: duration,
+	})
+	if len(s.segments) > streamStatsSegments {
+		s.segments = s.segments[len(s.segments)-streamStatsSegments:]
+	}
+}
+
+func (s *streamStats) renditionAppeared(seqNo uint64, profile string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seg := s.findSegment(seqNo)
+	if seg == nil {
+		return
+	}
+	lag, ok := s.lags[profile]
+	if !ok {
+		lag = &renditionLag{}
+		s.lags[profile] = lag
+	}
+	lag.last = time.Since(seg.emerged)
+	lag.total += lag.last
+	lag.count++
+}
+
+func (s *streamStats) segmentTranscoded(seqNo uint64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seg := s.findSegment(seqNo); seg != nil && !seg.transcoded {
+		seg.transcoded = true
+		seg.failed = false
+		s.transcoded++
+	}
+}
+
+func (s *streamStats) segmentFailed(seqNo uint64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seg := s.findSegment(seqNo); seg != nil && !seg.transcoded && !seg.failed {
+		seg.failed = true
+		s.failed++
+	}
+}
+
+func (s *streamStats) paymentSent(numTickets int, value *big.Rat) {
+	if s == nil || value == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ticketsSent += numTickets
+	s.ticketValueSent.Add(s.ticketValueSent, value)
+}
+
+// findSegment must be called with the lock held
+func (s *streamStats) findSegment(seqNo uint64) *segmentRecord {
+	// Recent segments are the most likely to be looked up
+	for i := len(s.segments) - 1; i >= 0; i-- {
+		if s.segments[i].seqNo == seqNo {
+			return s.segments[i]
+		}
+	}
+	return nil
+}
+
+// ingestBitrate returns the estimated ingest bitrate in bits per second
+// over the most recent segments
+func (s *streamStats) ingestBitrate() int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bytes int
+	var duration float64
+	for i := len(s.segments) - 1; i >= 0 && i >= len(s.segments)-ingestBitrateSegments; i-- {
+		bytes += s.segments[i].bytes
+		duration += s.segments[i].duration
+	}
+	if duration <= 0 {
+		return 0
+	}
+	return int64(float64(bytes*8) / duration)
+}
+
+// successRates returns the ratio of transcoded segments to completed segments
+// for each window. Segments that are still in flight are not counted and
+// windows without any completed segments are omitted.
+func (s *streamStats) successRates() map[string]float64 {
+	rates := make(map[string]float64)
+	if s == nil {
+		return rates
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, w := range successRateWindows {
+		var done, transcoded int
+		for i := len(s.segments) - 1; i >= 0; i-- {
+			seg := s.segments[i]
+			if now.Sub(seg.emerged) > w.dur {
+				break
+			}
+			if seg.transcoded {
+				transcoded++
+			}
+			if seg.transcoded || seg.failed {
+				done++
+			}
+		}
+		if done > 0 {
+			rates[w.name] = float64(transcoded) / float64(done)
+		}
+	}
+	return rates
+}
+
+type renditionLagInfo struct {
+	LastMs    int64 `json:"lastMs"`
+	AverageMs int64 `json:"averageMs"`
+}
+
+type segmentCounts struct {
+	Emerged    int `json:"emerged"`
+	Transcoded int `json:"transcoded"`
+	Failed     int `json:"failed"`
+}
+
+type paymentTotals struct {
+	TicketsSent     int    `json:"ticketsSent"`
+	TicketValueSent string `json:"ticketValueSent"`
+}
+
+type orchestratorSessionInfo struct {
+	Transcoder    string  `json:"transcoder"`
+	Address       string  `json:"address,omitempty"`
+	LatencyScore  float64 `json:"latencyScore"`
+	PricePerUnit  int64   `json:"pricePerUnit"`
+	PixelsPerUnit int64   `json:"pixelsPerUnit"`
+}
+
+// streamSummary is the per-stream entry returned by the /streams API
+type streamSummary struct {
+	ManifestID       core.ManifestID           `json:"manifestID"`
+	StartedAt        time.Time                 `json:"startedAt"`
+	SourceResolution string                    `json:"sourceResolution"`
+	Profiles         []string                  `json:"profiles"`
+	IngestBitrate    int64                     `json:"ingestBitrate"`
+	Orchestrators    []orchestratorSessionInfo `json:"orchestrators"`
+}
+
+// streamHealth is returned by the /streams/{manifestID}/health API
+type streamHealth struct {
+	streamSummary
+	Segments     segmentCounts               `json:"segments"`
+	SuccessRate  map[string]float64          `json:"successRate"`
+	RenditionLag map[string]renditionLagInfo `json:"renditionLag"`
+	Payments     paymentTotals               `json:"payments"`
+}
+
+func (s *streamStats) startedAt() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started
+}
+
+func (s *streamStats) fillHealth(h *streamHealth) {
+	h.SuccessRate = s.successRates()
+	h.RenditionLag = make(map[string]renditionLagInfo)
+	h.Payments.TicketValueSent = "0"
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h.Segments = segmentCounts{
+		Emerged:    s.emerged,
+		Transcoded: s.transcoded,
+		Failed:     s.failed,
+	}
+	for profile, lag := range s.lags {
+		h.RenditionLag[profile] = renditionLagInfo{
+			LastMs:    int64(lag.last / time.Millisecond),
+			AverageMs: int64(lag.total / time.Duration(lag.count) / time.Millisecond),
+		}
+	}
+	h.Payments = paymentTotals{
+		TicketsSent:     s.ticketsSent,
+		TicketValueSent: s.ticketValueSent.FloatString(0),
+	}
+}
+
+// sessionsInfo returns a summary of the orchestrator sessions currently known to the manager
+func (bsm *BroadcastSessionsManager) sessionsInfo() []orchestratorSessionInfo {
+	bsm.sessLock.Lock()
+	defer bsm.sessLock.Unlock()
+
+	infos := make([]orchestratorSessionInfo, 0, len(bsm.sessMap))
+	for _, sess := range bsm.sessMap {
+		info := orchestratorSessionInfo{
+			Transcoder:   sess.OrchestratorInfo.Transcoder,
+			LatencyScore: sess.LatencyScore,
+		}
+		if tp := sess.OrchestratorInfo.TicketParams; tp != nil && len(tp.Recipient) > 0 {
+			info.Address = ethcommon.BytesToAddress(tp.Recipient).Hex()
+		}
+		if pi := sess.OrchestratorInfo.PriceInfo; pi != nil {
+			info.PricePerUnit = pi.PricePerUnit
+			info.PixelsPerUnit = pi.PixelsPerUnit
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func (cxn *rtmpConnection) summary() streamSummary {
+	profiles := make([]string, 0, len(cxn.params.profiles))
+	for _, p := range cxn.params.profiles {
+		profiles = append(profiles, p.Name)
+	}
+	return streamSummary{
+		ManifestID:       cxn.mid,
+		StartedAt:        cxn.stats.startedAt(),
+		SourceResolution: cxn.profile.Resolution,
+		Profiles:         profiles,
+		IngestBitrate:    cxn.stats.ingestBitrate(),
+		Orchestrators:    cxn.sessManager.sessionsInfo(),
+	}
+}
+
+func (cxn *rtmpConnection) health() *streamHealth {
+	h := &streamHealth{streamSummary: cxn.summary()}
+	cxn.stats.fillHealth(h)
+	return h
+}
+
+// streamSummaries returns a summary of every active stream ordered by manifest ID
+func (s *LivepeerServer) streamSummaries() []streamSummary {
+	s.connectionLock.RLock()
+	cxns := make([]*rtmpConnection, 0, len(s.rtmpConnections))
+	for _, cxn := range s.rtmpConnections {
+		cxns = append(cxns, cxn)
+	}
+	s.connectionLock.RUnlock()
+
+	summaries := make([]streamSummary, 0, len(cxns))
+	for _, cxn := range cxns {
+		summaries = append(summaries, cxn.summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].ManifestID < summaries[j].ManifestID })
+	return summaries
+}
+
+// streamHealth returns the health of the stream with the given manifest ID
+// or nil if there is no such active stream
+func (s *LivepeerServer) streamHealth(mid core.ManifestID) *streamHealth {
+	s.connectionLock.RLock()
+	cxn, ok := s.rtmpConnections[mid]
+	s.connectionLock.RUnlock()
+	if !ok {
+		return nil
+	}
+	return cxn.health()
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/livepeer/go-livepeer/core"
+	"github.com/livepeer/go-livepeer/net"
+	"github.com/livepeer/lpms/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamStats_NilSafe(t *testing.T) {
+	var s *streamStats
+
+	assert := assert.New(t)
+	assert.NotPanics(func() {
+		s.segmentEmerged(1, 100, 2.0)
+		s.renditionAppeared(1, "P240p30fps16x9")
+		s.segmentTranscoded(1)
+		s.segmentFailed(1)
+		s.paymentSent(1, big.NewRat(1, 1))
+	})
+	assert.Zero(s.ingestBitrate())
+	assert.Empty(s.successRates())
+
+	h := &streamHealth{}
+	s.fillHealth(h)
+	assert.Equal("0", h.Payments.TicketValueSent)
+}
+
+func TestStreamStats_IngestBitrate(t *testing.T) {
+	assert := assert.New(t)
+	s := newStreamStats()
+
+	assert.Zero(s.ingestBitrate())
+
+	// 1000 bytes over 2 seconds
+	s.segmentEmerged(0, 1000, 2.0)
+	assert.Equal(int64(4000), s.ingestBitrate())
+
+	// Only the most recent segments are used for the estimate
+	for i := 1; i <= ingestBitrateSegments; i++ {
+		s.segmentEmerged(uint64(i), 2000, 2.0)
+	}
+	assert.Equal(int64(8000), s.ingestBitrate())
+}
+
+func TestStreamStats_SuccessRates(t *testing.T) {
+	assert := assert.New(t)
+	s := newStreamStats()
+
+	// No completed segments
+	s.segmentEmerged(0, 1, 1)
+	assert.Empty(s.successRates())
+
+	s.segmentTranscoded(0)
+	s.segmentEmerged(1, 1, 1)
+	s.segmentFailed(1)
+	s.segmentEmerged(2, 1, 1) // in flight
+
+	rates := s.successRates()
+	assert.Equal(0.5, rates["1m"])
+	assert.Equal(0.5, rates["5m"])
+	assert.Equal(0.5, rates["15m"])
+
+	// Segments outside of a window are not counted
+	s.segments[0].emerged = time.Now().Add(-2 * time.Minute)
+	rates = s.successRates()
+	assert.Equal(0.0, rates["1m"])
+	assert.Equal(0.5, rates["5m"])
+
+	// A failed segment that is later transcoded counts as transcoded
+	s.segmentTranscoded(1)
+	rates = s.successRates()
+	assert.Equal(1.0, rates["1m"])
+
+	h := &streamHealth{}
+	s.fillHealth(h)
+	assert.Equal(segmentCounts{Emerged: 3, Transcoded: 2, Failed: 1}, h.Segments)
+}
+
+func TestStreamStats_RenditionLagAndPayments(t *testing.T) {
+	assert := assert.New(t)
+	s := newStreamStats()
+
+	// Unknown segments are ignored
+	s.renditionAppeared(5, "P240p30fps16x9")
+	assert.Empty(s.lags)
+
+	s.segmentEmerged(5, 1, 1)
+	s.segments[0].emerged = time.Now().Add(-time.Second)
+	s.renditionAppeared(5, "P240p30fps16x9")
+
+	s.paymentSent(2, big.NewRat(300, 1))
+	s.paymentSent(1, big.NewRat(150, 1))
+	s.paymentSent(1, nil)
+
+	h := &streamHealth{}
+	s.fillHealth(h)
+	require.Contains(t, h.RenditionLag, "P240p30fps16x9")
+	assert.True(h.RenditionLag["P240p30fps16x9"].LastMs >= 1000)
+	assert.Equal(h.RenditionLag["P240p30fps16x9"].LastMs, h.RenditionLag["P240p30fps16x9"].AverageMs)
+	assert.Equal(paymentTotals{TicketsSent: 3, TicketValueSent: "450"}, h.Payments)
+}
+
+func TestStreamHandlers(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	s := setupServer()
+	defer serverCleanup(s)
+	sd := &stubDiscovery{}
+	sd.infos = []*net.OrchestratorInfo{
+		{
+			Transcoder:   "transcoder1",
+			TicketParams: &net.TicketParams{Recipient: ethcommon.HexToAddress("0x1").Bytes()},
+			PriceInfo:    &net.PriceInfo{PricePerUnit: 7, PixelsPerUnit: 3},
+		},
+	}
+	oldPool := s.LivepeerNode.OrchestratorPool
+	s.LivepeerNode.OrchestratorPool = sd
+	defer func() { s.LivepeerNode.OrchestratorPool = oldPool }()
+
+	mid := core.ManifestID(t.Name())
+	strm := stream.NewBasicRTMPVideoStream(&streamParameters{mid: mid, resolution: "1280x720", profiles: BroadcastJobVideoProfiles})
+	cxn, err := s.registerConnection(strm)
+	require.Nil(err)
+	defer removeRTMPStream(s, mid)
+	cxn.stats.segmentEmerged(0, 500, 1.0)
+	cxn.stats.segmentTranscoded(0)
+
+	get := func(h http.Handler, path string) *http.Response {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Result()
+	}
+
+	// List all streams
+	resp := get(streamsHandler(s), "/streams")
+	require.Equal(http.StatusOK, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	var summaries []streamSummary
+	require.Nil(json.Unmarshal(body, &summaries))
+	var found *streamSummary
+	for i := range summaries {
+		if summaries[i].ManifestID == mid {
+			found = &summaries[i]
+		}
+	}
+	require.NotNil(found)
+	assert.Equal("1280x720", found.SourceResolution)
+	assert.Equal(int64(4000), found.IngestBitrate)
+	require.Len(found.Orchestrators, 1)
+	assert.Equal("transcoder1", found.Orchestrators[0].Transcoder)
+	assert.Equal(ethcommon.HexToAddress("0x1").Hex(), found.Orchestrators[0].Address)
+	assert.Equal(int64(7), found.Orchestrators[0].PricePerUnit)
+
+	// Health for a single stream
+	handler := streamHealthHandler(s)
+	resp = get(handler, "/streams/"+string(mid)+"/health")
+	require.Equal(http.StatusOK, resp.StatusCode)
+	body, _ = ioutil.ReadAll(resp.Body)
+	var health streamHealth
+	require.Nil(json.Unmarshal(body, &health))
+	assert.Equal(mid, health.ManifestID)
+	assert.Equal(1.0, health.SuccessRate["1m"])
+	assert.Equal(1, health.Segments.Transcoded)
+
+	// Unknown stream
+	resp = get(handler, "/streams/foo/health")
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+
+	// Unknown path
+	resp = get(handler, "/streams/"+string(mid)+"/bar")
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+	resp = get(handler, "/streams/")
+	assert.Equal(http.StatusNotFound, resp.StatusCode)
+}
@@ -748,6 +748,20 @@ func (s *LivepeerServer) cliWebServerHandlers(bindAddr string) *http.ServeMux {
 		w.Write(js)
 	})
 
+	// Per-stream health
+	mux.Handle("/streams", streamsHandler(s))
+	mux.Handle("/streams/", streamHealthHandler(s))
+
+	mux.Handle("/healthz", healthHandler(s.livenessChecks))
+	mux.Handle("/readyz", healthHandler(s.readinessChecks))
+	mux.Handle("/drain", drainHandler(s.LivepeerNode))
+	mux.Handle("/balances", balancesHandler(s.LivepeerNode))
+	mux.Handle("/ledger", ledgerHandler(s.LivepeerNode.Database))
+	mux.Handle("/spending", spendingHandler(s.LivepeerNode.Database))
+	mux.Handle("/budgets", budgetsHandler(s.LivepeerNode))
+	mux.Handle("/transactions", transactionsHandler(s.LivepeerNode.Database))
+	mux.Handle("/orchestratorLists", orchestratorListsHandler(s.LivepeerNode))
+
 	mux.HandleFunc("/debug", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(fmt.Sprintf("\n\nLatestPlaylist: %v", s.LatestPlaylist())))
 	})
@@ -1144,7 +1158,7 @@ func (s *LivepeerServer) cliWebServerHandlers(bindAddr string) *http.ServeMux {
 	mux.Handle("/unlock", unlockHandler(s.LivepeerNode.Eth))
 	mux.Handle("/cancelUnlock", cancelUnlockHandler(s.LivepeerNode.Eth))
 	mux.Handle("/withdraw", withdrawHandler(s.LivepeerNode.Eth))
-	mux.Handle("/senderInfo", senderInfoHandler(s.LivepeerNode.Eth))
+	mux.Handle("/senderInfo", senderInfoHandler(s.LivepeerNode.Eth, s.LivepeerNode.Sender))
 	mux.Handle("/ticketBrokerParams", ticketBrokerParamsHandler(s.LivepeerNode.Eth))
 
 	// Metrics
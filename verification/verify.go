@@ -193,10 +193,21 @@ func (sv *SegmentVerifier) sigVerification(params *Params) error {
 		segHashes[i] = crypto.Keccak256(params.Renditions[i])
 	}
 
+	// Segments are signed by the ticket recipient unless it authorized a segment signer
+	signer := ethcommon.BytesToAddress(params.Orchestrator.TicketParams.Recipient)
+	if len(params.Orchestrator.SegmentSigner) > 0 {
+		segmentSigner := ethcommon.BytesToAddress(params.Orchestrator.SegmentSigner)
+		if !sv.verifySig(signer, crypto.Keccak256(core.SegmentSignerMsg(segmentSigner)), params.Orchestrator.SegmentSignerSig) {
+			glog.Error("Segment signer check failed")
+			return errPMCheckFailed
+		}
+		signer = segmentSigner
+	}
+
 	// Might not have seg hashes if results are directly uploaded to the broadcaster's OS
 	// TODO: Consider downloading the results to generate seg hashes if results are directly uploaded to the broadcaster's OS
 	if !sv.verifySig(
-		ethcommon.BytesToAddress(params.Orchestrator.TicketParams.Recipient),
+		signer,
 		crypto.Keccak256(segHashes...),
 		params.Results.Sig) {
 		glog.Error("Sig check failed")
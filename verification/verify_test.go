@@ -1,6 +1,7 @@
 package verification
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"io/ioutil"
 	"testing"
@@ -8,8 +9,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/livepeer/go-livepeer/core"
 	"github.com/livepeer/go-livepeer/drivers"
 	"github.com/livepeer/go-livepeer/net"
 	"github.com/livepeer/lpms/ffmpeg"
@@ -344,3 +348,48 @@ func TestVerifyPixels(t *testing.T) {
 	err = verifyPixels("../server/test.flv", nil, 50)
 	assert.EqualError(err, "Invalid data found when processing input")
 }
+
+func TestVerify_SegmentSigner(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sign := func(key *ecdsa.PrivateKey, hash []byte) []byte {
+		sig, err := ethcrypto.Sign(accounts.TextHash(hash), key)
+		require.Nil(err)
+		sig[64] += 27
+		return sig
+	}
+	recipientKey, err := ethcrypto.GenerateKey()
+	require.Nil(err)
+	signerKey, err := ethcrypto.GenerateKey()
+	require.Nil(err)
+	recipient := ethcrypto.PubkeyToAddress(recipientKey.PublicKey)
+	signer := ethcrypto.PubkeyToAddress(signerKey.PublicKey)
+
+	renditions := [][]byte{{0}, {1}}
+	segHash := ethcrypto.Keccak256(ethcrypto.Keccak256(renditions[0]), ethcrypto.Keccak256(renditions[1]))
+	delegation := sign(recipientKey, ethcrypto.Keccak256(core.SegmentSignerMsg(signer)))
+
+	verify := func(orch *net.OrchestratorInfo, sig []byte) error {
+		sv := NewSegmentVerifier(&Policy{Verifier: &stubVerifier{}, Retries: 2})
+		data := &net.TranscodeData{Segments: []*net.TranscodedSegmentData{{Url: "xyz"}, {Url: "xyz"}}, Sig: sig}
+		_, err := sv.Verify(&Params{Results: data, Orchestrator: orch, Renditions: renditions})
+		return err
+	}
+	ticketParams := &net.TicketParams{Recipient: recipient.Bytes()}
+
+	// Segments signed by the recipient
+	assert.Nil(verify(&net.OrchestratorInfo{TicketParams: ticketParams}, sign(recipientKey, segHash)))
+	assert.Equal(errPMCheckFailed, verify(&net.OrchestratorInfo{TicketParams: ticketParams}, sign(signerKey, segHash)))
+
+	// Segments signed by a segment signer that is authorized by the recipient
+	orch := &net.OrchestratorInfo{TicketParams: ticketParams, SegmentSigner: signer.Bytes(), SegmentSignerSig: delegation}
+	assert.Nil(verify(orch, sign(signerKey, segHash)))
+	assert.Equal(errPMCheckFailed, verify(orch, sign(recipientKey, segHash)))
+
+	// Segment signer that is not authorized by the recipient
+	orch.SegmentSignerSig = sign(signerKey, ethcrypto.Keccak256(core.SegmentSignerMsg(signer)))
+	assert.Equal(errPMCheckFailed, verify(orch, sign(signerKey, segHash)))
+	orch.SegmentSignerSig = nil
+	assert.Equal(errPMCheckFailed, verify(orch, sign(signerKey, segHash)))
+}